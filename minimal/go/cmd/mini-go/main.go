@@ -2,27 +2,209 @@ package main
 
 import (
 	"bufio"
+	"errors"
+	"flag"
+	"fmt"
 	"os"
 	"path/filepath"
+	"runtime/debug"
 	"strings"
+	"text/tabwriter"
 
 	"minimal-go/internal/core"
+	"minimal-go/internal/ui"
 )
 
+var version = "dev"
+
+type command struct {
+	name    string
+	args    string
+	summary string
+	// Commands with their own flag set get their arguments untouched and
+	// answer -h themselves.
+	ownFlags bool
+	run      func(args []string, options core.MainOptions) error
+}
+
+var commands = []command{
+	{name: "chat", summary: "Start an interactive session (the default)", run: func(_ []string, options core.MainOptions) error { return core.Main(options) }},
+	{name: "run", args: "<tasks.yaml> [--report file]", summary: "Run a task file unattended", ownFlags: true, run: core.RunTasks},
+	{name: "serve", args: "[--addr host:port] [--token secret]", summary: "Serve sessions over HTTP with an SSE event stream", ownFlags: true, run: core.Serve},
+	{name: "daemon", summary: "Keep sessions running in the background", run: func(_ []string, options core.MainOptions) error { return core.RunDaemon(options) }},
+	{name: "attach", args: "[session]", summary: "Attach to a daemon session", run: func(args []string, _ core.MainOptions) error { return core.Attach(args) }},
+	{name: "init", summary: "Create ~/.minimal with a starter config", run: func([]string, core.MainOptions) error { return core.Init() }},
+	{name: "new", args: "<template> [dir]", summary: "Scaffold a workspace from a template", run: func(args []string, _ core.MainOptions) error { return core.NewWorkspace(args) }},
+	{name: "config", args: "validate [path]", summary: "Check config.json for mistakes", run: func(args []string, _ core.MainOptions) error { return core.ConfigCommand(args) }},
+	{name: "doctor", summary: "Diagnose configuration and provider access", run: func([]string, core.MainOptions) error { return core.Doctor() }},
+	{name: "skills", args: "[list] | sync", summary: "List skills or sync them from skills.repo", run: func(args []string, _ core.MainOptions) error { return core.SkillsCommand(args) }},
+	{name: "sessions", args: "[list]", summary: "List saved sessions", run: func(args []string, _ core.MainOptions) error { return core.SessionsCommand(args) }},
+	{name: "recover", args: "[session]", summary: "Rebuild a crashed session from its journal", run: func(args []string, _ core.MainOptions) error { return core.RecoverCommand(args) }},
+	{name: "search", args: "[--limit N] <query>", summary: "Search past session transcripts", ownFlags: true, run: func(args []string, _ core.MainOptions) error { return core.SearchCommand(args) }},
+	{name: "replay", args: "[--rerun] [--all] <session>", summary: "Step through a saved session turn by turn", ownFlags: true, run: func(args []string, _ core.MainOptions) error { return core.Replay(args) }},
+	{name: "report", args: "[--since 7d] [--csv]", summary: "Summarize tokens and cost per day, model and project", ownFlags: true, run: func(args []string, _ core.MainOptions) error { return core.Report(args) }},
+	{name: "commit", args: "[--pr [--base ref]]", summary: "Draft a commit message for the staged changes and commit", ownFlags: true, run: core.CommitCommand},
+	{name: "approvals", args: "[list] | approve <id> | deny <id>", summary: "Answer queued approval requests", run: func(args []string, _ core.MainOptions) error { return core.ApprovalsCommand(args) }},
+	{name: "bench", args: "-p <prompt> [-v variants] [--full] [--seed n] [--top-p p]", summary: "Compare variants on one prompt", ownFlags: true, run: func(args []string, _ core.MainOptions) error { return core.Bench(args) }},
+	{name: "tour", summary: "Walk through approvals and tools with a scripted model", run: func([]string, core.MainOptions) error { return core.Tour() }},
+	{name: "selftest", summary: "Check the agent loop offline", run: func([]string, core.MainOptions) error { return core.SelfTest() }},
+}
+
 func main() {
 	loadDotEnv(filepath.Join(".", ".env"))
+	os.Exit(run(os.Args[1:]))
+}
+
+func run(args []string) int {
+	var options core.MainOptions
+	var noColor, showVersion bool
+	flags := flag.NewFlagSet("mini-go", flag.ContinueOnError)
+	addGlobalFlags(flags, &options, &noColor)
+	flags.BoolVar(&showVersion, "version", false, "print the version and exit")
+	flags.Usage = func() { printUsage(flags) }
+	if err := flags.Parse(args); err != nil {
+		return exitCode(err)
+	}
+	if showVersion {
+		fmt.Println("mini-go " + buildVersion())
+		return 0
+	}
+
+	name, rest := "chat", flags.Args()
+	if len(rest) > 0 {
+		name, rest = rest[0], rest[1:]
+	}
+	if name == "help" {
+		if len(rest) == 0 {
+			printUsage(flags)
+			return 0
+		}
+		name, rest = rest[0], []string{"-h"}
+	}
+	cmd, ok := findCommand(name)
+	if !ok {
+		fmt.Fprintf(os.Stderr, "mini-go: unknown command %q\n\n", name)
+		printUsage(flags)
+		return 2
+	}
 
-	debug := false
-	for _, arg := range os.Args[1:] {
-		if arg == "-d" || arg == "--debug" {
-			debug = true
-			break
+	if !cmd.ownFlags {
+		commandFlags := flag.NewFlagSet("mini-go "+cmd.name, flag.ContinueOnError)
+		addGlobalFlags(commandFlags, &options, &noColor)
+		commandFlags.Usage = func() { printCommandUsage(cmd, commandFlags) }
+		positional, err := parseInterspersed(commandFlags, rest)
+		if err != nil {
+			return exitCode(err)
 		}
+		rest = positional
+	} else if len(rest) == 1 && rest[0] == "-h" {
+		fmt.Fprintf(os.Stderr, "Usage: mini-go %s %s\n\n%s.\n\n", cmd.name, cmd.args, cmd.summary)
 	}
 
-	if err := core.Main(core.MainOptions{Debug: debug}); err != nil {
-		os.Exit(1)
+	ui.SetTheme(ui.Theme{Enabled: ui.ColorEnabled(noColor)})
+	if err := cmd.run(rest, options); err != nil {
+		return exitCode(err)
+	}
+	return 0
+}
+
+func addGlobalFlags(flags *flag.FlagSet, options *core.MainOptions, noColor *bool) {
+	flags.BoolVar(&options.Debug, "d", options.Debug, "shorthand for --debug")
+	flags.BoolVar(&options.Debug, "debug", options.Debug, "log requests, responses and timing")
+	flags.BoolVar(noColor, "no-color", *noColor, "disable colored output")
+	flags.StringVar(&options.RecordDir, "record", options.RecordDir, "record provider traffic into `dir`")
+	flags.StringVar(&options.ReplayDir, "replay", options.ReplayDir, "answer from traffic recorded in `dir`")
+	flags.BoolVar(&options.NoCache, "no-cache", options.NoCache, "always call the provider, even when cache.enabled is set")
+	flags.StringVar(&options.Agent, "agent", options.Agent, "use the agent profile `name` from config.json")
+	flags.BoolVar(&options.ReadOnly, "read-only", options.ReadOnly, "deny file writes and any command that could change state")
+	flags.BoolVar(&options.Auto, "auto", options.Auto, "run without approval prompts (needs a clean git worktree or a sandbox)")
+}
+
+// parseInterspersed accepts flags before, between and after positional
+// arguments, as the old hand-written parser did.
+func parseInterspersed(flags *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := flags.Parse(args); err != nil {
+			return nil, err
+		}
+		if flags.NArg() == 0 {
+			return positional, nil
+		}
+		positional = append(positional, flags.Arg(0))
+		args = flags.Args()[1:]
+	}
+}
+
+func findCommand(name string) (command, bool) {
+	for _, cmd := range commands {
+		if cmd.name == name {
+			return cmd, true
+		}
+	}
+	return command{}, false
+}
+
+func exitCode(err error) int {
+	if errors.Is(err, flag.ErrHelp) {
+		return 0
+	}
+	return 1
+}
+
+func printUsage(flags *flag.FlagSet) {
+	out := flags.Output()
+	fmt.Fprintln(out, "Usage: mini-go [flags] [command] [args]")
+	fmt.Fprintln(out, "")
+	fmt.Fprintln(out, "Commands:")
+	writer := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	for _, cmd := range commands {
+		fmt.Fprintf(writer, "  %s\t%s\n", cmd.name, cmd.summary)
+	}
+	writer.Flush()
+	fmt.Fprintln(out, "")
+	fmt.Fprintln(out, "Flags:")
+	flags.PrintDefaults()
+	fmt.Fprintln(out, "")
+	fmt.Fprintln(out, "Run 'mini-go help <command>' for a command's arguments.")
+}
+
+func printCommandUsage(cmd command, flags *flag.FlagSet) {
+	out := flags.Output()
+	fmt.Fprintf(out, "Usage: mini-go %s [flags] %s\n\n%s.\n\nFlags:\n", cmd.name, cmd.args, cmd.summary)
+	flags.PrintDefaults()
+}
+
+func buildVersion() string {
+	if version != "dev" {
+		return version
+	}
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return version
+	}
+	if info.Main.Version != "" && info.Main.Version != "(devel)" {
+		return info.Main.Version
+	}
+	var revision, modified string
+	for _, setting := range info.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			revision = setting.Value
+		case "vcs.modified":
+			modified = setting.Value
+		}
+	}
+	if len(revision) > 12 {
+		revision = revision[:12]
+	}
+	if revision == "" {
+		return version
+	}
+	if modified == "true" {
+		revision += "-dirty"
 	}
+	return version + " (" + revision + ")"
 }
 
 func loadDotEnv(path string) {
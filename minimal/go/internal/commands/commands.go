@@ -0,0 +1,146 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"minimal-go/internal/config"
+	"minimal-go/internal/shell"
+)
+
+var (
+	// !`command` runs before the prompt is sent and is replaced by its output.
+	snippetPattern     = regexp.MustCompile("!`([^`\n]+)`")
+	placeholderPattern = regexp.MustCompile(`\$(ARGUMENTS|[1-9])`)
+)
+
+// Command is a prompt template from ~/.minimal/commands/<name>.md. Unlike a
+// skill it is sent as the user's own message, after $ARGUMENTS and $1..$9
+// are filled in and its shell snippets have run.
+type Command struct {
+	Name        string
+	Description string
+	Body        string
+}
+
+func List() []Command {
+	entries, err := os.ReadDir(config.CommandsDir)
+	if err != nil {
+		return nil
+	}
+	var list []Command
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, ".md") {
+			continue
+		}
+		if command, err := Load(strings.TrimSuffix(name, ".md")); err == nil {
+			list = append(list, command)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
+
+func Load(name string) (Command, error) {
+	if name == "" || name != filepath.Base(name) {
+		return Command{}, fmt.Errorf("invalid command name %q", name)
+	}
+	data, err := os.ReadFile(filepath.Join(config.CommandsDir, name+".md"))
+	if err != nil {
+		return Command{}, err
+	}
+	command := Command{Name: name, Body: strings.TrimSpace(string(data))}
+	if rest, ok := strings.CutPrefix(command.Body, "---\n"); ok {
+		if header, body, ok := strings.Cut(rest, "\n---"); ok {
+			command.Body = strings.TrimSpace(body)
+			for _, line := range strings.Split(header, "\n") {
+				if key, value, ok := strings.Cut(line, ":"); ok && strings.TrimSpace(key) == "description" {
+					command.Description = strings.TrimSpace(value)
+				}
+			}
+		}
+	}
+	if command.Description == "" {
+		command.Description, _, _ = strings.Cut(command.Body, "\n")
+	}
+	return command, nil
+}
+
+// Arity is the highest $N the template uses.
+func (c Command) Arity() int {
+	arity := 0
+	for _, match := range placeholderPattern.FindAllStringSubmatch(c.Body, -1) {
+		if n, err := strconv.Atoi(match[1]); err == nil && n > arity {
+			arity = n
+		}
+	}
+	return arity
+}
+
+// Expand fills in the arguments and replaces each snippet with what run
+// returns for it. Arguments are shell-quoted inside snippets.
+func (c Command) Expand(args []string, run func(snippet string) string) (string, error) {
+	if arity := c.Arity(); len(args) < arity {
+		return "", fmt.Errorf("/cmd %s expects %d argument(s), got %d", c.Name, arity, len(args))
+	}
+	expanded := snippetPattern.ReplaceAllStringFunc(c.Body, func(match string) string {
+		snippet := snippetPattern.FindStringSubmatch(match)[1]
+		return run(substitute(snippet, args, shell.Quote))
+	})
+	return substitute(expanded, args, func(value string) string { return value }), nil
+}
+
+func substitute(text string, args []string, quote func(string) string) string {
+	return placeholderPattern.ReplaceAllStringFunc(text, func(match string) string {
+		if match == "$ARGUMENTS" {
+			quoted := make([]string, len(args))
+			for i, arg := range args {
+				quoted[i] = quote(arg)
+			}
+			return strings.Join(quoted, " ")
+		}
+		n, _ := strconv.Atoi(match[1:])
+		if n > len(args) {
+			return ""
+		}
+		return quote(args[n-1])
+	})
+}
+
+// SplitArgs splits a /cmd argument string on spaces, keeping quoted
+// ("…" or '…') parts together.
+func SplitArgs(text string) []string {
+	var args []string
+	var current strings.Builder
+	var quote rune
+	inArg := false
+	for _, r := range text {
+		switch {
+		case quote != 0 && r == quote:
+			quote = 0
+		case quote != 0:
+			current.WriteRune(r)
+		case r == '"' || r == '\'':
+			quote, inArg = r, true
+		case r == ' ' || r == '\t':
+			if inArg {
+				args = append(args, current.String())
+				current.Reset()
+				inArg = false
+			}
+		default:
+			current.WriteRune(r)
+			inArg = true
+		}
+	}
+	if inArg {
+		args = append(args, current.String())
+	}
+	return args
+}
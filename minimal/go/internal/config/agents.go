@@ -0,0 +1,128 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// AgentProfile is a named role such as "planner" or "reviewer", picked with
+// --agent or /agent. Empty fields keep the base configuration.
+type AgentProfile struct {
+	Description string `json:"description"`
+	// SystemPrompt is a file that replaces system.md, relative to
+	// ~/.minimal unless absolute.
+	SystemPrompt string          `json:"systemPrompt"`
+	Variant      string          `json:"variant"`
+	Model        string          `json:"model"`
+	Tools        []string        `json:"tools"`
+	Policy       *PolicyOverride `json:"policy"`
+}
+
+// PolicyOverride replaces the listed policy fields and keeps the rest.
+type PolicyOverride struct {
+	DefaultAction string   `json:"defaultAction"`
+	DenyPatterns  []string `json:"denyPatterns"`
+	AutoCommands  []string `json:"autoCommands"`
+	AllowedPaths  []string `json:"allowedPaths"`
+	// ReadOnly can only switch read-only mode on.
+	ReadOnly bool `json:"readOnly"`
+}
+
+func (p *PolicyOverride) Apply(base PolicyConfig) PolicyConfig {
+	if p == nil {
+		return base
+	}
+	if p.DefaultAction != "" {
+		base.DefaultAction = p.DefaultAction
+	}
+	if p.DenyPatterns != nil {
+		base.DenyPatterns = p.DenyPatterns
+	}
+	if p.AutoCommands != nil {
+		base.AutoCommands = p.AutoCommands
+	}
+	if p.AllowedPaths != nil {
+		base.AllowedPaths = make([]string, 0, len(p.AllowedPaths))
+		for _, path := range p.AllowedPaths {
+			base.AllowedPaths = append(base.AllowedPaths, ExpandHome(path))
+		}
+	}
+	if p.ReadOnly {
+		base.ReadOnly = true
+	}
+	return base
+}
+
+func (p AgentProfile) PromptPath() string {
+	if p.SystemPrompt == "" {
+		return ""
+	}
+	path := ExpandHome(p.SystemPrompt)
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(MinimalDir, path)
+	}
+	return path
+}
+
+func (p AgentProfile) LoadPrompt() (string, error) {
+	data, err := os.ReadFile(p.PromptPath())
+	if err != nil {
+		return "", err
+	}
+	content := strings.TrimSpace(string(data))
+	if content == "" {
+		return "", fmt.Errorf("%s is empty", p.PromptPath())
+	}
+	return content, nil
+}
+
+// ApplyAgent returns cfg with the named profile's model and policy applied.
+// The system prompt and tool subset are applied by the caller.
+func (cfg Config) ApplyAgent(name string) (Config, AgentProfile, error) {
+	profile, ok := cfg.Agents[name]
+	if !ok {
+		if len(cfg.Agents) == 0 {
+			return cfg, profile, fmt.Errorf("unknown agent %q: no agents are configured in %s", name, ConfigPath)
+		}
+		return cfg, profile, fmt.Errorf("unknown agent %q (configured: %s)", name, strings.Join(sortedKeys(cfg.Agents), ", "))
+	}
+	if profile.Variant != "" {
+		if _, ok := cfg.LLM.Variants[profile.Variant]; !ok {
+			return cfg, profile, fmt.Errorf("agent %q: variant %q is not configured", name, profile.Variant)
+		}
+		cfg.LLM.CurrentProvider = profile.Variant
+		cfg.LLM.CurrentModel = ""
+	}
+	if profile.Model != "" {
+		cfg.LLM.CurrentModel = profile.Model
+	}
+	cfg.Policy = profile.Policy.Apply(cfg.Policy)
+	cfg.Agent = name
+	return cfg, profile, nil
+}
+
+func validateAgents(agents map[string]AgentProfile, variants map[string]rawVariant, report func(string, string, ...interface{})) {
+	for _, name := range sortedKeys(agents) {
+		profile := agents[name]
+		path := "agents." + name
+		if strings.TrimSpace(name) == "" {
+			report("agents", "names must not be empty")
+		}
+		if profile.Variant != "" {
+			if _, ok := variants[profile.Variant]; !ok {
+				report(path+".variant", "%q is not a configured variant", profile.Variant)
+			}
+		}
+		if profile.SystemPrompt != "" {
+			if _, err := os.Stat(profile.PromptPath()); errors.Is(err, os.ErrNotExist) {
+				report(path+".systemPrompt", "%s does not exist", profile.PromptPath())
+			}
+		}
+		if profile.Policy != nil && profile.Policy.DefaultAction != "" && !validDefaultActions[profile.Policy.DefaultAction] {
+			report(path+".policy.defaultAction", "unknown action %q (use \"ask\" or \"deny\")", profile.Policy.DefaultAction)
+		}
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"net/url"
 	"os"
 	"path/filepath"
 	"strings"
@@ -14,8 +15,15 @@ type SchemaType string
 const (
 	SchemaOpenAI    SchemaType = "openai"
 	SchemaAnthropic SchemaType = "anthropic"
+	SchemaMock      SchemaType = "mock"
+	// SchemaVertex is Google Vertex AI with Application Default
+	// Credentials: Gemini models natively, claude-* models in the Anthropic
+	// format.
+	SchemaVertex SchemaType = "vertex"
 )
 
+const defaultVertexLocation = "us-central1"
+
 type LlmVariant struct {
 	SchemaType  SchemaType
 	APIKey      string
@@ -24,6 +32,38 @@ type LlmVariant struct {
 	Model       string
 	Temperature float64
 	MaxTokens   int
+	Sampling    Sampling
+	Fixture     string
+	RecordTo    string
+	Proxy       string
+	CABundle    string
+	Insecure    bool
+	Timeout     int
+	Quirks      *ProviderQuirks
+	Headers     map[string]string
+	Query       map[string]string
+	ToolChoice  string
+	Parallel    *bool
+	Project     string
+	Location    string
+}
+
+// Tool choice values besides the name of a single tool to force.
+const (
+	ToolChoiceAuto     = "auto"
+	ToolChoiceNone     = "none"
+	ToolChoiceRequired = "required"
+)
+
+type ProviderQuirks struct {
+	NoParallelToolCalls bool `json:"noParallelToolCalls"`
+	// OmitParallelFlag leaves parallel_tool_calls out of the request for
+	// endpoints that reject it with a 400; NoParallelToolCalls then only
+	// trims the reply to its first call.
+	OmitParallelFlag  bool `json:"omitParallelFlag"`
+	StrictAlternation bool `json:"strictAlternation"`
+	SystemFirstOnly   bool `json:"systemFirstOnly"`
+	MaxToolNameLength int  `json:"maxToolNameLength"`
 }
 
 type LlmConfig struct {
@@ -36,11 +76,213 @@ type PolicyConfig struct {
 	DefaultAction string   `json:"defaultAction"`
 	DenyPatterns  []string `json:"denyPatterns"`
 	AutoCommands  []string `json:"autoCommands"`
+	AllowedPaths  []string `json:"allowedPaths"`
+	// AutoProjectCommands auto-approves the build, test and lint commands
+	// of the detected project toolchain.
+	AutoProjectCommands bool `json:"autoProjectCommands"`
+	// ConfirmUntracked asks before changes to files git does not track,
+	// even when the command or file would otherwise be auto-approved.
+	ConfirmUntracked bool `json:"confirmUntracked"`
+	// ConfirmDangerous names built-in deny classes that ask for a typed
+	// confirmation instead of being denied. Only classes that cannot destroy
+	// anything qualify; see ConfirmableDenyClass.
+	ConfirmDangerous []string `json:"confirmDangerous"`
+	// ReadOnly denies file writes and every command that is not known to
+	// only inspect the workspace.
+	ReadOnly bool `json:"readOnly"`
+	// RiskRules add labels to the ones shown before a command is approved.
+	RiskRules []RiskRule `json:"riskRules"`
+	// ClassifierModel, when set, also asks this model of the current
+	// provider to label each command that needs approval.
+	ClassifierModel string `json:"classifierModel"`
+	// Files adjusts which files tools and commands may touch.
+	Files FileAccessConfig `json:"files"`
+}
+
+// FileAccessConfig holds globs checked before the built-in secret and lock
+// file rules. Deny wins over Allow; Allow only lifts the built-in rules.
+// A glob without a slash matches any path component ("*.tfstate"); one
+// with a slash matches the workspace-relative path, where ** spans
+// directories ("config/**/*.local.json").
+type FileAccessConfig struct {
+	Allow []string `json:"allow"`
+	Deny  []string `json:"deny"`
+	// Projects adds globs for one workspace, keyed by its root directory.
+	Projects map[string]FileAccessRules `json:"projects"`
+}
+
+type FileAccessRules struct {
+	Allow []string `json:"allow"`
+	Deny  []string `json:"deny"`
+}
+
+// ForWorkspace returns the rules that apply in workspaceRoot, with the
+// project's globs appended to the global ones.
+func (c FileAccessConfig) ForWorkspace(workspaceRoot string) FileAccessConfig {
+	resolved := FileAccessConfig{Allow: c.Allow, Deny: c.Deny}
+	for root, rules := range c.Projects {
+		if filepath.Clean(ExpandHome(root)) != filepath.Clean(workspaceRoot) {
+			continue
+		}
+		resolved.Allow = append(append([]string{}, resolved.Allow...), rules.Allow...)
+		resolved.Deny = append(append([]string{}, resolved.Deny...), rules.Deny...)
+	}
+	return resolved
+}
+
+type RiskRule struct {
+	Pattern string `json:"pattern"`
+	Label   string `json:"label"`
+	// Risk is "low", "medium" (the default) or "high".
+	Risk string `json:"risk"`
+}
+
+var confirmableDenyClasses = []string{"recursive_listing", "in_place_edit"}
+
+func ConfirmableDenyClass(name string) bool {
+	for _, class := range confirmableDenyClasses {
+		if class == name {
+			return true
+		}
+	}
+	return false
 }
 
+type UIConfig struct {
+	ThemePreset string
+	ThemeStyles map[string]string
+	Thinking    string
+	ModelTitles bool
+	Notify      NotifyConfig
+}
+
+type NotifyConfig struct {
+	// After is how long a turn must run, in seconds, before its end is
+	// announced. 0 turns notifications off.
+	After int `json:"after"`
+	// Command replaces the desktop notification. It gets the text in
+	// $MINI_GO_TITLE and $MINI_GO_MESSAGE.
+	Command string `json:"command"`
+	// Always notifies even when the terminal seems to be in front.
+	Always bool `json:"always"`
+}
+
+const (
+	ThinkingOn        = "on"
+	ThinkingOff       = "off"
+	ThinkingCollapsed = "collapsed"
+)
+
+type ShellConfig struct {
+	Persistent bool `json:"persistent"`
+}
+
+type BudgetConfig struct {
+	MaxSessionTokens int     `json:"maxSessionTokens"`
+	MaxSessionCost   float64 `json:"maxSessionCost"`
+	MaxDailyTokens   int     `json:"maxDailyTokens"`
+	MaxDailyCost     float64 `json:"maxDailyCost"`
+}
+
+type ContextConfig struct {
+	PruneToolResults   bool `json:"pruneToolResults"`
+	KeepTurns          int  `json:"keepTurns"`
+	PruneMinChars      int  `json:"pruneMinChars"`
+	AttachOverChars    int  `json:"attachOverChars"`
+	SystemPromptBudget int  `json:"systemPromptBudget"`
+	WatchFiles         bool `json:"watchFiles"`
+	// SummarizeOnClear makes /clear keep a short summary of a long
+	// conversation as the first message of the new one.
+	SummarizeOnClear bool `json:"summarizeOnClear"`
+}
+
+type ApprovalConfig struct {
+	Strategy  string        `json:"strategy"`
+	Timeout   int           `json:"timeout"`
+	OnTimeout string        `json:"onTimeout"`
+	Webhook   WebhookConfig `json:"webhook"`
+}
+
+// HTTPConfig tunes the connection pool shared by all provider clients.
+// Timeouts are in seconds; 0 keeps the default. RequestTimeout bounds the
+// wait for a response to start (a variant's timeout overrides it);
+// ReadIdleTimeout bounds each pause while the response is read.
+type HTTPConfig struct {
+	DialTimeout         int `json:"dialTimeout"`
+	TLSHandshakeTimeout int `json:"tlsHandshakeTimeout"`
+	RequestTimeout      int `json:"requestTimeout"`
+	ReadIdleTimeout     int `json:"readIdleTimeout"`
+	IdleConnTimeout     int `json:"idleConnTimeout"`
+	MaxIdleConnsPerHost int `json:"maxIdleConnsPerHost"`
+}
+
+// CacheConfig keeps completions on disk keyed by the full request, so an
+// identical request within TTL seconds is answered without calling the
+// provider.
+type CacheConfig struct {
+	Enabled bool `json:"enabled"`
+	TTL     int  `json:"ttl"`
+}
+
+// StorageConfig.Encrypt names where the key for encrypting sessions, the
+// audit log and memory comes from; empty keeps them in plaintext.
+type StorageConfig struct {
+	Encrypt string `json:"encrypt"`
+}
+
+const (
+	EncryptKeychain   = "keychain"
+	EncryptPassphrase = "passphrase"
+)
+
+type SkillsConfig struct {
+	Repo   string            `json:"repo"`
+	Ref    string            `json:"ref"`
+	Subdir string            `json:"subdir"`
+	Pins   map[string]string `json:"pins"`
+}
+
+type WebhookConfig struct {
+	URL     string            `json:"url"`
+	Headers map[string]string `json:"headers"`
+	Secret  string            `json:"secret"`
+}
+
+const (
+	ApprovalPrompt  = "prompt"
+	ApprovalDeny    = "deny"
+	ApprovalSafe    = "approve-safe"
+	ApprovalQueue   = "queue"
+	ApprovalWebhook = "webhook"
+)
+
+var approvalStrategies = map[string]bool{ApprovalPrompt: true, ApprovalDeny: true, ApprovalSafe: true, ApprovalQueue: true, ApprovalWebhook: true}
+
+var timeoutActions = map[string]bool{ApprovalDeny: true, ApprovalSafe: true}
+
 type Config struct {
-	LLM    LlmConfig
-	Policy PolicyConfig
+	LLM         LlmConfig
+	Policy      PolicyConfig
+	Approval    ApprovalConfig
+	Models      map[string]ModelInfo
+	UI          UIConfig
+	Shell       ShellConfig
+	Budget      BudgetConfig
+	Context     ContextConfig
+	Skills      SkillsConfig
+	HTTP        HTTPConfig
+	Storage     StorageConfig
+	Cache       CacheConfig
+	Retrieval   RetrievalConfig
+	Diagnostics map[string]string
+	Hooks       HooksConfig
+	Agents      map[string]AgentProfile
+	// Agent is the active profile from Agents, if any.
+	Agent     string
+	RecordDir string
+	ReplayDir string
+	// Auto skips every approval prompt (--auto); denied commands still stop.
+	Auto bool
 }
 
 type ResolvedLlmConfig struct {
@@ -49,14 +291,47 @@ type ResolvedLlmConfig struct {
 	Model       string
 	Temperature float64
 	MaxTokens   int
+	Sampling    Sampling
 	APIKey      string
 	APIKeyEnv   string
 	BaseURL     string
+	ModelInfo   ModelInfo
+	ModelKnown  bool
+	Fixture     string
+	RecordTo    string
+	RecordDir   string
+	ReplayDir   string
+	Proxy       string
+	CABundle    string
+	Insecure    bool
+	Timeout     int
+	Quirks      *ProviderQuirks
+	Headers     map[string]string
+	Query       map[string]string
+	HTTP        HTTPConfig
+	Cache       CacheConfig
+	ToolChoice  string
+	Parallel    *bool
+	Project     string
+	Location    string
 }
 
 const (
 	defaultTemperature = 0.7
 	defaultMaxTokens   = 4096
+
+	defaultKeepTurns     = 3
+	defaultPruneMinChars = 2000
+	defaultAttachChars   = 20000
+	defaultPromptBudget  = 4000
+	defaultCacheTTL      = 24 * 60 * 60
+
+	defaultDialTimeout         = 10
+	defaultTLSHandshakeTimeout = 10
+	defaultRequestTimeout      = 120
+	defaultReadIdleTimeout     = 60
+	defaultIdleConnTimeout     = 90
+	defaultMaxIdleConnsPerHost = 8
 )
 
 var (
@@ -64,6 +339,20 @@ var (
 	ConfigPath   = filepath.Join(MinimalDir, "config.json")
 	SystemMDPath = filepath.Join(MinimalDir, "system.md")
 	SkillsDir    = filepath.Join(MinimalDir, "skills")
+	SessionsDir  = filepath.Join(MinimalDir, "sessions")
+	MemoryPath   = filepath.Join(MinimalDir, "memory.md")
+	SharedDir    = filepath.Join(MinimalDir, "shared")
+	TemplatesDir = filepath.Join(MinimalDir, "templates")
+	CommandsDir  = filepath.Join(MinimalDir, "commands")
+	DaemonSocket = filepath.Join(MinimalDir, "daemon.sock")
+	UsagePath    = filepath.Join(MinimalDir, "usage.json")
+	HistoryDir   = filepath.Join(MinimalDir, "history")
+	OutputsDir   = filepath.Join(MinimalDir, "outputs")
+	AuditLogPath = filepath.Join(MinimalDir, "audit.jsonl")
+	DebugDir     = filepath.Join(MinimalDir, "debug")
+	VaultPath    = filepath.Join(MinimalDir, "vault.json")
+	CacheDir     = filepath.Join(MinimalDir, "cache")
+	ApprovalsDir = filepath.Join(MinimalDir, "approvals")
 )
 
 func DefaultConfig() Config {
@@ -85,7 +374,14 @@ func DefaultConfig() Config {
 			DefaultAction: "ask",
 			DenyPatterns:  []string{},
 			AutoCommands:  []string{},
+			AllowedPaths:  []string{},
 		},
+		Models:      map[string]ModelInfo{},
+		UI:          UIConfig{Thinking: ThinkingOn},
+		Diagnostics: map[string]string{},
+		Context:     normalizeContext(ContextConfig{}),
+		Approval:    normalizeApproval(ApprovalConfig{}),
+		Retrieval:   normalizeRetrieval(RetrievalConfig{}),
 	}
 }
 
@@ -95,12 +391,19 @@ func normalizeSchemaType(value string) (SchemaType, bool) {
 		return SchemaOpenAI, true
 	case string(SchemaAnthropic):
 		return SchemaAnthropic, true
+	case string(SchemaMock):
+		return SchemaMock, true
+	case string(SchemaVertex):
+		return SchemaVertex, true
 	default:
 		return "", false
 	}
 }
 
 func defaultBaseURL(provider string, schemaType SchemaType) string {
+	if schemaType == SchemaMock {
+		return "mock://" + provider
+	}
 	if schemaType == SchemaOpenAI {
 		switch provider {
 		case "openai":
@@ -109,6 +412,14 @@ func defaultBaseURL(provider string, schemaType SchemaType) string {
 			return "https://api.groq.com/openai/v1"
 		case "deepseek":
 			return "https://api.deepseek.com"
+		case "xai", "grok":
+			return "https://api.x.ai/v1"
+		case "fireworks":
+			return "https://api.fireworks.ai/inference/v1"
+		case "together":
+			return "https://api.together.xyz/v1"
+		case "cerebras":
+			return "https://api.cerebras.ai/v1"
 		}
 	}
 	if schemaType == SchemaAnthropic {
@@ -122,19 +433,96 @@ func defaultBaseURL(provider string, schemaType SchemaType) string {
 	return ""
 }
 
+// DefaultAPIKeyEnv is the variable a preset provider reads its key from when
+// the variant sets neither api_key nor api_key_env.
+func DefaultAPIKeyEnv(provider string) string {
+	switch provider {
+	case "openai":
+		return "OPENAI_API_KEY"
+	case "anthropic":
+		return "ANTHROPIC_API_KEY"
+	case "groq":
+		return "GROQ_API_KEY"
+	case "deepseek":
+		return "DEEPSEEK_API_KEY"
+	case "minimax":
+		return "MINIMAX_API_KEY"
+	case "xai", "grok":
+		return "XAI_API_KEY"
+	case "fireworks":
+		return "FIREWORKS_API_KEY"
+	case "together":
+		return "TOGETHER_API_KEY"
+	case "cerebras":
+		return "CEREBRAS_API_KEY"
+	}
+	return ""
+}
+
 type rawVariant struct {
-	SchemaType      string  `json:"schema_type"`
-	SchemaTypeCamel string  `json:"schemaType"`
-	APIKey          string  `json:"api_key"`
-	APIKeyCamel     string  `json:"apiKey"`
-	APIKeyEnv       string  `json:"api_key_env"`
-	APIKeyEnvCamel  string  `json:"apiKeyEnv"`
-	BaseURL         string  `json:"base_url"`
-	BaseURLCamel    string  `json:"baseUrl"`
-	Model           string  `json:"model"`
-	Temperature     float64 `json:"temperature"`
-	MaxTokens       int     `json:"max_tokens"`
-	MaxTokensCamel  int     `json:"maxTokens"`
+	SchemaType      string            `json:"schema_type"`
+	SchemaTypeCamel string            `json:"schemaType"`
+	APIKey          string            `json:"api_key"`
+	APIKeyCamel     string            `json:"apiKey"`
+	APIKeyEnv       string            `json:"api_key_env"`
+	APIKeyEnvCamel  string            `json:"apiKeyEnv"`
+	BaseURL         string            `json:"base_url"`
+	BaseURLCamel    string            `json:"baseUrl"`
+	Model           string            `json:"model"`
+	Temperature     float64           `json:"temperature"`
+	MaxTokens       int               `json:"max_tokens"`
+	MaxTokensCamel  int               `json:"maxTokens"`
+	Fixture         string            `json:"fixture"`
+	RecordTo        string            `json:"record_to"`
+	RecordToCamel   string            `json:"recordTo"`
+	Proxy           string            `json:"proxy"`
+	CABundle        string            `json:"ca_bundle"`
+	CABundleCamel   string            `json:"caBundle"`
+	Insecure        bool              `json:"insecure_skip_verify"`
+	InsecureCamel   bool              `json:"insecureSkipVerify"`
+	Timeout         int               `json:"timeout"`
+	Quirks          *ProviderQuirks   `json:"quirks"`
+	Headers         map[string]string `json:"headers"`
+	Query           map[string]string `json:"query"`
+	ToolChoice      string            `json:"tool_choice"`
+	ToolChoiceCamel string            `json:"toolChoice"`
+	Parallel        *bool             `json:"parallel_tool_calls"`
+	ParallelCamel   *bool             `json:"parallelToolCalls"`
+	Project         string            `json:"project"`
+	Location        string            `json:"location"`
+
+	TopP                  *float64 `json:"top_p"`
+	TopPCamel             *float64 `json:"topP"`
+	TopK                  *int     `json:"top_k"`
+	TopKCamel             *int     `json:"topK"`
+	FrequencyPenalty      *float64 `json:"frequency_penalty"`
+	FrequencyPenaltyCamel *float64 `json:"frequencyPenalty"`
+	PresencePenalty       *float64 `json:"presence_penalty"`
+	PresencePenaltyCamel  *float64 `json:"presencePenalty"`
+	Stop                  []string `json:"stop"`
+	Seed                  *int64   `json:"seed"`
+}
+
+// sampling reads the sampling parameters; the snake_case spelling wins
+// when both are set.
+func (v rawVariant) sampling() Sampling {
+	return Sampling{
+		TopP:             firstSet(v.TopP, v.TopPCamel),
+		TopK:             firstSet(v.TopK, v.TopKCamel),
+		FrequencyPenalty: firstSet(v.FrequencyPenalty, v.FrequencyPenaltyCamel),
+		PresencePenalty:  firstSet(v.PresencePenalty, v.PresencePenaltyCamel),
+		Stop:             v.Stop,
+		Seed:             v.Seed,
+	}
+}
+
+func firstSet[T any](values ...*T) *T {
+	for _, value := range values {
+		if value != nil {
+			return value
+		}
+	}
+	return nil
 }
 
 type rawLLM struct {
@@ -145,9 +533,30 @@ type rawLLM struct {
 	Variants             map[string]rawVariant `json:"variants"`
 }
 
+type rawUI struct {
+	Theme       json.RawMessage `json:"theme"`
+	Thinking    string          `json:"thinking"`
+	ModelTitles bool            `json:"modelTitles"`
+	Notify      NotifyConfig    `json:"notify"`
+}
+
 type rawConfig struct {
-	LLM    rawLLM       `json:"llm"`
-	Policy PolicyConfig `json:"policy"`
+	LLM         rawLLM                  `json:"llm"`
+	Policy      PolicyConfig            `json:"policy"`
+	Models      map[string]rawModelInfo `json:"models"`
+	UI          rawUI                   `json:"ui"`
+	Shell       ShellConfig             `json:"shell"`
+	Budget      BudgetConfig            `json:"budget"`
+	Context     ContextConfig           `json:"context"`
+	Approval    ApprovalConfig          `json:"approval"`
+	Skills      SkillsConfig            `json:"skills"`
+	HTTP        HTTPConfig              `json:"http"`
+	Storage     StorageConfig           `json:"storage"`
+	Cache       CacheConfig             `json:"cache"`
+	Retrieval   RetrievalConfig         `json:"retrieval"`
+	Diagnostics map[string]string       `json:"diagnostics"`
+	Hooks       HooksConfig             `json:"hooks"`
+	Agents      map[string]AgentProfile `json:"agents"`
 }
 
 func normalizeVariants(variants map[string]rawVariant) map[string]LlmVariant {
@@ -178,6 +587,14 @@ func normalizeVariants(variants map[string]rawVariant) map[string]LlmVariant {
 		if maxTokens == 0 {
 			maxTokens = variant.MaxTokensCamel
 		}
+		recordTo := variant.RecordTo
+		if recordTo == "" {
+			recordTo = variant.RecordToCamel
+		}
+		parallel := variant.Parallel
+		if parallel == nil {
+			parallel = variant.ParallelCamel
+		}
 
 		normalized[name] = LlmVariant{
 			SchemaType:  schemaType,
@@ -187,6 +604,20 @@ func normalizeVariants(variants map[string]rawVariant) map[string]LlmVariant {
 			Model:       variant.Model,
 			Temperature: variant.Temperature,
 			MaxTokens:   maxTokens,
+			Sampling:    variant.sampling(),
+			Fixture:     ExpandHome(variant.Fixture),
+			RecordTo:    ExpandHome(recordTo),
+			Proxy:       variant.Proxy,
+			CABundle:    ExpandHome(firstNonEmpty(variant.CABundle, variant.CABundleCamel)),
+			Insecure:    variant.Insecure || variant.InsecureCamel,
+			Timeout:     variant.Timeout,
+			Quirks:      variant.Quirks,
+			Headers:     variant.Headers,
+			Query:       variant.Query,
+			ToolChoice:  firstNonEmpty(variant.ToolChoice, variant.ToolChoiceCamel),
+			Parallel:    parallel,
+			Project:     variant.Project,
+			Location:    variant.Location,
 		}
 	}
 
@@ -205,6 +636,16 @@ func normalizeConfig(raw rawConfig) (Config, error) {
 	if raw.Policy.AutoCommands != nil {
 		policy.AutoCommands = raw.Policy.AutoCommands
 	}
+	policy.AutoProjectCommands = raw.Policy.AutoProjectCommands
+	policy.ConfirmUntracked = raw.Policy.ConfirmUntracked
+	policy.ConfirmDangerous = raw.Policy.ConfirmDangerous
+	policy.ReadOnly = raw.Policy.ReadOnly
+	policy.RiskRules = raw.Policy.RiskRules
+	policy.ClassifierModel = strings.TrimSpace(raw.Policy.ClassifierModel)
+	policy.Files = raw.Policy.Files
+	for _, path := range raw.Policy.AllowedPaths {
+		policy.AllowedPaths = append(policy.AllowedPaths, ExpandHome(path))
+	}
 
 	currentProvider := raw.LLM.CurrentProvider
 	if currentProvider == "" {
@@ -224,16 +665,176 @@ func normalizeConfig(raw rawConfig) (Config, error) {
 		return Config{}, errors.New("llm.variants is required in config.json")
 	}
 
+	uiConfig, err := normalizeUI(raw.UI)
+	if err != nil {
+		return Config{}, err
+	}
+
 	return Config{
 		LLM: LlmConfig{
 			CurrentProvider: currentProvider,
 			CurrentModel:    currentModel,
 			Variants:        variants,
 		},
-		Policy: policy,
+		Policy:      policy,
+		Models:      normalizeModels(raw.Models),
+		UI:          uiConfig,
+		Shell:       raw.Shell,
+		Budget:      raw.Budget,
+		Context:     normalizeContext(raw.Context),
+		Approval:    normalizeApproval(raw.Approval),
+		Skills:      raw.Skills,
+		HTTP:        normalizeHTTP(raw.HTTP),
+		Storage:     raw.Storage,
+		Cache:       normalizeCache(raw.Cache),
+		Retrieval:   normalizeRetrieval(raw.Retrieval),
+		Diagnostics: raw.Diagnostics,
+		Hooks:       raw.Hooks,
+		Agents:      raw.Agents,
 	}, nil
 }
 
+func normalizeContext(raw ContextConfig) ContextConfig {
+	if raw.KeepTurns <= 0 {
+		raw.KeepTurns = defaultKeepTurns
+	}
+	if raw.PruneMinChars <= 0 {
+		raw.PruneMinChars = defaultPruneMinChars
+	}
+	if raw.AttachOverChars <= 0 {
+		raw.AttachOverChars = defaultAttachChars
+	}
+	if raw.SystemPromptBudget <= 0 {
+		raw.SystemPromptBudget = defaultPromptBudget
+	}
+	return raw
+}
+
+func normalizeCache(raw CacheConfig) CacheConfig {
+	if raw.TTL <= 0 {
+		raw.TTL = defaultCacheTTL
+	}
+	return raw
+}
+
+func normalizeHTTP(raw HTTPConfig) HTTPConfig {
+	defaults := []struct {
+		value    *int
+		fallback int
+	}{
+		{&raw.DialTimeout, defaultDialTimeout},
+		{&raw.TLSHandshakeTimeout, defaultTLSHandshakeTimeout},
+		{&raw.RequestTimeout, defaultRequestTimeout},
+		{&raw.ReadIdleTimeout, defaultReadIdleTimeout},
+		{&raw.IdleConnTimeout, defaultIdleConnTimeout},
+		{&raw.MaxIdleConnsPerHost, defaultMaxIdleConnsPerHost},
+	}
+	for _, field := range defaults {
+		if *field.value <= 0 {
+			*field.value = field.fallback
+		}
+	}
+	return raw
+}
+
+func normalizeApproval(raw ApprovalConfig) ApprovalConfig {
+	if !approvalStrategies[raw.Strategy] {
+		raw.Strategy = ApprovalPrompt
+	}
+	if !timeoutActions[raw.OnTimeout] {
+		raw.OnTimeout = ApprovalDeny
+	}
+	if raw.Timeout < 0 {
+		raw.Timeout = 0
+	}
+	raw.Webhook.URL = os.ExpandEnv(raw.Webhook.URL)
+	raw.Webhook.Secret = os.ExpandEnv(raw.Webhook.Secret)
+	raw.Webhook.Headers = expandValues(raw.Webhook.Headers)
+	return raw
+}
+
+func normalizeUI(raw rawUI) (UIConfig, error) {
+	uiConfig := UIConfig{ThemeStyles: map[string]string{}, Thinking: ThinkingOn, ModelTitles: raw.ModelTitles, Notify: raw.Notify}
+	if raw.Thinking != "" {
+		if !ValidThinkingDisplay(raw.Thinking) {
+			return UIConfig{}, fmt.Errorf("ui.thinking must be %q, %q or %q", ThinkingOn, ThinkingOff, ThinkingCollapsed)
+		}
+		uiConfig.Thinking = raw.Thinking
+	}
+	if len(raw.Theme) == 0 || string(raw.Theme) == "null" {
+		return uiConfig, nil
+	}
+
+	var preset string
+	if err := json.Unmarshal(raw.Theme, &preset); err == nil {
+		uiConfig.ThemePreset = preset
+		return uiConfig, nil
+	}
+
+	var styles map[string]string
+	if err := json.Unmarshal(raw.Theme, &styles); err != nil {
+		return UIConfig{}, errors.New("ui.theme must be a preset name or an object mapping roles to styles")
+	}
+	for role, style := range styles {
+		if role == "preset" {
+			uiConfig.ThemePreset = style
+			continue
+		}
+		uiConfig.ThemeStyles[role] = style
+	}
+	return uiConfig, nil
+}
+
+func ValidThinkingDisplay(mode string) bool {
+	return mode == ThinkingOn || mode == ThinkingOff || mode == ThinkingCollapsed
+}
+
+func SetUIOption(key string, value interface{}) error {
+	root, err := ReadConfigTree()
+	if err != nil {
+		return err
+	}
+	uiSection, _ := root["ui"].(map[string]interface{})
+	if uiSection == nil {
+		uiSection = map[string]interface{}{}
+	}
+	uiSection[key] = value
+	root["ui"] = uiSection
+	return WriteConfigTree(root)
+}
+
+func ReadConfigTree() (map[string]interface{}, error) {
+	root := map[string]interface{}{}
+	data, err := os.ReadFile(ConfigPath)
+	if err == nil {
+		if err := json.Unmarshal(data, &root); err != nil {
+			return nil, fmt.Errorf("invalid config.json: %w", err)
+		}
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	return root, nil
+}
+
+func EncodeConfigTree(root map[string]interface{}) ([]byte, error) {
+	payload, err := json.MarshalIndent(root, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(payload, '\n'), nil
+}
+
+func WriteConfigTree(root map[string]interface{}) error {
+	payload, err := EncodeConfigTree(root)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(ConfigPath), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(ConfigPath, payload, 0o644)
+}
+
 func LoadConfig() (Config, error) {
 	if _, err := os.Stat(ConfigPath); err != nil {
 		if errors.Is(err, os.ErrNotExist) {
@@ -247,6 +848,10 @@ func LoadConfig() (Config, error) {
 		return Config{}, err
 	}
 
+	return ParseConfig(data)
+}
+
+func ParseConfig(data []byte) (Config, error) {
 	var raw rawConfig
 	if err := json.Unmarshal(data, &raw); err != nil {
 		return Config{}, fmt.Errorf("invalid config.json: %w", err)
@@ -264,10 +869,21 @@ func ResolveLlmConfig(config Config) (ResolvedLlmConfig, error) {
 
 	schemaType, ok := normalizeSchemaType(string(variant.SchemaType))
 	if !ok {
-		return ResolvedLlmConfig{}, fmt.Errorf("invalid schema type for %s. Use \"openai\" or \"anthropic\"", provider)
+		return ResolvedLlmConfig{}, fmt.Errorf("invalid schema type for %s. Use \"openai\", \"anthropic\", \"vertex\" or \"mock\"", provider)
+	}
+
+	project, location := variant.Project, variant.Location
+	if schemaType == SchemaVertex {
+		project, location = vertexTarget(variant)
+		if project == "" {
+			return ResolvedLlmConfig{}, fmt.Errorf("project is required for provider: %s (or set GOOGLE_CLOUD_PROJECT)", provider)
+		}
 	}
 
 	baseURL := variant.BaseURL
+	if baseURL == "" && schemaType == SchemaVertex {
+		baseURL = VertexBaseURL(location)
+	}
 	if baseURL == "" {
 		baseURL = defaultBaseURL(provider, schemaType)
 	}
@@ -284,8 +900,23 @@ func ResolveLlmConfig(config Config) (ResolvedLlmConfig, error) {
 	}
 
 	apiKey := variant.APIKey
-	if apiKey == "" && variant.APIKeyEnv != "" {
-		apiKey = os.Getenv(variant.APIKeyEnv)
+	apiKeyEnv := variant.APIKeyEnv
+	if apiKey == "" && apiKeyEnv == "" {
+		apiKeyEnv = DefaultAPIKeyEnv(provider)
+	}
+	if apiKey == "" && apiKeyEnv != "" {
+		apiKey = os.Getenv(apiKeyEnv)
+	}
+
+	if variant.Proxy != "" {
+		if _, err := parseProxyURL(variant.Proxy); err != nil {
+			return ResolvedLlmConfig{}, fmt.Errorf("invalid proxy for %s: %w", provider, err)
+		}
+	}
+	if variant.CABundle != "" {
+		if _, err := os.Stat(variant.CABundle); err != nil {
+			return ResolvedLlmConfig{}, fmt.Errorf("ca_bundle for %s: %w", provider, err)
+		}
 	}
 
 	temperature := variant.Temperature
@@ -297,18 +928,76 @@ func ResolveLlmConfig(config Config) (ResolvedLlmConfig, error) {
 		maxTokens = defaultMaxTokens
 	}
 
+	httpConfig := normalizeHTTP(config.HTTP)
+	timeout := variant.Timeout
+	if timeout <= 0 {
+		timeout = httpConfig.RequestTimeout
+	}
+
+	modelInfo, modelKnown := LookupModel(model, config.Models)
+	if modelKnown && modelInfo.MaxOutputTokens > 0 && maxTokens > modelInfo.MaxOutputTokens {
+		maxTokens = modelInfo.MaxOutputTokens
+	}
+
 	return ResolvedLlmConfig{
 		Provider:    provider,
 		SchemaType:  schemaType,
 		Model:       model,
 		Temperature: temperature,
 		MaxTokens:   maxTokens,
+		Sampling:    variant.Sampling,
 		APIKey:      apiKey,
 		APIKeyEnv:   variant.APIKeyEnv,
 		BaseURL:     baseURL,
+		ModelInfo:   modelInfo,
+		ModelKnown:  modelKnown,
+		Fixture:     variant.Fixture,
+		RecordTo:    variant.RecordTo,
+		RecordDir:   config.RecordDir,
+		ReplayDir:   config.ReplayDir,
+		Proxy:       variant.Proxy,
+		CABundle:    variant.CABundle,
+		Insecure:    variant.Insecure,
+		Timeout:     timeout,
+		Quirks:      variant.Quirks,
+		Headers:     expandValues(variant.Headers),
+		Query:       expandValues(variant.Query),
+		HTTP:        httpConfig,
+		Cache:       normalizeCache(config.Cache),
+		ToolChoice:  variant.ToolChoice,
+		Parallel:    variant.Parallel,
+		Project:     project,
+		Location:    location,
 	}, nil
 }
 
+// vertexTarget fills in the project and region from the environment gcloud
+// and the Google client libraries use when the variant leaves them out.
+func vertexTarget(variant LlmVariant) (string, string) {
+	project := firstNonEmpty(variant.Project, os.Getenv("GOOGLE_CLOUD_PROJECT"), os.Getenv("CLOUDSDK_CORE_PROJECT"))
+	location := firstNonEmpty(variant.Location, os.Getenv("GOOGLE_CLOUD_LOCATION"), defaultVertexLocation)
+	return project, location
+}
+
+// VertexBaseURL is the regional endpoint, or the global one for "global".
+func VertexBaseURL(location string) string {
+	if location == "global" {
+		return "https://aiplatform.googleapis.com"
+	}
+	return "https://" + location + "-aiplatform.googleapis.com"
+}
+
+func expandValues(values map[string]string) map[string]string {
+	if len(values) == 0 {
+		return nil
+	}
+	expanded := make(map[string]string, len(values))
+	for key, value := range values {
+		expanded[key] = os.ExpandEnv(value)
+	}
+	return expanded
+}
+
 func LoadSystemPrompt() (string, error) {
 	data, err := os.ReadFile(SystemMDPath)
 	if err != nil {
@@ -328,6 +1017,37 @@ func EnsureMinimalDir() error {
 	return nil
 }
 
+func ExpandHome(path string) string {
+	if path == "~" {
+		return userHomeDir()
+	}
+	if strings.HasPrefix(path, "~/") {
+		return filepath.Join(userHomeDir(), path[2:])
+	}
+	return path
+}
+
+func parseProxyURL(value string) (*url.URL, error) {
+	parsed, err := url.Parse(value)
+	if err != nil {
+		return nil, err
+	}
+	switch parsed.Scheme {
+	case "http", "https", "socks5":
+	default:
+		return nil, fmt.Errorf("unsupported scheme %q (use http, https or socks5)", parsed.Scheme)
+	}
+	if parsed.Host == "" {
+		return nil, fmt.Errorf("missing host in %q", value)
+	}
+	return parsed, nil
+}
+
+func ProxyURL(value string) *url.URL {
+	parsed, _ := parseProxyURL(value)
+	return parsed
+}
+
 func userHomeDir() string {
 	home, err := os.UserHomeDir()
 	if err != nil {
@@ -0,0 +1,33 @@
+package config
+
+import "testing"
+
+func TestProviderPresets(t *testing.T) {
+	presets := []struct {
+		provider, baseURL, keyEnv string
+	}{
+		{"xai", "https://api.x.ai/v1", "XAI_API_KEY"},
+		{"grok", "https://api.x.ai/v1", "XAI_API_KEY"},
+		{"fireworks", "https://api.fireworks.ai/inference/v1", "FIREWORKS_API_KEY"},
+		{"together", "https://api.together.xyz/v1", "TOGETHER_API_KEY"},
+		{"cerebras", "https://api.cerebras.ai/v1", "CEREBRAS_API_KEY"},
+	}
+	for _, preset := range presets {
+		t.Run(preset.provider, func(t *testing.T) {
+			t.Setenv(preset.keyEnv, "key-"+preset.provider)
+			resolved, err := ResolveLlmConfig(Config{LLM: LlmConfig{
+				CurrentProvider: preset.provider,
+				Variants:        map[string]LlmVariant{preset.provider: {SchemaType: SchemaOpenAI, Model: "some-model"}},
+			}})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if resolved.BaseURL != preset.baseURL {
+				t.Errorf("base URL = %q, want %q", resolved.BaseURL, preset.baseURL)
+			}
+			if resolved.APIKey != "key-"+preset.provider {
+				t.Errorf("API key = %q, want the value of %s", resolved.APIKey, preset.keyEnv)
+			}
+		})
+	}
+}
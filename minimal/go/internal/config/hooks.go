@@ -0,0 +1,89 @@
+package config
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+type HooksConfig struct {
+	// PostEdit runs after each batch of tool calls over the files the batch
+	// wrote, e.g. {"match": "*.go", "command": "gofmt -w {files}"}.
+	PostEdit []PostEditHook `json:"postEdit"`
+	// PreTool runs before each tool call it applies to and may allow, deny
+	// or rewrite the call.
+	PreTool []PreToolHook `json:"preTool"`
+}
+
+// PostEditHook is a command template; {files} expands to the matching files
+// and {packages} to their directories, as for diagnostics.
+type PostEditHook struct {
+	// Match is a glob checked against the file name and its
+	// workspace-relative path. Empty matches every file.
+	Match   string `json:"match"`
+	Command string `json:"command"`
+}
+
+// PreToolHook is a command that gets the proposed call as JSON on stdin. It
+// allows the call by exiting 0 with no output, denies it by exiting 2 (stderr
+// is the reason), or prints {"decision": "allow"|"deny", "message": "...",
+// "input": {...}} where input replaces the call's arguments. Any other
+// failure denies the call.
+type PreToolHook struct {
+	// Tools limits the hook to these tool names. Empty means every tool.
+	Tools   []string `json:"tools"`
+	Command string   `json:"command"`
+	// Timeout is in seconds; 0 means 10.
+	Timeout int `json:"timeout"`
+}
+
+func (h PreToolHook) Applies(tool string) bool {
+	if len(h.Tools) == 0 {
+		return true
+	}
+	for _, name := range h.Tools {
+		if name == tool {
+			return true
+		}
+	}
+	return false
+}
+
+func (h PostEditHook) Matches(file string) bool {
+	if h.Match == "" {
+		return true
+	}
+	file = strings.ReplaceAll(file, "\\", "/")
+	for _, candidate := range []string{path.Base(file), file} {
+		if ok, _ := path.Match(h.Match, candidate); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func validateHooks(hooks HooksConfig, report func(string, string, ...interface{})) {
+	for i, hook := range hooks.PostEdit {
+		prefix := fmt.Sprintf("hooks.postEdit[%d]", i)
+		if strings.TrimSpace(hook.Command) == "" {
+			report(prefix+".command", "is required")
+		}
+		if _, err := path.Match(hook.Match, ""); err != nil {
+			report(prefix+".match", "invalid glob %q", hook.Match)
+		}
+	}
+	for i, hook := range hooks.PreTool {
+		prefix := fmt.Sprintf("hooks.preTool[%d]", i)
+		if strings.TrimSpace(hook.Command) == "" {
+			report(prefix+".command", "is required")
+		}
+		if hook.Timeout < 0 {
+			report(prefix+".timeout", "must not be negative")
+		}
+		for _, name := range hook.Tools {
+			if !toolNamePattern.MatchString(name) {
+				report(prefix+".tools", "%q is not a valid tool name", name)
+			}
+		}
+	}
+}
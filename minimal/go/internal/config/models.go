@@ -0,0 +1,130 @@
+package config
+
+import "strings"
+
+type ModelInfo struct {
+	ContextWindow   int     `json:"contextWindow"`
+	MaxOutputTokens int     `json:"maxOutputTokens"`
+	SupportsTools   bool    `json:"supportsTools"`
+	SupportsVision  bool    `json:"supportsVision"`
+	InputPrice      float64 `json:"inputPrice"`
+	OutputPrice     float64 `json:"outputPrice"`
+}
+
+type rawModelInfo struct {
+	ContextWindow        int      `json:"context_window"`
+	ContextWindowCamel   int      `json:"contextWindow"`
+	MaxOutputTokens      int      `json:"max_output_tokens"`
+	MaxOutputTokensCamel int      `json:"maxOutputTokens"`
+	SupportsTools        *bool    `json:"supports_tools"`
+	SupportsToolsCamel   *bool    `json:"supportsTools"`
+	SupportsVision       *bool    `json:"supports_vision"`
+	SupportsVisionCamel  *bool    `json:"supportsVision"`
+	InputPrice           *float64 `json:"input_price"`
+	InputPriceCamel      *float64 `json:"inputPrice"`
+	OutputPrice          *float64 `json:"output_price"`
+	OutputPriceCamel     *float64 `json:"outputPrice"`
+}
+
+var builtinModels = map[string]ModelInfo{
+	"moonshotai/kimi-k2-instruct": {ContextWindow: 131072, MaxOutputTokens: 16384, SupportsTools: true, InputPrice: 1.00, OutputPrice: 3.00},
+	"openai/gpt-oss-120b":         {ContextWindow: 131072, MaxOutputTokens: 65536, SupportsTools: true, InputPrice: 0.15, OutputPrice: 0.75},
+	"llama-3.3-70b-versatile":     {ContextWindow: 131072, MaxOutputTokens: 32768, SupportsTools: true, InputPrice: 0.59, OutputPrice: 0.79},
+	"gpt-4o":                      {ContextWindow: 128000, MaxOutputTokens: 16384, SupportsTools: true, SupportsVision: true, InputPrice: 2.50, OutputPrice: 10.00},
+	"gpt-4o-mini":                 {ContextWindow: 128000, MaxOutputTokens: 16384, SupportsTools: true, SupportsVision: true, InputPrice: 0.15, OutputPrice: 0.60},
+	"gpt-4.1":                     {ContextWindow: 1047576, MaxOutputTokens: 32768, SupportsTools: true, SupportsVision: true, InputPrice: 2.00, OutputPrice: 8.00},
+	"gpt-5":                       {ContextWindow: 400000, MaxOutputTokens: 128000, SupportsTools: true, SupportsVision: true, InputPrice: 1.25, OutputPrice: 10.00},
+	"deepseek-chat":               {ContextWindow: 65536, MaxOutputTokens: 8192, SupportsTools: true, InputPrice: 0.27, OutputPrice: 1.10},
+	"deepseek-reasoner":           {ContextWindow: 65536, MaxOutputTokens: 32768, SupportsTools: true, InputPrice: 0.55, OutputPrice: 2.19},
+	"claude-3-5-haiku":            {ContextWindow: 200000, MaxOutputTokens: 8192, SupportsTools: true, SupportsVision: true, InputPrice: 0.80, OutputPrice: 4.00},
+	"claude-3-7-sonnet":           {ContextWindow: 200000, MaxOutputTokens: 64000, SupportsTools: true, SupportsVision: true, InputPrice: 3.00, OutputPrice: 15.00},
+	"claude-sonnet-4":             {ContextWindow: 200000, MaxOutputTokens: 64000, SupportsTools: true, SupportsVision: true, InputPrice: 3.00, OutputPrice: 15.00},
+	"claude-opus-4":               {ContextWindow: 200000, MaxOutputTokens: 32000, SupportsTools: true, SupportsVision: true, InputPrice: 15.00, OutputPrice: 75.00},
+	"MiniMax-M2":                  {ContextWindow: 204800, MaxOutputTokens: 131072, SupportsTools: true, InputPrice: 0.30, OutputPrice: 1.20},
+}
+
+func normalizeModels(models map[string]rawModelInfo) map[string]ModelInfo {
+	normalized := map[string]ModelInfo{}
+	for name, raw := range models {
+		info, _ := lookupBuiltinModel(name)
+
+		contextWindow := raw.ContextWindow
+		if contextWindow == 0 {
+			contextWindow = raw.ContextWindowCamel
+		}
+		if contextWindow != 0 {
+			info.ContextWindow = contextWindow
+		}
+		maxOutput := raw.MaxOutputTokens
+		if maxOutput == 0 {
+			maxOutput = raw.MaxOutputTokensCamel
+		}
+		if maxOutput != 0 {
+			info.MaxOutputTokens = maxOutput
+		}
+		if value := firstBool(raw.SupportsTools, raw.SupportsToolsCamel); value != nil {
+			info.SupportsTools = *value
+		}
+		if value := firstBool(raw.SupportsVision, raw.SupportsVisionCamel); value != nil {
+			info.SupportsVision = *value
+		}
+		if value := firstFloat(raw.InputPrice, raw.InputPriceCamel); value != nil {
+			info.InputPrice = *value
+		}
+		if value := firstFloat(raw.OutputPrice, raw.OutputPriceCamel); value != nil {
+			info.OutputPrice = *value
+		}
+
+		normalized[name] = info
+	}
+	return normalized
+}
+
+func firstBool(values ...*bool) *bool {
+	for _, value := range values {
+		if value != nil {
+			return value
+		}
+	}
+	return nil
+}
+
+func firstFloat(values ...*float64) *float64 {
+	for _, value := range values {
+		if value != nil {
+			return value
+		}
+	}
+	return nil
+}
+
+func (m ModelInfo) Cost(promptTokens int, completionTokens int) float64 {
+	return (float64(promptTokens)*m.InputPrice + float64(completionTokens)*m.OutputPrice) / 1_000_000
+}
+
+func LookupModel(model string, overrides map[string]ModelInfo) (ModelInfo, bool) {
+	if info, ok := matchModel(model, overrides); ok {
+		return info, true
+	}
+	return lookupBuiltinModel(model)
+}
+
+func lookupBuiltinModel(model string) (ModelInfo, bool) {
+	return matchModel(model, builtinModels)
+}
+
+func matchModel(model string, table map[string]ModelInfo) (ModelInfo, bool) {
+	if info, ok := table[model]; ok {
+		return info, true
+	}
+	bestKey := ""
+	for key := range table {
+		if strings.HasPrefix(model, key) && len(key) > len(bestKey) {
+			bestKey = key
+		}
+	}
+	if bestKey == "" {
+		return ModelInfo{}, false
+	}
+	return table[bestKey], true
+}
@@ -0,0 +1,77 @@
+package config
+
+import "strings"
+
+const (
+	RetrievalSkills = "skills"
+	RetrievalMemory = "memory"
+
+	defaultRetrievalBaseURL  = "https://api.openai.com/v1"
+	defaultRetrievalKeyEnv   = "OPENAI_API_KEY"
+	defaultRetrievalTopK     = 3
+	defaultRetrievalMinScore = 0.3
+)
+
+// RetrievalConfig embeds each prompt with an OpenAI-compatible embeddings
+// endpoint and attaches the closest skills and memory entries to it.
+// Retrieval stays off until Model is set.
+type RetrievalConfig struct {
+	BaseURL   string `json:"baseUrl"`
+	Model     string `json:"model"`
+	APIKeyEnv string `json:"apiKeyEnv"`
+	// TopK caps how many entries are attached to one prompt.
+	TopK int `json:"topK"`
+	// MinScore is the cosine similarity an entry needs to be attached.
+	MinScore float64 `json:"minScore"`
+	// Sources is what gets searched: "skills", "memory" or both (default).
+	Sources []string `json:"sources"`
+}
+
+func (r RetrievalConfig) Enabled() bool {
+	return r.Model != ""
+}
+
+func (r RetrievalConfig) Searches(source string) bool {
+	for _, name := range r.Sources {
+		if name == source {
+			return true
+		}
+	}
+	return false
+}
+
+func normalizeRetrieval(raw RetrievalConfig) RetrievalConfig {
+	if raw.BaseURL == "" {
+		raw.BaseURL = defaultRetrievalBaseURL
+	}
+	if raw.APIKeyEnv == "" {
+		raw.APIKeyEnv = defaultRetrievalKeyEnv
+	}
+	if raw.TopK <= 0 {
+		raw.TopK = defaultRetrievalTopK
+	}
+	if raw.MinScore <= 0 {
+		raw.MinScore = defaultRetrievalMinScore
+	}
+	if len(raw.Sources) == 0 {
+		raw.Sources = []string{RetrievalSkills, RetrievalMemory}
+	}
+	return raw
+}
+
+func validateRetrieval(raw RetrievalConfig, report func(string, string, ...interface{})) {
+	if raw.TopK < 0 {
+		report("retrieval.topK", "must not be negative")
+	}
+	if raw.MinScore < 0 || raw.MinScore > 1 {
+		report("retrieval.minScore", "must be between 0 and 1")
+	}
+	for _, source := range raw.Sources {
+		if source != RetrievalSkills && source != RetrievalMemory {
+			report("retrieval.sources", "unknown source %q (use %q or %q)", source, RetrievalSkills, RetrievalMemory)
+		}
+	}
+	if raw.BaseURL != "" && !strings.HasPrefix(raw.BaseURL, "http://") && !strings.HasPrefix(raw.BaseURL, "https://") {
+		report("retrieval.baseUrl", "must be an http(s) URL")
+	}
+}
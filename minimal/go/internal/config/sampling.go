@@ -0,0 +1,58 @@
+package config
+
+// Sampling holds the optional sampling parameters of a variant or a single
+// request. Unset fields are left out of the request, so the provider's own
+// defaults apply. Providers ignore the parameters their API lacks: Anthropic
+// has no penalties or seed, OpenAI no top_k.
+type Sampling struct {
+	TopP             *float64 `json:"topP,omitempty"`
+	TopK             *int     `json:"topK,omitempty"`
+	FrequencyPenalty *float64 `json:"frequencyPenalty,omitempty"`
+	PresencePenalty  *float64 `json:"presencePenalty,omitempty"`
+	Stop             []string `json:"stop,omitempty"`
+	Seed             *int64   `json:"seed,omitempty"`
+}
+
+// Override returns s with every field set in override replacing its own.
+func (s Sampling) Override(override Sampling) Sampling {
+	if override.TopP != nil {
+		s.TopP = override.TopP
+	}
+	if override.TopK != nil {
+		s.TopK = override.TopK
+	}
+	if override.FrequencyPenalty != nil {
+		s.FrequencyPenalty = override.FrequencyPenalty
+	}
+	if override.PresencePenalty != nil {
+		s.PresencePenalty = override.PresencePenalty
+	}
+	if override.Stop != nil {
+		s.Stop = override.Stop
+	}
+	if override.Seed != nil {
+		s.Seed = override.Seed
+	}
+	return s
+}
+
+func validateSampling(path string, s Sampling, report func(string, string, ...interface{})) {
+	if s.TopP != nil && (*s.TopP <= 0 || *s.TopP > 1) {
+		report(path+".top_p", "must be greater than 0 and at most 1")
+	}
+	if s.TopK != nil && *s.TopK <= 0 {
+		report(path+".top_k", "must be positive")
+	}
+	if s.FrequencyPenalty != nil && (*s.FrequencyPenalty < -2 || *s.FrequencyPenalty > 2) {
+		report(path+".frequency_penalty", "must be between -2 and 2")
+	}
+	if s.PresencePenalty != nil && (*s.PresencePenalty < -2 || *s.PresencePenalty > 2) {
+		report(path+".presence_penalty", "must be between -2 and 2")
+	}
+	for _, stop := range s.Stop {
+		if stop == "" {
+			report(path+".stop", "must not contain empty strings")
+			break
+		}
+	}
+}
@@ -0,0 +1,491 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+type ValidationIssue struct {
+	Path    string
+	Message string
+}
+
+func (i ValidationIssue) String() string {
+	if i.Path == "" {
+		return i.Message
+	}
+	return i.Path + ": " + i.Message
+}
+
+var validDefaultActions = map[string]bool{"ask": true, "deny": true}
+
+var toolNamePattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,64}$`)
+
+func ValidateConfigFile(path string) ([]ValidationIssue, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ValidateConfig(data), nil
+}
+
+func ValidateConfig(data []byte) []ValidationIssue {
+	var issues []ValidationIssue
+	report := func(path string, format string, args ...interface{}) {
+		issues = append(issues, ValidationIssue{Path: path, Message: fmt.Sprintf(format, args...)})
+	}
+
+	var root map[string]json.RawMessage
+	if err := json.Unmarshal(data, &root); err != nil {
+		report("", "invalid JSON: %s", describeJSONError(data, err))
+		return issues
+	}
+	checkKeys("", root, rawConfig{}, report)
+
+	var raw rawConfig
+	for key, value := range root {
+		var target interface{}
+		switch key {
+		case "llm":
+			target = &raw.LLM
+		case "policy":
+			target = &raw.Policy
+		case "models":
+			target = &raw.Models
+		case "ui":
+			target = &raw.UI
+		case "shell":
+			target = &raw.Shell
+		case "budget":
+			target = &raw.Budget
+		case "context":
+			target = &raw.Context
+		case "approval":
+			target = &raw.Approval
+		case "skills":
+			target = &raw.Skills
+		case "http":
+			target = &raw.HTTP
+		case "diagnostics":
+			target = &raw.Diagnostics
+		case "storage":
+			target = &raw.Storage
+		case "cache":
+			target = &raw.Cache
+		case "retrieval":
+			target = &raw.Retrieval
+		case "hooks":
+			target = &raw.Hooks
+		case "agents":
+			target = &raw.Agents
+		default:
+			continue
+		}
+		if err := json.Unmarshal(value, target); err != nil {
+			var typeErr *json.UnmarshalTypeError
+			if errors.As(err, &typeErr) && typeErr.Field != "" {
+				report(joinPath(key, typeErr.Field), "should be %s, got %s", typeErr.Type, typeErr.Value)
+				continue
+			}
+			report(key, "%s", describeJSONError(value, err))
+		}
+	}
+
+	var llm map[string]json.RawMessage
+	if json.Unmarshal(root["llm"], &llm) == nil {
+		checkKeys("llm", llm, rawLLM{}, report)
+		var variants map[string]map[string]json.RawMessage
+		if json.Unmarshal(llm["variants"], &variants) == nil {
+			for _, name := range sortedKeys(variants) {
+				checkKeys("llm.variants."+name, variants[name], rawVariant{}, report)
+				var quirks map[string]json.RawMessage
+				if json.Unmarshal(variants[name]["quirks"], &quirks) == nil {
+					checkKeys("llm.variants."+name+".quirks", quirks, ProviderQuirks{}, report)
+				}
+			}
+		}
+	}
+	var agents map[string]map[string]json.RawMessage
+	if json.Unmarshal(root["agents"], &agents) == nil {
+		for _, name := range sortedKeys(agents) {
+			checkKeys("agents."+name, agents[name], AgentProfile{}, report)
+			var policy map[string]json.RawMessage
+			if json.Unmarshal(agents[name]["policy"], &policy) == nil {
+				checkKeys("agents."+name+".policy", policy, PolicyOverride{}, report)
+			}
+		}
+	}
+	var hooks map[string]json.RawMessage
+	if json.Unmarshal(root["hooks"], &hooks) == nil {
+		checkKeys("hooks", hooks, HooksConfig{}, report)
+		var postEdit []map[string]json.RawMessage
+		if json.Unmarshal(hooks["postEdit"], &postEdit) == nil {
+			for i, hook := range postEdit {
+				checkKeys(fmt.Sprintf("hooks.postEdit[%d]", i), hook, PostEditHook{}, report)
+			}
+		}
+		var preTool []map[string]json.RawMessage
+		if json.Unmarshal(hooks["preTool"], &preTool) == nil {
+			for i, hook := range preTool {
+				checkKeys(fmt.Sprintf("hooks.preTool[%d]", i), hook, PreToolHook{}, report)
+			}
+		}
+	}
+	var models map[string]map[string]json.RawMessage
+	if json.Unmarshal(root["models"], &models) == nil {
+		for _, name := range sortedKeys(models) {
+			checkKeys("models."+name, models[name], rawModelInfo{}, report)
+		}
+	}
+	var policy map[string]json.RawMessage
+	if json.Unmarshal(root["policy"], &policy) == nil {
+		checkKeys("policy", policy, PolicyConfig{}, report)
+		var files map[string]json.RawMessage
+		if json.Unmarshal(policy["files"], &files) == nil {
+			checkKeys("policy.files", files, FileAccessConfig{}, report)
+			var projects map[string]map[string]json.RawMessage
+			if json.Unmarshal(files["projects"], &projects) == nil {
+				for _, root := range sortedKeys(projects) {
+					checkKeys("policy.files.projects."+root, projects[root], FileAccessRules{}, report)
+				}
+			}
+		}
+	}
+	var retrieval map[string]json.RawMessage
+	if json.Unmarshal(root["retrieval"], &retrieval) == nil {
+		checkKeys("retrieval", retrieval, RetrievalConfig{}, report)
+	}
+	var shell map[string]json.RawMessage
+	if json.Unmarshal(root["shell"], &shell) == nil {
+		checkKeys("shell", shell, ShellConfig{}, report)
+	}
+	var budget map[string]json.RawMessage
+	if json.Unmarshal(root["budget"], &budget) == nil {
+		checkKeys("budget", budget, BudgetConfig{}, report)
+	}
+	var contextSection map[string]json.RawMessage
+	if json.Unmarshal(root["context"], &contextSection) == nil {
+		checkKeys("context", contextSection, ContextConfig{}, report)
+	}
+	var approval map[string]json.RawMessage
+	if json.Unmarshal(root["approval"], &approval) == nil {
+		checkKeys("approval", approval, ApprovalConfig{}, report)
+		var webhook map[string]json.RawMessage
+		if json.Unmarshal(approval["webhook"], &webhook) == nil {
+			checkKeys("approval.webhook", webhook, WebhookConfig{}, report)
+		}
+	}
+
+	var uiSection map[string]json.RawMessage
+	if json.Unmarshal(root["ui"], &uiSection) == nil {
+		checkKeys("ui", uiSection, rawUI{}, report)
+		var notify map[string]json.RawMessage
+		if json.Unmarshal(uiSection["notify"], &notify) == nil {
+			checkKeys("ui.notify", notify, NotifyConfig{}, report)
+		}
+	}
+	var skillsSection map[string]json.RawMessage
+	if json.Unmarshal(root["skills"], &skillsSection) == nil {
+		checkKeys("skills", skillsSection, SkillsConfig{}, report)
+	}
+	var httpSection map[string]json.RawMessage
+	if json.Unmarshal(root["http"], &httpSection) == nil {
+		checkKeys("http", httpSection, HTTPConfig{}, report)
+	}
+	if h := raw.HTTP; h.DialTimeout < 0 || h.TLSHandshakeTimeout < 0 || h.RequestTimeout < 0 || h.ReadIdleTimeout < 0 || h.IdleConnTimeout < 0 || h.MaxIdleConnsPerHost < 0 {
+		report("http", "timeouts and maxIdleConnsPerHost must not be negative (0 keeps the default)")
+	}
+	if raw.Skills.Subdir != "" && (filepath.IsAbs(raw.Skills.Subdir) || strings.HasPrefix(filepath.Clean(raw.Skills.Subdir), "..")) {
+		report("skills.subdir", "must be a relative path inside the repository")
+	}
+
+	currentProvider := firstNonEmpty(raw.LLM.CurrentProvider, raw.LLM.CurrentProviderCamel)
+	currentModel := firstNonEmpty(raw.LLM.CurrentModel, raw.LLM.CurrentModelCamel)
+	if len(raw.LLM.Variants) == 0 {
+		report("llm.variants", "at least one variant is required")
+	} else if currentProvider != "" {
+		if _, ok := raw.LLM.Variants[currentProvider]; !ok {
+			report("llm.current_provider", "%q is not a configured variant", currentProvider)
+		}
+	}
+
+	for _, name := range sortedKeys(raw.LLM.Variants) {
+		variant := raw.LLM.Variants[name]
+		path := "llm.variants." + name
+		schemaValue := firstNonEmpty(variant.SchemaType, variant.SchemaTypeCamel)
+		schemaType, ok := normalizeSchemaType(schemaValue)
+		if !ok {
+			if schemaValue == "" {
+				report(path+".schema_type", "is required (\"openai\", \"anthropic\", \"vertex\" or \"mock\")")
+			} else {
+				report(path+".schema_type", "unknown schema type %q (use \"openai\", \"anthropic\", \"vertex\" or \"mock\")", schemaValue)
+			}
+			continue
+		}
+
+		if variant.Model == "" && !(name == currentProvider && currentModel != "") {
+			report(path+".model", "is required")
+		}
+		if schemaType == SchemaVertex {
+			if project, _ := vertexTarget(LlmVariant{Project: variant.Project}); project == "" {
+				report(path+".project", "is required for vertex variants (or set GOOGLE_CLOUD_PROJECT)")
+			}
+		} else if variant.Project != "" || variant.Location != "" {
+			report(path, "project and location only apply to vertex variants")
+		}
+		if firstNonEmpty(variant.BaseURL, variant.BaseURLCamel) == "" && defaultBaseURL(name, schemaType) == "" && schemaType != SchemaVertex {
+			report(path+".base_url", "is required (no default endpoint for %q)", name)
+		}
+		if variant.Temperature < 0 || variant.Temperature > 2 {
+			report(path+".temperature", "must be between 0 and 2")
+		}
+		if variant.MaxTokens < 0 || variant.MaxTokensCamel < 0 {
+			report(path+".max_tokens", "must be positive")
+		}
+		validateSampling(path, variant.sampling(), report)
+		if variant.Timeout < 0 {
+			report(path+".timeout", "must not be negative (0 uses http.requestTimeout)")
+		}
+		if choice := firstNonEmpty(variant.ToolChoice, variant.ToolChoiceCamel); choice != "" && !toolNamePattern.MatchString(choice) {
+			report(path+".tool_choice", "must be %q, %q, %q or a tool name", ToolChoiceAuto, ToolChoiceNone, ToolChoiceRequired)
+		}
+		if variant.Quirks != nil && variant.Quirks.MaxToolNameLength != 0 && variant.Quirks.MaxToolNameLength < 16 {
+			report(path+".quirks.maxToolNameLength", "must be at least 16 (0 means no limit)")
+		}
+
+		if schemaType == SchemaMock {
+			if variant.Fixture == "" {
+				report(path+".fixture", "is required for mock variants")
+			} else if _, err := os.Stat(ExpandHome(variant.Fixture)); err != nil {
+				report(path+".fixture", "cannot read %s", variant.Fixture)
+			}
+			continue
+		}
+
+		if variant.Proxy != "" {
+			if _, err := parseProxyURL(variant.Proxy); err != nil {
+				report(path+".proxy", "%v", err)
+			}
+		}
+		if caBundle := firstNonEmpty(variant.CABundle, variant.CABundleCamel); caBundle != "" {
+			if _, err := os.Stat(ExpandHome(caBundle)); err != nil {
+				report(path+".ca_bundle", "cannot read %s", caBundle)
+			}
+		}
+
+		for _, section := range []struct {
+			name   string
+			values map[string]string
+		}{{"headers", variant.Headers}, {"query", variant.Query}} {
+			for _, key := range sortedKeys(section.values) {
+				if strings.TrimSpace(key) == "" {
+					report(path+"."+section.name, "names must not be empty")
+				}
+				for _, name := range unsetEnv(section.values[key]) {
+					report(path+"."+section.name+"."+key, "environment variable %s is not set", name)
+				}
+			}
+		}
+
+		apiKeyEnv := firstNonEmpty(variant.APIKeyEnv, variant.APIKeyEnvCamel)
+		if firstNonEmpty(variant.APIKey, variant.APIKeyCamel) == "" && apiKeyEnv != "" && os.Getenv(apiKeyEnv) == "" {
+			report(path+".api_key_env", "environment variable %s is not set", apiKeyEnv)
+		}
+	}
+
+	if raw.Policy.DefaultAction != "" && !validDefaultActions[raw.Policy.DefaultAction] {
+		report("policy.defaultAction", "unknown action %q (use \"ask\" or \"deny\")", raw.Policy.DefaultAction)
+	}
+	for i, class := range raw.Policy.ConfirmDangerous {
+		if !ConfirmableDenyClass(class) {
+			report(fmt.Sprintf("policy.confirmDangerous[%d]", i), "%q cannot be confirmed (use %s; destructive rules always deny)", class, strings.Join(confirmableDenyClasses, " or "))
+		}
+	}
+	for i, pattern := range raw.Policy.DenyPatterns {
+		if _, err := regexp.Compile(pattern); err != nil {
+			report(fmt.Sprintf("policy.denyPatterns[%d]", i), "invalid regular expression: %v", err)
+		}
+	}
+	checkFileGlobs := func(path string, rules FileAccessRules) {
+		for i, glob := range rules.Allow {
+			if err := ValidateFileGlob(glob); err != nil {
+				report(fmt.Sprintf("%s.allow[%d]", path, i), "%v", err)
+			}
+		}
+		for i, glob := range rules.Deny {
+			if err := ValidateFileGlob(glob); err != nil {
+				report(fmt.Sprintf("%s.deny[%d]", path, i), "%v", err)
+			}
+		}
+	}
+	checkFileGlobs("policy.files", FileAccessRules{Allow: raw.Policy.Files.Allow, Deny: raw.Policy.Files.Deny})
+	for _, root := range sortedKeys(raw.Policy.Files.Projects) {
+		checkFileGlobs("policy.files.projects."+root, raw.Policy.Files.Projects[root])
+	}
+	for i, rule := range raw.Policy.RiskRules {
+		path := fmt.Sprintf("policy.riskRules[%d]", i)
+		if rule.Pattern == "" {
+			report(path+".pattern", "a pattern is required")
+		} else if _, err := regexp.Compile(rule.Pattern); err != nil {
+			report(path+".pattern", "invalid regular expression: %v", err)
+		}
+		if strings.TrimSpace(rule.Label) == "" {
+			report(path+".label", "a label is required")
+		}
+		switch rule.Risk {
+		case "", "low", "medium", "high":
+		default:
+			report(path+".risk", "unknown risk %q (use \"low\", \"medium\" or \"high\")", rule.Risk)
+		}
+	}
+
+	if raw.Budget.MaxSessionTokens < 0 || raw.Budget.MaxDailyTokens < 0 || raw.Budget.MaxSessionCost < 0 || raw.Budget.MaxDailyCost < 0 {
+		report("budget", "limits must not be negative (0 disables a limit)")
+	}
+	if raw.Context.KeepTurns < 0 || raw.Context.PruneMinChars < 0 || raw.Context.AttachOverChars < 0 || raw.Context.SystemPromptBudget < 0 {
+		report("context", "keepTurns, pruneMinChars, attachOverChars and systemPromptBudget must not be negative")
+	}
+
+	if raw.Approval.Strategy != "" && !approvalStrategies[raw.Approval.Strategy] {
+		report("approval.strategy", "unknown strategy %q (use %q, %q, %q, %q or %q)", raw.Approval.Strategy, ApprovalPrompt, ApprovalDeny, ApprovalSafe, ApprovalQueue, ApprovalWebhook)
+	}
+	if raw.Approval.Strategy == ApprovalWebhook {
+		if raw.Approval.Webhook.URL == "" {
+			report("approval.webhook.url", "is required when approval.strategy is %q", ApprovalWebhook)
+		} else if parsed, err := url.Parse(os.ExpandEnv(raw.Approval.Webhook.URL)); err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") {
+			report("approval.webhook.url", "must be an http(s) URL")
+		}
+	}
+	if raw.Approval.OnTimeout != "" && !timeoutActions[raw.Approval.OnTimeout] {
+		report("approval.onTimeout", "unknown action %q (use %q or %q)", raw.Approval.OnTimeout, ApprovalDeny, ApprovalSafe)
+	}
+	if raw.Approval.Timeout < 0 {
+		report("approval.timeout", "must not be negative (0 waits indefinitely)")
+	}
+
+	validateAgents(raw.Agents, raw.LLM.Variants, report)
+	validateHooks(raw.Hooks, report)
+	validateRetrieval(raw.Retrieval, report)
+
+	if raw.Cache.TTL < 0 {
+		report("cache.ttl", "must not be negative (0 uses the default of one day)")
+	}
+	if raw.Storage.Encrypt != "" && raw.Storage.Encrypt != EncryptKeychain && raw.Storage.Encrypt != EncryptPassphrase {
+		report("storage.encrypt", "unknown key source %q (use %q or %q)", raw.Storage.Encrypt, EncryptKeychain, EncryptPassphrase)
+	}
+
+	if _, err := normalizeUI(rawUI{Theme: raw.UI.Theme}); err != nil {
+		report("ui.theme", "%v", err)
+	}
+	if raw.UI.Thinking != "" && !ValidThinkingDisplay(raw.UI.Thinking) {
+		report("ui.thinking", "unknown mode %q (use %q, %q or %q)", raw.UI.Thinking, ThinkingOn, ThinkingOff, ThinkingCollapsed)
+	}
+	if raw.UI.Notify.After < 0 {
+		report("ui.notify.after", "must not be negative (0 turns notifications off)")
+	}
+
+	return issues
+}
+
+func unsetEnv(value string) []string {
+	var missing []string
+	os.Expand(value, func(name string) string {
+		if os.Getenv(name) == "" {
+			missing = append(missing, name)
+		}
+		return ""
+	})
+	return missing
+}
+
+func checkKeys(path string, object map[string]json.RawMessage, schema interface{}, report func(string, string, ...interface{})) {
+	allowed := jsonKeys(schema)
+	for _, key := range sortedKeys(object) {
+		if !allowed[key] {
+			report(joinPath(path, key), "unknown key")
+		}
+	}
+}
+
+func jsonKeys(schema interface{}) map[string]bool {
+	keys := map[string]bool{}
+	schemaType := reflect.TypeOf(schema)
+	for i := 0; i < schemaType.NumField(); i++ {
+		name := strings.Split(schemaType.Field(i).Tag.Get("json"), ",")[0]
+		if name != "" && name != "-" {
+			keys[name] = true
+		}
+	}
+	return keys
+}
+
+func describeJSONError(data []byte, err error) string {
+	var syntaxErr *json.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		line, column := offsetPosition(data, syntaxErr.Offset)
+		return fmt.Sprintf("%v (line %d, column %d)", err, line, column)
+	}
+	return err.Error()
+}
+
+func offsetPosition(data []byte, offset int64) (int, int) {
+	if offset > int64(len(data)) {
+		offset = int64(len(data))
+	}
+	before := data[:offset]
+	line := bytes.Count(before, []byte("\n")) + 1
+	column := int(offset) - bytes.LastIndexByte(before, '\n')
+	return line, column
+}
+
+func joinPath(path string, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// ValidateFileGlob checks a policy.files glob: path.Match syntax per
+// slash-separated segment, where a segment may also be **.
+func ValidateFileGlob(glob string) error {
+	if strings.TrimSpace(glob) == "" {
+		return errors.New("glob must not be empty")
+	}
+	for _, segment := range strings.Split(strings.Trim(glob, "/"), "/") {
+		if segment == "**" {
+			continue
+		}
+		if _, err := path.Match(segment, ""); err != nil {
+			return fmt.Errorf("invalid glob %q: %v", glob, err)
+		}
+	}
+	return nil
+}
+
+func sortedKeys[V any](values map[string]V) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
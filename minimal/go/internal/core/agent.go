@@ -4,21 +4,43 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"os"
 	"strings"
+	"sync"
+	"time"
 
 	"minimal-go/internal/config"
 	"minimal-go/internal/core/providers"
+	"minimal-go/internal/index"
+	"minimal-go/internal/memory"
 	"minimal-go/internal/policy"
+	"minimal-go/internal/session"
+	"minimal-go/internal/shell"
 	"minimal-go/internal/tools"
 	"minimal-go/internal/types"
-	"minimal-go/internal/ui"
 )
 
 type AgentCallbacks struct {
-	PromptApproval func(command string) (bool, error)
+	PromptApproval    func(command string) (bool, error)
+	PromptCommand     func(command string) (bool, string, error)
+	PromptFileChanges func(command string, changes []FileChange) ([]FileApproval, error)
+	// PromptRemainingCalls decides whether the rest of a batch still runs
+	// after the user rejects one of its calls. Without it they all run.
+	PromptRemainingCalls func(remaining []types.ToolCall) bool
+	// ConfirmDangerous asks for the typed confirmation a PolicyConfirm
+	// command needs. Without it such commands are denied.
+	ConfirmDangerous func(command string, reason string) (bool, error)
+	// AskUser puts an ask_user question to the user; answered is false when
+	// they skip it. Without it the model is told no one can answer.
+	AskUser func(question Question) (answer string, answered bool, err error)
+	// OnMessages is called after the conversation changes with all of its
+	// messages; those from index from on are new or replaced.
+	OnMessages     func(messages []types.Message, from int)
 	OnAutoApproved func(command string)
 	OnDenied       func(command string)
 	OnDebugLog     func(label string, data interface{})
+	Approver       func() string
 }
 
 type AgentOptions struct {
@@ -27,6 +49,9 @@ type AgentOptions struct {
 	WorkspaceRoot string
 	Debug         bool
 	Callbacks     AgentCallbacks
+	Provider      providers.ChatProvider
+	Output        io.Writer
+	OnEvent       func(Event)
 }
 
 type Agent interface {
@@ -35,6 +60,38 @@ type Agent interface {
 	Clear()
 	GetTokens() TokenUsage
 	GetModel() string
+	GetDenialStats() DenialStats
+	GetMessages() []types.Message
+	SetMessages(messages []types.Message)
+	GetBudgetStatus() BudgetStatus
+	GetTurnStats() []TurnRecord
+	// GetTurnSummary describes the last RunAgentTurn.
+	GetTurnSummary() session.TurnSummary
+	OverrideBudget()
+	GetThinkingDisplay() string
+	SetThinkingDisplay(mode string)
+	GetLastThinking() string
+	GetCommandOutput(id int) (CommandOutput, bool)
+	GetRateLimits() map[string]providers.RateLimit
+	GetLlmConfig() config.ResolvedLlmConfig
+	SetModel(model string) error
+	GetConfig() config.Config
+	ApplyConfig(cfg config.Config) error
+	SuggestTitle(prompt string) (string, error)
+	ReviewDiff(path string, diff string) ([]ReviewFinding, error)
+	DraftDescription(kind DraftKind, diff string) (string, error)
+	RunCommand(command string) (policy.BashResult, error)
+	SummarizeText(text string, maxTokens int) (string, error)
+	SummarizeConversation() (string, error)
+	SetSystemPrompt(systemPrompt string)
+	GrantSkill(dir string, scripts []string)
+	Shutdown()
+}
+
+type DenialStats struct {
+	Denials  int
+	Adapted  int
+	Repeated int
 }
 
 type TokenUsage struct {
@@ -44,15 +101,50 @@ type TokenUsage struct {
 }
 
 type agent struct {
-	llmConfig     config.ResolvedLlmConfig
-	provider      providers.ChatProvider
-	messages      []types.Message
-	sessionTokens TokenUsage
-	tools         []types.Tool
-	callbacks     AgentCallbacks
-	workspaceRoot string
-	debug         bool
-	config        config.Config
+	mu             sync.Mutex
+	turnMu         sync.Mutex
+	llmConfig      config.ResolvedLlmConfig
+	provider       providers.ChatProvider
+	messages       []types.Message
+	sessionTokens  TokenUsage
+	denialStats    DenialStats
+	lastDenial     *policy.Decision
+	lastDenied     string
+	tools          []types.Tool
+	callbacks      AgentCallbacks
+	workspaceRoot  string
+	debug          bool
+	config         config.Config
+	onEvent        func(Event)
+	activeCall     string
+	debugFile      debugSessionLog
+	turnMetrics    TurnMetrics
+	callRejected   bool
+	readablePaths  []string
+	skillScripts   []string
+	alwaysWritable map[string]bool
+	lastThinking   string
+	auditLog       auditLog
+	shellCwd       string
+	shell          *shell.Session
+	sessionCost    float64
+	budgetOverride bool
+	budgetWarned   map[string]bool
+	turns          []TurnRecord
+	editedFiles    []string
+	turnFiles      map[string]bool
+	turnToolCalls  int
+	turnSummary    session.TurnSummary
+	batchEdits     []batchEdit
+	autoRuns       []string
+	loops          *loopDetector
+	outputDir      string
+	watched        *workspaceScan
+	codeIndex      *index.Index
+	outputs        []CommandOutput
+	outputCount    int
+	rateLimits     map[string]providers.RateLimit
+	quotaWarned    map[string]time.Time
 }
 
 func CreateAgent(options AgentOptions) (Agent, error) {
@@ -61,16 +153,41 @@ func CreateAgent(options AgentOptions) (Agent, error) {
 		return nil, err
 	}
 
+	provider := options.Provider
+	if provider == nil {
+		provider = providers.CreateProvider(llmConfig)
+	}
+	onEvent := options.OnEvent
+	if onEvent == nil {
+		out := options.Output
+		if out == nil {
+			out = os.Stdout
+		}
+		onEvent = RenderEvents(out)
+	}
+
+	if options.Config.Context.PruneToolResults {
+		options.Config.Policy.AllowedPaths = append(append([]string{}, options.Config.Policy.AllowedPaths...), config.OutputsDir)
+	}
+	options.Config.Policy.Files = options.Config.Policy.Files.ForWorkspace(options.WorkspaceRoot)
+
 	return &agent{
-		llmConfig:     llmConfig,
-		provider:      providers.CreateProvider(llmConfig),
-		messages:      []types.Message{{Role: types.RoleSystem, Content: options.SystemPrompt}},
-		sessionTokens: TokenUsage{},
-		tools:         []types.Tool{tools.BashTool},
-		callbacks:     options.Callbacks,
-		workspaceRoot: options.WorkspaceRoot,
-		debug:         options.Debug,
-		config:        options.Config,
+		llmConfig:      llmConfig,
+		provider:       provider,
+		messages:       []types.Message{{Role: types.RoleSystem, Content: options.SystemPrompt}},
+		sessionTokens:  TokenUsage{},
+		tools:          agentTools(options.Config),
+		callbacks:      options.Callbacks,
+		workspaceRoot:  options.WorkspaceRoot,
+		debug:          options.Debug,
+		config:         options.Config,
+		onEvent:        onEvent,
+		alwaysWritable: map[string]bool{},
+		shellCwd:       options.WorkspaceRoot,
+		budgetWarned:   map[string]bool{},
+		quotaWarned:    map[string]time.Time{},
+		loops:          newLoopDetector(),
+		outputDir:      newOutputDir(),
 	}, nil
 }
 
@@ -78,109 +195,459 @@ func (a *agent) debugLog(label string, data interface{}) {
 	if a.debug && a.callbacks.OnDebugLog != nil {
 		a.callbacks.OnDebugLog(label, data)
 	}
+	a.recordDebug(label, data)
 }
 
-func (a *agent) handleBashTool(command string, callID string) types.Message {
-	policyResult := policy.CheckPolicy(command, a.config)
+func (a *agent) handleBashTool(request bashRequest, callID string) types.Message {
+	plan, err := a.planBash(request)
+	if err != nil {
+		return a.pathDenied(callID, request.Cwd, err)
+	}
+	if plan.command == "" {
+		a.shellCwd = plan.nextCwd
+		a.notice(LevelInfo, "[cwd] "+a.relativeToWorkspace(a.shellCwd))
+		return toolError(callID, "Current directory (relative to the workspace root): "+a.relativeToWorkspace(a.shellCwd))
+	}
 
-	switch policyResult {
-	case policy.PolicyDeny:
-		if a.callbacks.OnDenied != nil {
-			a.callbacks.OnDenied(command)
+	original := request.Command
+	edited, rejection := a.authorizeCommand(request.Command, plan.command, plan.dir, plan.env, callID)
+	if rejection != nil {
+		return *rejection
+	}
+	if edited != "" {
+		// The approval showed the env as assignments before the command;
+		// an edit that removed them drops the env with them.
+		if rest, ok := strings.CutPrefix(edited, envAssignments(request.Env)); ok {
+			edited = rest
+		} else {
+			request.Env = nil
 		}
-		return types.Message{Role: types.RoleTool, ToolCallID: callID, Content: "Command denied by policy."}
-	case policy.PolicyAuto:
-		if a.callbacks.OnAutoApproved != nil {
-			a.callbacks.OnAutoApproved(command)
+		request.Command = edited
+		if plan, err = a.planBash(request); err != nil {
+			return a.pathDenied(callID, request.Cwd, err)
 		}
-	default:
-		approved, err := a.callbacks.PromptApproval(command)
-		if err != nil || !approved {
-			return types.Message{Role: types.RoleTool, ToolCallID: callID, Content: "User rejected command."}
+		if plan.command == "" {
+			a.shellCwd = plan.nextCwd
+			return toolError(callID, "The user replaced the command with a directory change. Current directory (relative to the workspace root): "+a.relativeToWorkspace(a.shellCwd))
 		}
 	}
-
-	result := policy.RunBash(command, a.workspaceRoot)
+	command := plan.command
+
+	started := time.Now().Add(-mtimeGranularity)
+	untrackedBefore := a.untrackedSnapshot()
+	var result policy.BashResult
+	if a.config.Shell.Persistent {
+		result = a.runPersistent(plan)
+	} else {
+		result = policy.RunBashWithOptions(command, policy.BashOptions{Dir: plan.dir, Env: plan.env, Scrub: true})
+		if plan.nextCwd != "" && result.Code == 0 {
+			a.shellCwd = plan.nextCwd
+		}
+	}
+	result.Stdout = sanitizeOutput(result.Stdout)
+	result.Stderr = sanitizeOutput(result.Stderr)
+	outputID := a.recordOutput(request.Command, result.Stdout, result.Stderr, result.Code)
 	if strings.TrimSpace(result.Stdout) != "" {
-		fmt.Println(strings.TrimRight(result.Stdout, "\n"))
+		a.emit(Event{Kind: EventCommandOutput, Level: LevelInfo, Text: result.Stdout, OutputID: outputID})
 	}
 	if strings.TrimSpace(result.Stderr) != "" {
+		level := LevelInfo
 		if result.Code != 0 {
-			fmt.Println(ui.Red(strings.TrimRight(result.Stderr, "\n")))
-		} else {
-			fmt.Println(strings.TrimRight(result.Stderr, "\n"))
+			level = LevelError
 		}
+		a.emit(Event{Kind: EventCommandOutput, Level: level, Text: result.Stderr, Stderr: true, OutputID: outputID})
 	}
 
-	payload, _ := json.MarshalIndent(map[string]interface{}{
-		"command":  command,
+	fields := map[string]interface{}{
+		"command":  request.Command,
 		"exitCode": result.Code,
 		"stdout":   result.Stdout,
 		"stderr":   result.Stderr,
-	}, "", "  ")
+	}
+	if a.shellCwd != a.workspaceRoot {
+		fields["cwd"] = a.relativeToWorkspace(a.shellCwd)
+	}
+	if modified := a.untrackedModified(untrackedBefore); len(modified) > 0 {
+		a.notice(LevelWarning, "[policy] command changed files git does not track: "+strings.Join(modified, ", "))
+		fields["untrackedModified"] = modified
+	}
+	if edited != "" {
+		fields["proposedCommand"] = original
+		fields["note"] = "The user edited your proposed command before approving it; the command above is what actually ran."
+	}
+	images := a.outputImages(request.Command, result.Stdout, plan.dir, started)
+	if len(images) > 0 {
+		paths := make([]string, 0, len(images))
+		for _, image := range images {
+			paths = append(paths, image.Path)
+		}
+		fields["images"] = paths
+	}
+	payload, _ := json.MarshalIndent(fields, "", "  ")
 
-	return types.Message{Role: types.RoleTool, ToolCallID: callID, Content: string(payload)}
+	return types.Message{Role: types.RoleTool, ToolCallID: callID, Content: string(payload), Images: images}
 }
 
-func (a *agent) handleToolCalls(toolCalls []types.ToolCall) []types.Message {
-	results := make([]types.Message, 0, len(toolCalls))
-	for _, call := range toolCalls {
-		if call.Name != "bash" {
-			results = append(results, types.Message{
-				Role:       types.RoleTool,
-				ToolCallID: call.ID,
-				Content:    fmt.Sprintf("Unknown tool: %s", call.Name),
-			})
+// RunCommand runs a command on the user's behalf in the workspace root,
+// through the same policy and approval as the model's commands.
+func (a *agent) RunCommand(command string) (policy.BashResult, error) {
+	edited, rejection := a.authorizeCommand(command, command, a.workspaceRoot, nil, "")
+	if rejection != nil {
+		return policy.BashResult{}, errors.New("Not run: " + command)
+	}
+	if edited != "" {
+		command = edited
+	}
+	return policy.RunBashWithOptions(command, policy.BashOptions{Dir: a.workspaceRoot}), nil
+}
+
+func (a *agent) authorizeCommand(display string, command string, dir string, env map[string]string, callID string) (string, *types.Message) {
+	decision := policy.EvaluateInWorkspace(command, a.config, a.workspaceRoot, dir)
+	if a.isSkillScript(command, dir) {
+		if decision = policy.EvaluatePolicy(command, a.config); decision.Result != policy.PolicyDeny && decision.Result != policy.PolicyConfirm {
+			decision = policy.Decision{Result: policy.PolicyAuto}
+		}
+	}
+	decision = policy.EvaluateEnv(decision, env)
+	display = envAssignments(env) + display
+	a.trackDenialAdaptation(display, decision)
+	untracked := false
+	if decision.Result == policy.PolicyAuto || a.config.Auto && decision.Result == policy.PolicyAsk {
+		if touched := a.untrackedTouches(command, dir); len(touched) > 0 {
+			a.notice(LevelWarning, "[policy] command touches files git does not track: "+strings.Join(touched, ", "))
+			decision = policy.Decision{Result: policy.PolicyAsk, OutsidePaths: decision.OutsidePaths}
+			untracked = true
+		}
+	}
+	// --auto relies on git to undo what the agent does, which does not
+	// cover paths outside the workspace or untracked files, and the policy
+	// never vetted a model-supplied env; outside a sandbox those still ask.
+	if a.config.Auto && decision.Result == policy.PolicyAsk && (sandboxed() || !untracked && len(decision.OutsidePaths) == 0 && len(env) == 0) {
+		decision = policy.Decision{Result: policy.PolicyAuto, OutsidePaths: decision.OutsidePaths}
+	}
+	if len(decision.OutsidePaths) > 0 {
+		a.notice(LevelWarning, "[policy] command references paths outside the workspace: "+strings.Join(decision.OutsidePaths, ", "))
+	}
+
+	switch decision.Result {
+	case policy.PolicyDeny:
+		a.audit("command", display, "denied", string(decision.Class))
+		return "", a.commandDenial(display, decision, callID)
+	case policy.PolicyConfirm:
+		if a.callbacks.ConfirmDangerous == nil {
+			decision.Reason += " (typed confirmation is not available here)"
+			a.audit("command", display, "denied", string(decision.Class))
+			return "", a.commandDenial(display, decision, callID)
+		}
+		assessment := a.assessCommand(display)
+		a.noticeRisk(assessment)
+		a.emit(Event{Kind: EventApproval, CallID: a.activeCall, Text: display, Risk: assessment})
+		confirmed, err := a.callbacks.ConfirmDangerous(display, decision.Reason)
+		if err != nil || !confirmed {
+			a.audit("command", display, "rejected", string(decision.Class))
+			a.callRejected = true
+			return "", &types.Message{Role: types.RoleTool, ToolCallID: callID, Content: "User rejected command."}
+		}
+		a.audit("command", display, "confirmed", string(decision.Class))
+		a.recordAutoRun("$ " + display)
+	case policy.PolicyAuto:
+		a.audit("command", display, "auto", "")
+		if a.callbacks.OnAutoApproved != nil {
+			a.callbacks.OnAutoApproved(display)
+		}
+		a.recordAutoRun("$ " + display)
+	default:
+		if dir != a.workspaceRoot {
+			a.notice(LevelInfo, "[cwd] "+a.relativeToWorkspace(dir))
+		}
+		assessment := a.assessCommand(display)
+		a.noticeRisk(assessment)
+		approved, edited, err := a.approveCommand(display, dir, assessment)
+		if err != nil || !approved {
+			a.audit("command", display, "rejected", "")
+			a.callRejected = true
+			return "", &types.Message{Role: types.RoleTool, ToolCallID: callID, Content: "User rejected command."}
+		}
+		if edited != "" && edited != display {
+			if decision := policy.EvaluateInWorkspace(edited, a.config, a.workspaceRoot, dir); decision.Result == policy.PolicyDeny {
+				a.audit("command", edited, "denied", string(decision.Class))
+				return "", a.commandDenial(edited, decision, callID)
+			}
+			a.audit("command", edited, "edited", "")
+			return edited, nil
+		}
+		a.audit("command", display, "approved", "")
+	}
+	return "", nil
+}
+
+func (a *agent) commandDenial(display string, decision policy.Decision, callID string) *types.Message {
+	if a.callbacks.OnDenied != nil {
+		a.callbacks.OnDenied(display)
+	}
+	message := "Command denied by policy."
+	if decision.Reason != "" {
+		message = "Command denied by policy: " + decision.Reason + "."
+	}
+	denial := map[string]interface{}{
+		"status":     "denied",
+		"message":    message,
+		"command":    display,
+		"class":      decision.Class,
+		"suggestion": decision.Suggestion,
+		"retry":      "Running the same command again will be denied again; change approach.",
+	}
+	if decision.Rule != "" {
+		denial["rule"] = decision.Rule
+		denial["matched"] = decision.Match
+	}
+	if len(decision.OutsidePaths) > 0 {
+		denial["paths"] = decision.OutsidePaths
+	}
+	payload, _ := json.MarshalIndent(denial, "", "  ")
+	return &types.Message{Role: types.RoleTool, ToolCallID: callID, Content: string(payload)}
+}
+
+func (a *agent) approveCommand(command string, dir string, assessment policy.Assessment) (bool, string, error) {
+	changes := a.redirectChanges(command, dir)
+	if len(changes) == 0 || a.callbacks.PromptFileChanges == nil {
+		a.emit(Event{Kind: EventApproval, CallID: a.activeCall, Text: command, Changes: changes, Risk: assessment})
+		if a.callbacks.PromptCommand != nil {
+			return a.callbacks.PromptCommand(command)
+		}
+		approved, err := a.callbacks.PromptApproval(command)
+		return approved, "", err
+	}
+	approvals, err := a.confirmFileChanges(command, changes)
+	if err != nil {
+		return false, "", err
+	}
+	for _, approved := range approvals {
+		if !approved {
+			return false, "", nil
+		}
+	}
+	return true, "", nil
+}
+
+func (a *agent) trackDenialAdaptation(command string, decision policy.Decision) {
+	a.mu.Lock()
+	followUp := a.lastDenial != nil
+	repeated := false
+	if followUp {
+		repeated = command == a.lastDenied || (decision.Result == policy.PolicyDeny && decision.Class == a.lastDenial.Class)
+		if repeated {
+			a.denialStats.Repeated++
+		} else {
+			a.denialStats.Adapted++
+		}
+		a.lastDenial = nil
+	}
+	stats := a.denialStats
+	if decision.Result == policy.PolicyDeny {
+		a.denialStats.Denials++
+		a.lastDenial = &decision
+		a.lastDenied = command
+	}
+	a.mu.Unlock()
+	if followUp {
+		a.debugLog("Denial follow-up", map[string]interface{}{"command": command, "repeated": repeated, "stats": stats})
+	}
+}
+
+// handleToolCalls runs the calls in order and appends each result to the
+// history as soon as it exists, so the journal has it even if the process
+// dies on a later call of the batch.
+func (a *agent) handleToolCalls(toolCalls []types.ToolCall) int {
+	malformed := 0
+	defer func() { a.activeCall = "" }()
+	for i, call := range toolCalls {
+		a.activeCall = call.ID
+		a.callRejected = false
+		a.turnToolCalls++
+		a.emit(Event{Kind: EventToolCall, CallID: call.ID, ToolCall: &call})
+		result, bad := a.handleToolCall(call)
+		if bad {
+			malformed++
+		}
+		a.emit(Event{Kind: EventToolResult, CallID: call.ID, Text: result.Content})
+		a.appendMessages(result)
+
+		remaining := toolCalls[i+1:]
+		if !a.callRejected || len(remaining) == 0 || a.callbacks.PromptRemainingCalls == nil {
 			continue
 		}
+		if !a.callbacks.PromptRemainingCalls(remaining) {
+			for _, skipped := range remaining {
+				a.emit(Event{Kind: EventToolCall, CallID: skipped.ID, ToolCall: &skipped})
+				result := skippedCallResult(skipped, call)
+				a.emit(Event{Kind: EventToolResult, CallID: skipped.ID, Text: result.Content})
+				a.appendMessages(result)
+			}
+			break
+		}
+	}
+	a.runPostEditHooks()
+	return malformed
+}
 
-		command := extractCommand(call.Input)
-		if command == "" {
-			results = append(results, types.Message{
+func skippedCallResult(call types.ToolCall, rejected types.ToolCall) types.Message {
+	return types.Message{
+		Role:       types.RoleTool,
+		ToolCallID: call.ID,
+		Content:    fmt.Sprintf("Not run: the user rejected an earlier call in this batch (%s, id %s) and chose to skip the calls after it. Nothing from this call was executed; propose it again if it is still needed.", rejected.Name, rejected.ID),
+	}
+}
+
+func (a *agent) handleToolCall(call types.ToolCall) (types.Message, bool) {
+	if a.config.Policy.ReadOnly && mutatingTools[call.Name] {
+		a.audit("tool", describeToolCall(call, 80), "denied", string(policy.DenyReadOnly))
+		return toolError(call.ID, fmt.Sprintf("Denied: %s is disabled in read-only mode. %s", call.Name, policy.SuggestionFor(policy.DenyReadOnly))), false
+	}
+	args, failure := a.validateToolCall(call)
+	if failure != nil {
+		a.debugLog("Malformed tool call", map[string]interface{}{"call": call, "error": failure.message})
+		return a.toolCallErrorResult(call, failure), true
+	}
+	call.Input = args
+	if reason := a.runPreToolHooks(&call); reason != "" {
+		return toolError(call.ID, reason), false
+	}
+
+	signature := toolCallSignature(call)
+	if reason := a.loops.repetition(signature); reason != "" {
+		return a.suppressRepeatedCall(call, signature, reason), false
+	}
+
+	var result types.Message
+	switch call.Name {
+	case tools.BashTool.Name:
+		request, err := parseBashRequest(call.Input)
+		if err != nil {
+			return toolError(call.ID, err.Error()), false
+		}
+		if request.Command == "" {
+			return types.Message{
 				Role:       types.RoleTool,
 				ToolCallID: call.ID,
 				Content:    "No command provided.",
-			})
-			continue
+			}, false
 		}
+		result = a.handleBashTool(request, call.ID)
+	case tools.WriteFileTool.Name:
+		result = a.handleWriteFile(call.Input, call.ID)
+	case tools.EditFileTool.Name:
+		result = a.handleEditFile(call.Input, call.ID)
+	case tools.DiagnosticsTool.Name:
+		result = a.handleDiagnostics(call.Input, call.ID)
+	case tools.ReadFileTool.Name:
+		result = a.handleReadFile(call.Input, call.ID)
+	case tools.ViewImageTool.Name:
+		result = a.handleViewImage(call.Input, call.ID)
+	case tools.RunTestsTool.Name:
+		result = a.handleRunTests(call.Input, call.ID)
+	case tools.ResetShellTool.Name:
+		result = a.handleResetShell(call.ID)
+	case tools.SearchCodeTool.Name:
+		result = a.handleSearchCode(call.Input, call.ID)
+	case tools.RememberTool.Name:
+		result = a.handleRememberTool(extractStringArg(call.Input, "fact"), call.ID)
+	case tools.AskUserTool.Name:
+		result = a.handleAskUser(call.Input, call.ID)
+	default:
+		return a.toolCallErrorResult(call, &toolCallError{kind: "unknown_tool", message: fmt.Sprintf("There is no tool named %q.", call.Name)}), true
+	}
+	a.loops.record(signature, result)
+	return result, false
+}
 
-		results = append(results, a.handleBashTool(command, call.ID))
+func (a *agent) handleRememberTool(fact string, callID string) types.Message {
+	if err := memory.Append(fact); err != nil {
+		return types.Message{Role: types.RoleTool, ToolCallID: callID, Content: "Failed to save memory: " + err.Error()}
 	}
-	return results
+	a.notice(LevelSuccess, "✓ Remembered: "+fact)
+	return types.Message{Role: types.RoleTool, ToolCallID: callID, Content: "Saved to memory."}
 }
 
 func (a *agent) RunAgentTurn() error {
+	a.turnMu.Lock()
+	defer a.turnMu.Unlock()
+	defer a.flushAudit()
+	a.turnMetrics = TurnMetrics{}
+	a.autoRuns = nil
+	a.turnFiles, a.turnToolCalls = map[string]bool{}, 0
+	started, tokens, cost := time.Now(), a.GetTokens(), a.currentCost()
+	a.noteExternalChanges()
+	rounds, err := a.runTurn()
+	a.snapshotWorkspace()
+	a.recordTurnMetrics()
+	a.reportAutoRuns()
+	a.summarizeTurn(started, tokens, cost, err)
+	if err != nil {
+		a.emit(Event{Kind: EventError, Level: LevelError, Turn: rounds, Text: err.Error()})
+		return err
+	}
+	a.emit(Event{Kind: EventTurnCompleted, Turn: rounds, Session: a.GetTokens()})
+	return nil
+}
+
+func (a *agent) runTurn() (int, error) {
 	loopCount := 0
+	malformedStreak := 0
+	a.loops = newLoopDetector()
+	a.pruneStaleToolResults()
 	for {
 		loopCount++
-		fmt.Println(ui.Gray(fmt.Sprintf("\n─── turn %d ───\n", loopCount)))
+		a.emit(Event{Kind: EventTurnStarted, Turn: loopCount})
+
+		requestTools := a.tools
+		if a.llmConfig.ModelKnown && !a.llmConfig.ModelInfo.SupportsTools {
+			requestTools = nil
+		}
+		if err := a.ensureContextFits(requestTools); err != nil {
+			return loopCount, err
+		}
+		if err := a.checkBudget(); err != nil {
+			return loopCount, err
+		}
 
 		requestParams := providers.CreateChatParams{
 			Model:       a.llmConfig.Model,
 			Temperature: a.llmConfig.Temperature,
 			MaxTokens:   a.llmConfig.MaxTokens,
-			Messages:    a.messages,
-			Tools:       a.tools,
+			Sampling:    a.llmConfig.Sampling,
+			Messages:    a.GetMessages(),
+			Tools:       requestTools,
+			ToolChoice:  a.toolChoiceFor(loopCount),
+			Parallel:    a.llmConfig.Parallel,
 		}
 		a.debugLog("API Request", requestParams)
+		breakdown := estimateBreakdown(requestParams.Messages, requestParams.Tools)
 
-		response, err := a.provider.CreateChatCompletion(requestParams)
+		response, latency, err := a.requestCompletion(requestParams, loopCount)
+		if err != nil && isContextOverflow(err) && a.recoverFromOverflow(requestParams.Messages, requestTools) {
+			requestParams.Messages = a.GetMessages()
+			breakdown = estimateBreakdown(requestParams.Messages, requestParams.Tools)
+			response, latency, err = a.requestCompletion(requestParams, loopCount)
+		}
 		if err != nil {
-			return mapProviderError(err)
+			return loopCount, mapProviderError(err)
 		}
 		a.debugLog("API Response", response)
+		a.recordRequestMetrics(newRequestMetrics(loopCount, requestParams.Model, malformedStreak, latency, response.Timing, response.Usage))
 
+		cost := 0.0
 		if response.Usage != nil {
-			a.sessionTokens.Prompt += response.Usage.PromptTokens
-			a.sessionTokens.Completion += response.Usage.CompletionTokens
-			a.sessionTokens.Total += response.Usage.TotalTokens
-			fmt.Println(ui.Gray(fmt.Sprintf("[tokens] in:%d out:%d | session:%d", response.Usage.PromptTokens, response.Usage.CompletionTokens, a.sessionTokens.Total)))
+			cost = a.recordUsage(response.Usage)
+			a.emit(Event{Kind: EventUsage, Turn: loopCount, Usage: response.Usage, Session: a.GetTokens()})
 		}
+		a.recordRateLimit(response)
 
 		assistant := response.Message
 		content := assistant.Content
 		thinking := assistant.Thinking
 		toolCalls := assistant.ToolCalls
+		a.recordTurn(latency, response.Usage, cost, toolCalls, breakdown)
 		a.debugLog("Assistant message", map[string]interface{}{"content": content, "thinking": thinking, "toolCalls": toolCalls})
 
 		msg := types.Message{Role: types.RoleAssistant, Content: content}
@@ -190,58 +657,164 @@ func (a *agent) RunAgentTurn() error {
 		if len(toolCalls) > 0 {
 			msg.ToolCalls = toolCalls
 		}
-		a.messages = append(a.messages, msg)
+		a.appendMessages(msg)
 
 		if thinking != "" {
-			fmt.Println(ui.Gray("─── thinking ───"))
-			fmt.Println(ui.Gray(thinking))
-			fmt.Println(ui.Gray("────────────────"))
+			a.printThinking(thinking)
 		}
 
 		if content != "" {
-			fmt.Println(content)
+			a.emit(Event{Kind: EventAssistant, Turn: loopCount, Text: content})
 		}
 
 		if len(toolCalls) == 0 {
-			return nil
+			return loopCount, nil
+		}
+
+		malformed := a.handleToolCalls(toolCalls)
+		if a.loops.suppressed >= maxLoopSuppressions {
+			return loopCount, fmt.Errorf("stopped: %s kept repeating the same tool calls (%d suppressed); rephrase the request or give it more context", a.llmConfig.Model, a.loops.suppressed)
 		}
+		if malformed < len(toolCalls) {
+			malformedStreak = 0
+			continue
+		}
+		malformedStreak++
+		if malformedStreak >= maxMalformedToolTurns {
+			return loopCount, fmt.Errorf("stopped after %d consecutive rounds of malformed tool calls from %s; try rephrasing the request or switching models", malformedStreak, a.llmConfig.Model)
+		}
+	}
+}
+
+func (a *agent) ensureContextFits(requestTools []types.Tool) error {
+	window := a.llmConfig.ModelInfo.ContextWindow
+	if !a.llmConfig.ModelKnown || window == 0 {
+		return nil
+	}
+	budget := window - a.llmConfig.MaxTokens
+	if budget <= 0 {
+		return fmt.Errorf("max_tokens (%d) leaves no room in the %d token context window of %s", a.llmConfig.MaxTokens, window, a.llmConfig.Model)
+	}
+
+	messages := a.GetMessages()
+	estimate := estimateRequestTokens(messages, requestTools)
+	if estimate <= budget {
+		return nil
+	}
 
-		toolResults := a.handleToolCalls(toolCalls)
-		a.messages = append(a.messages, toolResults...)
+	compacted := compactMessages(messages, requestTools, budget)
+	a.debugLog("Context compaction", map[string]interface{}{
+		"before":          estimate,
+		"after":           compacted.EstimatedTokens,
+		"budget":          budget,
+		"toolResultsCut":  compacted.ToolResultsCut,
+		"exchangesPruned": compacted.ExchangesPruned,
+	})
+	if compacted.EstimatedTokens > budget {
+		return contextOverflowError(compacted.EstimatedTokens, budget, window)
 	}
+
+	a.SetMessages(compacted.Messages)
+	a.notice(LevelWarning, fmt.Sprintf("[context] compacted ~%d → ~%d tokens to fit %s (%d tool results trimmed, %d exchanges dropped)", estimate, compacted.EstimatedTokens, a.llmConfig.Model, compacted.ToolResultsCut, compacted.ExchangesPruned))
+	return nil
 }
 
 func (a *agent) AddUserMessage(content string) {
-	a.messages = append(a.messages, types.Message{Role: types.RoleUser, Content: content})
+	a.appendMessages(types.Message{Role: types.RoleUser, Content: a.attachLargeContent(content)})
+}
+
+// updateToolResult rewrites the newest result for callID in the history.
+func (a *agent) updateToolResult(callID string, update func(content string) string) {
+	a.mu.Lock()
+	index := len(a.messages) - 1
+	for index >= 0 && !(a.messages[index].Role == types.RoleTool && a.messages[index].ToolCallID == callID) {
+		index--
+	}
+	if index < 0 {
+		a.mu.Unlock()
+		return
+	}
+	a.messages[index].Content = update(a.messages[index].Content)
+	current := append([]types.Message{}, a.messages...)
+	a.mu.Unlock()
+	a.messagesChanged(current, index)
+}
+
+func (a *agent) appendMessages(messages ...types.Message) {
+	a.mu.Lock()
+	from := len(a.messages)
+	a.messages = append(a.messages, messages...)
+	current := append([]types.Message{}, a.messages...)
+	a.mu.Unlock()
+	a.messagesChanged(current, from)
 }
 
 func (a *agent) Clear() {
+	a.mu.Lock()
 	if len(a.messages) > 0 {
 		a.messages = a.messages[:1]
 	}
+	current := append([]types.Message{}, a.messages...)
+	a.mu.Unlock()
+	a.messagesChanged(current, len(current))
+	a.resetShell()
+}
+
+func (a *agent) messagesChanged(messages []types.Message, from int) {
+	if a.callbacks.OnMessages != nil {
+		a.callbacks.OnMessages(messages, from)
+	}
+}
+
+func (a *agent) GetDenialStats() DenialStats {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.denialStats
+}
+
+func (a *agent) GetMessages() []types.Message {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return append([]types.Message{}, a.messages...)
+}
+
+func (a *agent) SetMessages(messages []types.Message) {
+	a.mu.Lock()
+	a.messages = append([]types.Message{}, messages...)
+	current := append([]types.Message{}, a.messages...)
+	a.mu.Unlock()
+	a.messagesChanged(current, 0)
 }
 
 func (a *agent) GetTokens() TokenUsage {
+	a.mu.Lock()
+	defer a.mu.Unlock()
 	return a.sessionTokens
 }
 
 func (a *agent) GetModel() string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
 	return a.llmConfig.Model
 }
 
 func extractCommand(input interface{}) string {
+	return extractStringArg(input, "command")
+}
+
+func extractStringArg(input interface{}, key string) string {
 	switch value := input.(type) {
 	case string:
 		var parsed map[string]interface{}
 		if err := json.Unmarshal([]byte(value), &parsed); err == nil {
-			if cmd, ok := parsed["command"].(string); ok {
-				return cmd
+			if arg, ok := parsed[key].(string); ok {
+				return arg
 			}
 		}
 		return value
 	case map[string]interface{}:
-		if cmd, ok := value["command"].(string); ok {
-			return cmd
+		if arg, ok := value[key].(string); ok {
+			return arg
 		}
 	default:
 		return ""
@@ -249,8 +822,38 @@ func extractCommand(input interface{}) string {
 	return ""
 }
 
+func (a *agent) requestCompletion(params providers.CreateChatParams, turn int) (providers.ChatResponse, time.Duration, error) {
+	a.emit(Event{Kind: EventRequestStarted, Turn: turn, Text: fmt.Sprintf("%s · %s · turn %d", a.llmConfig.Provider, a.llmConfig.Model, turn)})
+	started := time.Now()
+	response, err := a.provider.CreateChatCompletion(params)
+	latency := time.Since(started)
+	a.emit(Event{Kind: EventRequestFinished, Turn: turn})
+	if err == nil && response.Cached {
+		a.notice(LevelInfo, "[cache] reused an identical earlier response (no tokens billed)")
+	}
+	if a.debug && len(response.Repairs) > 0 {
+		a.recordDebug("History repairs", response.Repairs)
+		a.notice(LevelWarning, "[history] repaired before sending: "+strings.Join(response.Repairs, "; "))
+	}
+	return response, latency, err
+}
+
+// A forced tool call ("required" or a tool name) applies to the first
+// request of a turn only; forcing every request would never let the model
+// answer.
+func (a *agent) toolChoiceFor(request int) string {
+	choice := a.llmConfig.ToolChoice
+	if request > 1 && choice != config.ToolChoiceNone {
+		return ""
+	}
+	return choice
+}
+
 func mapProviderError(err error) error {
 	msg := err.Error()
+	if isContextOverflow(err) {
+		return fmt.Errorf("The conversation is too long for the model's context window (%s). Use /drop or /clear, or switch to a model with a larger window.", msg)
+	}
 	if strings.Contains(msg, "401") {
 		return errors.New("Invalid API key.")
 	}
@@ -0,0 +1,177 @@
+package core
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"minimal-go/internal/config"
+	"minimal-go/internal/tools"
+	"minimal-go/internal/types"
+	"minimal-go/internal/ui"
+)
+
+const noAgent = "none"
+
+// Tools that change files or run project code; read-only mode drops them.
+var mutatingTools = map[string]bool{
+	tools.WriteFileTool.Name: true,
+	tools.EditFileTool.Name:  true,
+	tools.RunTestsTool.Name:  true,
+	tools.RememberTool.Name:  true,
+}
+
+// agentTools is the tool set for cfg: every tool, narrowed to the active
+// profile's list when it has one and to the non-mutating ones in read-only
+// mode.
+func agentTools(cfg config.Config) []types.Tool {
+	all := []types.Tool{tools.BashTool, tools.ReadFileTool, tools.WriteFileTool, tools.EditFileTool, tools.DiagnosticsTool, tools.RunTestsTool, tools.ViewImageTool, tools.RememberTool, tools.SearchCodeTool, tools.AskUserTool}
+	if cfg.Shell.Persistent {
+		all = append(all, tools.ResetShellTool)
+	}
+	if cfg.Policy.ReadOnly {
+		var kept []types.Tool
+		for _, tool := range all {
+			if !mutatingTools[tool.Name] {
+				kept = append(kept, tool)
+			}
+		}
+		all = kept
+	}
+	allowed := cfg.Agents[cfg.Agent].Tools
+	if cfg.Agent == "" || len(allowed) == 0 {
+		return all
+	}
+	var selected []types.Tool
+	for _, tool := range all {
+		for _, name := range allowed {
+			if tool.Name == name {
+				selected = append(selected, tool)
+				break
+			}
+		}
+	}
+	return selected
+}
+
+// applyAgentProfile returns env with the named profile applied on top of
+// it. The result remembers the environment it started from, so switching
+// profiles never stacks one profile's overrides on another's.
+func applyAgentProfile(env environment, name string) (environment, error) {
+	base := env
+	if env.base != nil {
+		base = *env.base
+	}
+	if name == noAgent {
+		return base, nil
+	}
+
+	cfg, profile, err := base.config.ApplyAgent(name)
+	if err != nil {
+		return env, err
+	}
+	known := map[string]bool{}
+	for _, tool := range agentTools(config.Config{Shell: config.ShellConfig{Persistent: true}}) {
+		known[tool.Name] = true
+	}
+	for _, toolName := range profile.Tools {
+		if !known[toolName] {
+			return env, fmt.Errorf("agent %q: unknown tool %q", name, toolName)
+		}
+	}
+
+	next := base
+	next.config = cfg
+	next.base = &base
+	if profile.SystemPrompt != "" {
+		content, err := profile.LoadPrompt()
+		if err != nil {
+			return env, fmt.Errorf("agent %q: %w", name, err)
+		}
+		next.prompt = buildPromptSections(content, base.workspaceRoot)
+		next.systemPrompt = next.prompt.Build()
+	}
+	return next, nil
+}
+
+func describeAgent(cfg config.Config) string {
+	profile := cfg.Agents[cfg.Agent]
+	text := "[agent] " + cfg.Agent
+	if profile.Description != "" {
+		text += " — " + profile.Description
+	}
+	if len(profile.Tools) > 0 {
+		text += " · tools: " + strings.Join(profile.Tools, ", ")
+	}
+	return text
+}
+
+// /agent lists the profiles; /agent <name> switches to one and /agent none
+// goes back to the base configuration. The conversation carries over.
+func (s *replState) handleAgentCommand(args string) error {
+	cfg := s.env.config
+	if args == "" {
+		if len(cfg.Agents) == 0 {
+			fmt.Println(ui.Muted("No agent profiles. Add them under \"agents\" in " + config.ConfigPath + "."))
+			return nil
+		}
+		names := make([]string, 0, len(cfg.Agents))
+		for name := range cfg.Agents {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(writer, "  NAME\tMODEL\tTOOLS\tDESCRIPTION")
+		for _, name := range names {
+			profile := cfg.Agents[name]
+			marker := "  "
+			if name == cfg.Agent {
+				marker = ui.Success("● ")
+			}
+			model := profile.Model
+			if model == "" {
+				model = profile.Variant
+			}
+			if model == "" {
+				model = "-"
+			}
+			toolList := "all"
+			if len(profile.Tools) > 0 {
+				toolList = strings.Join(profile.Tools, ",")
+			}
+			fmt.Fprintf(writer, "%s%s\t%s\t%s\t%s\n", marker, ui.Cyan(name), ui.Muted(model), toolList, profile.Description)
+		}
+		writer.Flush()
+		fmt.Println(ui.Muted("/agent <name> switches; /agent none goes back to the base configuration."))
+		return nil
+	}
+
+	next, err := applyAgentProfile(s.env, args)
+	if err != nil {
+		return err
+	}
+	resolved, err := config.ResolveLlmConfig(next.config)
+	if err != nil {
+		return err
+	}
+	if err := s.agent.ApplyConfig(next.config); err != nil {
+		return err
+	}
+	if err := s.agent.SetModel(resolved.Model); err != nil {
+		return err
+	}
+	s.agent.SetSystemPrompt(next.systemPrompt)
+	s.env = next
+	s.prompt = next.prompt
+	s.session.Agent = next.config.Agent
+	s.session.Model = s.agent.GetModel()
+	if next.config.Agent == "" {
+		printSuccess(fmt.Sprintf("✓ Back to the base configuration (%s).", s.agent.GetModel()))
+		return nil
+	}
+	printSuccess(fmt.Sprintf("✓ Switched to agent %s (%s).", next.config.Agent, s.agent.GetModel()))
+	fmt.Println(ui.Muted(describeAgent(next.config)))
+	return nil
+}
@@ -0,0 +1,337 @@
+package core
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"minimal-go/internal/config"
+	"minimal-go/internal/policy"
+	"minimal-go/internal/ui"
+)
+
+const approvalPollInterval = 500 * time.Millisecond
+
+var errPromptTimeout = errors.New("no answer before the approval timeout")
+
+type pendingApproval struct {
+	ID        string    `json:"id"`
+	Command   string    `json:"command,omitempty"`
+	Files     []string  `json:"files,omitempty"`
+	Workspace string    `json:"workspace"`
+	PID       int       `json:"pid"`
+	CreatedAt time.Time `json:"createdAt"`
+	Status    string    `json:"status"`
+	Approver  string    `json:"approver,omitempty"`
+}
+
+type approvalOutcome struct {
+	Approved bool
+	Reason   string
+	Approver string
+}
+
+type approverRecord struct {
+	mu   sync.Mutex
+	name string
+}
+
+func (r *approverRecord) set(name string) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.name = name
+}
+
+func (r *approverRecord) get() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.name
+}
+
+func approvalTimeout(cfg config.ApprovalConfig) time.Duration {
+	return time.Duration(cfg.Timeout) * time.Second
+}
+
+func unattendedDecision(cfg config.ApprovalConfig, strategy string, command string, changes []FileChange) (approvalOutcome, error) {
+	switch strategy {
+	case config.ApprovalSafe:
+		if len(changes) > 0 {
+			return approvalOutcome{Reason: "approve-safe never approves file writes", Approver: strategy}, nil
+		}
+		// approve-safe allows what read-only mode allows: a build or test
+		// run executes whatever the agent put in the project.
+		if policy.ReadOnlyCommand(command) {
+			return approvalOutcome{Approved: true, Reason: "read-only command", Approver: strategy}, nil
+		}
+		return approvalOutcome{Reason: "not on the approve-safe list", Approver: strategy}, nil
+	case config.ApprovalQueue:
+		return queueApproval(cfg, command, changes)
+	case config.ApprovalWebhook:
+		return webhookApproval(cfg, command, changes)
+	}
+	return approvalOutcome{Reason: "no one is available to approve it", Approver: config.ApprovalDeny}, nil
+}
+
+func unattendedCallbacks(cfg config.ApprovalConfig, strategy string, label string, approver *approverRecord) (func(string) (bool, error), func(string, []FileChange) ([]FileApproval, error)) {
+	decide := func(command string, changes []FileChange) (bool, error) {
+		outcome, err := unattendedDecision(cfg, strategy, command, changes)
+		if err != nil {
+			return false, err
+		}
+		approver.set(outcome.Approver)
+		subject := command
+		if subject == "" {
+			subject = "write " + changePaths(changes)
+		}
+		if outcome.Approved {
+			fmt.Println(ui.Success(fmt.Sprintf("✓ (%s) %s", label, subject)) + ui.Muted(" ["+outcome.Reason+"]"))
+		} else {
+			fmt.Println(ui.Warning(fmt.Sprintf("✗ (%s) rejected: ", label)) + ui.Muted(subject+" ["+outcome.Reason+"]"))
+		}
+		return outcome.Approved, nil
+	}
+	promptApproval := func(command string) (bool, error) {
+		return decide(command, nil)
+	}
+	promptFileChanges := func(command string, changes []FileChange) ([]FileApproval, error) {
+		answers := make([]FileApproval, len(changes))
+		approved, err := decide(command, changes)
+		if approved {
+			fillApprovals(answers, FileApproved)
+		}
+		return answers, err
+	}
+	return promptApproval, promptFileChanges
+}
+
+func timeoutDecision(cfg config.ApprovalConfig, approver *approverRecord, command string, changes []FileChange) bool {
+	outcome, _ := unattendedDecision(cfg, cfg.OnTimeout, command, changes)
+	approver.set("timeout:" + outcome.Approver)
+	fmt.Println(ui.Warning(fmt.Sprintf("\n⏱ No answer within %ds; %s (approval.onTimeout: %s, %s)", cfg.Timeout, approvalVerdict(outcome.Approved), cfg.OnTimeout, outcome.Reason)))
+	return outcome.Approved
+}
+
+func approvalVerdict(approved bool) string {
+	if approved {
+		return "approved"
+	}
+	return "rejected"
+}
+
+func printApprovalTimeout(cfg config.ApprovalConfig) {
+	if cfg.Timeout > 0 {
+		fmt.Println(ui.Muted(fmt.Sprintf("  (no answer in %ds: %s)", cfg.Timeout, cfg.OnTimeout)))
+	}
+}
+
+func changePaths(changes []FileChange) string {
+	paths := make([]string, 0, len(changes))
+	for _, change := range changes {
+		paths = append(paths, change.Path)
+	}
+	return strings.Join(paths, ", ")
+}
+
+func queueApproval(cfg config.ApprovalConfig, command string, changes []FileChange) (approvalOutcome, error) {
+	request, path, err := createPendingApproval(command, changes)
+	if err != nil {
+		return approvalOutcome{}, err
+	}
+	defer os.Remove(path)
+
+	fmt.Println(ui.Warning("⏸ Waiting for approval "+request.ID+": ") + ui.Muted("mini-go approvals approve|deny "+request.ID))
+	return awaitPendingApproval(path, approvalTimeout(cfg), nil), nil
+}
+
+func createPendingApproval(command string, changes []FileChange) (pendingApproval, string, error) {
+	if err := os.MkdirAll(config.ApprovalsDir, 0o700); err != nil {
+		return pendingApproval{}, "", err
+	}
+	suffix := make([]byte, 3)
+	_, _ = rand.Read(suffix)
+	workspace, _ := os.Getwd()
+	request := pendingApproval{
+		ID:        time.Now().Format("150405") + "-" + hex.EncodeToString(suffix),
+		Command:   command,
+		Workspace: workspace,
+		PID:       os.Getpid(),
+		CreatedAt: time.Now(),
+		Status:    "pending",
+	}
+	for _, change := range changes {
+		request.Files = append(request.Files, change.Path)
+	}
+	path := filepath.Join(config.ApprovalsDir, request.ID+".json")
+	if err := writeApproval(path, request); err != nil {
+		return request, "", err
+	}
+	return request, path, nil
+}
+
+func awaitPendingApproval(path string, timeout time.Duration, remote func() (approvalOutcome, bool)) approvalOutcome {
+	var deadline <-chan time.Time
+	if timeout > 0 {
+		deadline = time.After(timeout)
+	}
+	ticker := time.NewTicker(approvalPollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-deadline:
+			return approvalOutcome{Reason: "approval timed out", Approver: "timeout"}
+		case <-ticker.C:
+		}
+		current, err := readApproval(path)
+		if err != nil {
+			return approvalOutcome{Reason: "pending approval was removed"}
+		}
+		switch current.Status {
+		case "approved":
+			return approvalOutcome{Approved: true, Reason: "approved by " + current.Approver, Approver: current.Approver}
+		case "denied":
+			return approvalOutcome{Reason: "denied by " + current.Approver, Approver: current.Approver}
+		}
+		if remote != nil {
+			if outcome, done := remote(); done {
+				return outcome
+			}
+		}
+	}
+}
+
+func writeApproval(path string, request pendingApproval) error {
+	payload, err := json.MarshalIndent(request, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, payload, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+func readApproval(path string) (pendingApproval, error) {
+	var request pendingApproval
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return request, err
+	}
+	return request, json.Unmarshal(data, &request)
+}
+
+func ApprovalsCommand(args []string) error {
+	if len(args) == 0 || args[0] == "list" {
+		return listApprovals()
+	}
+	if len(args) != 2 || (args[0] != "approve" && args[0] != "deny") {
+		printError("Usage: mini-go approvals [list] | approve <id> | deny <id>")
+		return errors.New("unknown approvals command")
+	}
+	path := filepath.Join(config.ApprovalsDir, filepath.Base(args[1])+".json")
+	request, err := readApproval(path)
+	if err != nil {
+		printError("No pending approval " + args[1])
+		return err
+	}
+	verb := "Approved"
+	request.Status = "approved"
+	if args[0] == "deny" {
+		request.Status, verb = "denied", "Denied"
+	}
+	request.Approver = approverName()
+	if err := writeApproval(path, request); err != nil {
+		printError(err.Error())
+		return err
+	}
+	printSuccess(fmt.Sprintf("✓ %s %s", verb, request.ID))
+	return nil
+}
+
+func listApprovals() error {
+	entries, err := os.ReadDir(config.ApprovalsDir)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		printError(err.Error())
+		return err
+	}
+	var pending []pendingApproval
+	for _, entry := range entries {
+		if !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+		if request, err := readApproval(filepath.Join(config.ApprovalsDir, entry.Name())); err == nil && request.Status == "pending" {
+			pending = append(pending, request)
+		}
+	}
+	if len(pending) == 0 {
+		fmt.Println(ui.Muted("No pending approvals."))
+		return nil
+	}
+	sort.Slice(pending, func(i, j int) bool { return pending[i].CreatedAt.Before(pending[j].CreatedAt) })
+	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "ID\tWAITING\tWORKSPACE\tREQUEST")
+	for _, request := range pending {
+		subject := request.Command
+		if subject == "" {
+			subject = "write " + strings.Join(request.Files, ", ")
+		}
+		fmt.Fprintf(writer, "%s\t%s\t%s\t%s\n", ui.Cyan(request.ID), time.Since(request.CreatedAt).Round(time.Second), ui.Muted(request.Workspace), subject)
+	}
+	writer.Flush()
+	return nil
+}
+
+func approverName() string {
+	if name := os.Getenv("USER"); name != "" {
+		return name
+	}
+	return "cli"
+}
+
+type pendingRead struct {
+	lines chan string
+	errs  chan error
+}
+
+var (
+	pendingReadsMu sync.Mutex
+	pendingReads   = map[*bufio.Reader]*pendingRead{}
+)
+
+func startRead(reader *bufio.Reader) *pendingRead {
+	pendingReadsMu.Lock()
+	defer pendingReadsMu.Unlock()
+	if read, ok := pendingReads[reader]; ok {
+		return read
+	}
+	read := &pendingRead{lines: make(chan string, 1), errs: make(chan error, 1)}
+	pendingReads[reader] = read
+	go func() {
+		text, err := reader.ReadString('\n')
+		if err != nil {
+			read.errs <- err
+			return
+		}
+		read.lines <- text
+	}()
+	return read
+}
+
+func finishRead(reader *bufio.Reader) {
+	pendingReadsMu.Lock()
+	defer pendingReadsMu.Unlock()
+	delete(pendingReads, reader)
+}
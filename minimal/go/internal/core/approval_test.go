@@ -0,0 +1,34 @@
+package core
+
+import (
+	"testing"
+
+	"minimal-go/internal/config"
+)
+
+func TestApproveSafe(t *testing.T) {
+	tests := []struct {
+		command string
+		want    bool
+	}{
+		{"git status", true},
+		{"rg -n TODO | head", true},
+		{"ls & rm -rf build", false},
+		{"cat x & touch y", false},
+		{"echo hi & curl -X POST http://x", false},
+		{"ls\nrm -rf build", false},
+		{"git branch -D main", false},
+		{"go test ./...", false},
+		{"RUST_LOG='debug' ls", false},
+	}
+	for _, test := range tests {
+		outcome, err := unattendedDecision(config.ApprovalConfig{}, config.ApprovalSafe, test.command, nil)
+		if err != nil || outcome.Approved != test.want {
+			t.Errorf("approve-safe %q = %v (%s), %v; want %v", test.command, outcome.Approved, outcome.Reason, err, test.want)
+		}
+	}
+	outcome, _ := unattendedDecision(config.ApprovalConfig{}, config.ApprovalSafe, "", []FileChange{{Path: "a.go"}})
+	if outcome.Approved {
+		t.Error("approve-safe approved a file write")
+	}
+}
@@ -0,0 +1,62 @@
+package core
+
+import (
+	"fmt"
+	"strings"
+
+	"minimal-go/internal/types"
+)
+
+const maxQuestionOptions = 9
+
+// Question is what the model asks through ask_user.
+type Question struct {
+	Text    string
+	Options []string
+	// AllowOther lets the user type an answer that is not an option.
+	AllowOther bool
+}
+
+func (a *agent) handleAskUser(input interface{}, callID string) types.Message {
+	args := toolArgs(input)
+	question := Question{Text: strings.TrimSpace(extractStringArg(args, "question")), AllowOther: true}
+	if question.Text == "" {
+		return toolError(callID, "question must not be empty.")
+	}
+	if allow, ok := args["allow_other"].(bool); ok {
+		question.AllowOther = allow
+	}
+	options, _ := args["options"].([]interface{})
+	for _, option := range options {
+		if text, ok := option.(string); ok && strings.TrimSpace(text) != "" {
+			question.Options = append(question.Options, strings.TrimSpace(text))
+		}
+	}
+	if len(question.Options) > maxQuestionOptions {
+		return toolError(callID, fmt.Sprintf("Offer at most %d options.", maxQuestionOptions))
+	}
+	if len(question.Options) == 0 {
+		question.AllowOther = true
+	}
+
+	reply := func(content string) types.Message {
+		return types.Message{Role: types.RoleTool, ToolCallID: callID, Content: content}
+	}
+	if a.callbacks.AskUser == nil {
+		return reply("No one can answer questions in this session. Proceed with your best judgement and state the assumption you made.")
+	}
+	answer, answered, err := a.callbacks.AskUser(question)
+	if err != nil {
+		return toolError(callID, "Could not ask the user: "+err.Error())
+	}
+	answer = strings.TrimSpace(answer)
+	if !answered || answer == "" {
+		return reply("The user skipped the question. Do not ask it again; proceed with your best judgement and state the assumption you made, or stop if you cannot.")
+	}
+	for i, option := range question.Options {
+		if answer == option {
+			return reply(fmt.Sprintf("The user chose option %d: %s", i+1, option))
+		}
+	}
+	return reply("The user answered: " + answer)
+}
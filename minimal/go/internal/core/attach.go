@@ -0,0 +1,120 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"minimal-go/internal/types"
+	"minimal-go/internal/vault"
+)
+
+const (
+	attachmentExcerptChars = 2000
+	readFileDefaultLimit   = 400
+	readFileMaxChars       = 40000
+)
+
+func (a *agent) attachLargeContent(content string) string {
+	threshold := a.config.Context.AttachOverChars
+	if threshold <= 0 || len(content) <= threshold {
+		return content
+	}
+
+	dir := filepath.Join(a.workspaceRoot, ".minimal", "attachments")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		a.debugLog("Attach user content", err.Error())
+		return content
+	}
+	ignore := filepath.Join(dir, ".gitignore")
+	if _, err := os.Stat(ignore); os.IsNotExist(err) {
+		_ = os.WriteFile(ignore, []byte("*\n"), 0o644)
+	}
+	path := filepath.Join(dir, time.Now().Format("20060102-150405.000")+".txt")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		a.debugLog("Attach user content", err.Error())
+		return content
+	}
+
+	relative := a.relativeToWorkspace(path)
+	lines := strings.Count(content, "\n") + 1
+	a.notice(LevelWarning, fmt.Sprintf("[context] message is %d chars (~%d tokens); attached as %s with an excerpt", len(content), estimateTextTokens(content), relative))
+
+	head := strings.ToValidUTF8(content[:attachmentExcerptChars], "")
+	tail := strings.ToValidUTF8(content[len(content)-attachmentExcerptChars:], "")
+	return fmt.Sprintf("[My message was too large to send inline (%d chars, %d lines). The full text is in %s; use read_file with offset/limit to read the parts you need.]\n\n--- beginning ---\n%s\n...\n--- end ---\n%s",
+		len(content), lines, relative, head, tail)
+}
+
+func (a *agent) handleReadFile(input interface{}, callID string) types.Message {
+	args := toolArgs(input)
+	path := extractStringArg(input, "path")
+	if path == "" {
+		return toolError(callID, "read_file requires path.")
+	}
+	fullPath, err := a.resolveReadPath(path)
+	if err != nil {
+		return a.pathDenied(callID, path, err)
+	}
+	if decision, denied := a.fileDenied(fullPath); denied {
+		return *a.commandDenial("read_file "+path, decision, callID)
+	}
+
+	read := os.ReadFile
+	if isPrunedOutput(fullPath) {
+		read = vault.ReadFile
+	}
+	data, err := read(fullPath)
+	if err != nil {
+		return toolError(callID, fmt.Sprintf("Cannot read %s: %v", path, err))
+	}
+	if text := string(data); sanitizeOutput(text) != text {
+		return toolError(callID, fmt.Sprintf("%s is a binary file (%d bytes).", path, len(data)))
+	}
+
+	offset := intArg(args, "offset", 1)
+	limit := intArg(args, "limit", readFileDefaultLimit)
+	lines := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+	if offset < 1 {
+		offset = 1
+	}
+	if offset > len(lines) {
+		return toolError(callID, fmt.Sprintf("%s has only %d lines.", path, len(lines)))
+	}
+	end := offset - 1 + limit
+	if limit <= 0 || end > len(lines) {
+		end = len(lines)
+	}
+
+	var b strings.Builder
+	last := offset - 1
+	for _, line := range lines[offset-1 : end] {
+		if b.Len()+len(line) > readFileMaxChars {
+			break
+		}
+		b.WriteString(line)
+		b.WriteString("\n")
+		last++
+	}
+	fields := map[string]interface{}{
+		"path":       path,
+		"lines":      fmt.Sprintf("%d-%d", offset, last),
+		"totalLines": len(lines),
+		"content":    b.String(),
+	}
+	if last < len(lines) {
+		fields["next"] = fmt.Sprintf("call read_file with offset %d to continue", last+1)
+	}
+	payload, _ := json.MarshalIndent(fields, "", "  ")
+	return types.Message{Role: types.RoleTool, ToolCallID: callID, Content: string(payload)}
+}
+
+func intArg(args map[string]interface{}, key string, fallback int) int {
+	if value, ok := args[key].(float64); ok {
+		return int(value)
+	}
+	return fallback
+}
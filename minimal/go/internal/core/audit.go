@@ -0,0 +1,84 @@
+package core
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"minimal-go/internal/config"
+	"minimal-go/internal/vault"
+)
+
+type auditEntry struct {
+	Time      time.Time `json:"time"`
+	Workspace string    `json:"workspace"`
+	Action    string    `json:"action"`
+	Target    string    `json:"target"`
+	Decision  string    `json:"decision"`
+	Class     string    `json:"class,omitempty"`
+	Approver  string    `json:"approver,omitempty"`
+}
+
+type auditLog struct {
+	mu      sync.Mutex
+	pending []auditEntry
+}
+
+func (a *agent) audit(action string, target string, decision string, class string) {
+	entry := auditEntry{
+		Time:      time.Now(),
+		Workspace: a.workspaceRoot,
+		Action:    action,
+		Target:    target,
+		Decision:  decision,
+		Class:     class,
+	}
+	switch decision {
+	case "approved", "edited", "rejected":
+		if a.callbacks.Approver != nil {
+			entry.Approver = a.callbacks.Approver()
+		}
+	}
+	a.auditLog.mu.Lock()
+	defer a.auditLog.mu.Unlock()
+	a.auditLog.pending = append(a.auditLog.pending, entry)
+}
+
+func (a *agent) flushAudit() {
+	a.auditLog.mu.Lock()
+	defer a.auditLog.mu.Unlock()
+	if len(a.auditLog.pending) == 0 {
+		return
+	}
+	if err := appendAudit(a.auditLog.pending); err != nil {
+		a.debugLog("Audit log", err.Error())
+		return
+	}
+	a.auditLog.pending = nil
+}
+
+func appendAudit(entries []auditEntry) error {
+	if err := os.MkdirAll(filepath.Dir(config.AuditLogPath), 0o755); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(config.AuditLogPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	for _, entry := range entries {
+		line, err := json.Marshal(entry)
+		if err != nil {
+			return err
+		}
+		if line, err = vault.SealLine(line); err != nil {
+			return err
+		}
+		if _, err := file.Write(append(line, '\n')); err != nil {
+			return err
+		}
+	}
+	return file.Sync()
+}
@@ -0,0 +1,67 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"minimal-go/internal/config"
+)
+
+const maxAutoSummary = 40
+
+// autoGuardrail checks that --auto can be undone: either the process runs in
+// a container (or MINI_GO_SANDBOX=1 vouches for another sandbox), or the
+// workspace is a git worktree with nothing uncommitted, so git can restore
+// anything the agent changes.
+func autoGuardrail(workspaceRoot string) (string, error) {
+	if sandboxed() {
+		return "running in a sandbox", nil
+	}
+	output, err := exec.Command("git", "-C", workspaceRoot, "status", "--porcelain").Output()
+	if err != nil {
+		return "", errors.New("--auto needs a sandbox or a git worktree: " + workspaceRoot + " is not a git repository (set MINI_GO_SANDBOX=1 if it runs in a sandbox)")
+	}
+	if pending := strings.TrimSpace(string(output)); pending != "" {
+		return "", fmt.Errorf("--auto needs a clean git worktree so every change can be reverted; commit or stash the %d pending change(s) first", len(strings.Split(pending, "\n")))
+	}
+	return "git worktree is clean", nil
+}
+
+func sandboxed() bool {
+	if os.Getenv("MINI_GO_SANDBOX") == "1" || os.Getenv("container") != "" {
+		return true
+	}
+	for _, marker := range []string{"/.dockerenv", "/run/.containerenv"} {
+		if _, err := os.Stat(marker); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+func (a *agent) recordAutoRun(action string) {
+	if a.config.Auto {
+		a.autoRuns = append(a.autoRuns, action)
+	}
+}
+
+// reportAutoRuns lists what ran without approval during the turn, since in
+// --auto mode nobody looked at it beforehand.
+func (a *agent) reportAutoRuns() {
+	if len(a.autoRuns) == 0 {
+		return
+	}
+	lines := []string{fmt.Sprintf("━━ auto mode: %d action(s) ran without approval this turn ━━", len(a.autoRuns))}
+	for i, action := range a.autoRuns {
+		if i == maxAutoSummary {
+			lines = append(lines, fmt.Sprintf("  … and %d more (see %s)", len(a.autoRuns)-maxAutoSummary, config.AuditLogPath))
+			break
+		}
+		lines = append(lines, "  "+previewLine(action, 100))
+	}
+	a.notice(LevelWarning, strings.Join(lines, "\n"))
+	a.autoRuns = nil
+}
@@ -0,0 +1,191 @@
+package core
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"minimal-go/internal/taskfile"
+	"minimal-go/internal/types"
+	"minimal-go/internal/ui"
+)
+
+type taskReport struct {
+	Name     string  `json:"name"`
+	Status   string  `json:"status"`
+	Error    string  `json:"error,omitempty"`
+	Duration string  `json:"duration"`
+	Tokens   int     `json:"tokens"`
+	Cost     float64 `json:"cost,omitempty"`
+	Tools    int     `json:"tools"`
+	Denied   int     `json:"denied"`
+	Rejected int     `json:"rejected"`
+	Response string  `json:"response,omitempty"`
+}
+
+func RunTasks(args []string, options MainOptions) error {
+	flags := flag.NewFlagSet("run", flag.ContinueOnError)
+	reportPath := flags.String("report", "", "write a JSON report to this file")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() == 0 {
+		printError("Usage: mini-go run tasks.yaml [--report report.json]")
+		return errors.New("task file required")
+	}
+	path := flags.Arg(0)
+	if err := flags.Parse(flags.Args()[1:]); err != nil {
+		return err
+	}
+
+	file, err := taskfile.Load(path)
+	if err != nil {
+		printError(err.Error())
+		return err
+	}
+	env, err := loadEnvironment()
+	if err != nil {
+		return err
+	}
+	if err := options.apply(&env); err != nil {
+		return err
+	}
+
+	fmt.Println(ui.Bold(fmt.Sprintf("Running %d task(s)", len(file.Tasks))) + ui.Muted(fmt.Sprintf(" (%s, approvals: %s)", file.Mode, file.Approve)))
+	var reports []taskReport
+	var history []types.Message
+	failed := 0
+	for i, task := range file.Tasks {
+		fmt.Println("")
+		fmt.Println(ui.Accent(fmt.Sprintf("═══ [%d/%d] %s ═══", i+1, len(file.Tasks), task.Name)))
+		report, messages := runTask(env, file, task, history)
+		if file.Mode == taskfile.ModeChained {
+			history = messages
+		}
+		reports = append(reports, report)
+		if report.Status != "ok" {
+			failed++
+			if file.StopOnError {
+				fmt.Println(ui.Warning("Stopping: stopOnError is set."))
+				break
+			}
+		}
+	}
+
+	printTaskReports(reports)
+	if *reportPath != "" {
+		payload, _ := json.MarshalIndent(map[string]interface{}{"file": path, "mode": file.Mode, "tasks": reports}, "", "  ")
+		if err := os.WriteFile(*reportPath, append(payload, '\n'), 0o644); err != nil {
+			printError(err.Error())
+			return err
+		}
+		fmt.Println(ui.Muted("Report written to " + *reportPath))
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d task(s) failed", failed)
+	}
+	return nil
+}
+
+func runTask(env environment, file taskfile.File, task taskfile.Task, history []types.Message) (report taskReport, messages []types.Message) {
+	report = taskReport{Name: task.Name, Status: "ok"}
+	start := time.Now()
+	defer func() { report.Duration = time.Since(start).Round(time.Millisecond).String() }()
+
+	cfg := env.config
+	cfg.Policy = file.Policy.Apply(cfg.Policy)
+	cfg.Policy = task.Policy.Apply(cfg.Policy)
+	approve := task.Approve == taskfile.ApproveAll
+	approver := &approverRecord{}
+	unattendedCommand, unattendedFiles := unattendedCallbacks(cfg.Approval, cfg.Approval.Strategy, "batch", approver)
+
+	created, err := CreateAgent(AgentOptions{
+		Config:        cfg,
+		SystemPrompt:  env.systemPrompt,
+		WorkspaceRoot: env.workspaceRoot,
+		Callbacks: AgentCallbacks{
+			PromptApproval: func(command string) (bool, error) {
+				if approve {
+					approver.set("task:approve-all")
+					fmt.Println(ui.Success("✓ (batch) " + command))
+					return true, nil
+				}
+				approved, err := unattendedCommand(command)
+				if !approved {
+					report.Rejected++
+				}
+				return approved, err
+			},
+			PromptFileChanges: func(command string, changes []FileChange) ([]FileApproval, error) {
+				answers := make([]FileApproval, len(changes))
+				for _, change := range changes {
+					printFileChange(os.Stdout, change)
+				}
+				if approve {
+					approver.set("task:approve-all")
+					fillApprovals(answers, FileApproved)
+					return answers, nil
+				}
+				answers, err := unattendedFiles(command, changes)
+				if len(answers) > 0 && answers[0] != FileApproved {
+					report.Rejected += len(changes)
+				}
+				return answers, err
+			},
+			OnAutoApproved: printAutoApproved,
+			OnDenied:       printDenied,
+			Approver:       approver.get,
+		},
+	})
+	if err != nil {
+		report.Status = "error"
+		report.Error = err.Error()
+		return report, history
+	}
+	if len(history) > 0 {
+		created.SetMessages(history)
+	}
+
+	created.AddUserMessage(task.Prompt)
+	if err := created.RunAgentTurn(); err != nil {
+		report.Status = "error"
+		report.Error = err.Error()
+		printError(err.Error())
+	}
+
+	messages = created.GetMessages()
+	for i := len(messages) - 1; i >= 0; i-- {
+		if messages[i].Role == types.RoleAssistant && strings.TrimSpace(messages[i].Content) != "" {
+			report.Response = messages[i].Content
+			break
+		}
+	}
+	for _, turn := range created.GetTurnStats() {
+		report.Tools += len(turn.Tools)
+	}
+	report.Tokens = created.GetTokens().Total
+	report.Cost = created.GetBudgetStatus().Session.Cost
+	report.Denied = created.GetDenialStats().Denials
+	return report, messages
+}
+
+func printTaskReports(reports []taskReport) {
+	fmt.Println("")
+	fmt.Println(ui.Bold("Summary:"))
+	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "  TASK\tSTATUS\tTIME\tTOKENS\tTOOLS\tDENIED\tREJECTED\tRESULT")
+	for _, report := range reports {
+		result := previewLine(report.Response, benchPreviewLength)
+		if report.Error != "" {
+			result = previewLine(report.Error, benchPreviewLength)
+		}
+		fmt.Fprintf(writer, "  %s\t%s\t%s\t%d\t%d\t%d\t%d\t%s\n", report.Name, report.Status, report.Duration, report.Tokens, report.Tools, report.Denied, report.Rejected, result)
+	}
+	writer.Flush()
+	fmt.Println("")
+}
@@ -0,0 +1,186 @@
+package core
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"minimal-go/internal/config"
+	"minimal-go/internal/core/providers"
+	"minimal-go/internal/types"
+	"minimal-go/internal/ui"
+)
+
+const benchPreviewLength = 60
+
+type benchResult struct {
+	variant  string
+	model    string
+	latency  time.Duration
+	usage    *types.Usage
+	cost     float64
+	priced   bool
+	response string
+	err      error
+}
+
+func Bench(args []string) error {
+	flags := flag.NewFlagSet("bench", flag.ContinueOnError)
+	prompt := flags.String("p", "", "prompt to send to every variant")
+	variantList := flags.String("v", "", "comma-separated variants (default: all configured)")
+	full := flags.Bool("full", false, "print full responses after the table")
+	// Overrides apply on top of each variant's own sampling settings.
+	var override config.Sampling
+	flags.Func("seed", "seed for every variant that supports one", func(value string) error {
+		seed, err := strconv.ParseInt(value, 10, 64)
+		override.Seed = &seed
+		return err
+	})
+	flags.Func("top-p", "top_p for every variant", func(value string) error {
+		topP, err := strconv.ParseFloat(value, 64)
+		if err == nil && (topP <= 0 || topP > 1) {
+			err = errors.New("must be greater than 0 and at most 1")
+		}
+		override.TopP = &topP
+		return err
+	})
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if strings.TrimSpace(*prompt) == "" {
+		printError("Usage: mini-go bench -p \"prompt\" [-v groq,anthropic] [--full] [--seed n] [--top-p p]")
+		return errors.New("prompt required")
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		printError(err.Error())
+		return err
+	}
+
+	var variants []string
+	if *variantList != "" {
+		for _, name := range strings.Split(*variantList, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				variants = append(variants, name)
+			}
+		}
+	} else {
+		for name := range cfg.LLM.Variants {
+			variants = append(variants, name)
+		}
+		sort.Strings(variants)
+	}
+
+	messages := []types.Message{}
+	if systemPrompt, err := config.LoadSystemPrompt(); err == nil {
+		messages = append(messages, types.Message{Role: types.RoleSystem, Content: systemPrompt})
+	}
+	messages = append(messages, types.Message{Role: types.RoleUser, Content: *prompt})
+
+	fmt.Println(ui.Muted(fmt.Sprintf("Sending to %d variant(s)...", len(variants))))
+	results := make([]benchResult, len(variants))
+	var wg sync.WaitGroup
+	for i, name := range variants {
+		wg.Add(1)
+		go func(i int, name string) {
+			defer wg.Done()
+			results[i] = benchVariant(cfg, name, messages, override)
+		}(i, name)
+	}
+	wg.Wait()
+
+	printBenchTable(results)
+	if *full {
+		for _, result := range results {
+			if result.err != nil {
+				continue
+			}
+			fmt.Println("")
+			fmt.Println(ui.Accent(result.variant) + ui.Muted(" ("+result.model+")"))
+			fmt.Println(result.response)
+		}
+	}
+
+	for _, result := range results {
+		if result.err == nil {
+			return nil
+		}
+	}
+	return errors.New("all variants failed")
+}
+
+func benchVariant(cfg config.Config, name string, messages []types.Message, override config.Sampling) benchResult {
+	variantCfg := cfg
+	variantCfg.LLM.CurrentProvider = name
+	if name != cfg.LLM.CurrentProvider {
+		variantCfg.LLM.CurrentModel = ""
+	}
+
+	resolved, err := config.ResolveLlmConfig(variantCfg)
+	if err != nil {
+		return benchResult{variant: name, err: err}
+	}
+
+	provider := providers.CreateProvider(resolved)
+	started := time.Now()
+	response, err := provider.CreateChatCompletion(providers.CreateChatParams{
+		Model:       resolved.Model,
+		Temperature: resolved.Temperature,
+		MaxTokens:   resolved.MaxTokens,
+		Sampling:    resolved.Sampling.Override(override),
+		Messages:    messages,
+	})
+	result := benchResult{variant: name, model: resolved.Model, latency: time.Since(started), err: err}
+	if err != nil {
+		result.err = mapProviderError(err)
+		return result
+	}
+
+	result.response = strings.TrimSpace(response.Message.Content)
+	result.usage = response.Usage
+	if response.Usage != nil && resolved.ModelKnown {
+		result.cost = resolved.ModelInfo.Cost(response.Usage.PromptTokens, response.Usage.CompletionTokens)
+		result.priced = true
+	}
+	return result
+}
+
+func printBenchTable(results []benchResult) {
+	fmt.Println("")
+	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "VARIANT\tMODEL\tLATENCY\tIN\tOUT\tCOST\tRESPONSE")
+	for _, result := range results {
+		if result.err != nil {
+			fmt.Fprintf(writer, "%s\t%s\t-\t-\t-\t-\t%s\n", result.variant, result.model, "error: "+result.err.Error())
+			continue
+		}
+		in, out := "-", "-"
+		if result.usage != nil {
+			in = fmt.Sprintf("%d", result.usage.PromptTokens)
+			out = fmt.Sprintf("%d", result.usage.CompletionTokens)
+		}
+		cost := "-"
+		if result.priced {
+			cost = fmt.Sprintf("$%.5f", result.cost)
+		}
+		fmt.Fprintf(writer, "%s\t%s\t%.2fs\t%s\t%s\t%s\t%s\n", result.variant, result.model, result.latency.Seconds(), in, out, cost, previewLine(result.response, benchPreviewLength))
+	}
+	writer.Flush()
+}
+
+func previewLine(text string, limit int) string {
+	line := strings.Join(strings.Fields(text), " ")
+	runes := []rune(line)
+	if len(runes) > limit {
+		return string(runes[:limit]) + "…"
+	}
+	return line
+}
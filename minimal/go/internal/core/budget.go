@@ -0,0 +1,124 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+
+	"minimal-go/internal/config"
+	"minimal-go/internal/types"
+	"minimal-go/internal/ui"
+	"minimal-go/internal/usage"
+)
+
+const budgetWarnRatio = 0.8
+
+var ErrBudgetExceeded = errors.New("budget exceeded")
+
+type BudgetStatus struct {
+	Limits     config.BudgetConfig
+	Session    usage.Totals
+	Daily      usage.Totals
+	Priced     bool
+	Overridden bool
+}
+
+type budgetLimit struct {
+	name  string
+	used  float64
+	limit float64
+	cost  bool
+}
+
+func (s BudgetStatus) limits() []budgetLimit {
+	return []budgetLimit{
+		{"session tokens", float64(s.Session.Tokens), float64(s.Limits.MaxSessionTokens), false},
+		{"session cost", s.Session.Cost, s.Limits.MaxSessionCost, true},
+		{"daily tokens", float64(s.Daily.Tokens), float64(s.Limits.MaxDailyTokens), false},
+		{"daily cost", s.Daily.Cost, s.Limits.MaxDailyCost, true},
+	}
+}
+
+func (l budgetLimit) format(value float64) string {
+	if l.cost {
+		return fmt.Sprintf("$%.4f", value)
+	}
+	return fmt.Sprintf("%d", int(value))
+}
+
+func (a *agent) GetBudgetStatus() BudgetStatus {
+	daily, err := usage.Today()
+	if err != nil {
+		a.debugLog("Usage ledger", err.Error())
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return BudgetStatus{
+		Limits:     a.config.Budget,
+		Session:    usage.Totals{Tokens: a.sessionTokens.Total, Cost: a.sessionCost},
+		Daily:      daily,
+		Priced:     a.llmConfig.ModelKnown,
+		Overridden: a.budgetOverride,
+	}
+}
+
+func (a *agent) OverrideBudget() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.budgetOverride = true
+}
+
+func (a *agent) checkBudget() error {
+	status := a.GetBudgetStatus()
+	for _, limit := range status.limits() {
+		if limit.limit <= 0 {
+			continue
+		}
+		ratio := limit.used / limit.limit
+		if ratio >= 1 && !status.Overridden {
+			return fmt.Errorf("%w: %s %s of %s. Run /budget override to continue anyway", ErrBudgetExceeded, limit.name, limit.format(limit.used), limit.format(limit.limit))
+		}
+		if ratio >= budgetWarnRatio && !a.budgetWarned[limit.name] {
+			a.budgetWarned[limit.name] = true
+			a.notice(LevelWarning, fmt.Sprintf("[budget] %s at %.0f%% (%s of %s)", limit.name, ratio*100, limit.format(limit.used), limit.format(limit.limit)))
+		}
+	}
+	return nil
+}
+
+func (a *agent) recordUsage(responseUsage *types.Usage) float64 {
+	cost := 0.0
+	if a.llmConfig.ModelKnown {
+		cost = a.llmConfig.ModelInfo.Cost(responseUsage.PromptTokens, responseUsage.CompletionTokens)
+	}
+	a.mu.Lock()
+	a.sessionTokens.Prompt += responseUsage.PromptTokens
+	a.sessionTokens.Completion += responseUsage.CompletionTokens
+	a.sessionTokens.Total += responseUsage.TotalTokens
+	a.sessionCost += cost
+	a.mu.Unlock()
+	if err := usage.Record(a.llmConfig.Model, a.workspaceRoot, responseUsage.TotalTokens, cost); err != nil {
+		a.debugLog("Usage ledger", err.Error())
+	}
+	return cost
+}
+
+func printBudgetStatus(status BudgetStatus) {
+	fmt.Println("")
+	fmt.Println(ui.Bold("Budget:"))
+	for _, limit := range status.limits() {
+		line := fmt.Sprintf("  %-15s %s", limit.name, limit.format(limit.used))
+		if limit.limit > 0 {
+			line += fmt.Sprintf(" / %s (%.0f%%)", limit.format(limit.limit), limit.used/limit.limit*100)
+		} else {
+			line += ui.Muted(" (no limit)")
+		}
+		fmt.Println(line)
+	}
+	if !status.Priced {
+		fmt.Println(ui.Muted("  Cost is not tracked for this model; add pricing under \"models\" in config.json."))
+	}
+	if status.Overridden {
+		fmt.Println(ui.Warning("  Limits overridden for this session."))
+	}
+	fmt.Println("")
+}
@@ -0,0 +1,93 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"minimal-go/internal/ui"
+)
+
+const maxShellBufferChars = 16000
+
+type shellBuffer struct {
+	entries []string
+	evicted int
+}
+
+func (b *shellBuffer) add(entry string) {
+	if len(entry) > maxShellBufferChars {
+		entry = fmt.Sprintf("[earlier output truncated: %d chars]\n", len(entry)-maxShellBufferChars) + entry[len(entry)-maxShellBufferChars:]
+	}
+	b.entries = append(b.entries, entry)
+	for len(b.entries) > 1 && b.size() > maxShellBufferChars {
+		b.entries = b.entries[1:]
+		b.evicted++
+	}
+}
+
+func (b *shellBuffer) size() int {
+	total := 0
+	for _, entry := range b.entries {
+		total += len(entry)
+	}
+	return total
+}
+
+func (b *shellBuffer) empty() bool {
+	return len(b.entries) == 0
+}
+
+func (b *shellBuffer) String() string {
+	return strings.Join(b.entries, "\n\n")
+}
+
+func (b *shellBuffer) prepend(content string) string {
+	if b.empty() {
+		return content
+	}
+	combined := b.String() + "\n\n" + content
+	b.clear()
+	return combined
+}
+
+func (b *shellBuffer) clear() {
+	b.entries = nil
+	b.evicted = 0
+}
+
+func (b *shellBuffer) indicator() string {
+	if b.empty() {
+		return ""
+	}
+	return ui.Muted(fmt.Sprintf("[buf %d · %s] ", len(b.entries), formatChars(b.size())))
+}
+
+func (s *replState) handleBufferCommand(args string) error {
+	switch args {
+	case "", "show":
+		if s.buffer.empty() {
+			fmt.Println(ui.Muted("Shell output buffer is empty."))
+			return nil
+		}
+		fmt.Println(ui.Bold(fmt.Sprintf("Buffered shell output (%d entries, %s, sent with your next prompt):", len(s.buffer.entries), formatChars(s.buffer.size()))))
+		if s.buffer.evicted > 0 {
+			fmt.Println(ui.Warning(fmt.Sprintf("  %d older entries were dropped to stay under %s", s.buffer.evicted, formatChars(maxShellBufferChars))))
+		}
+		fmt.Println(s.buffer.String())
+		return nil
+	case "clear", "drop":
+		entries := len(s.buffer.entries)
+		s.buffer.clear()
+		printSuccess(fmt.Sprintf("✓ Dropped %d buffered shell output entries.", entries))
+		return nil
+	}
+	return errors.New("Usage: /buffer [show|clear]")
+}
+
+func formatChars(n int) string {
+	if n < 1000 {
+		return fmt.Sprintf("%d chars", n)
+	}
+	return fmt.Sprintf("%.1fk chars", float64(n)/1000)
+}
@@ -0,0 +1,75 @@
+package core
+
+import (
+	"strings"
+
+	"minimal-go/internal/core/providers"
+	"minimal-go/internal/policy"
+)
+
+const maxModelRiskLabels = 3
+
+var riskFormat = &providers.ResponseFormat{
+	Type: providers.FormatJSONSchema,
+	Name: "command_risk",
+	Schema: map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"risk":   map[string]interface{}{"type": "string", "enum": []string{"low", "medium", "high"}},
+			"labels": map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}},
+		},
+		"required":             []string{"risk", "labels"},
+		"additionalProperties": false,
+	},
+}
+
+const riskInstructions = "You review shell commands before a developer approves them. List what the command below would do that the developer should know before running it, " +
+	"each as a short lower-case phrase such as \"modifies git history\", \"network egress\" or \"deletes build output\" (at most 3; none for harmless commands), " +
+	"and rate the overall risk as low, medium or high. Return JSON: {\"risk\": \"...\", \"labels\": [\"...\"]}."
+
+// assessCommand labels command for the approval prompt: the policy rules
+// first, then policy.classifierModel when one is configured.
+func (a *agent) assessCommand(command string) policy.Assessment {
+	chain := []policy.Classifier{policy.RiskClassifier(a.config)}
+	if a.config.Policy.ClassifierModel != "" {
+		chain = append(chain, policy.ClassifierFunc(a.classifyWithModel))
+	}
+	return policy.Classify(command, chain...)
+}
+
+// classifyWithModel asks the classifier model for labels. The check only
+// adds information, so a failure leaves the rule-based labels alone.
+func (a *agent) classifyWithModel(command string) []policy.Finding {
+	var result struct {
+		Risk   string   `json:"risk"`
+		Labels []string `json:"labels"`
+	}
+	if err := a.completeJSONWith(a.config.Policy.ClassifierModel, riskInstructions, command, riskFormat, structuredMaxTokens, &result); err != nil {
+		a.debugLog("Risk classifier failed", map[string]interface{}{"command": command, "error": err.Error()})
+		return nil
+	}
+	risk, _ := policy.ParseRisk(result.Risk)
+	var findings []policy.Finding
+	for _, label := range result.Labels {
+		label = strings.ToLower(strings.Trim(strings.TrimSpace(label), "."))
+		if label == "" {
+			continue
+		}
+		findings = append(findings, policy.Finding{Label: previewLine(label, 60), Risk: risk})
+		if len(findings) == maxModelRiskLabels {
+			break
+		}
+	}
+	return findings
+}
+
+func (a *agent) noticeRisk(assessment policy.Assessment) {
+	if assessment.Empty() {
+		return
+	}
+	level := LevelInfo
+	if assessment.Risk > policy.RiskLow {
+		level = LevelWarning
+	}
+	a.notice(level, "[risk] "+assessment.String())
+}
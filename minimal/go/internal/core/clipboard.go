@@ -0,0 +1,138 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"minimal-go/internal/types"
+	"minimal-go/internal/ui"
+)
+
+type codeBlock struct {
+	language string
+	code     string
+}
+
+type clipboardTool struct {
+	name string
+	args []string
+}
+
+// Tried in order; the first one installed wins. powershell.exe also covers
+// WSL, where the Linux tools usually have no display to talk to.
+func clipboardTools() []clipboardTool {
+	switch runtime.GOOS {
+	case "darwin":
+		return []clipboardTool{{name: "pbcopy"}}
+	case "windows":
+		return []clipboardTool{{name: "clip"}, {name: "powershell", args: []string{"-NoProfile", "-Command", "$input | Set-Clipboard"}}}
+	}
+	var tools []clipboardTool
+	if os.Getenv("WAYLAND_DISPLAY") != "" {
+		tools = append(tools, clipboardTool{name: "wl-copy"})
+	}
+	return append(tools,
+		clipboardTool{name: "xclip", args: []string{"-selection", "clipboard"}},
+		clipboardTool{name: "xsel", args: []string{"--clipboard", "--input"}},
+		clipboardTool{name: "clip.exe"},
+		clipboardTool{name: "powershell.exe", args: []string{"-NoProfile", "-Command", "$input | Set-Clipboard"}},
+	)
+}
+
+func copyToClipboard(text string) (string, error) {
+	var tried []string
+	for _, tool := range clipboardTools() {
+		path, err := exec.LookPath(tool.name)
+		if err != nil {
+			tried = append(tried, tool.name)
+			continue
+		}
+		cmd := exec.Command(path, tool.args...)
+		cmd.Stdin = strings.NewReader(text)
+		if output, err := cmd.CombinedOutput(); err != nil {
+			if message := strings.TrimSpace(string(output)); message != "" {
+				return "", fmt.Errorf("%s failed: %s", tool.name, message)
+			}
+			return "", fmt.Errorf("%s failed: %w", tool.name, err)
+		}
+		return tool.name, nil
+	}
+	return "", fmt.Errorf("no clipboard tool found (tried %s)", strings.Join(tried, ", "))
+}
+
+// extractCodeBlocks returns the fenced blocks of a Markdown text. An
+// unterminated fence runs to the end, as it does when rendered.
+func extractCodeBlocks(text string) []codeBlock {
+	var blocks []codeBlock
+	var current *codeBlock
+	var fence string
+	var lines []string
+	for _, line := range strings.Split(text, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if current == nil {
+			if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+				info := strings.TrimLeft(trimmed, trimmed[:1])
+				fence = trimmed[:len(trimmed)-len(info)]
+				current = &codeBlock{language: strings.TrimSpace(info)}
+				lines = nil
+			}
+			continue
+		}
+		if strings.HasPrefix(trimmed, fence) && strings.Trim(trimmed, fence[:1]) == "" {
+			current.code = strings.Join(lines, "\n")
+			blocks = append(blocks, *current)
+			current = nil
+			continue
+		}
+		lines = append(lines, line)
+	}
+	if current != nil {
+		current.code = strings.Join(lines, "\n")
+		blocks = append(blocks, *current)
+	}
+	return blocks
+}
+
+// /copy [n] copies the nth code block of the last answer, or its last block
+// when n is left out.
+func (s *replState) handleCopyCommand(args string) error {
+	messages := s.agent.GetMessages()
+	var blocks []codeBlock
+	for i := len(messages) - 1; i > 0; i-- {
+		if messages[i].Role == types.RoleAssistant && messages[i].Content != "" {
+			blocks = extractCodeBlocks(messages[i].Content)
+			break
+		}
+	}
+	if len(blocks) == 0 {
+		return errors.New("The last answer has no code blocks.")
+	}
+
+	n := len(blocks)
+	if args != "" {
+		var err error
+		if n, err = strconv.Atoi(args); err != nil || n < 1 || n > len(blocks) {
+			return fmt.Errorf("Usage: /copy [n] (the last answer has %d code block(s))", len(blocks))
+		}
+	}
+	block := blocks[n-1]
+	tool, err := copyToClipboard(block.code + "\n")
+	if err != nil {
+		return err
+	}
+	label := fmt.Sprintf("block %d of %d", n, len(blocks))
+	if block.language != "" {
+		label += ", " + block.language
+	}
+	lines := strings.Count(block.code, "\n") + 1
+	printSuccess(fmt.Sprintf("✓ Copied %s (%d line(s)) with %s.", label, lines, tool))
+	if len(blocks) > 1 && args == "" {
+		fmt.Println(ui.Muted("/copy <n> picks another block."))
+	}
+	return nil
+}
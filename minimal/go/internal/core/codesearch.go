@@ -0,0 +1,60 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"minimal-go/internal/config"
+	"minimal-go/internal/index"
+	"minimal-go/internal/policy"
+	"minimal-go/internal/types"
+)
+
+func (a *agent) handleSearchCode(input interface{}, callID string) types.Message {
+	args := toolArgs(input)
+	query := strings.TrimSpace(extractStringArg(input, "query"))
+	if query == "" {
+		return toolError(callID, "search_code requires query.")
+	}
+
+	codeIndex := a.workspaceIndex()
+	stats, err := codeIndex.Refresh()
+	if err != nil {
+		return toolError(callID, "Cannot index the workspace: "+err.Error())
+	}
+	if stats.Updated > 0 || stats.Removed > 0 {
+		a.notice(LevelInfo, fmt.Sprintf("[index] %d file(s) indexed (%d updated, %d removed)", stats.Files, stats.Updated, stats.Removed))
+	}
+
+	results := codeIndex.Search(query, index.SearchOptions{Limit: intArg(args, "limit", 0), Path: extractStringArg(args, "path")})
+	fields := map[string]interface{}{"query": query, "results": results}
+	if len(results) == 0 {
+		fields["note"] = "No indexed file matches. Try other words or a symbol name, or grep for an exact string."
+	}
+	payload, _ := json.MarshalIndent(fields, "", "  ")
+	return types.Message{Role: types.RoleTool, ToolCallID: callID, Content: string(payload)}
+}
+
+// workspaceIndex opens the index on first use. Files the policy treats as
+// sensitive are never read into it, so their contents cannot surface in a
+// snippet or in the cached index.
+func (a *agent) workspaceIndex() *index.Index {
+	if a.codeIndex == nil {
+		a.codeIndex = index.Open(a.workspaceRoot, config.CacheDir, func(rel string) bool {
+			_, denied := policy.CheckFile(rel, "", a.config)
+			return denied
+		})
+	}
+	return a.codeIndex
+}
+
+// updateIndex keeps an open index current with the agent's own writes.
+func (a *agent) updateIndex(path string) {
+	if a.codeIndex == nil {
+		return
+	}
+	if fullPath, err := a.resolvePath(path); err == nil {
+		a.codeIndex.Update(a.relativeToWorkspace(fullPath))
+	}
+}
@@ -0,0 +1,69 @@
+package core
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"minimal-go/internal/commands"
+	"minimal-go/internal/config"
+	"minimal-go/internal/policy"
+	"minimal-go/internal/ui"
+)
+
+const maxSnippetOutput = 8000
+
+// /cmd <name> [args] sends ~/.minimal/commands/<name>.md as the user message;
+// /cmd alone lists the templates.
+func (s *replState) handleCmdCommand(args string) error {
+	name, rest, _ := strings.Cut(args, " ")
+	if name == "" {
+		printCommandList(commands.List())
+		return nil
+	}
+	command, err := commands.Load(name)
+	if err != nil {
+		printError("Command not found: " + name)
+		printCommandList(commands.List())
+		return nil
+	}
+
+	content, err := command.Expand(commands.SplitArgs(rest), func(snippet string) string {
+		fmt.Println(ui.Muted("[cmd] $ " + snippet))
+		result := policy.RunBash(snippet, s.workspaceRoot)
+		output := strings.TrimSpace(result.Stdout + result.Stderr)
+		if len(output) > maxSnippetOutput {
+			output = output[:maxSnippetOutput] + "\n… (truncated)"
+		}
+		if result.Code != 0 {
+			output = strings.TrimSpace(output + fmt.Sprintf("\n(exit %d)", result.Code))
+		}
+		return output
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Println(ui.Muted(fmt.Sprintf("[cmd] %s → ~%d tokens", command.Name, estimateTextTokens(content))))
+
+	s.agent.AddUserMessage(s.buffer.prepend(content))
+	s.runTurn()
+	s.saveSession()
+	return nil
+}
+
+func printCommandList(list []commands.Command) {
+	fmt.Println("")
+	fmt.Println(ui.Bold("Prompt templates:") + ui.Muted(" ("+config.CommandsDir+"/<name>.md)"))
+	if len(list) == 0 {
+		fmt.Println(ui.Muted("  (none)"))
+	} else {
+		writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		for _, command := range list {
+			fmt.Fprintf(writer, "  %s\t%s\n", ui.Cyan(command.Name), previewLine(command.Description, 60))
+		}
+		writer.Flush()
+	}
+	fmt.Println(ui.Muted("\nUsage: /cmd <name> [args]; templates use $ARGUMENTS, $1..$9 and !`command` for output."))
+	fmt.Println("")
+}
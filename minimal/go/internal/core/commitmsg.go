@@ -0,0 +1,258 @@
+package core
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strings"
+
+	"minimal-go/internal/core/providers"
+	"minimal-go/internal/ui"
+)
+
+const (
+	draftMaxTokens = 800
+	// Diffs beyond this are cut; the file list from --stat still covers
+	// everything that changed.
+	maxDraftDiff = 48 * 1024
+)
+
+type DraftKind string
+
+const (
+	DraftCommit DraftKind = "commit"
+	DraftPR     DraftKind = "pr"
+)
+
+var draftFormat = &providers.ResponseFormat{
+	Type: providers.FormatJSONSchema,
+	Name: "change_description",
+	Schema: map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"title": map[string]interface{}{"type": "string"},
+			"body":  map[string]interface{}{"type": "string"},
+		},
+		"required":             []string{"title", "body"},
+		"additionalProperties": false,
+	},
+}
+
+var draftInstructions = map[DraftKind]string{
+	DraftCommit: `Write a git commit message for the staged diff below in Conventional Commits style. "title" is "type(scope): summary" — type is one of feat, fix, refactor, perf, docs, test, build, ci or chore; the scope is optional; the summary is imperative, lower case, without a trailing period, and the whole title stays under 72 characters. "body" explains what changed and why in a few short lines wrapped at 72 columns, or is empty for a trivial change. Describe only what the diff shows. Return JSON {"title": "...", "body": "..."}.`,
+	DraftPR:     `Write a pull request description for the diff below. "title" is a short plain-language summary under 72 characters. "body" is Markdown: one or two sentences on what the change does and why, then a "## Changes" list with one fact per bullet, then a "## Testing" section with what a reviewer should check. Describe only what the diff shows and keep it under 250 words. Return JSON {"title": "...", "body": "..."}.`,
+}
+
+// DraftDescription is a single small call without tools or history, so it
+// costs little next to a turn.
+func (a *agent) DraftDescription(kind DraftKind, diff string) (string, error) {
+	var result struct {
+		Title string `json:"title"`
+		Body  string `json:"body"`
+	}
+	if err := a.completeJSON(draftInstructions[kind], diff, draftFormat, draftMaxTokens, &result); err != nil {
+		return "", err
+	}
+	title := strings.TrimSpace(result.Title)
+	if title == "" {
+		return "", errors.New("the model returned an empty title")
+	}
+	if body := strings.TrimSpace(result.Body); body != "" {
+		return title + "\n\n" + body, nil
+	}
+	return title, nil
+}
+
+// CommitCommand drafts a message for the staged changes and, once the user
+// accepts it, runs git commit through the usual command approval. It never
+// stages anything itself.
+func CommitCommand(args []string, options MainOptions) error {
+	flags := flag.NewFlagSet("commit", flag.ContinueOnError)
+	pr := flags.Bool("pr", false, "draft a pull request description instead of committing")
+	base := flags.String("base", "", "with --pr, describe `ref`...HEAD instead of the staged diff")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() > 0 {
+		printError("Usage: mini-go commit [--pr [--base ref]]")
+		return errors.New("unexpected arguments")
+	}
+
+	env, err := loadEnvironment()
+	if err != nil {
+		return err
+	}
+	if err := options.apply(&env); err != nil {
+		return err
+	}
+	reader := bufio.NewReader(os.Stdin)
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, os.Interrupt)
+	defer signal.Stop(sigCh)
+
+	approver := &approverRecord{}
+	created, err := CreateAgent(AgentOptions{
+		Config:        env.config,
+		SystemPrompt:  env.systemPrompt,
+		WorkspaceRoot: env.workspaceRoot,
+		Debug:         options.Debug,
+		Callbacks: AgentCallbacks{
+			PromptApproval: newPromptApproval(reader, sigCh, env.config.Approval, approver),
+			PromptCommand:  newPromptCommand(reader, sigCh, env.config.Approval, approver),
+			OnAutoApproved: printAutoApproved,
+			OnDenied:       printDenied,
+			Approver:       approver.get,
+		},
+		OnEvent: RenderEvents(os.Stdout),
+	})
+	if err != nil {
+		printError(err.Error())
+		return err
+	}
+	defer created.Shutdown()
+
+	if *pr {
+		_, err = draftPullRequest(created, reader, sigCh, env.workspaceRoot, *base)
+	} else {
+		err = draftCommit(created, reader, sigCh, env.workspaceRoot)
+	}
+	if err != nil {
+		printError(err.Error())
+	}
+	return err
+}
+
+func draftCommit(agent Agent, reader *bufio.Reader, sigCh <-chan os.Signal, workspaceRoot string) error {
+	diff, err := changeDiff(workspaceRoot, "")
+	if err != nil {
+		return err
+	}
+	if diff == "" {
+		return errors.New("Nothing is staged. Stage the changes to commit with git add first.")
+	}
+	message, err := draftAndEdit(agent, reader, sigCh, DraftCommit, diff, "[enter] commit · [e] edit · [n] cancel")
+	if err != nil || message == "" {
+		return err
+	}
+
+	title, body, _ := strings.Cut(message, "\n")
+	command := "git commit -m " + shellQuote(title)
+	if body = strings.TrimSpace(body); body != "" {
+		command += " -m " + shellQuote(body)
+	}
+	result, err := agent.RunCommand(command)
+	if err != nil {
+		return err
+	}
+	output := strings.TrimSpace(result.Stdout + "\n" + result.Stderr)
+	if result.Code != 0 {
+		return fmt.Errorf("git commit failed (exit %d): %s", result.Code, output)
+	}
+	if output != "" {
+		fmt.Println(output)
+	}
+	printSuccess("✓ Committed: " + title)
+	return nil
+}
+
+func draftPullRequest(agent Agent, reader *bufio.Reader, sigCh <-chan os.Signal, workspaceRoot string, base string) (string, error) {
+	diff, err := changeDiff(workspaceRoot, base)
+	if err != nil {
+		return "", err
+	}
+	if diff == "" {
+		if base == "" {
+			return "", errors.New("Nothing is staged. Stage the changes to describe, or pass a base ref to describe the branch.")
+		}
+		return "", fmt.Errorf("No changes between %s and HEAD.", base)
+	}
+	description, err := draftAndEdit(agent, reader, sigCh, DraftPR, diff, "[enter] done · [e] edit")
+	if err != nil || description == "" {
+		return "", err
+	}
+	fmt.Println("")
+	fmt.Println(description)
+	return description, nil
+}
+
+// draftAndEdit shows the draft until the user accepts it; an empty result
+// means they cancelled.
+func draftAndEdit(agent Agent, reader *bufio.Reader, sigCh <-chan os.Signal, kind DraftKind, diff string, choices string) (string, error) {
+	fmt.Println(ui.Muted("Drafting…"))
+	text, err := agent.DraftDescription(kind, diff)
+	if err != nil {
+		return "", err
+	}
+	for {
+		fmt.Println("")
+		for _, line := range strings.Split(text, "\n") {
+			fmt.Println(ui.Muted("│ ") + line)
+		}
+		fmt.Println("")
+		answer, cancelled, err := readLine(reader, ui.Prompt(choices+": "), sigCh, false)
+		if err != nil || cancelled {
+			return "", err
+		}
+		switch strings.ToLower(strings.TrimSpace(answer)) {
+		case "":
+			return text, nil
+		case "e", "edit":
+			edited, err := editInput(reader, sigCh, text)
+			if err != nil {
+				return "", err
+			}
+			if edited == "" {
+				return "", nil
+			}
+			text = edited
+		case "n", "no":
+			fmt.Println(ui.Muted("Cancelled."))
+			return "", nil
+		}
+	}
+}
+
+// changeDiff returns the staged diff, or base...HEAD when a base is given,
+// headed by a --stat summary.
+func changeDiff(workspaceRoot string, base string) (string, error) {
+	args := []string{"--cached"}
+	if base != "" {
+		if strings.HasPrefix(base, "-") {
+			return "", fmt.Errorf("invalid ref %q", base)
+		}
+		args = []string{base + "...HEAD"}
+	}
+	git := func(extra ...string) (string, error) {
+		full := append([]string{"-C", workspaceRoot, "diff", "--no-color", "--no-ext-diff"}, extra...)
+		output, err := exec.Command("git", append(full, args...)...).CombinedOutput()
+		if err != nil {
+			return "", fmt.Errorf("git diff: %s", strings.TrimSpace(string(output)))
+		}
+		return string(output), nil
+	}
+	stat, err := git("--stat")
+	if err != nil || strings.TrimSpace(stat) == "" {
+		return "", err
+	}
+	diff, err := git()
+	if err != nil {
+		return "", err
+	}
+	if len(diff) > maxDraftDiff {
+		diff = strings.ToValidUTF8(diff[:maxDraftDiff], "") + "\n… (diff truncated)\n"
+	}
+	return stat + "\n" + diff, nil
+}
+
+func shellQuote(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+}
+
+func (s *replState) handlePRDescCommand(args string) error {
+	_, err := draftPullRequest(s.agent, s.reader, s.sigCh, s.workspaceRoot, strings.TrimSpace(args))
+	return err
+}
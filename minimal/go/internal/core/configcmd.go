@@ -0,0 +1,49 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+
+	"minimal-go/internal/config"
+	"minimal-go/internal/ui"
+)
+
+func ConfigCommand(args []string) error {
+	if len(args) == 0 || args[0] != "validate" {
+		printError("Usage: mini-go config validate [path]")
+		return errors.New("unknown config command")
+	}
+
+	path := config.ConfigPath
+	if len(args) > 1 {
+		path = args[1]
+	}
+	issues, err := config.ValidateConfigFile(path)
+	if err != nil {
+		printError(err.Error())
+		return err
+	}
+	if len(issues) == 0 {
+		printSuccess("✓ " + path + " is valid.")
+		return nil
+	}
+
+	fmt.Println(ui.Bold(path))
+	for _, issue := range issues {
+		fmt.Println(ui.Error("  ✗ ") + issue.String())
+	}
+	fmt.Println("")
+	printError(fmt.Sprintf("%d problem(s) found.", len(issues)))
+	return errors.New("invalid config")
+}
+
+func warnConfigIssues() {
+	issues, err := config.ValidateConfigFile(config.ConfigPath)
+	if err != nil || len(issues) == 0 {
+		return
+	}
+	for _, issue := range issues {
+		fmt.Println(ui.Warning("[config] " + issue.String()))
+	}
+	fmt.Println(ui.Muted("Run `mini-go config validate` after editing " + config.ConfigPath + "."))
+}
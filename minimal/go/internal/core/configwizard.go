@@ -0,0 +1,281 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"minimal-go/internal/config"
+	"minimal-go/internal/core/providers"
+	"minimal-go/internal/diff"
+	"minimal-go/internal/ui"
+)
+
+func (a *agent) GetConfig() config.Config {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.config
+}
+
+func (a *agent) ApplyConfig(cfg config.Config) error {
+	a.turnMu.Lock()
+	defer a.turnMu.Unlock()
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if cfg.LLM.CurrentProvider == a.config.LLM.CurrentProvider && a.config.LLM.CurrentModel != "" {
+		cfg.LLM.CurrentModel = a.config.LLM.CurrentModel
+	}
+	resolved, err := config.ResolveLlmConfig(cfg)
+	if err != nil {
+		return err
+	}
+	if cfg.Context.PruneToolResults {
+		cfg.Policy.AllowedPaths = append(append([]string{}, cfg.Policy.AllowedPaths...), config.OutputsDir)
+	}
+	cfg.Policy.Files = cfg.Policy.Files.ForWorkspace(a.workspaceRoot)
+	if resolved.Provider != a.llmConfig.Provider {
+		a.provider = providers.CreateProvider(resolved)
+	}
+	a.config = cfg
+	a.llmConfig = resolved
+	a.tools = agentTools(cfg)
+	return nil
+}
+
+func (s *replState) handleConfigCommand() error {
+	for {
+		cfg := s.agent.GetConfig()
+		llmConfig := s.agent.GetLlmConfig()
+		autoCommands := "(none)"
+		if len(cfg.Policy.AutoCommands) > 0 {
+			autoCommands = strings.Join(cfg.Policy.AutoCommands, ", ")
+		}
+
+		fmt.Println("")
+		fmt.Println(ui.Bold("Configuration") + ui.Muted(" ("+config.ConfigPath+")"))
+		fmt.Printf("  %s variant          %s\n", ui.Cyan("1."), llmConfig.Provider+ui.Muted(" ("+llmConfig.Model+")"))
+		fmt.Printf("  %s temperature      %g\n", ui.Cyan("2."), llmConfig.Temperature)
+		fmt.Printf("  %s max tokens       %d\n", ui.Cyan("3."), llmConfig.MaxTokens)
+		fmt.Printf("  %s default action   %s\n", ui.Cyan("4."), cfg.Policy.DefaultAction)
+		fmt.Printf("  %s auto-commands    %s\n", ui.Cyan("5."), autoCommands)
+		fmt.Println("")
+
+		choice, cancelled, err := readLine(s.reader, ui.Muted("Edit (number, enter to finish): "), s.sigCh, false)
+		if err != nil || cancelled {
+			return err
+		}
+		var edit func(root map[string]interface{}) error
+		switch strings.TrimSpace(choice) {
+		case "":
+			return nil
+		case "1":
+			edit, err = s.promptVariant(cfg)
+		case "2":
+			edit, err = s.promptVariantValue(llmConfig.Provider, "temperature", func(text string) (interface{}, error) {
+				value, err := strconv.ParseFloat(text, 64)
+				if err != nil || value < 0 || value > 2 {
+					return nil, errors.New("temperature must be a number between 0 and 2")
+				}
+				return value, nil
+			})
+		case "3":
+			edit, err = s.promptVariantValue(llmConfig.Provider, "max_tokens", func(text string) (interface{}, error) {
+				value, err := strconv.Atoi(text)
+				if err != nil || value <= 0 {
+					return nil, errors.New("max tokens must be a positive integer")
+				}
+				return value, nil
+			})
+		case "4":
+			edit = toggleDefaultAction(cfg.Policy.DefaultAction)
+		case "5":
+			edit, err = s.promptAutoCommand(cfg.Policy.AutoCommands)
+		default:
+			printError(fmt.Sprintf("No setting #%s", strings.TrimSpace(choice)))
+			continue
+		}
+		if err != nil {
+			printError(err.Error())
+			continue
+		}
+		if edit == nil {
+			continue
+		}
+		if err := s.commitConfigEdit(edit); err != nil {
+			printError(err.Error())
+		}
+	}
+}
+
+func (s *replState) promptVariant(cfg config.Config) (func(map[string]interface{}) error, error) {
+	names := make([]string, 0, len(cfg.LLM.Variants))
+	for name := range cfg.LLM.Variants {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for i, name := range names {
+		marker := "  "
+		if name == cfg.LLM.CurrentProvider {
+			marker = ui.Success("● ")
+		}
+		fmt.Printf("  %s%s %s%s\n", marker, ui.Cyan(fmt.Sprintf("%2d.", i+1)), name, ui.Muted(" ("+cfg.LLM.Variants[name].Model+")"))
+	}
+	answer, cancelled, err := readLine(s.reader, ui.Muted("Variant (number or name): "), s.sigCh, false)
+	if err != nil || cancelled {
+		return nil, err
+	}
+	name := strings.TrimSpace(answer)
+	if name == "" {
+		return nil, nil
+	}
+	if index, err := strconv.Atoi(name); err == nil {
+		if index < 1 || index > len(names) {
+			return nil, fmt.Errorf("no variant #%d", index)
+		}
+		name = names[index-1]
+	}
+	if _, ok := cfg.LLM.Variants[name]; !ok {
+		return nil, fmt.Errorf("unknown variant %q", name)
+	}
+	return func(root map[string]interface{}) error {
+		llm := configSection(root, "llm")
+		llm["current_provider"] = name
+		delete(llm, "current_model")
+		return nil
+	}, nil
+}
+
+func (s *replState) promptVariantValue(variant string, key string, parse func(string) (interface{}, error)) (func(map[string]interface{}) error, error) {
+	answer, cancelled, err := readLine(s.reader, ui.Muted(fmt.Sprintf("New %s: ", strings.ReplaceAll(key, "_", " "))), s.sigCh, false)
+	if err != nil || cancelled {
+		return nil, err
+	}
+	answer = strings.TrimSpace(answer)
+	if answer == "" {
+		return nil, nil
+	}
+	value, err := parse(answer)
+	if err != nil {
+		return nil, err
+	}
+	return func(root map[string]interface{}) error {
+		variants := configSection(configSection(root, "llm"), "variants")
+		entry, ok := variants[variant].(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("variant %q is not defined in config.json", variant)
+		}
+		if key == "max_tokens" {
+			if _, camel := entry["maxTokens"]; camel {
+				key = "maxTokens"
+			}
+		}
+		entry[key] = value
+		return nil
+	}, nil
+}
+
+func toggleDefaultAction(current string) func(map[string]interface{}) error {
+	next := "deny"
+	if current == "deny" {
+		next = "ask"
+	}
+	return func(root map[string]interface{}) error {
+		configSection(root, "policy")["defaultAction"] = next
+		return nil
+	}
+}
+
+func (s *replState) promptAutoCommand(current []string) (func(map[string]interface{}) error, error) {
+	answer, cancelled, err := readLine(s.reader, ui.Muted("Command prefix to add (or remove, if already listed): "), s.sigCh, false)
+	if err != nil || cancelled {
+		return nil, err
+	}
+	command := strings.Join(strings.Fields(answer), " ")
+	if command == "" {
+		return nil, nil
+	}
+	commands := []interface{}{}
+	removed := false
+	for _, existing := range current {
+		if existing == command {
+			removed = true
+			continue
+		}
+		commands = append(commands, existing)
+	}
+	if !removed {
+		commands = append(commands, command)
+	}
+	return func(root map[string]interface{}) error {
+		configSection(root, "policy")["autoCommands"] = commands
+		return nil
+	}, nil
+}
+
+func (s *replState) commitConfigEdit(edit func(root map[string]interface{}) error) error {
+	root, err := config.ReadConfigTree()
+	if err != nil {
+		return err
+	}
+	before, err := config.EncodeConfigTree(root)
+	if err != nil {
+		return err
+	}
+	if err := edit(root); err != nil {
+		return err
+	}
+	after, err := config.EncodeConfigTree(root)
+	if err != nil {
+		return err
+	}
+	if string(before) == string(after) {
+		fmt.Println(ui.Muted("No change."))
+		return nil
+	}
+	existing := map[string]bool{}
+	for _, issue := range config.ValidateConfig(before) {
+		existing[issue.String()] = true
+	}
+	for _, issue := range config.ValidateConfig(after) {
+		if !existing[issue.String()] {
+			return fmt.Errorf("change rejected: %s", issue.String())
+		}
+	}
+	updated, err := config.ParseConfig(after)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("")
+	printFileChange(os.Stdout, FileChange{Path: config.ConfigPath, Diff: diff.Unified("config.json", string(before), string(after))})
+	fmt.Println("")
+	answer, cancelled, err := readLine(s.reader, ui.Prompt("Write config.json? [y/N] "), s.sigCh, true)
+	if err != nil || cancelled {
+		return err
+	}
+	if strings.ToLower(strings.TrimSpace(answer)) != "y" {
+		fmt.Println(ui.Warning("✗ Discarded"))
+		return nil
+	}
+	if err := s.agent.ApplyConfig(updated); err != nil {
+		return err
+	}
+	if err := config.WriteConfigTree(root); err != nil {
+		return err
+	}
+	s.models = updated.Models
+	printSuccess("✓ Saved " + config.ConfigPath)
+	return nil
+}
+
+func configSection(root map[string]interface{}, key string) map[string]interface{} {
+	section, ok := root[key].(map[string]interface{})
+	if !ok {
+		section = map[string]interface{}{}
+		root[key] = section
+	}
+	return section
+}
@@ -0,0 +1,157 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"minimal-go/internal/types"
+)
+
+const (
+	charsPerToken          = 4
+	messageOverheadTokens  = 4
+	compactedToolResultMsg = "[tool output removed to fit the context window]"
+	imageTokenEstimate     = 1500
+)
+
+func estimateTextTokens(text string) int {
+	return (len(text) + charsPerToken - 1) / charsPerToken
+}
+
+func estimateMessageTokens(message types.Message) int {
+	tokens := messageOverheadTokens + estimateTextTokens(message.Content) + estimateTextTokens(message.Thinking)
+	tokens += len(message.Images) * imageTokenEstimate
+	for _, call := range message.ToolCalls {
+		input, _ := json.Marshal(call.Input)
+		tokens += estimateTextTokens(call.Name) + estimateTextTokens(string(input))
+	}
+	return tokens
+}
+
+func estimateRequestTokens(messages []types.Message, tools []types.Tool) int {
+	total := 0
+	for _, message := range messages {
+		total += estimateMessageTokens(message)
+	}
+	if len(tools) > 0 {
+		schema, _ := json.Marshal(tools)
+		total += estimateTextTokens(string(schema))
+	}
+	return total
+}
+
+type compactionResult struct {
+	Messages        []types.Message
+	ToolResultsCut  int
+	ExchangesPruned int
+	EstimatedTokens int
+}
+
+func compactMessages(messages []types.Message, tools []types.Tool, budget int) compactionResult {
+	compacted := append([]types.Message{}, messages...)
+	result := compactionResult{}
+	estimate := estimateRequestTokens(compacted, tools)
+
+	lastUser := lastUserIndex(compacted)
+	for i := range compacted {
+		if estimate <= budget {
+			break
+		}
+		if i >= lastUser || compacted[i].Role != types.RoleTool || compacted[i].Content == compactedToolResultMsg {
+			continue
+		}
+		before := estimateMessageTokens(compacted[i])
+		compacted[i].Content = compactedToolResultMsg
+		compacted[i].Images = nil
+		estimate -= before - estimateMessageTokens(compacted[i])
+		result.ToolResultsCut++
+	}
+
+	for estimate > budget {
+		start := firstNonSystemIndex(compacted)
+		end := nextUserIndex(compacted, start+1)
+		if start < 0 || end < 0 || end > lastUserIndex(compacted) {
+			break
+		}
+		compacted = append(compacted[:start], compacted[end:]...)
+		estimate = estimateRequestTokens(compacted, tools)
+		result.ExchangesPruned++
+	}
+
+	result.Messages = compacted
+	result.EstimatedTokens = estimate
+	return result
+}
+
+func firstNonSystemIndex(messages []types.Message) int {
+	for i, message := range messages {
+		if message.Role != types.RoleSystem {
+			return i
+		}
+	}
+	return -1
+}
+
+func nextUserIndex(messages []types.Message, from int) int {
+	for i := from; i < len(messages); i++ {
+		if messages[i].Role == types.RoleUser {
+			return i
+		}
+	}
+	return -1
+}
+
+func lastUserIndex(messages []types.Message) int {
+	for i := len(messages) - 1; i >= 0; i-- {
+		if messages[i].Role == types.RoleUser {
+			return i
+		}
+	}
+	return len(messages)
+}
+
+func contextOverflowError(estimate int, budget int, window int) error {
+	return fmt.Errorf("Request would exceed the context window (~%d tokens, budget %d of %d). Use /clear to start over.", estimate, budget, window)
+}
+
+// Phrases providers use when a request is longer than the model accepts.
+var overflowMarkers = []string{
+	"context_length_exceeded",
+	"context length",
+	"context window",
+	"maximum context",
+	"prompt is too long",
+	"input is too long",
+	"too many tokens",
+	"reduce the length",
+}
+
+func isContextOverflow(err error) bool {
+	msg := strings.ToLower(err.Error())
+	for _, marker := range overflowMarkers {
+		if strings.Contains(msg, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+// recoverFromOverflow shrinks the history after the provider rejected it as
+// too long. Our estimate evidently undercounts, so aim a quarter below it.
+func (a *agent) recoverFromOverflow(messages []types.Message, tools []types.Tool) bool {
+	estimate := estimateRequestTokens(messages, tools)
+	compacted := compactMessages(messages, tools, estimate*3/4)
+	a.debugLog("Context overflow recovery", map[string]interface{}{
+		"before":          estimate,
+		"after":           compacted.EstimatedTokens,
+		"toolResultsCut":  compacted.ToolResultsCut,
+		"exchangesPruned": compacted.ExchangesPruned,
+	})
+	if compacted.ToolResultsCut == 0 && compacted.ExchangesPruned == 0 {
+		return false
+	}
+	a.SetMessages(compacted.Messages)
+	a.notice(LevelWarning, fmt.Sprintf("[context] %s rejected the request as too long; compacted ~%d → ~%d tokens (%d tool results trimmed, %d exchanges dropped) and retrying", a.llmConfig.Model, estimate, compacted.EstimatedTokens, compacted.ToolResultsCut, compacted.ExchangesPruned))
+	return true
+}
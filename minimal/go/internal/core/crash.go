@@ -0,0 +1,122 @@
+package core
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"minimal-go/internal/config"
+	"minimal-go/internal/policy"
+	"minimal-go/internal/types"
+	"minimal-go/internal/ui"
+	"minimal-go/internal/vault"
+)
+
+// Results are recorded as each call finishes, so a call left without one was
+// running, or about to run, when the error hit; whether it had an effect is
+// unknown.
+const interruptedCallResult = "Unknown: mini-go hit an internal error while this batch ran, so it is not known whether this call ran or what it changed. Check its effects (git status, the files or state it touches) before proposing it again; do not repeat a non-idempotent call blindly."
+
+// guard runs fn, turning a panic into a crash report instead of ending the
+// session. It returns true when fn panicked.
+func (s *replState) guard(where string, fn func() error) (crashed bool, err error) {
+	defer func() {
+		if value := recover(); value != nil {
+			crashed, err = true, nil
+			s.survivePanic(where, value, debug.Stack())
+		}
+	}()
+	return false, fn()
+}
+
+// survivePanic saves what it can, tells the user where the details are and
+// asks whether to keep going. Declining exits the way a terminating signal
+// does, with the session already saved.
+func (s *replState) survivePanic(where string, value interface{}, stack []byte) {
+	policy.KillRunning()
+	restoreTerminal()
+	s.answerInterruptedCalls()
+	saved := s.saveAfterPanic()
+
+	report, err := writeCrashReport(where, value, stack, s.session.ID, s.agent.GetModel())
+	fmt.Println("")
+	printError(fmt.Sprintf("mini-go crashed in %s: %v", where, value))
+	if saved {
+		fmt.Println(ui.Muted("Session saved as " + s.session.ID + "."))
+	}
+	if err != nil {
+		fmt.Println(ui.Muted("Could not write a crash report: " + err.Error()))
+	} else {
+		fmt.Println(ui.Muted("Crash report: " + report + " (attach it when reporting the bug)"))
+	}
+
+	answer, cancelled, err := readLine(s.reader, ui.Prompt("Continue this session? [Y/n] "), s.sigCh, false)
+	if err == nil && !cancelled && !strings.HasPrefix(strings.ToLower(strings.TrimSpace(answer)), "n") {
+		return
+	}
+	s.agent.Shutdown()
+	os.Exit(1)
+}
+
+// answerInterruptedCalls gives every tool call of the last assistant message
+// a result, since providers reject a history with unanswered calls.
+func (s *replState) answerInterruptedCalls() {
+	messages := s.agent.GetMessages()
+	last := len(messages) - 1
+	for last >= 0 && messages[last].Role == types.RoleTool {
+		last--
+	}
+	if last < 0 || messages[last].Role != types.RoleAssistant || len(messages[last].ToolCalls) == 0 {
+		return
+	}
+	answered := map[string]bool{}
+	for _, message := range messages[last+1:] {
+		answered[message.ToolCallID] = true
+	}
+	added := false
+	for _, call := range messages[last].ToolCalls {
+		if !answered[call.ID] {
+			messages = append(messages, types.Message{Role: types.RoleTool, ToolCallID: call.ID, Content: interruptedCallResult})
+			added = true
+		}
+	}
+	if added {
+		s.agent.SetMessages(messages)
+	}
+}
+
+// saveAfterPanic saves the session unless saving panics too; the journal
+// still has the messages then.
+func (s *replState) saveAfterPanic() (saved bool) {
+	defer func() {
+		if recover() != nil {
+			saved = false
+		}
+	}()
+	s.saveSession()
+	return len(s.agent.GetMessages()) > 1
+}
+
+func writeCrashReport(where string, value interface{}, stack []byte, sessionID string, model string) (string, error) {
+	if err := os.MkdirAll(config.DebugDir, 0o700); err != nil {
+		return "", err
+	}
+	now := time.Now()
+	path := filepath.Join(config.DebugDir, fmt.Sprintf("crash-%s-%d.log", now.Format("20060102-150405"), os.Getpid()))
+	var b strings.Builder
+	fmt.Fprintf(&b, "time: %s\n", now.Format(time.RFC3339))
+	fmt.Fprintf(&b, "where: %s\n", where)
+	fmt.Fprintf(&b, "panic: %v\n", value)
+	fmt.Fprintf(&b, "session: %s\n", sessionID)
+	fmt.Fprintf(&b, "model: %s\n", model)
+	fmt.Fprintf(&b, "go: %s %s/%s\n", runtime.Version(), runtime.GOOS, runtime.GOARCH)
+	if info, ok := debug.ReadBuildInfo(); ok {
+		fmt.Fprintf(&b, "module: %s %s\n", info.Main.Path, info.Main.Version)
+	}
+	fmt.Fprintf(&b, "\n%s", stack)
+	return path, vault.WriteFile(path, []byte(b.String()), 0o600)
+}
@@ -0,0 +1,435 @@
+package core
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"minimal-go/internal/config"
+	"minimal-go/internal/session"
+	"minimal-go/internal/ui"
+)
+
+const defaultDaemonSession = "default"
+
+type daemonMessage struct {
+	Type      string `json:"type"`
+	Session   string `json:"session,omitempty"`
+	Workspace string `json:"workspace,omitempty"`
+	Text      string `json:"text,omitempty"`
+	Command   string `json:"command,omitempty"`
+}
+
+type daemonClient struct {
+	mu      sync.Mutex
+	conn    net.Conn
+	encoder *json.Encoder
+}
+
+func (c *daemonClient) send(message daemonMessage) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_ = c.encoder.Encode(message)
+}
+
+type daemonSession struct {
+	name     string
+	agent    Agent
+	record   *session.Session
+	turnMu   sync.Mutex
+	mu       sync.Mutex
+	clients  map[*daemonClient]bool
+	approval chan string
+	policy   config.ApprovalConfig
+	approver approverRecord
+}
+
+func (s *daemonSession) broadcast(message daemonMessage) {
+	s.mu.Lock()
+	clients := make([]*daemonClient, 0, len(s.clients))
+	for client := range s.clients {
+		clients = append(clients, client)
+	}
+	s.mu.Unlock()
+	for _, client := range clients {
+		client.send(message)
+	}
+}
+
+func (s *daemonSession) Write(p []byte) (int, error) {
+	s.broadcast(daemonMessage{Type: "output", Text: string(p)})
+	return len(p), nil
+}
+
+func (s *daemonSession) println(text string) {
+	fmt.Fprintln(s, text)
+}
+
+func (s *daemonSession) requestApproval(command string) (bool, error) {
+	return s.awaitApproval(daemonMessage{Type: "approval_request", Command: command}, nil)
+}
+
+func (s *daemonSession) requestFileChanges(command string, changes []FileChange) ([]FileApproval, error) {
+	var preview strings.Builder
+	for _, change := range changes {
+		printFileChange(&preview, change)
+	}
+	if command == "" {
+		command = "write " + changes[0].Path
+		for _, change := range changes[1:] {
+			command += " " + change.Path
+		}
+	}
+
+	answers := make([]FileApproval, len(changes))
+	approved, err := s.awaitApproval(daemonMessage{Type: "approval_request", Command: command, Text: preview.String()}, changes)
+	if approved {
+		fillApprovals(answers, FileApproved)
+	}
+	return answers, err
+}
+
+func (s *daemonSession) awaitApproval(request daemonMessage, changes []FileChange) (bool, error) {
+	s.mu.Lock()
+	if len(s.clients) == 0 {
+		s.mu.Unlock()
+		outcome, err := unattendedDecision(s.policy, s.policy.OnTimeout, request.Command, changes)
+		s.approver.set(outcome.Approver)
+		s.println(ui.Muted(fmt.Sprintf("[approval] no client attached; %s (%s)", approvalVerdict(outcome.Approved), outcome.Reason)))
+		return outcome.Approved, err
+	}
+	answers := make(chan string, 1)
+	s.approval = answers
+	s.mu.Unlock()
+
+	var deadline <-chan time.Time
+	if timeout := approvalTimeout(s.policy); timeout > 0 {
+		deadline = time.After(timeout)
+	}
+	s.broadcast(request)
+	var answer string
+	timedOut := false
+	select {
+	case answer = <-answers:
+		answer = strings.ToLower(strings.TrimSpace(answer))
+	case <-deadline:
+		timedOut = true
+	}
+
+	s.mu.Lock()
+	s.approval = nil
+	s.mu.Unlock()
+
+	if timedOut {
+		outcome, err := unattendedDecision(s.policy, s.policy.OnTimeout, request.Command, changes)
+		s.approver.set("timeout:" + outcome.Approver)
+		s.println(ui.Warning(fmt.Sprintf("⏱ No answer within %ds; %s (approval.onTimeout: %s, %s)", s.policy.Timeout, approvalVerdict(outcome.Approved), s.policy.OnTimeout, outcome.Reason)))
+		return outcome.Approved, err
+	}
+
+	s.approver.set(approverName())
+
+	if answer == "" || answer == "y" {
+		s.println(ui.Success("✓ Running..."))
+		return true, nil
+	}
+	s.println(ui.Warning("✗ Rejected"))
+	return false, nil
+}
+
+func (s *daemonSession) answerApproval(text string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.approval == nil {
+		return false
+	}
+	select {
+	case s.approval <- text:
+	default:
+	}
+	return true
+}
+
+func (s *daemonSession) detach(client *daemonClient) {
+	s.mu.Lock()
+	delete(s.clients, client)
+	remaining := len(s.clients)
+	s.mu.Unlock()
+	if remaining == 0 {
+		s.answerApproval("n")
+	}
+}
+
+func (s *daemonSession) runInput(text string) {
+	defer s.broadcast(daemonMessage{Type: "ready"})
+
+	switch text {
+	case "/clear", "/new":
+		s.agent.Clear()
+		s.record = session.New(s.name, s.agent.GetModel(), s.record.Workspace)
+		s.println(ui.Success("✓ Conversation cleared."))
+		return
+	}
+	if strings.HasPrefix(text, "/") {
+		s.println(ui.Error("Error: only /clear and /detach are available in attached sessions."))
+		return
+	}
+
+	s.agent.AddUserMessage(text)
+	if err := s.agent.RunAgentTurn(); err != nil {
+		s.println(ui.Error("Error: " + err.Error()))
+	}
+	s.record.Messages = s.agent.GetMessages()
+	s.record.Model = s.agent.GetModel()
+	if err := session.Save(s.record); err != nil {
+		s.println(ui.Muted("[session] save failed: " + err.Error()))
+	}
+}
+
+type daemonServer struct {
+	env      environment
+	debug    bool
+	mu       sync.Mutex
+	sessions map[string]*daemonSession
+}
+
+func RunDaemon(options MainOptions) error {
+	env, err := loadEnvironment()
+	if err != nil {
+		return err
+	}
+	if err := options.apply(&env); err != nil {
+		return err
+	}
+
+	if conn, err := net.Dial("unix", config.DaemonSocket); err == nil {
+		conn.Close()
+		printError("a daemon is already listening on " + config.DaemonSocket)
+		return errors.New("daemon already running")
+	}
+	_ = os.Remove(config.DaemonSocket)
+
+	listener, err := listenOwnerOnly(config.DaemonSocket)
+	if err != nil {
+		printError(err.Error())
+		return err
+	}
+	defer os.Remove(config.DaemonSocket)
+
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-sigCh
+		listener.Close()
+	}()
+
+	server := &daemonServer{env: env, debug: options.Debug, sessions: map[string]*daemonSession{}}
+	fmt.Println(ui.Bold("mini-go daemon") + ui.Muted(" listening on "+config.DaemonSocket))
+
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				fmt.Println(ui.Muted("daemon stopped"))
+				return nil
+			}
+			return err
+		}
+		if err := checkPeer(conn); err != nil {
+			fmt.Println(ui.Warning("rejected a connection: " + err.Error()))
+			conn.Close()
+			continue
+		}
+		go server.handle(conn)
+	}
+}
+
+func (d *daemonServer) session(name string, workspace string) (*daemonSession, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if existing, ok := d.sessions[name]; ok {
+		return existing, nil
+	}
+
+	if workspace == "" {
+		workspace = d.env.workspaceRoot
+	}
+	s := &daemonSession{name: name, clients: map[*daemonClient]bool{}, policy: d.env.config.Approval}
+	promptApproval, promptFileChanges := s.requestApproval, s.requestFileChanges
+	if s.policy.Strategy != config.ApprovalPrompt {
+		promptApproval, promptFileChanges = unattendedCallbacks(s.policy, s.policy.Strategy, "daemon", &s.approver)
+	}
+	created, err := CreateAgent(AgentOptions{
+		Config:        d.env.config,
+		SystemPrompt:  d.env.systemPrompt,
+		WorkspaceRoot: workspace,
+		Debug:         d.debug,
+		Output:        s,
+		Callbacks: AgentCallbacks{
+			PromptApproval:    promptApproval,
+			PromptFileChanges: promptFileChanges,
+			OnAutoApproved:    func(command string) { s.println(ui.Success("✓ " + command)) },
+			OnDenied:          func(command string) { s.println(ui.Bold(ui.Error("✗ Denied by policy: ")) + ui.Muted(command)) },
+			Approver:          s.approver.get,
+			OnDebugLog: func(label string, data interface{}) {
+				payload, _ := json.MarshalIndent(data, "", "  ")
+				s.println(ui.Debug("[DEBUG] " + label + "\n" + string(payload)))
+			},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.agent = created
+	s.record = session.New(name, created.GetModel(), workspace)
+	if stored, err := session.Load(name); err == nil && stored.Name == name {
+		s.record = stored
+		created.SetMessages(stored.Messages)
+	}
+
+	d.sessions[name] = s
+	return s, nil
+}
+
+func (d *daemonServer) handle(conn net.Conn) {
+	defer conn.Close()
+	client := &daemonClient{conn: conn, encoder: json.NewEncoder(conn)}
+	decoder := json.NewDecoder(conn)
+
+	var hello daemonMessage
+	if err := decoder.Decode(&hello); err != nil || hello.Type != "attach" {
+		client.send(daemonMessage{Type: "error", Text: "expected attach message"})
+		return
+	}
+	name := hello.Session
+	if name == "" {
+		name = defaultDaemonSession
+	}
+
+	s, err := d.session(name, hello.Workspace)
+	if err != nil {
+		client.send(daemonMessage{Type: "error", Text: err.Error()})
+		return
+	}
+	s.mu.Lock()
+	s.clients[client] = true
+	viewers := len(s.clients)
+	s.mu.Unlock()
+	defer s.detach(client)
+
+	client.send(daemonMessage{Type: "output", Text: ui.Bold("Attached to "+name) + ui.Muted(fmt.Sprintf(" (%s, %d messages, %d client(s))\n", s.agent.GetModel(), len(s.agent.GetMessages()), viewers))})
+	client.send(daemonMessage{Type: "ready"})
+
+	for {
+		var message daemonMessage
+		if err := decoder.Decode(&message); err != nil {
+			s.turnMu.Lock()
+			s.turnMu.Unlock()
+			return
+		}
+		if message.Type != "input" {
+			continue
+		}
+		if s.answerApproval(message.Text) {
+			continue
+		}
+		text := strings.TrimSpace(message.Text)
+		if text == "" {
+			client.send(daemonMessage{Type: "ready"})
+			continue
+		}
+		if !s.turnMu.TryLock() {
+			client.send(daemonMessage{Type: "output", Text: ui.Warning("A turn is already running in this session.\n")})
+			continue
+		}
+		go func() {
+			defer s.turnMu.Unlock()
+			s.println(ui.Prompt("> ") + text)
+			s.runInput(text)
+		}()
+	}
+}
+
+func Attach(args []string) error {
+	name := defaultDaemonSession
+	if len(args) > 0 {
+		name = args[0]
+	}
+
+	conn, err := net.DialTimeout("unix", config.DaemonSocket, 2*time.Second)
+	if err != nil {
+		printError("no daemon running on " + config.DaemonSocket + ". Start one with: mini-go daemon")
+		return err
+	}
+	defer conn.Close()
+
+	workspace, err := resolveWorkspaceRoot()
+	if err != nil {
+		return err
+	}
+	encoder := json.NewEncoder(conn)
+	if err := encoder.Encode(daemonMessage{Type: "attach", Session: name, Workspace: workspace}); err != nil {
+		return err
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		decoder := json.NewDecoder(conn)
+		for {
+			var message daemonMessage
+			if err := decoder.Decode(&message); err != nil {
+				if errors.Is(err, io.EOF) || errors.Is(err, net.ErrClosed) {
+					err = nil
+				}
+				done <- err
+				return
+			}
+			switch message.Type {
+			case "output":
+				fmt.Print(message.Text)
+			case "ready":
+				fmt.Print(ui.Prompt("> "))
+			case "approval_request":
+				fmt.Println("")
+				fmt.Println(ui.Warning("Command:"))
+				fmt.Println(ui.Bold("  " + message.Command))
+				if message.Text != "" {
+					fmt.Print(message.Text)
+				}
+				fmt.Println(ui.Muted("  [enter/y] Run   [n] Reject"))
+				fmt.Print(ui.Prompt("> "))
+			case "error":
+				printError(message.Text)
+			}
+		}
+	}()
+
+	go func() {
+		scanner := bufio.NewScanner(os.Stdin)
+		for scanner.Scan() {
+			line := scanner.Text()
+			if strings.TrimSpace(line) == "/detach" || strings.TrimSpace(line) == "/exit" {
+				break
+			}
+			if err := encoder.Encode(daemonMessage{Type: "input", Text: line}); err != nil {
+				break
+			}
+		}
+		if unixConn, ok := conn.(*net.UnixConn); ok {
+			_ = unixConn.CloseWrite()
+			return
+		}
+		conn.Close()
+	}()
+
+	return <-done
+}
@@ -0,0 +1,34 @@
+package core
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+// From <sys/un.h> and <sys/ucred.h>; the syscall package has no wrapper for
+// LOCAL_PEERCRED.
+const (
+	solLocal      = 0
+	localPeerCred = 0x001
+	xucredVersion = 0
+)
+
+type xucred struct {
+	Version uint32
+	UID     uint32
+	Ngroups int16
+	Groups  [16]uint32
+}
+
+func socketPeerUID(fd uintptr) (int, error) {
+	var cred xucred
+	size := uint32(unsafe.Sizeof(cred))
+	_, _, errno := syscall.Syscall6(syscall.SYS_GETSOCKOPT, fd, solLocal, localPeerCred, uintptr(unsafe.Pointer(&cred)), uintptr(unsafe.Pointer(&size)), 0)
+	if errno != 0 {
+		return 0, errno
+	}
+	if cred.Version != xucredVersion {
+		return 0, syscall.EINVAL
+	}
+	return int(cred.UID), nil
+}
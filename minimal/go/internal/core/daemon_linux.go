@@ -0,0 +1,11 @@
+package core
+
+import "syscall"
+
+func socketPeerUID(fd uintptr) (int, error) {
+	cred, err := syscall.GetsockoptUcred(int(fd), syscall.SOL_SOCKET, syscall.SO_PEERCRED)
+	if err != nil {
+		return 0, err
+	}
+	return int(cred.Uid), nil
+}
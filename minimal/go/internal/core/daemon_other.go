@@ -0,0 +1,19 @@
+//go:build !linux && !darwin
+
+package core
+
+import (
+	"errors"
+	"net"
+	"runtime"
+)
+
+// Without a way to read the peer's credentials any local user could drive
+// the daemon, so it does not start.
+func listenOwnerOnly(path string) (net.Listener, error) {
+	return nil, errors.New("mini-go daemon cannot verify who connects on " + runtime.GOOS + "; it runs on Linux and macOS only")
+}
+
+func checkPeer(conn net.Conn) error {
+	return errors.New("peer credentials are not available on " + runtime.GOOS)
+}
@@ -0,0 +1,50 @@
+//go:build linux || darwin
+
+package core
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"syscall"
+)
+
+// listenOwnerOnly creates the socket owner-only rather than chmodding it
+// afterwards, which would leave a window where anyone could connect.
+func listenOwnerOnly(path string) (net.Listener, error) {
+	umask := syscall.Umask(0o077)
+	defer syscall.Umask(umask)
+	return net.Listen("unix", path)
+}
+
+// peerUID returns the user id of the process at the other end of conn.
+func peerUID(conn net.Conn) (int, error) {
+	unix, ok := conn.(*net.UnixConn)
+	if !ok {
+		return 0, errors.New("not a unix socket")
+	}
+	raw, err := unix.SyscallConn()
+	if err != nil {
+		return 0, err
+	}
+	var uid int
+	var uidErr error
+	if err := raw.Control(func(fd uintptr) { uid, uidErr = socketPeerUID(fd) }); err != nil {
+		return 0, err
+	}
+	return uid, uidErr
+}
+
+// checkPeer accepts only connections from processes running as the daemon's
+// own user, whatever the socket's permissions end up being.
+func checkPeer(conn net.Conn) error {
+	uid, err := peerUID(conn)
+	if err != nil {
+		return err
+	}
+	if uid != os.Getuid() {
+		return fmt.Errorf("peer uid %d is not %d", uid, os.Getuid())
+	}
+	return nil
+}
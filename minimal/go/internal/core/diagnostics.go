@@ -0,0 +1,223 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"minimal-go/internal/policy"
+	"minimal-go/internal/shell"
+	"minimal-go/internal/types"
+)
+
+const (
+	maxDiagnostics      = 50
+	maxDiagnosticOutput = 2000
+)
+
+var (
+	diagnosticPattern  = regexp.MustCompile(`^(?:vet: )?(\.?[^\s:][^:]*):(\d+)(?::(\d+))?(?::\d+-\d+)?:?\s*(.*)$`)
+	diagnosticLanguage = map[string]string{
+		".go":  "go",
+		".py":  "python",
+		".ts":  "typescript",
+		".tsx": "typescript",
+		".js":  "javascript",
+		".mjs": "javascript",
+		".rs":  "rust",
+	}
+)
+
+type diagnostic struct {
+	File    string `json:"file"`
+	Line    int    `json:"line"`
+	Column  int    `json:"column,omitempty"`
+	Message string `json:"message"`
+}
+
+func defaultDiagnosticCommand(language string, workspaceRoot string) string {
+	switch language {
+	case "go":
+		if _, err := exec.LookPath("gopls"); err == nil {
+			return "gopls check {files}"
+		}
+		return "go vet {packages}"
+	case "python":
+		if _, err := exec.LookPath("ruff"); err == nil {
+			return "ruff check --output-format=concise {files}"
+		}
+		return "python3 -m py_compile {files}"
+	case "typescript":
+		if _, err := os.Stat(filepath.Join(workspaceRoot, "tsconfig.json")); err == nil {
+			return "npx --no-install tsc --noEmit --pretty false"
+		}
+	case "javascript":
+		return "node --check {files}"
+	case "rust":
+		return "cargo check --quiet --message-format short"
+	}
+	return ""
+}
+
+func (a *agent) trackEdited(path string) {
+	a.batchEdits = append(a.batchEdits, batchEdit{path: path, callID: a.activeCall})
+	a.updateIndex(path)
+	if a.turnFiles != nil {
+		a.turnFiles[path] = true
+	}
+	for _, existing := range a.editedFiles {
+		if existing == path {
+			return
+		}
+	}
+	a.editedFiles = append(a.editedFiles, path)
+}
+
+func (a *agent) handleDiagnostics(input interface{}, callID string) types.Message {
+	var files []string
+	if raw, ok := toolArgs(input)["files"].([]interface{}); ok {
+		for _, value := range raw {
+			if file, ok := value.(string); ok && file != "" {
+				files = append(files, file)
+			}
+		}
+	}
+	if len(files) == 0 {
+		files = a.editedFiles
+	}
+	if len(files) == 0 {
+		return toolError(callID, "No files to check: pass files explicitly or edit files with write_file/edit_file first.")
+	}
+
+	byLanguage := map[string][]string{}
+	var skipped []string
+	for _, file := range files {
+		fullPath, err := a.resolvePath(file)
+		if err != nil {
+			return a.pathDenied(callID, file, err)
+		}
+		language := diagnosticLanguage[strings.ToLower(filepath.Ext(file))]
+		if language == "" {
+			skipped = append(skipped, file)
+			continue
+		}
+		byLanguage[language] = append(byLanguage[language], a.relativeToWorkspace(fullPath))
+	}
+
+	languages := make([]string, 0, len(byLanguage))
+	for language := range byLanguage {
+		languages = append(languages, language)
+	}
+	sort.Strings(languages)
+
+	var runs []map[string]interface{}
+	total := 0
+	for _, language := range languages {
+		template := a.config.Diagnostics[language]
+		if template == "" {
+			template = defaultDiagnosticCommand(language, a.workspaceRoot)
+		}
+		if template == "" {
+			skipped = append(skipped, byLanguage[language]...)
+			continue
+		}
+
+		command := expandDiagnosticCommand(template, byLanguage[language])
+		a.notice(LevelInfo, "[diagnostics] "+command)
+		result := policy.RunBashWithOptions(command, policy.BashOptions{Dir: a.workspaceRoot, Scrub: true})
+		found, unparsed := parseDiagnostics(result.Stdout + "\n" + result.Stderr)
+		total += len(found)
+
+		run := map[string]interface{}{
+			"language":    language,
+			"command":     command,
+			"exitCode":    result.Code,
+			"diagnostics": found,
+		}
+		if len(found) > maxDiagnostics {
+			run["diagnostics"] = found[:maxDiagnostics]
+			run["truncated"] = len(found) - maxDiagnostics
+		}
+		if result.Code != 0 && len(found) == 0 && unparsed != "" {
+			run["output"] = tailText(unparsed, maxDiagnosticOutput)
+		}
+		runs = append(runs, run)
+	}
+	a.editedFiles = nil
+
+	status := "clean"
+	for _, run := range runs {
+		if run["exitCode"].(int) != 0 {
+			status = "issues"
+		}
+	}
+	if status == "clean" {
+		a.notice(LevelSuccess, fmt.Sprintf("✓ diagnostics clean (%d files)", len(files)-len(skipped)))
+	} else {
+		a.notice(LevelWarning, fmt.Sprintf("[diagnostics] %d issue(s)", total))
+	}
+
+	fields := map[string]interface{}{"status": status, "runs": runs}
+	if len(skipped) > 0 {
+		fields["skipped"] = skipped
+	}
+	payload, _ := json.MarshalIndent(fields, "", "  ")
+	return types.Message{Role: types.RoleTool, ToolCallID: callID, Content: string(payload)}
+}
+
+func expandDiagnosticCommand(template string, files []string) string {
+	quoted := make([]string, 0, len(files))
+	packageSet := map[string]bool{}
+	var packages []string
+	for _, file := range files {
+		quoted = append(quoted, shell.Quote(file))
+		pkg := "./" + filepath.ToSlash(filepath.Dir(file))
+		if pkg == "./." {
+			pkg = "."
+		}
+		if !packageSet[pkg] {
+			packageSet[pkg] = true
+			packages = append(packages, shell.Quote(pkg))
+		}
+	}
+	command := strings.ReplaceAll(template, "{files}", strings.Join(quoted, " "))
+	return strings.ReplaceAll(command, "{packages}", strings.Join(packages, " "))
+}
+
+func parseDiagnostics(output string) ([]diagnostic, string) {
+	found := []diagnostic{}
+	var unparsed []string
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimRight(line, "\r")
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(line, "# ") {
+			continue
+		}
+		match := diagnosticPattern.FindStringSubmatch(line)
+		if match == nil {
+			unparsed = append(unparsed, line)
+			continue
+		}
+		lineNumber, _ := strconv.Atoi(match[2])
+		column, _ := strconv.Atoi(match[3])
+		found = append(found, diagnostic{
+			File:    strings.TrimPrefix(match[1], "./"),
+			Line:    lineNumber,
+			Column:  column,
+			Message: strings.TrimSpace(match[4]),
+		})
+	}
+	return found, strings.Join(unparsed, "\n")
+}
+
+func tailText(text string, limit int) string {
+	if len(text) <= limit {
+		return text
+	}
+	return "..." + text[len(text)-limit:]
+}
@@ -0,0 +1,232 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+	"time"
+
+	"minimal-go/internal/config"
+	"minimal-go/internal/core/providers"
+	"minimal-go/internal/ui"
+)
+
+const doctorDialTimeout = 5 * time.Second
+
+type doctorStatus int
+
+const (
+	doctorOK doctorStatus = iota
+	doctorWarn
+	doctorFail
+)
+
+type doctorFinding struct {
+	status doctorStatus
+	check  string
+	detail string
+	fix    string
+}
+
+func Doctor() error {
+	var findings []doctorFinding
+	add := func(status doctorStatus, check string, detail string, fix string) {
+		findings = append(findings, doctorFinding{status, check, detail, fix})
+	}
+
+	if err := config.EnsureMinimalDir(); err != nil {
+		add(doctorFail, "~/.minimal", "directory not found", "mkdir -p ~/.minimal/skills")
+	} else {
+		add(doctorOK, "~/.minimal", config.MinimalDir, "")
+	}
+
+	if _, err := config.LoadSystemPrompt(); err != nil {
+		add(doctorFail, "system.md", err.Error(), "echo \"You are a helpful coding assistant.\" > ~/.minimal/system.md")
+	} else {
+		add(doctorOK, "system.md", config.SystemMDPath, "")
+	}
+
+	cfg, err := config.LoadConfig()
+	switch {
+	case err != nil:
+		add(doctorFail, "config.json", err.Error(), "fix the JSON in "+config.ConfigPath)
+	case fileExists(config.ConfigPath):
+		issues, _ := config.ValidateConfigFile(config.ConfigPath)
+		for _, issue := range issues {
+			add(doctorWarn, "config.json", issue.String(), "run mini-go config validate for the full report")
+		}
+		add(doctorOK, "config.json", fmt.Sprintf("%d variant(s), current: %s", len(cfg.LLM.Variants), cfg.LLM.CurrentProvider), "")
+	default:
+		add(doctorWarn, "config.json", "not found, using built-in groq defaults", "create "+config.ConfigPath+" to configure providers")
+	}
+
+	if err == nil {
+		if _, ok := cfg.LLM.Variants[cfg.LLM.CurrentProvider]; !ok {
+			add(doctorFail, "current provider", "unknown provider: "+cfg.LLM.CurrentProvider, "set llm.current_provider to one of the configured variants")
+		}
+
+		names := make([]string, 0, len(cfg.LLM.Variants))
+		for name := range cfg.LLM.Variants {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			findings = append(findings, checkVariant(cfg, name)...)
+		}
+	}
+
+	for _, tool := range []string{"bash", "git"} {
+		if path, err := exec.LookPath(tool); err != nil {
+			status := doctorFail
+			if tool == "git" {
+				status = doctorWarn
+			}
+			add(status, tool, "not found in PATH", "install "+tool)
+		} else {
+			add(doctorOK, tool, path, "")
+		}
+	}
+
+	failures := 0
+	fmt.Println(ui.Bold("mini-go doctor"))
+	fmt.Println("")
+	for _, finding := range findings {
+		switch finding.status {
+		case doctorOK:
+			fmt.Println(ui.Success("✓ ") + finding.check + ui.Muted("  "+finding.detail))
+		case doctorWarn:
+			fmt.Println(ui.Warning("! ") + finding.check + ui.Muted("  "+finding.detail))
+		case doctorFail:
+			failures++
+			fmt.Println(ui.Error("✗ ") + finding.check + ui.Muted("  "+finding.detail))
+		}
+		if finding.fix != "" && finding.status != doctorOK {
+			fmt.Println(ui.Muted("    fix: " + finding.fix))
+		}
+	}
+
+	fmt.Println("")
+	if failures > 0 {
+		fmt.Println(ui.Error(fmt.Sprintf("%d problem(s) found.", failures)))
+		return errors.New("doctor found problems")
+	}
+	printSuccess("Everything looks good.")
+	return nil
+}
+
+func checkVariant(cfg config.Config, name string) []doctorFinding {
+	label := "variant " + name
+	variantCfg := cfg
+	variantCfg.LLM.CurrentProvider = name
+	if name != cfg.LLM.CurrentProvider {
+		variantCfg.LLM.CurrentModel = ""
+	}
+
+	resolved, err := config.ResolveLlmConfig(variantCfg)
+	if err != nil {
+		return []doctorFinding{{doctorFail, label, err.Error(), "add the missing field to llm.variants." + name}}
+	}
+
+	var findings []doctorFinding
+	if resolved.SchemaType == config.SchemaMock {
+		if !fileExists(resolved.Fixture) {
+			findings = append(findings, doctorFinding{doctorFail, label, "fixture not found: " + resolved.Fixture, "set llm.variants." + name + ".fixture to a JSONL file"})
+		} else {
+			findings = append(findings, doctorFinding{doctorOK, label, "mock fixture " + resolved.Fixture, ""})
+		}
+		return findings
+	}
+
+	variant := cfg.LLM.Variants[name]
+	if variant.APIKey == "" && variant.APIKeyEnv == "" {
+		variant.APIKeyEnv = config.DefaultAPIKeyEnv(name)
+	}
+	switch {
+	case resolved.SchemaType == config.SchemaVertex:
+		if source, err := providers.DescribeGoogleCredentials(); err != nil {
+			findings = append(findings, doctorFinding{doctorFail, label + " credentials", err.Error(), "gcloud auth application-default login"})
+		} else {
+			findings = append(findings, doctorFinding{doctorOK, label + " credentials", source, ""})
+		}
+	case resolved.APIKey != "":
+		findings = append(findings, doctorFinding{doctorOK, label + " api key", keySource(variant), ""})
+	case variant.APIKeyEnv != "":
+		findings = append(findings, doctorFinding{doctorFail, label + " api key", "$" + variant.APIKeyEnv + " is not set", "export " + variant.APIKeyEnv + "=... (or add it to .env)"})
+	default:
+		findings = append(findings, doctorFinding{doctorWarn, label + " api key", "no api_key or api_key_env configured", "set llm.variants." + name + ".api_key_env"})
+	}
+
+	if resolved.Insecure {
+		findings = append(findings, doctorFinding{doctorWarn, label + " tls", "certificate verification is disabled", "set llm.variants." + name + ".ca_bundle to your proxy's CA instead of insecure_skip_verify"})
+	}
+
+	if detail, err := pingEndpoint(resolved.BaseURL, resolved.Proxy); err != nil {
+		findings = append(findings, doctorFinding{doctorFail, label + " endpoint", err.Error(), "check base_url and your network/proxy settings"})
+	} else {
+		findings = append(findings, doctorFinding{doctorOK, label + " endpoint", detail, ""})
+	}
+	return findings
+}
+
+func keySource(variant config.LlmVariant) string {
+	if variant.APIKey != "" {
+		return "set inline in config.json"
+	}
+	return "from $" + variant.APIKeyEnv
+}
+
+func pingEndpoint(baseURL string, proxy string) (string, error) {
+	parsed, err := url.Parse(baseURL)
+	if err != nil || parsed.Host == "" {
+		return "", fmt.Errorf("invalid base_url: %s", baseURL)
+	}
+	target := parsed
+	via := ""
+	if proxyURL := config.ProxyURL(proxy); proxyURL != nil {
+		target = proxyURL
+	} else if proxyURL, err := http.ProxyFromEnvironment(&http.Request{URL: parsed}); err == nil && proxyURL != nil {
+		target = proxyURL
+	}
+	if target != parsed {
+		via = " via proxy " + target.Host
+	}
+
+	host := target.Host
+	if target.Port() == "" {
+		if target.Scheme == "http" {
+			host = net.JoinHostPort(target.Hostname(), "80")
+		} else {
+			host = net.JoinHostPort(target.Hostname(), "443")
+		}
+	}
+
+	started := time.Now()
+	conn, err := net.DialTimeout("tcp", host, doctorDialTimeout)
+	if err != nil {
+		return "", fmt.Errorf("cannot reach %s%s: %v", target.Host, via, trimDialError(err))
+	}
+	conn.Close()
+	return fmt.Sprintf("%s reachable%s in %dms", parsed.Host, via, time.Since(started).Milliseconds()), nil
+}
+
+func trimDialError(err error) string {
+	msg := err.Error()
+	if index := strings.LastIndex(msg, ": "); index >= 0 {
+		return msg[index+2:]
+	}
+	return msg
+}
+
+func fileExists(path string) bool {
+	if path == "" {
+		return false
+	}
+	_, err := os.Stat(path)
+	return err == nil
+}
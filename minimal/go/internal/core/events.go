@@ -0,0 +1,130 @@
+package core
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"minimal-go/internal/policy"
+	"minimal-go/internal/types"
+	"minimal-go/internal/ui"
+)
+
+type EventKind string
+
+const (
+	EventTurnStarted     EventKind = "turn_started"
+	EventRequestStarted  EventKind = "request_started"
+	EventRequestFinished EventKind = "request_finished"
+	EventUsage           EventKind = "usage"
+	EventThinking        EventKind = "thinking"
+	EventAssistant       EventKind = "assistant"
+	EventCommandOutput   EventKind = "command_output"
+	EventNotice          EventKind = "notice"
+	EventToolCall        EventKind = "tool_call"
+	EventApproval        EventKind = "approval_required"
+	EventToolResult      EventKind = "tool_result"
+	EventTurnCompleted   EventKind = "turn_completed"
+	EventError           EventKind = "error"
+)
+
+type EventLevel string
+
+const (
+	LevelInfo    EventLevel = "info"
+	LevelSuccess EventLevel = "success"
+	LevelWarning EventLevel = "warning"
+	LevelError   EventLevel = "error"
+)
+
+type Event struct {
+	Kind      EventKind
+	Level     EventLevel
+	Text      string
+	Turn      int
+	Usage     *types.Usage
+	Session   TokenUsage
+	Stderr    bool
+	Collapsed bool
+	ToolCall  *types.ToolCall
+	CallID    string
+	Changes   []FileChange
+	OutputID  int
+	// Risk labels the command of an EventApproval.
+	Risk policy.Assessment
+}
+
+func (a *agent) emit(event Event) {
+	if a.onEvent != nil {
+		a.onEvent(event)
+	}
+}
+
+func (a *agent) notice(level EventLevel, text string) {
+	a.emit(Event{Kind: EventNotice, Level: level, Text: text})
+}
+
+func RenderEvents(out io.Writer) func(Event) {
+	var spinner *ui.Spinner
+	var frame *commandFrame
+	return func(event Event) {
+		switch event.Kind {
+		case EventTurnStarted:
+			fmt.Fprintln(out, ui.Muted(fmt.Sprintf("\n─── turn %d ───\n", event.Turn)))
+		case EventRequestStarted:
+			if out == os.Stdout {
+				spinner = ui.StartSpinner(event.Text)
+			}
+		case EventRequestFinished:
+			if spinner != nil {
+				spinner.Stop()
+				spinner = nil
+			}
+		case EventUsage:
+			fmt.Fprintln(out, ui.Muted(fmt.Sprintf("[tokens] in:%d out:%d | session:%d", event.Usage.PromptTokens, event.Usage.CompletionTokens, event.Session.Total)))
+		case EventThinking:
+			if event.Collapsed {
+				fmt.Fprintln(out, ui.Thinking(fmt.Sprintf("▸ thought for %d tokens (/thinking to expand)", estimateTextTokens(event.Text))))
+				return
+			}
+			printThinkingBlock(out, event.Text)
+		case EventAssistant:
+			fmt.Fprintln(out, event.Text)
+		case EventToolCall:
+			frame = nil
+			if command, ok := bashCommand(event.ToolCall); ok {
+				frame = &commandFrame{command: command}
+			}
+		case EventCommandOutput:
+			if frame != nil {
+				frame.add(event)
+				return
+			}
+			text := strings.TrimRight(event.Text, "\n")
+			if event.Level == LevelError {
+				text = ui.Error(text)
+			}
+			fmt.Fprintln(out, text)
+		case EventToolResult:
+			if frame != nil {
+				frame.finish(out, event.Text)
+				frame = nil
+			}
+		case EventNotice:
+			fmt.Fprintln(out, styleLevel(event.Level, event.Text))
+		}
+	}
+}
+
+func styleLevel(level EventLevel, text string) string {
+	switch level {
+	case LevelSuccess:
+		return ui.Success(text)
+	case LevelWarning:
+		return ui.Warning(text)
+	case LevelError:
+		return ui.Error(text)
+	}
+	return ui.Muted(text)
+}
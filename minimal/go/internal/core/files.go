@@ -0,0 +1,283 @@
+package core
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"minimal-go/internal/diff"
+	"minimal-go/internal/policy"
+	"minimal-go/internal/types"
+	"minimal-go/internal/ui"
+)
+
+const maxDiffPreviewLines = 200
+
+type FileChange struct {
+	Path      string
+	Diff      string
+	Created   bool
+	Unknown   bool
+	Untracked bool
+}
+
+type FileApproval int
+
+const (
+	FileRejected FileApproval = iota
+	FileApproved
+	FileApprovedAlways
+)
+
+func newFileChange(path string, before string, after string, created bool) FileChange {
+	return FileChange{Path: path, Diff: diff.Unified(path, before, after), Created: created}
+}
+
+func (a *agent) handleWriteFile(input interface{}, callID string) types.Message {
+	path := extractStringArg(input, "path")
+	content, ok := toolArgs(input)["content"].(string)
+	if path == "" || !ok {
+		return toolError(callID, "write_file requires path and content.")
+	}
+
+	fullPath, err := a.resolvePath(path)
+	if err != nil {
+		return a.pathDenied(callID, path, err)
+	}
+	if decision, denied := a.fileDenied(fullPath); denied {
+		return *a.commandDenial("write_file "+path, decision, callID)
+	}
+	before, err := os.ReadFile(fullPath)
+	created := os.IsNotExist(err)
+	if err != nil && !created {
+		return toolError(callID, fmt.Sprintf("Cannot read %s: %v", path, err))
+	}
+	if !created && string(before) == content {
+		return toolError(callID, "No changes: "+path+" already has this content.")
+	}
+
+	return a.applyFileChange(path, fullPath, string(before), content, created, callID)
+}
+
+func (a *agent) handleEditFile(input interface{}, callID string) types.Message {
+	args := toolArgs(input)
+	path := extractStringArg(input, "path")
+	oldString, _ := args["old_string"].(string)
+	newString, _ := args["new_string"].(string)
+	replaceAll, _ := args["replace_all"].(bool)
+	if path == "" || oldString == "" {
+		return toolError(callID, "edit_file requires path and a non-empty old_string.")
+	}
+
+	fullPath, err := a.resolvePath(path)
+	if err != nil {
+		return a.pathDenied(callID, path, err)
+	}
+	if decision, denied := a.fileDenied(fullPath); denied {
+		return *a.commandDenial("edit_file "+path, decision, callID)
+	}
+	data, err := os.ReadFile(fullPath)
+	if err != nil {
+		return toolError(callID, fmt.Sprintf("Cannot read %s: %v", path, err))
+	}
+	before := string(data)
+	count := strings.Count(before, oldString)
+	switch {
+	case count == 0:
+		return toolError(callID, "old_string was not found in "+path+". Read the file again and copy the snippet exactly.")
+	case count > 1 && !replaceAll:
+		return toolError(callID, fmt.Sprintf("old_string matches %d times in %s. Include more surrounding context or set replace_all.", count, path))
+	}
+
+	after := strings.Replace(before, oldString, newString, 1)
+	if replaceAll {
+		after = strings.ReplaceAll(before, oldString, newString)
+	}
+	if after == before {
+		return toolError(callID, "No changes: old_string and new_string are identical.")
+	}
+
+	return a.applyFileChange(path, fullPath, before, after, false, callID)
+}
+
+func (a *agent) applyFileChange(path string, fullPath string, before string, after string, created bool, callID string) types.Message {
+	change := newFileChange(path, before, after, created)
+	change.Untracked = !created && a.isUntracked(fullPath)
+	approvals, err := a.confirmFileChanges("", []FileChange{change})
+	if err != nil || !approvals[0] {
+		a.audit("write", path, "rejected", "")
+		a.callRejected = true
+		return toolError(callID, "User rejected change to "+path+".")
+	}
+
+	mode := os.FileMode(0o644)
+	if info, err := os.Stat(fullPath); err == nil {
+		mode = info.Mode().Perm()
+	}
+	if err := os.MkdirAll(filepath.Dir(fullPath), 0o755); err != nil {
+		return toolError(callID, fmt.Sprintf("Cannot create directory for %s: %v", path, err))
+	}
+	if err := os.WriteFile(fullPath, []byte(after), mode); err != nil {
+		return toolError(callID, fmt.Sprintf("Cannot write %s: %v", path, err))
+	}
+
+	a.audit("write", path, "approved", "")
+	a.trackEdited(path)
+	a.loops.invalidate()
+	added, removed := diff.Stats(change.Diff)
+	a.notice(LevelSuccess, fmt.Sprintf("✓ Wrote %s (+%d -%d)", path, added, removed))
+	payload, _ := json.MarshalIndent(map[string]interface{}{
+		"status":  "written",
+		"path":    path,
+		"created": created,
+		"added":   added,
+		"removed": removed,
+	}, "", "  ")
+	return types.Message{Role: types.RoleTool, ToolCallID: callID, Content: string(payload)}
+}
+
+func (a *agent) confirmFileChanges(command string, changes []FileChange) ([]bool, error) {
+	approved := make([]bool, len(changes))
+	var pending []FileChange
+	var pendingIndex []int
+	for i, change := range changes {
+		if command == "" && (a.config.Auto && !change.Untracked || a.alwaysWritable[change.Path] && !change.Untracked) {
+			approved[i] = true
+			if a.callbacks.OnAutoApproved != nil {
+				a.callbacks.OnAutoApproved("write " + change.Path)
+			}
+			a.recordAutoRun("write " + change.Path)
+			continue
+		}
+		pending = append(pending, change)
+		pendingIndex = append(pendingIndex, i)
+	}
+	if len(pending) == 0 {
+		return approved, nil
+	}
+	a.emit(Event{Kind: EventApproval, CallID: a.activeCall, Text: command, Changes: pending})
+
+	if a.callbacks.PromptFileChanges == nil {
+		label := command
+		if label == "" {
+			paths := make([]string, 0, len(pending))
+			for _, change := range pending {
+				paths = append(paths, change.Path)
+			}
+			label = "write " + strings.Join(paths, " ")
+		}
+		ok, err := a.callbacks.PromptApproval(label)
+		if err != nil {
+			return approved, err
+		}
+		for _, index := range pendingIndex {
+			approved[index] = ok
+		}
+		return approved, nil
+	}
+
+	answers, err := a.callbacks.PromptFileChanges(command, pending)
+	if err != nil {
+		return approved, err
+	}
+	for i, index := range pendingIndex {
+		if i >= len(answers) {
+			break
+		}
+		approved[index] = answers[i] != FileRejected
+		if answers[i] == FileApprovedAlways && command == "" && !pending[i].Untracked {
+			a.alwaysWritable[pending[i].Path] = true
+		}
+	}
+	return approved, nil
+}
+
+func printFileChange(w io.Writer, change FileChange) {
+	switch {
+	case change.Unknown:
+		fmt.Fprintln(w, ui.Warning("Write ")+ui.Bold(change.Path))
+		fmt.Fprintln(w, ui.Muted("  (contents are produced by the command; no preview available)"))
+		return
+	case change.Created:
+		fmt.Fprintln(w, ui.Warning("Create ")+ui.Bold(change.Path))
+	default:
+		added, removed := diff.Stats(change.Diff)
+		fmt.Fprintln(w, ui.Warning("Edit ")+ui.Bold(change.Path)+ui.Muted(fmt.Sprintf(" (+%d -%d)", added, removed)))
+	}
+	if change.Untracked {
+		fmt.Fprintln(w, ui.Warning("  not tracked by git; approval covers this change only"))
+	}
+
+	lines := strings.Split(strings.TrimRight(change.Diff, "\n"), "\n")
+	for i, line := range lines {
+		if i == maxDiffPreviewLines {
+			fmt.Fprintln(w, ui.Muted(fmt.Sprintf("  ... %d more diff lines", len(lines)-i)))
+			break
+		}
+		switch {
+		case strings.HasPrefix(line, "+++"), strings.HasPrefix(line, "---"):
+			fmt.Fprintln(w, ui.Muted("  "+line))
+		case strings.HasPrefix(line, "@@"):
+			fmt.Fprintln(w, ui.Accent("  "+line))
+		case strings.HasPrefix(line, "+"):
+			fmt.Fprintln(w, ui.DiffAdd("  "+line))
+		case strings.HasPrefix(line, "-"):
+			fmt.Fprintln(w, ui.DiffDel("  "+line))
+		default:
+			fmt.Fprintln(w, "  "+line)
+		}
+	}
+}
+
+func (a *agent) resolvePath(path string) (string, error) {
+	return policy.ResolvePath(path, a.workspaceRoot, a.config.Policy.AllowedPaths)
+}
+
+// fileDenied applies the file-access policy to a resolved path.
+func (a *agent) fileDenied(fullPath string) (policy.Decision, bool) {
+	decision, denied := policy.CheckFile(fullPath, a.workspaceRoot, a.config)
+	if denied {
+		a.audit("file", a.relativeToWorkspace(fullPath), "denied", string(decision.Class))
+	}
+	return decision, denied
+}
+
+func (a *agent) pathDenied(callID string, path string, err error) types.Message {
+	var pathErr *policy.PathError
+	if errors.As(err, &pathErr) {
+		if a.callbacks.OnDenied != nil {
+			a.callbacks.OnDenied(pathErr.Path)
+		}
+		payload, _ := json.MarshalIndent(map[string]interface{}{
+			"status":     "denied",
+			"message":    "Path is outside the workspace.",
+			"path":       pathErr.Path,
+			"resolved":   pathErr.Resolved,
+			"class":      policy.DenyOutsideWorkspace,
+			"suggestion": policy.SuggestionFor(policy.DenyOutsideWorkspace),
+		}, "", "  ")
+		return types.Message{Role: types.RoleTool, ToolCallID: callID, Content: string(payload)}
+	}
+	return toolError(callID, fmt.Sprintf("Invalid path: %v", err))
+}
+
+func toolArgs(input interface{}) map[string]interface{} {
+	switch value := input.(type) {
+	case map[string]interface{}:
+		return value
+	case string:
+		var parsed map[string]interface{}
+		if err := json.Unmarshal([]byte(value), &parsed); err == nil {
+			return parsed
+		}
+	}
+	return map[string]interface{}{}
+}
+
+func toolError(callID string, message string) types.Message {
+	return types.Message{Role: types.RoleTool, ToolCallID: callID, Content: message}
+}
@@ -0,0 +1,103 @@
+package core
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"minimal-go/internal/history"
+	"minimal-go/internal/ui"
+)
+
+const historyListDefault = 20
+
+func (s *replState) handleHistoryCommand(args string) error {
+	if query, ok := strings.CutPrefix(args, "search"); ok && (query == "" || query[0] == ' ') {
+		return s.searchHistory(strings.TrimSpace(query))
+	}
+	entries, err := history.Load(s.workspaceRoot)
+	if err != nil {
+		return err
+	}
+	if len(entries) == 0 {
+		fmt.Println(ui.Muted("No prompt history for this workspace yet."))
+		return nil
+	}
+
+	if index, err := strconv.Atoi(args); err == nil {
+		if index < 1 || index > len(entries) {
+			return fmt.Errorf("no history entry #%d", index)
+		}
+		return s.recallPrompt(entries[index-1])
+	}
+
+	matches := history.Search(entries, args)
+	if len(matches) == 0 {
+		fmt.Println(ui.Muted(fmt.Sprintf("No prompts matching %q.", args)))
+		return nil
+	}
+	if len(matches) > historyListDefault {
+		matches = matches[:historyListDefault]
+	}
+	fmt.Println("")
+	for i := len(matches) - 1; i >= 0; i-- {
+		index := matches[i]
+		fmt.Println(ui.Cyan(fmt.Sprintf("  %4d", index+1)) + "  " + historyPreview(entries[index]))
+	}
+	fmt.Println(ui.Muted("  /history <n> to recall a prompt, /history search <q> to recall the newest match"))
+	fmt.Println("")
+	return nil
+}
+
+// searchHistory recalls the newest prompt containing query, like a shell's
+// reverse search. The REPL reads cooked lines, so ^R itself never arrives:
+// the terminal driver takes it.
+func (s *replState) searchHistory(query string) error {
+	entries, err := history.Load(s.workspaceRoot)
+	if err != nil {
+		return err
+	}
+	matches := history.Search(entries, query)
+	if len(matches) == 0 {
+		fmt.Println(ui.Muted(fmt.Sprintf("No prompt matching %q.", query)))
+		return nil
+	}
+	return s.recallPrompt(entries[matches[0]])
+}
+
+func (s *replState) recallPrompt(prompt string) error {
+	fmt.Println("")
+	fmt.Println(ui.Bold("Recalled prompt:"))
+	fmt.Println(prompt)
+	fmt.Println("")
+	fmt.Println(ui.Cyan("  [enter/y]") + " Send")
+	fmt.Println(ui.Cyan("  [e]") + "       Edit before sending")
+	fmt.Println(ui.Cyan("  [n]") + "       Cancel")
+	fmt.Println("")
+
+	answer, cancelled, err := readLine(s.reader, ui.Prompt("> "), s.sigCh, true)
+	if err != nil || cancelled {
+		return err
+	}
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "", "y", "yes":
+		s.queued = prompt
+	case "e", "edit":
+		edited, err := editInput(s.reader, s.sigCh, prompt)
+		if err != nil {
+			return err
+		}
+		s.queued = edited
+	default:
+		fmt.Println(ui.Muted("Cancelled."))
+	}
+	return nil
+}
+
+func historyPreview(prompt string) string {
+	preview := strings.Join(strings.Fields(prompt), " ")
+	if len([]rune(preview)) > 100 {
+		preview = string([]rune(preview)[:100]) + "…"
+	}
+	return preview
+}
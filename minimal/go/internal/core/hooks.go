@@ -0,0 +1,219 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"minimal-go/internal/config"
+	"minimal-go/internal/diff"
+	"minimal-go/internal/policy"
+	"minimal-go/internal/types"
+)
+
+const (
+	maxHookOutput      = 2000
+	maxHookDiff        = 4000
+	defaultHookTimeout = 10 * time.Second
+	hookDenyExitCode   = 2
+)
+
+type batchEdit struct {
+	path   string
+	callID string
+}
+
+// runPostEditHooks runs the configured post-edit hooks over the files written
+// by this batch of tool calls. The report goes into the result of the last
+// call that wrote one of them, so the model's next request already sees what
+// the formatters changed.
+func (a *agent) runPostEditHooks() {
+	edits := a.batchEdits
+	a.batchEdits = nil
+	if len(edits) == 0 || len(a.config.Hooks.PostEdit) == 0 {
+		return
+	}
+
+	var files []string
+	fullPaths := map[string]string{}
+	lastCall := ""
+	for _, edit := range edits {
+		lastCall = edit.callID
+		fullPath, err := a.resolvePath(edit.path)
+		if err != nil {
+			continue
+		}
+		rel := a.relativeToWorkspace(fullPath)
+		if _, ok := fullPaths[rel]; !ok {
+			fullPaths[rel] = fullPath
+			files = append(files, rel)
+		}
+	}
+
+	before := map[string]string{}
+	for _, file := range files {
+		data, _ := os.ReadFile(fullPaths[file])
+		before[file] = string(data)
+	}
+
+	var runs []map[string]interface{}
+	failed := false
+	for _, hook := range a.config.Hooks.PostEdit {
+		var matched []string
+		for _, file := range files {
+			if hook.Matches(file) {
+				matched = append(matched, file)
+			}
+		}
+		if len(matched) == 0 {
+			continue
+		}
+		command := expandDiagnosticCommand(hook.Command, matched)
+		a.notice(LevelInfo, "[hooks] "+command)
+		result := policy.RunBash(command, a.workspaceRoot)
+		run := map[string]interface{}{"command": command, "exitCode": result.Code}
+		if output := strings.TrimSpace(result.Stdout + "\n" + result.Stderr); output != "" {
+			run["output"] = tailText(output, maxHookOutput)
+		}
+		if result.Code != 0 {
+			failed = true
+			a.notice(LevelWarning, fmt.Sprintf("[hooks] %s exited with %d", command, result.Code))
+		}
+		runs = append(runs, run)
+	}
+	if len(runs) == 0 {
+		return
+	}
+
+	var changed []string
+	diffs := map[string]string{}
+	for _, file := range files {
+		data, err := os.ReadFile(fullPaths[file])
+		if err != nil || string(data) == before[file] {
+			continue
+		}
+		changed = append(changed, file)
+		diffs[file] = tailText(diff.Unified(file, before[file], string(data)), maxHookDiff)
+	}
+	if len(changed) > 0 {
+		a.loops.invalidate()
+		a.notice(LevelSuccess, "✓ hooks updated "+strings.Join(changed, ", "))
+	}
+
+	report := map[string]interface{}{"runs": runs}
+	if len(changed) > 0 {
+		report["changed"] = diffs
+		report["note"] = "The hooks rewrote these files after your edit; the diffs show their final state. Re-read a file before editing it again."
+	} else if !failed {
+		report["note"] = "The hooks left the files unchanged."
+	}
+	a.updateToolResult(lastCall, func(content string) string {
+		return attachHookReport(content, report)
+	})
+}
+
+func attachHookReport(content string, report map[string]interface{}) string {
+	var fields map[string]interface{}
+	if json.Unmarshal([]byte(content), &fields) == nil {
+		fields["postEditHooks"] = report
+		if payload, err := json.MarshalIndent(fields, "", "  "); err == nil {
+			return string(payload)
+		}
+	}
+	payload, _ := json.MarshalIndent(report, "", "  ")
+	return content + "\n\nPost-edit hooks:\n" + string(payload)
+}
+
+type preToolRequest struct {
+	Tool      string      `json:"tool"`
+	Input     interface{} `json:"input"`
+	CallID    string      `json:"callId"`
+	Workspace string      `json:"workspace"`
+}
+
+type preToolResponse struct {
+	Decision string                 `json:"decision"`
+	Message  string                 `json:"message"`
+	Input    map[string]interface{} `json:"input"`
+}
+
+// runPreToolHooks passes the call through every applicable pre-tool hook in
+// order, each seeing the input as rewritten by the ones before it. It returns
+// the reason for the model when a hook denies the call. A rewritten call is
+// validated again and still goes through the usual policy checks afterwards.
+func (a *agent) runPreToolHooks(call *types.ToolCall) string {
+	for _, hook := range a.config.Hooks.PreTool {
+		if !hook.Applies(call.Name) {
+			continue
+		}
+		response, err := runPreToolHook(hook, *call, a.workspaceRoot)
+		if err != nil {
+			a.notice(LevelWarning, fmt.Sprintf("[hooks] %s failed: %v", hook.Command, err))
+			a.audit("hook", describeToolCall(*call, 80), "denied", "hook_error")
+			return fmt.Sprintf("Blocked: the pre-tool hook %q failed (%v), so the call was not run.", hook.Command, err)
+		}
+		if response.Decision == "deny" {
+			message := response.Message
+			if message == "" {
+				message = "no reason given"
+			}
+			a.notice(LevelWarning, fmt.Sprintf("[hooks] %s denied %s: %s", hook.Command, call.Name, message))
+			a.audit("hook", describeToolCall(*call, 80), "denied", "")
+			return "Blocked by a pre-tool hook: " + message
+		}
+		if response.Input == nil {
+			continue
+		}
+		rewritten := types.ToolCall{ID: call.ID, Name: call.Name, Input: response.Input}
+		args, failure := a.validateToolCall(rewritten)
+		if failure != nil {
+			a.notice(LevelWarning, fmt.Sprintf("[hooks] %s returned invalid input for %s: %s", hook.Command, call.Name, failure.message))
+			return fmt.Sprintf("Blocked: the pre-tool hook %q rewrote the call into invalid input (%s).", hook.Command, failure.message)
+		}
+		before := describeToolCall(*call, 80)
+		call.Input = args
+		a.notice(LevelInfo, fmt.Sprintf("[hooks] %s rewrote %s → %s", hook.Command, before, describeToolCall(*call, 80)))
+		a.audit("hook", before, "rewritten", "")
+	}
+	return ""
+}
+
+func runPreToolHook(hook config.PreToolHook, call types.ToolCall, workspaceRoot string) (preToolResponse, error) {
+	request, err := json.Marshal(preToolRequest{Tool: call.Name, Input: call.Input, CallID: call.ID, Workspace: workspaceRoot})
+	if err != nil {
+		return preToolResponse{}, err
+	}
+	timeout := defaultHookTimeout
+	if hook.Timeout > 0 {
+		timeout = time.Duration(hook.Timeout) * time.Second
+	}
+	result := policy.RunBashWithOptions(hook.Command, policy.BashOptions{Dir: workspaceRoot, Timeout: timeout, Stdin: string(request)})
+	switch result.Code {
+	case 0:
+	case hookDenyExitCode:
+		message := strings.TrimSpace(result.Stderr)
+		if message == "" {
+			message = strings.TrimSpace(result.Stdout)
+		}
+		return preToolResponse{Decision: "deny", Message: message}, nil
+	default:
+		return preToolResponse{}, fmt.Errorf("exit %d: %s", result.Code, previewLine(strings.TrimSpace(result.Stderr), 200))
+	}
+
+	output := strings.TrimSpace(result.Stdout)
+	if output == "" {
+		return preToolResponse{Decision: "allow"}, nil
+	}
+	var response preToolResponse
+	if err := json.Unmarshal([]byte(output), &response); err != nil {
+		return preToolResponse{}, fmt.Errorf("invalid JSON output: %v", err)
+	}
+	switch response.Decision {
+	case "", "allow", "deny":
+	default:
+		return preToolResponse{}, fmt.Errorf("unknown decision %q", response.Decision)
+	}
+	return response, nil
+}
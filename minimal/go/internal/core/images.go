@@ -0,0 +1,138 @@
+package core
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"minimal-go/internal/policy"
+	"minimal-go/internal/types"
+)
+
+const (
+	maxImageBytes     = 5 * 1024 * 1024
+	maxImagesPerTool  = 3
+	binaryProbeLength = 8000
+	// Some filesystems store whole-second mtimes, so an image written just
+	// after a command starts can look older than the start time.
+	mtimeGranularity = time.Second
+)
+
+var (
+	imageMediaTypes = map[string]string{
+		".png":  "image/png",
+		".jpg":  "image/jpeg",
+		".jpeg": "image/jpeg",
+		".gif":  "image/gif",
+		".webp": "image/webp",
+	}
+	imagePathPattern = regexp.MustCompile(`(?i)[\w./~-]+\.(?:png|jpe?g|gif|webp)\b`)
+)
+
+func (a *agent) visionEnabled() bool {
+	return !a.llmConfig.ModelKnown || a.llmConfig.ModelInfo.SupportsVision
+}
+
+func loadImage(path string) (types.Image, error) {
+	mediaType := imageMediaTypes[strings.ToLower(filepath.Ext(path))]
+	if mediaType == "" {
+		return types.Image{}, fmt.Errorf("unsupported image type %q (use png, jpeg, gif or webp)", filepath.Ext(path))
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return types.Image{}, err
+	}
+	if info.Size() > maxImageBytes {
+		return types.Image{}, fmt.Errorf("image is %d bytes; the limit is %d", info.Size(), maxImageBytes)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return types.Image{}, err
+	}
+	return types.Image{MediaType: mediaType, Data: base64.StdEncoding.EncodeToString(data)}, nil
+}
+
+func (a *agent) outputImages(command string, stdout string, dir string, since time.Time) []types.Image {
+	if !a.visionEnabled() {
+		return nil
+	}
+	var images []types.Image
+	seen := map[string]bool{}
+	for _, candidate := range imagePathPattern.FindAllString(command+"\n"+stdout, -1) {
+		if len(images) == maxImagesPerTool {
+			break
+		}
+		fullPath, err := policy.ResolvePathFrom(candidate, dir, a.workspaceRoot, a.config.Policy.AllowedPaths)
+		if err != nil || seen[fullPath] {
+			continue
+		}
+		seen[fullPath] = true
+		info, err := os.Stat(fullPath)
+		if err != nil || info.IsDir() || info.ModTime().Before(since) {
+			continue
+		}
+		image, err := loadImage(fullPath)
+		if err != nil {
+			continue
+		}
+		image.Path = a.relativeToWorkspace(fullPath)
+		images = append(images, image)
+		a.notice(LevelInfo, fmt.Sprintf("[image] attached %s (%d KB)", image.Path, info.Size()/1024))
+	}
+	return images
+}
+
+func (a *agent) handleViewImage(input interface{}, callID string) types.Message {
+	path, _ := toolArgs(input)["path"].(string)
+	fullPath, err := a.resolvePath(path)
+	if err != nil {
+		return a.pathDenied(callID, path, err)
+	}
+	if decision, denied := a.fileDenied(fullPath); denied {
+		return *a.commandDenial("view_image "+path, decision, callID)
+	}
+	if !a.visionEnabled() {
+		return toolError(callID, "The current model does not accept images.")
+	}
+	image, err := loadImage(fullPath)
+	if err != nil {
+		return toolError(callID, "Cannot load image: "+err.Error())
+	}
+	image.Path = a.relativeToWorkspace(fullPath)
+	a.notice(LevelInfo, "[image] "+image.Path)
+
+	payload, _ := json.MarshalIndent(map[string]interface{}{
+		"status":    "attached",
+		"path":      image.Path,
+		"mediaType": image.MediaType,
+	}, "", "  ")
+	return types.Message{Role: types.RoleTool, ToolCallID: callID, Content: string(payload), Images: []types.Image{image}}
+}
+
+func sanitizeOutput(text string) string {
+	probe := text
+	if len(probe) > binaryProbeLength {
+		probe = probe[:binaryProbeLength]
+	}
+	invalid := 0
+	for i := 0; i < len(probe); {
+		r, size := utf8.DecodeRuneInString(probe[i:])
+		if r == 0 {
+			return fmt.Sprintf("[binary output omitted: %d bytes]", len(text))
+		}
+		if r == utf8.RuneError && size == 1 {
+			invalid++
+		}
+		i += size
+	}
+	if invalid > len(probe)/10 {
+		return fmt.Sprintf("[binary output omitted: %d bytes]", len(text))
+	}
+	return text
+}
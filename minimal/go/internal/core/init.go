@@ -0,0 +1,76 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"minimal-go/internal/config"
+	"minimal-go/internal/ui"
+)
+
+const defaultSystemPrompt = "You are a helpful coding assistant.\n"
+
+// Init creates ~/.minimal with a starter system prompt and config. Existing
+// files are left alone, so it is safe to run again.
+func Init() error {
+	for _, dir := range []string{config.MinimalDir, config.SkillsDir} {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			printError(err.Error())
+			return err
+		}
+	}
+
+	created, err := createIfMissing(config.SystemMDPath, func() error {
+		return os.WriteFile(config.SystemMDPath, []byte(defaultSystemPrompt), 0o644)
+	})
+	if err != nil {
+		printError(err.Error())
+		return err
+	}
+	reportInit(config.SystemMDPath, created)
+
+	defaults := config.DefaultConfig()
+	variant := defaults.LLM.Variants[defaults.LLM.CurrentProvider]
+	created, err = createIfMissing(config.ConfigPath, func() error {
+		return config.WriteConfigTree(map[string]interface{}{
+			"llm": map[string]interface{}{
+				"current_provider": defaults.LLM.CurrentProvider,
+				"current_model":    defaults.LLM.CurrentModel,
+				"variants": map[string]interface{}{
+					defaults.LLM.CurrentProvider: map[string]interface{}{
+						"schema_type": string(variant.SchemaType),
+						"api_key_env": variant.APIKeyEnv,
+						"base_url":    variant.BaseURL,
+					},
+				},
+			},
+		})
+	})
+	if err != nil {
+		printError(err.Error())
+		return err
+	}
+	reportInit(config.ConfigPath, created)
+
+	fmt.Println("")
+	fmt.Println(ui.Muted(fmt.Sprintf("Set %s (or edit %s), then run mini-go doctor.", variant.APIKeyEnv, config.ConfigPath)))
+	return nil
+}
+
+func createIfMissing(path string, create func() error) (bool, error) {
+	if _, err := os.Stat(path); err == nil {
+		return false, nil
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return false, err
+	}
+	return true, create()
+}
+
+func reportInit(path string, created bool) {
+	if created {
+		printSuccess("✓ created " + path)
+		return
+	}
+	fmt.Println(ui.Muted("- kept " + path + " (already exists)"))
+}
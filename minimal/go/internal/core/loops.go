@@ -0,0 +1,70 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"minimal-go/internal/types"
+)
+
+const (
+	maxIdenticalToolCalls = 3
+	maxLoopSuppressions   = 3
+	maxRepeatedResult     = 2000
+)
+
+type loopDetector struct {
+	history    []string
+	counts     map[string]int
+	results    map[string]string
+	suppressed int
+}
+
+func newLoopDetector() *loopDetector {
+	return &loopDetector{counts: map[string]int{}, results: map[string]string{}}
+}
+
+func toolCallSignature(call types.ToolCall) string {
+	input, _ := json.Marshal(call.Input)
+	return call.Name + " " + string(input)
+}
+
+func (d *loopDetector) repetition(signature string) string {
+	if _, seen := d.results[signature]; !seen {
+		return ""
+	}
+	n := len(d.history)
+	switch {
+	case n > 0 && d.history[n-1] == signature:
+		return "identical to the previous call"
+	case n >= 3 && d.history[n-2] == signature && d.history[n-1] == d.history[n-3] && d.history[n-1] != signature:
+		return "alternating between the same two calls"
+	case d.counts[signature] >= maxIdenticalToolCalls:
+		return fmt.Sprintf("already made %d times in this turn", d.counts[signature])
+	}
+	return ""
+}
+
+func (d *loopDetector) record(signature string, result types.Message) {
+	d.history = append(d.history, signature)
+	d.counts[signature]++
+	d.results[signature] = result.Content
+}
+
+func (d *loopDetector) invalidate() {
+	d.history = nil
+	d.counts = map[string]int{}
+	d.results = map[string]string{}
+}
+
+func (a *agent) suppressRepeatedCall(call types.ToolCall, signature string, reason string) types.Message {
+	a.loops.suppressed++
+	a.loops.history = append(a.loops.history, signature)
+	a.notice(LevelWarning, fmt.Sprintf("[loop] %s call suppressed: %s", call.Name, reason))
+	payload, _ := json.MarshalIndent(map[string]interface{}{
+		"status":         "duplicate",
+		"message":        fmt.Sprintf("This %s call is %s, so it was not run again and its result is unchanged. Try a different approach or explain what is blocking you.", call.Name, reason),
+		"previousResult": tailText(a.loops.results[signature], maxRepeatedResult),
+	}, "", "  ")
+	return types.Message{Role: types.RoleTool, ToolCallID: call.ID, Content: string(payload)}
+}
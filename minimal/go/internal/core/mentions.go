@@ -0,0 +1,160 @@
+package core
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	maxMentionBytes = 100 * 1024
+	maxMentionLines = 2000
+)
+
+var (
+	mentionPattern = regexp.MustCompile(`(^|\s)@([^\s@]+)`)
+	rangePattern   = regexp.MustCompile(`^(.*):(\d+)(?:-(\d+))?$`)
+	fenceLanguages = map[string]string{
+		".go":   "go",
+		".ts":   "ts",
+		".tsx":  "tsx",
+		".js":   "js",
+		".py":   "python",
+		".rs":   "rust",
+		".rb":   "ruby",
+		".java": "java",
+		".c":    "c",
+		".h":    "c",
+		".cpp":  "cpp",
+		".sh":   "bash",
+		".json": "json",
+		".yaml": "yaml",
+		".yml":  "yaml",
+		".toml": "toml",
+		".md":   "markdown",
+		".sql":  "sql",
+		".lua":  "lua",
+		".php":  "php",
+	}
+)
+
+type fileMention struct {
+	Path      string
+	StartLine int
+	EndLine   int
+	Truncated bool
+}
+
+func (m fileMention) label() string {
+	if m.StartLine > 0 {
+		return fmt.Sprintf("%s:%d-%d", m.Path, m.StartLine, m.EndLine)
+	}
+	return m.Path
+}
+
+func expandFileMentions(input string, workspaceRoot string) (string, []fileMention, []string) {
+	var blocks []string
+	var mentions []fileMention
+	var warnings []string
+	seen := map[string]bool{}
+
+	for _, match := range mentionPattern.FindAllStringSubmatch(input, -1) {
+		token := strings.TrimRight(match[2], ".,;:!?)")
+		if seen[token] {
+			continue
+		}
+		seen[token] = true
+
+		mention, block, err := readMention(token, workspaceRoot)
+		if err != nil {
+			if !os.IsNotExist(err) {
+				warnings = append(warnings, fmt.Sprintf("@%s: %v", token, err))
+			}
+			continue
+		}
+		mentions = append(mentions, mention)
+		blocks = append(blocks, block)
+	}
+
+	if len(blocks) == 0 {
+		return input, nil, warnings
+	}
+	return input + "\n\n" + strings.Join(blocks, "\n\n"), mentions, warnings
+}
+
+func readMention(token string, workspaceRoot string) (fileMention, string, error) {
+	path := token
+	startLine, endLine := 0, 0
+	if _, err := os.Stat(resolveMentionPath(path, workspaceRoot)); err != nil {
+		if groups := rangePattern.FindStringSubmatch(token); groups != nil {
+			path = groups[1]
+			startLine, _ = strconv.Atoi(groups[2])
+			endLine = startLine
+			if groups[3] != "" {
+				endLine, _ = strconv.Atoi(groups[3])
+			}
+		}
+	}
+
+	fullPath := resolveMentionPath(path, workspaceRoot)
+	info, err := os.Stat(fullPath)
+	if err != nil {
+		return fileMention{}, "", err
+	}
+	if info.IsDir() {
+		return fileMention{}, "", fmt.Errorf("is a directory")
+	}
+	if startLine > 0 && endLine < startLine {
+		return fileMention{}, "", fmt.Errorf("invalid line range %d-%d", startLine, endLine)
+	}
+
+	data, err := os.ReadFile(fullPath)
+	if err != nil {
+		return fileMention{}, "", err
+	}
+	if strings.ContainsRune(string(data[:min(len(data), 8000)]), 0) {
+		return fileMention{}, "", fmt.Errorf("binary file not inlined")
+	}
+
+	mention := fileMention{Path: path}
+	lines := strings.Split(strings.TrimRight(string(data), "\n"), "\n")
+	if startLine > 0 {
+		if startLine > len(lines) {
+			return fileMention{}, "", fmt.Errorf("file has only %d lines", len(lines))
+		}
+		endLine = min(endLine, len(lines))
+		lines = lines[startLine-1 : endLine]
+		mention.StartLine, mention.EndLine = startLine, endLine
+	}
+
+	content := strings.Join(lines, "\n")
+	if len(lines) > maxMentionLines || len(content) > maxMentionBytes {
+		mention.Truncated = true
+		if len(lines) > maxMentionLines {
+			lines = lines[:maxMentionLines]
+		}
+		content = strings.Join(lines, "\n")
+		if len(content) > maxMentionBytes {
+			content = content[:maxMentionBytes]
+		}
+		content += "\n... (truncated; reference a line range like @" + path + ":1-200)"
+	}
+
+	language := fenceLanguages[strings.ToLower(filepath.Ext(path))]
+	block := fmt.Sprintf("%s\n```%s\n%s\n```", mention.label(), language, content)
+	return mention, block, nil
+}
+
+func resolveMentionPath(path string, workspaceRoot string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+	if strings.HasPrefix(path, "~/") {
+		home, _ := os.UserHomeDir()
+		return filepath.Join(home, path[2:])
+	}
+	return filepath.Join(workspaceRoot, path)
+}
@@ -0,0 +1,145 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"minimal-go/internal/types"
+	"minimal-go/internal/ui"
+)
+
+const (
+	messagePreviewLength = 70
+	droppedContent       = "[removed from history by the user]"
+)
+
+func (s *replState) handleMessagesCommand() error {
+	messages := s.agent.GetMessages()
+	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "#\tROLE\tTOKENS\tCONTENT")
+	for i, message := range messages {
+		fmt.Fprintf(writer, "%s\t%s\t%d\t%s\n", ui.Cyan(strconv.Itoa(i)), message.Role, estimateMessageTokens(message), ui.Muted(describeMessage(message)))
+	}
+	writer.Flush()
+	fmt.Println(ui.Muted(fmt.Sprintf("~%d tokens in %d messages. /drop <n>[-m] or /drop tool-results to trim.", estimateRequestTokens(messages, nil), len(messages))))
+	return nil
+}
+
+func describeMessage(message types.Message) string {
+	var parts []string
+	if message.Content != "" {
+		parts = append(parts, previewLine(message.Content, messagePreviewLength))
+	}
+	for _, call := range message.ToolCalls {
+		parts = append(parts, "→ "+describeToolCall(call, messagePreviewLength/2))
+	}
+	if len(message.Images) > 0 {
+		parts = append(parts, fmt.Sprintf("[%d image(s)]", len(message.Images)))
+	}
+	return strings.Join(parts, " ")
+}
+
+func describeToolCall(call types.ToolCall, width int) string {
+	input, _ := call.Input.(map[string]interface{})
+	for _, key := range []string{"command", "path"} {
+		if value, ok := input[key].(string); ok {
+			return call.Name + " " + previewLine(value, width)
+		}
+	}
+	return call.Name
+}
+
+func (s *replState) handleDropCommand(args string) error {
+	messages := s.agent.GetMessages()
+	before := estimateRequestTokens(messages, nil)
+
+	var updated []types.Message
+	var summary string
+	if args == "tool-results" {
+		cleared := 0
+		updated = make([]types.Message, len(messages))
+		for i, message := range messages {
+			if message.Role == types.RoleTool && message.Content != droppedContent {
+				message.Content = droppedContent
+				message.Images = nil
+				cleared++
+			}
+			updated[i] = message
+		}
+		summary = fmt.Sprintf("Cleared %d tool result(s)", cleared)
+	} else {
+		first, last, err := parseMessageRange(args, len(messages))
+		if err != nil {
+			return err
+		}
+		var dropped int
+		updated, dropped = dropMessages(messages, first, last)
+		summary = fmt.Sprintf("Dropped %d message(s)", dropped)
+	}
+
+	s.agent.SetMessages(updated)
+	printSuccess(fmt.Sprintf("✓ %s; history is now ~%d tokens (was ~%d).", summary, estimateRequestTokens(updated, nil), before))
+	return nil
+}
+
+func parseMessageRange(args string, count int) (int, int, error) {
+	usage := errors.New("Usage: /drop <n>, /drop <n>-<m> or /drop tool-results (see /messages)")
+	if args == "" {
+		return 0, 0, usage
+	}
+	from, to, isRange := strings.Cut(args, "-")
+	first, err := strconv.Atoi(strings.TrimSpace(from))
+	if err != nil {
+		return 0, 0, usage
+	}
+	last := first
+	if isRange {
+		if last, err = strconv.Atoi(strings.TrimSpace(to)); err != nil {
+			return 0, 0, usage
+		}
+	}
+	if first < 1 || last < first || last >= count {
+		return 0, 0, fmt.Errorf("messages 1-%d can be dropped (0 is the system prompt)", count-1)
+	}
+	return first, last, nil
+}
+
+// dropMessages removes messages first..last. Dropping an assistant message
+// also drops the tool results answering its calls, and a dropped tool result
+// whose call stays in the history is blanked instead, so every tool call
+// keeps exactly one result.
+func dropMessages(messages []types.Message, first int, last int) ([]types.Message, int) {
+	remove := map[int]bool{}
+	orphaned := map[int]bool{}
+	for i := first; i <= last; i++ {
+		remove[i] = true
+		if len(messages[i].ToolCalls) == 0 {
+			continue
+		}
+		for j := i + 1; j < len(messages) && messages[j].Role == types.RoleTool; j++ {
+			orphaned[j] = true
+		}
+	}
+	updated := make([]types.Message, 0, len(messages))
+	dropped := 0
+	for i, message := range messages {
+		switch {
+		case orphaned[i]:
+			dropped++
+		case remove[i] && message.Role == types.RoleTool:
+			message.Content = droppedContent
+			message.Images = nil
+			updated = append(updated, message)
+			dropped++
+		case remove[i]:
+			dropped++
+		default:
+			updated = append(updated, message)
+		}
+	}
+	return updated, dropped
+}
@@ -0,0 +1,173 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"minimal-go/internal/config"
+	"minimal-go/internal/core/providers"
+	"minimal-go/internal/types"
+	"minimal-go/internal/vault"
+)
+
+type RequestMetrics struct {
+	Request         int     `json:"request"`
+	Model           string  `json:"model"`
+	SerializeMs     float64 `json:"serializeMs"`
+	FirstByteMs     float64 `json:"firstByteMs"`
+	LatencyMs       float64 `json:"latencyMs"`
+	OutputTokens    int     `json:"outputTokens"`
+	TokensPerSecond float64 `json:"tokensPerSecond"`
+	Retry           int     `json:"retry"`
+}
+
+type TurnMetrics struct {
+	Requests        int     `json:"requests"`
+	SerializeMs     float64 `json:"serializeMs"`
+	ProviderMs      float64 `json:"providerMs"`
+	OutputTokens    int     `json:"outputTokens"`
+	TokensPerSecond float64 `json:"tokensPerSecond"`
+	Retries         int     `json:"retries"`
+}
+
+func newRequestMetrics(request int, model string, retry int, latency time.Duration, timing providers.RequestTiming, usage *types.Usage) RequestMetrics {
+	metrics := RequestMetrics{
+		Request:     request,
+		Model:       model,
+		SerializeMs: milliseconds(timing.Serialize),
+		FirstByteMs: milliseconds(timing.FirstByte),
+		LatencyMs:   milliseconds(latency),
+		Retry:       retry,
+	}
+	if usage != nil {
+		metrics.OutputTokens = usage.CompletionTokens
+		metrics.TokensPerSecond = tokensPerSecond(usage.CompletionTokens, metrics.LatencyMs)
+	}
+	return metrics
+}
+
+func (t *TurnMetrics) add(request RequestMetrics) {
+	t.Requests++
+	t.SerializeMs += request.SerializeMs
+	t.ProviderMs += request.LatencyMs
+	t.OutputTokens += request.OutputTokens
+	t.TokensPerSecond = tokensPerSecond(t.OutputTokens, t.ProviderMs)
+	if request.Retry > 0 {
+		t.Retries++
+	}
+}
+
+func (m RequestMetrics) String() string {
+	return fmt.Sprintf("[metrics] request %d · serialize %s · ttfb %s · total %s · %d tok out · %.0f tok/s · retry %d",
+		m.Request, formatMs(m.SerializeMs), formatMs(m.FirstByteMs), formatMs(m.LatencyMs), m.OutputTokens, m.TokensPerSecond, m.Retry)
+}
+
+func (t TurnMetrics) String() string {
+	return fmt.Sprintf("[metrics] turn · %d request(s) · provider %s · serialize %s · %.0f tok/s · retries %d",
+		t.Requests, formatMs(t.ProviderMs), formatMs(t.SerializeMs), t.TokensPerSecond, t.Retries)
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
+
+func tokensPerSecond(tokens int, ms float64) float64 {
+	if ms <= 0 {
+		return 0
+	}
+	return float64(tokens) * 1000 / ms
+}
+
+func formatMs(ms float64) string {
+	switch {
+	case ms == 0:
+		return "-"
+	case ms < 1:
+		return "<1ms"
+	case ms < 1000:
+		return fmt.Sprintf("%.0fms", ms)
+	}
+	return fmt.Sprintf("%.2fs", ms/1000)
+}
+
+// debugSessionLog keeps every --debug entry of a run as JSON lines, so
+// request timings can be compared after the fact.
+type debugSessionLog struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+type debugLogEntry struct {
+	Time  time.Time   `json:"time"`
+	Label string      `json:"label"`
+	Data  interface{} `json:"data,omitempty"`
+}
+
+func (l *debugSessionLog) write(label string, data interface{}) (string, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	opened := ""
+	if l.file == nil {
+		if err := os.MkdirAll(config.DebugDir, 0o700); err != nil {
+			return "", err
+		}
+		path := filepath.Join(config.DebugDir, fmt.Sprintf("%s-%d.jsonl", time.Now().Format("20060102-150405"), os.Getpid()))
+		file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+		if err != nil {
+			return "", err
+		}
+		l.file, opened = file, path
+	}
+	line, err := json.Marshal(debugLogEntry{Time: time.Now(), Label: label, Data: data})
+	if err != nil {
+		return opened, err
+	}
+	if line, err = vault.SealLine(line); err != nil {
+		return opened, err
+	}
+	_, err = l.file.Write(append(line, '\n'))
+	return opened, err
+}
+
+func (l *debugSessionLog) close() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.file != nil {
+		l.file.Close()
+		l.file = nil
+	}
+}
+
+func (a *agent) recordDebug(label string, data interface{}) {
+	if !a.debug {
+		return
+	}
+	opened, err := a.debugFile.write(label, data)
+	if opened != "" {
+		a.notice(LevelInfo, "[debug] logging to "+opened)
+	}
+	if err != nil && a.callbacks.OnDebugLog != nil {
+		a.callbacks.OnDebugLog("Debug log", err.Error())
+	}
+}
+
+func (a *agent) recordRequestMetrics(metrics RequestMetrics) {
+	a.turnMetrics.add(metrics)
+	if !a.debug {
+		return
+	}
+	a.recordDebug("Request metrics", metrics)
+	a.notice(LevelInfo, metrics.String())
+}
+
+func (a *agent) recordTurnMetrics() {
+	if !a.debug || a.turnMetrics.Requests == 0 {
+		return
+	}
+	a.recordDebug("Turn metrics", a.turnMetrics)
+	a.notice(LevelInfo, a.turnMetrics.String())
+}
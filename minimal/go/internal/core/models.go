@@ -0,0 +1,86 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"minimal-go/internal/config"
+	"minimal-go/internal/core/providers"
+	"minimal-go/internal/ui"
+)
+
+func (a *agent) SetModel(model string) error {
+	a.turnMu.Lock()
+	defer a.turnMu.Unlock()
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	cfg := a.config
+	cfg.LLM.CurrentModel = model
+	resolved, err := config.ResolveLlmConfig(cfg)
+	if err != nil {
+		return err
+	}
+	a.config = cfg
+	a.llmConfig = resolved
+	return nil
+}
+
+func (s *replState) handleModelsCommand(filter string) error {
+	llmConfig := s.agent.GetLlmConfig()
+	models, err := providers.ListModels(llmConfig)
+	if errors.Is(err, providers.ErrNoModelsEndpoint) {
+		return fmt.Errorf("%s does not expose a models list; set llm.current_model in config.json instead", llmConfig.Provider)
+	}
+	if err != nil {
+		return fmt.Errorf("listing models from %s: %w", llmConfig.Provider, err)
+	}
+
+	var shown []string
+	for _, model := range models {
+		if filter == "" || strings.Contains(strings.ToLower(model), strings.ToLower(filter)) {
+			shown = append(shown, model)
+		}
+	}
+	fmt.Println("")
+	fmt.Println(ui.Bold(fmt.Sprintf("Models available from %s (%d):", llmConfig.Provider, len(shown))))
+	if len(shown) == 0 {
+		fmt.Println(ui.Muted("  (none match)"))
+		fmt.Println("")
+		return nil
+	}
+	for i, model := range shown {
+		marker := "  "
+		if model == llmConfig.Model {
+			marker = ui.Success("● ")
+		}
+		line := fmt.Sprintf("  %s%s %s", marker, ui.Cyan(fmt.Sprintf("%3d.", i+1)), model)
+		if info, known := config.LookupModel(model, s.models); known && info.ContextWindow > 0 {
+			line += ui.Muted(fmt.Sprintf("  %dk context", info.ContextWindow/1000))
+		}
+		fmt.Println(line)
+	}
+	fmt.Println("")
+
+	choice, cancelled, err := readLine(s.reader, ui.Muted("Switch to (number or name, enter to keep current): "), s.sigCh, false)
+	if err != nil || cancelled {
+		return err
+	}
+	choice = strings.TrimSpace(choice)
+	if choice == "" {
+		return nil
+	}
+	model := choice
+	if index, err := strconv.Atoi(choice); err == nil {
+		if index < 1 || index > len(shown) {
+			return fmt.Errorf("no model #%d", index)
+		}
+		model = shown[index-1]
+	}
+	if err := s.agent.SetModel(model); err != nil {
+		return err
+	}
+	printSuccess("✓ Switched to " + model + " for this session")
+	return nil
+}
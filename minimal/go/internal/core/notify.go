@@ -0,0 +1,123 @@
+package core
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+
+	"minimal-go/internal/config"
+	"minimal-go/internal/policy"
+	"minimal-go/internal/types"
+	"minimal-go/internal/ui"
+)
+
+const notifyCommandTimeout = 10 * time.Second
+
+// Names System Events reports for the frontmost app, keyed by $TERM_PROGRAM.
+var macTerminalApps = map[string]string{
+	"Apple_Terminal": "Terminal",
+	"iTerm.app":      "iTerm2",
+	"vscode":         "Code",
+	"WezTerm":        "wezterm-gui",
+	"ghostty":        "Ghostty",
+}
+
+// runTurn runs one agent turn and, when it took longer than ui.notify.after,
+// tells the user it is done.
+func (s *replState) runTurn() {
+	started := time.Now()
+	crashed, err := s.guard("an agent turn", s.agent.RunAgentTurn)
+	if crashed {
+		return
+	}
+	if err != nil {
+		printError(err.Error())
+	}
+	summary := s.agent.GetTurnSummary()
+	fmt.Println(ui.Muted(formatTurnSummary(summary, s.agent.GetLlmConfig().ModelKnown)))
+	s.session.TurnLog = append(s.session.TurnLog, summary)
+	notifyTurnDone(s.agent.GetConfig().UI.Notify, time.Since(started), s.agent.GetMessages(), err)
+}
+
+func notifyTurnDone(cfg config.NotifyConfig, elapsed time.Duration, messages []types.Message, turnErr error) {
+	if cfg.After <= 0 || elapsed < time.Duration(cfg.After)*time.Second {
+		return
+	}
+	if !cfg.Always && terminalFocused() {
+		return
+	}
+
+	title := "mini-go finished (" + elapsed.Round(time.Second).String() + ")"
+	message := "The turn is done."
+	if turnErr != nil {
+		title = "mini-go stopped with an error"
+		message = previewLine(turnErr.Error(), 120)
+	} else {
+		for i := len(messages) - 1; i >= 0; i-- {
+			if messages[i].Role == types.RoleAssistant && strings.TrimSpace(messages[i].Content) != "" {
+				message = previewLine(messages[i].Content, 120)
+				break
+			}
+		}
+	}
+
+	fmt.Print("\a")
+	if err := sendNotification(cfg.Command, title, message); err != nil {
+		fmt.Println(ui.Muted("[notify] " + err.Error()))
+	}
+}
+
+func sendNotification(command string, title string, message string) error {
+	if command != "" {
+		result := policy.RunBashWithOptions(command, policy.BashOptions{
+			Env:     map[string]string{"MINI_GO_TITLE": title, "MINI_GO_MESSAGE": message},
+			Timeout: notifyCommandTimeout,
+		})
+		if result.Code != 0 {
+			return fmt.Errorf("notify command exited with %d: %s", result.Code, strings.TrimSpace(result.Stderr))
+		}
+		return nil
+	}
+
+	switch runtime.GOOS {
+	case "darwin":
+		script := fmt.Sprintf("display notification %s with title %s", appleScriptString(message), appleScriptString(title))
+		return exec.Command("osascript", "-e", script).Run()
+	case "linux":
+		if _, err := exec.LookPath("notify-send"); err != nil {
+			return nil
+		}
+		return exec.Command("notify-send", "--app-name=mini-go", title, message).Run()
+	}
+	return nil
+}
+
+func appleScriptString(text string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(text) + `"`
+}
+
+// terminalFocused is a best-effort check; when the window system cannot be
+// asked, the terminal counts as not focused so the notification still goes
+// out.
+func terminalFocused() bool {
+	switch runtime.GOOS {
+	case "darwin":
+		app := macTerminalApps[os.Getenv("TERM_PROGRAM")]
+		if app == "" {
+			return false
+		}
+		output, err := exec.Command("osascript", "-e", `tell application "System Events" to get name of first application process whose frontmost is true`).Output()
+		return err == nil && strings.TrimSpace(string(output)) == app
+	case "linux":
+		window := os.Getenv("WINDOWID")
+		if window == "" || os.Getenv("DISPLAY") == "" {
+			return false
+		}
+		output, err := exec.Command("xdotool", "getactivewindow").Output()
+		return err == nil && strings.TrimSpace(string(output)) == window
+	}
+	return false
+}
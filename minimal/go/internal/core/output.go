@@ -0,0 +1,160 @@
+package core
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"minimal-go/internal/types"
+	"minimal-go/internal/ui"
+)
+
+const (
+	collapsedOutputLines = 12
+	maxKeptOutputs       = 50
+)
+
+// CommandOutput is the full output of one bash call, kept so /expand can show
+// what the conversation view collapsed.
+type CommandOutput struct {
+	ID       int
+	Command  string
+	Stdout   string
+	Stderr   string
+	ExitCode int
+}
+
+func (a *agent) recordOutput(command string, stdout string, stderr string, exitCode int) int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.outputCount++
+	a.outputs = append(a.outputs, CommandOutput{ID: a.outputCount, Command: command, Stdout: stdout, Stderr: stderr, ExitCode: exitCode})
+	if len(a.outputs) > maxKeptOutputs {
+		a.outputs = a.outputs[len(a.outputs)-maxKeptOutputs:]
+	}
+	return a.outputCount
+}
+
+// GetCommandOutput returns output id, or the latest one for id 0.
+func (a *agent) GetCommandOutput(id int) (CommandOutput, bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if len(a.outputs) == 0 {
+		return CommandOutput{}, false
+	}
+	if id == 0 {
+		return a.outputs[len(a.outputs)-1], true
+	}
+	for _, output := range a.outputs {
+		if output.ID == id {
+			return output, true
+		}
+	}
+	return CommandOutput{}, false
+}
+
+// commandFrame collects a bash call's output between its tool_call and
+// tool_result events, so the renderer can print it as one framed block.
+type commandFrame struct {
+	command  string
+	lines    []string
+	outputID int
+}
+
+func (f *commandFrame) add(event Event) {
+	f.outputID = event.OutputID
+	for _, line := range strings.Split(strings.TrimRight(event.Text, "\n"), "\n") {
+		if event.Level == LevelError {
+			line = ui.Error(line)
+		}
+		f.lines = append(f.lines, line)
+	}
+}
+
+// finish prints the frame once the result is in. Results without an exit
+// code (denied or rejected commands) print any collected lines as they are.
+func (f *commandFrame) finish(out io.Writer, result string) {
+	var payload struct {
+		ExitCode *int `json:"exitCode"`
+	}
+	if json.Unmarshal([]byte(result), &payload) != nil || payload.ExitCode == nil {
+		for _, line := range f.lines {
+			fmt.Fprintln(out, line)
+		}
+		return
+	}
+	printCommandFrame(out, f.command, f.lines, *payload.ExitCode, f.outputID, collapsedOutputLines)
+}
+
+// printCommandFrame shows the last limit lines (all of them for limit 0):
+// the end of a build or test run is where the verdict is.
+func printCommandFrame(out io.Writer, command string, lines []string, exitCode int, outputID int, limit int) {
+	badge := ui.Success("✓ exit 0")
+	if exitCode != 0 {
+		badge = ui.Error(fmt.Sprintf("✗ exit %d", exitCode))
+	}
+	header := ui.Bold("$ " + previewLine(command, 100))
+	if len(lines) == 0 {
+		fmt.Fprintln(out, ui.Muted("── ")+header+"  "+badge+ui.Muted(" (no output)"))
+		return
+	}
+	fmt.Fprintln(out, ui.Muted("┌─ ")+header)
+	if limit > 0 && len(lines) > limit {
+		hidden := fmt.Sprintf("│ … %d earlier line(s) hidden", len(lines)-limit)
+		if outputID > 0 {
+			hidden += fmt.Sprintf(" (/expand %d)", outputID)
+		}
+		fmt.Fprintln(out, ui.Muted(hidden))
+		lines = lines[len(lines)-limit:]
+	}
+	for _, line := range lines {
+		fmt.Fprintln(out, ui.Muted("│ ")+line)
+	}
+	fmt.Fprintln(out, ui.Muted("└─ ")+badge)
+}
+
+func expandOutput(agent Agent, args string) error {
+	id := 0
+	if args != "" {
+		parsed, err := strconv.Atoi(strings.TrimPrefix(args, "#"))
+		if err != nil || parsed < 1 {
+			return errors.New("Usage: /expand [n]")
+		}
+		id = parsed
+	}
+	output, ok := agent.GetCommandOutput(id)
+	if !ok {
+		if id == 0 {
+			fmt.Println(ui.Muted("No command output in this session yet."))
+			return nil
+		}
+		return fmt.Errorf("No output #%d (the last %d are kept).", id, maxKeptOutputs)
+	}
+	var lines []string
+	for _, text := range []string{output.Stdout, output.Stderr} {
+		if strings.TrimSpace(text) == "" {
+			continue
+		}
+		for _, line := range strings.Split(strings.TrimRight(text, "\n"), "\n") {
+			if text == output.Stderr && output.ExitCode != 0 {
+				line = ui.Error(line)
+			}
+			lines = append(lines, line)
+		}
+	}
+	printCommandFrame(os.Stdout, output.Command, lines, output.ExitCode, output.ID, 0)
+	return nil
+}
+
+func bashCommand(call *types.ToolCall) (string, bool) {
+	if call == nil || call.Name != "bash" {
+		return "", false
+	}
+	input, _ := call.Input.(map[string]interface{})
+	command, _ := input["command"].(string)
+	return command, true
+}
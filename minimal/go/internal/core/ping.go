@@ -0,0 +1,43 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+
+	"minimal-go/internal/config"
+	"minimal-go/internal/core/providers"
+	"minimal-go/internal/ui"
+)
+
+func (a *agent) GetLlmConfig() config.ResolvedLlmConfig {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.llmConfig
+}
+
+func pingProvider(cfg config.ResolvedLlmConfig) error {
+	label := fmt.Sprintf("%s (%s)", cfg.Provider, cfg.Model)
+	if cfg.ReplayDir != "" {
+		fmt.Println(ui.Muted("[ping] " + label + " replaying recorded traffic; not contacted"))
+		return nil
+	}
+	latency, err := providers.Ping(cfg)
+	if err == nil {
+		fmt.Println(ui.Success("✓ ") + ui.Muted(fmt.Sprintf("%s reachable in %dms", label, latency.Milliseconds())))
+		return nil
+	}
+
+	var statusErr *providers.StatusError
+	if errors.As(err, &statusErr) && statusErr.Unauthorized() {
+		hint := "check llm.variants." + cfg.Provider + ".api_key"
+		if cfg.APIKeyEnv != "" {
+			hint = "check $" + cfg.APIKeyEnv
+		}
+		printError(fmt.Sprintf("%s rejected the API key: %v", label, err))
+		fmt.Println(ui.Muted("  " + hint + " (or run mini-go doctor)"))
+		return err
+	}
+	fmt.Println(ui.Warning(fmt.Sprintf("⚠ %s unavailable after %dms: %v", label, latency.Milliseconds(), err)))
+	fmt.Println(ui.Muted("  requests will likely fail; check base_url and network, then /ping"))
+	return err
+}
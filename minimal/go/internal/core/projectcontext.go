@@ -0,0 +1,165 @@
+package core
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"minimal-go/internal/prompt"
+	"minimal-go/internal/ui"
+)
+
+const (
+	agentsFile             = "AGENTS.md"
+	contextSectionPrefix   = "context:"
+	maxContextFiles        = 20
+	maxContextFileBytes    = 16 * 1024
+	maxContextSearchDepth  = 4
+	contextTruncatedMarker = "\n\n… (truncated; read the file for the rest)"
+)
+
+// Read only at the workspace root, and only when it has no AGENTS.md: these
+// are written for humans and would mostly repeat a dedicated agent file.
+var rootContextFallbacks = []string{"AGENT.md", "CONTRIBUTING.md"}
+
+const contextIntro = `## Project context
+
+Instructions from the repository's agent files. A file in a subdirectory applies to work under that directory and takes precedence there over the files above it.`
+
+type contextFile struct {
+	path    string
+	content string
+}
+
+// addProjectContext adds one section per discovered file so each shows up
+// separately in the prompt breakdown and /context. They keep the discovery
+// order behind a shared heading, so the text is stable between sessions.
+func addProjectContext(builder *prompt.Builder, workspaceRoot string) {
+	files, err := discoverContextFiles(workspaceRoot)
+	if err != nil {
+		fmt.Println(ui.Muted(fmt.Sprintf("[prompt] project context skipped: %v", err)))
+		return
+	}
+	if len(files) == 0 {
+		return
+	}
+	builder.Add(prompt.OrderContext, "context", contextIntro)
+	for i, file := range files {
+		heading := "### " + file.path
+		if dir := filepath.Dir(file.path); dir != "." {
+			heading += " (applies to " + filepath.ToSlash(dir) + "/)"
+		}
+		builder.Add(prompt.OrderContext+1+i, contextSectionPrefix+file.path, heading+"\n\n"+file.content)
+	}
+}
+
+func discoverContextFiles(workspaceRoot string) ([]contextFile, error) {
+	var paths []string
+	err := filepath.WalkDir(workspaceRoot, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			if path == workspaceRoot {
+				return err
+			}
+			return nil
+		}
+		rel, _ := filepath.Rel(workspaceRoot, path)
+		if entry.IsDir() {
+			name := entry.Name()
+			if path != workspaceRoot && (strings.HasPrefix(name, ".") || workspaceMapSkip[name] || strings.Count(rel, string(filepath.Separator)) >= maxContextSearchDepth) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if entry.Name() == agentsFile {
+			paths = append(paths, rel)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	// Shallower files first: general instructions before the ones that
+	// refine them for a package.
+	sort.Slice(paths, func(i, j int) bool {
+		di, dj := strings.Count(paths[i], string(filepath.Separator)), strings.Count(paths[j], string(filepath.Separator))
+		if di != dj {
+			return di < dj
+		}
+		return paths[i] < paths[j]
+	})
+	if len(paths) > maxContextFiles {
+		paths = paths[:maxContextFiles]
+	}
+	if len(paths) == 0 || paths[0] != agentsFile {
+		for _, name := range rootContextFallbacks {
+			if _, err := os.Stat(filepath.Join(workspaceRoot, name)); err == nil {
+				paths = append([]string{name}, paths...)
+				break
+			}
+		}
+	}
+
+	files := make([]contextFile, 0, len(paths))
+	for _, rel := range paths {
+		data, err := os.ReadFile(filepath.Join(workspaceRoot, rel))
+		if err != nil {
+			continue
+		}
+		content := strings.TrimSpace(string(data))
+		if len(content) > maxContextFileBytes {
+			content = strings.TrimSpace(strings.ToValidUTF8(content[:maxContextFileBytes], "")) + contextTruncatedMarker
+		}
+		if content != "" {
+			files = append(files, contextFile{path: filepath.ToSlash(rel), content: content})
+		}
+	}
+	return files, nil
+}
+
+func (s *replState) handleContextCommand(args string) error {
+	if s.prompt == nil {
+		return nil
+	}
+	var loaded []prompt.Section
+	for _, section := range s.prompt.Sections() {
+		if section.Name == "project" || strings.HasPrefix(section.Name, contextSectionPrefix) {
+			loaded = append(loaded, section)
+		}
+	}
+	if len(loaded) == 0 {
+		fmt.Println(ui.Muted("No project context loaded. Add an " + agentsFile + " to the workspace (or to a package directory) or " + projectPromptFile + "."))
+		return nil
+	}
+
+	if args == "full" {
+		for _, section := range loaded {
+			fmt.Println(ui.Bold(contextSource(section.Name)))
+			fmt.Println(section.Content)
+			fmt.Println("")
+		}
+		return nil
+	}
+	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "FILE\tTOKENS\tNOTE")
+	for _, section := range loaded {
+		note := ""
+		if strings.HasSuffix(section.Content, strings.TrimSpace(contextTruncatedMarker)) {
+			note = fmt.Sprintf("truncated to %d KB", maxContextFileBytes/1024)
+		}
+		fmt.Fprintf(writer, "%s\t%d\t%s\n", ui.Cyan(contextSource(section.Name)), estimateTextTokens(section.Content), ui.Muted(note))
+	}
+	writer.Flush()
+	fmt.Println(ui.Muted("/context full prints the text as sent to the model."))
+	return nil
+}
+
+func contextSource(name string) string {
+	if name == "project" {
+		return projectPromptFile
+	}
+	return strings.TrimPrefix(name, contextSectionPrefix)
+}
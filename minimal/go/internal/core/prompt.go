@@ -0,0 +1,126 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"minimal-go/internal/config"
+	"minimal-go/internal/memory"
+	"minimal-go/internal/prompt"
+	"minimal-go/internal/ui"
+)
+
+const (
+	maxWorkspaceMapEntries = 60
+	projectPromptFile      = ".minimal/system.md"
+)
+
+var workspaceMapSkip = map[string]bool{
+	"node_modules": true,
+	"vendor":       true,
+	"dist":         true,
+	"build":        true,
+	"target":       true,
+	"__pycache__":  true,
+}
+
+const toolGuidance = `## Tools
+
+- Find code with search_code when you do not know where it lives, then read files with read_file, paging through large ones with offset and limit.
+- Change files with write_file or edit_file rather than shell redirection; each change is shown to the user as a diff.
+- After editing, call diagnostics to check the files you touched, and run_tests to run the test suite.
+- Use remember only for durable facts worth keeping across sessions.
+- When a decision is the user's to make, call ask_user with the likely answers as options instead of ending your turn with a question.`
+
+type promptSource struct {
+	order int
+	name  string
+	load  func(workspaceRoot string) (string, error)
+}
+
+var promptSources = []promptSource{
+	{prompt.OrderTools, "tools", func(string) (string, error) { return toolGuidance, nil }},
+	{prompt.OrderWorkspace, "workspace", workspaceMap},
+	{prompt.OrderToolchain, "toolchain", toolchainSection},
+	{prompt.OrderProject, "project", projectPrompt},
+	{prompt.OrderMemory, "memory", memorySection},
+}
+
+func BuildSystemPrompt(base string, workspaceRoot string) string {
+	return buildPromptSections(base, workspaceRoot).Build()
+}
+
+func buildPromptSections(base string, workspaceRoot string) *prompt.Builder {
+	builder := &prompt.Builder{}
+	builder.Add(prompt.OrderBase, "base", base)
+	for _, source := range promptSources {
+		content, err := source.load(workspaceRoot)
+		if err != nil {
+			fmt.Println(ui.Muted(fmt.Sprintf("[prompt] %s section skipped: %v", source.name, err)))
+			continue
+		}
+		builder.Add(source.order, source.name, content)
+	}
+	addProjectContext(builder, workspaceRoot)
+	return builder
+}
+
+func workspaceMap(workspaceRoot string) (string, error) {
+	entries, err := os.ReadDir(workspaceRoot)
+	if err != nil {
+		return "", err
+	}
+	var names []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if strings.HasPrefix(name, ".") || workspaceMapSkip[name] {
+			continue
+		}
+		if entry.IsDir() {
+			name += "/"
+		}
+		names = append(names, name)
+	}
+	if len(names) == 0 {
+		return "", nil
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	b.WriteString("## Workspace\n\nTop-level entries of " + filepath.Base(workspaceRoot) + ":\n\n")
+	for i, name := range names {
+		if i == maxWorkspaceMapEntries {
+			fmt.Fprintf(&b, "- … %d more\n", len(names)-i)
+			break
+		}
+		b.WriteString("- " + name + "\n")
+	}
+	return b.String(), nil
+}
+
+func projectPrompt(workspaceRoot string) (string, error) {
+	data, err := os.ReadFile(filepath.Join(workspaceRoot, projectPromptFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	content := strings.TrimSpace(string(data))
+	if content == "" {
+		return "", nil
+	}
+	return "## Project instructions\n\n" + content, nil
+}
+
+func memorySection(string) (string, error) {
+	savedMemory, err := memory.Load()
+	if err != nil {
+		return "", fmt.Errorf("could not read %s: %w", config.MemoryPath, err)
+	}
+	return memory.Section(savedMemory), nil
+}
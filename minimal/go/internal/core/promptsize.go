@@ -0,0 +1,179 @@
+package core
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"minimal-go/internal/core/providers"
+	"minimal-go/internal/prompt"
+	"minimal-go/internal/types"
+	"minimal-go/internal/ui"
+)
+
+const (
+	summarizeMaxTokens = 1024
+	trimmedMarker      = "\n- … (trimmed to fit context.systemPromptBudget)"
+)
+
+// Sections the agent can work without: the file map can be rediscovered with
+// ls, and memory is a convenience. Trimmed in this order.
+var lowPriorityPromptSections = []string{"workspace", "memory"}
+
+func promptTokens(builder *prompt.Builder) int {
+	return estimateTextTokens(builder.Build())
+}
+
+func isLowPriority(name string) bool {
+	for _, low := range lowPriorityPromptSections {
+		if low == name {
+			return true
+		}
+	}
+	return false
+}
+
+func printPromptBreakdown(builder *prompt.Builder, budget int) {
+	total := promptTokens(builder)
+	fmt.Println(ui.Warning(fmt.Sprintf("⚠ The system prompt is ~%d tokens (context.systemPromptBudget: %d) and is sent with every request.", total, budget)))
+	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for _, section := range builder.Sections() {
+		tokens := estimateTextTokens(section.Content)
+		note := ""
+		if isLowPriority(section.Name) {
+			note = "low priority"
+		}
+		fmt.Fprintf(writer, "  %s\t%d\t%s\t%s\n", section.Name, tokens, percentOf(tokens, total), ui.Muted(note))
+	}
+	writer.Flush()
+}
+
+func trimPromptSections(builder *prompt.Builder, budget int) *prompt.Builder {
+	trimmed := &prompt.Builder{}
+	for _, section := range builder.Sections() {
+		trimmed.Add(section.Order, section.Name, section.Content)
+	}
+	for _, name := range lowPriorityPromptSections {
+		over := promptTokens(trimmed) - budget
+		if over <= 0 {
+			break
+		}
+		for _, section := range trimmed.Sections() {
+			if section.Name != name {
+				continue
+			}
+			keep := estimateTextTokens(section.Content) - over - estimateTextTokens(trimmedMarker)
+			content := truncateToTokens(section.Content, keep)
+			if content == "" {
+				trimmed.Remove(name)
+			} else {
+				trimmed.Add(section.Order, name, content+trimmedMarker)
+			}
+		}
+	}
+	return trimmed
+}
+
+func truncateToTokens(content string, tokens int) string {
+	if tokens <= 0 {
+		return ""
+	}
+	var kept []string
+	used := 0
+	for _, line := range strings.Split(content, "\n") {
+		used += estimateTextTokens(line + "\n")
+		if used > tokens {
+			break
+		}
+		kept = append(kept, line)
+	}
+	// A heading with nothing under it is not worth keeping.
+	if len(kept) <= 2 {
+		return ""
+	}
+	return strings.Join(kept, "\n")
+}
+
+func (a *agent) SummarizeText(text string, maxTokens int) (string, error) {
+	response, err := a.provider.CreateChatCompletion(providers.CreateChatParams{
+		Model:     a.llmConfig.Model,
+		MaxTokens: summarizeMaxTokens,
+		Messages: []types.Message{
+			{Role: types.RoleSystem, Content: fmt.Sprintf("Condense the user's text to at most %d tokens. Keep every instruction, name, path and fact that could matter to a coding assistant; drop repetition and examples. Keep any leading Markdown heading. Reply with the condensed text only.", maxTokens)},
+			{Role: types.RoleUser, Content: text},
+		},
+	})
+	if err != nil {
+		return "", mapProviderError(err)
+	}
+	if response.Usage != nil {
+		a.recordUsage(response.Usage)
+	}
+	return strings.TrimSpace(response.Message.Content), nil
+}
+
+func (a *agent) SetSystemPrompt(systemPrompt string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if len(a.messages) > 0 && a.messages[0].Role == types.RoleSystem {
+		a.messages[0].Content = systemPrompt
+	}
+}
+
+func offerPromptTrim(reader *bufio.Reader, agent Agent, builder *prompt.Builder, budget int) {
+	if builder == nil || promptTokens(builder) <= budget {
+		return
+	}
+	fmt.Println(ui.Muted("Reduce it for this session?"))
+	fmt.Println(ui.Muted("  [t] Truncate low-priority sections  [s] Summarize them with the model  [enter] Keep as is"))
+	fmt.Print(ui.Prompt("> "))
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		return
+	}
+
+	var reduced *prompt.Builder
+	switch strings.ToLower(strings.TrimSpace(line)) {
+	case "t":
+		reduced = trimPromptSections(builder, budget)
+	case "s":
+		reduced = summarizePromptSections(agent, builder, budget)
+	default:
+		return
+	}
+	agent.SetSystemPrompt(reduced.Build())
+	printSuccess(fmt.Sprintf("✓ System prompt reduced from ~%d to ~%d tokens for this session.", promptTokens(builder), promptTokens(reduced)))
+}
+
+func summarizePromptSections(agent Agent, builder *prompt.Builder, budget int) *prompt.Builder {
+	summarized := &prompt.Builder{}
+	for _, section := range builder.Sections() {
+		summarized.Add(section.Order, section.Name, section.Content)
+	}
+	for _, section := range builder.Sections() {
+		over := promptTokens(summarized) - budget
+		if over <= 0 || !isLowPriority(section.Name) {
+			continue
+		}
+		target := estimateTextTokens(section.Content) - over
+		if target < 50 {
+			target = 50
+		}
+		stop := ui.StartSpinner("summarizing " + section.Name)
+		summary, err := agent.SummarizeText(section.Content, target)
+		stop.Stop()
+		if err != nil || summary == "" {
+			reason := "empty reply"
+			if err != nil {
+				reason = err.Error()
+			}
+			fmt.Println(ui.Warning(fmt.Sprintf("Could not summarize %s (%s); truncating it instead.", section.Name, reason)))
+			summarized = trimPromptSections(summarized, budget)
+			continue
+		}
+		summarized.Add(section.Order, section.Name, summary)
+	}
+	return summarized
+}
@@ -0,0 +1,107 @@
+package core
+
+import (
+	"time"
+
+	"minimal-go/internal/types"
+)
+
+// ProtocolVersion is bumped on any incompatible change to ProtocolEvent.
+// Adding event types or optional fields does not change it; clients must
+// ignore types and fields they do not know.
+const ProtocolVersion = 1
+
+const (
+	ProtocolTurnStarted    = "turn_started"
+	ProtocolAssistantDelta = "assistant_delta"
+	ProtocolThinkingDelta  = "thinking_delta"
+	ProtocolToolCall       = "tool_call_proposed"
+	ProtocolApproval       = "approval_required"
+	ProtocolToolResult     = "tool_result"
+	ProtocolUsage          = "usage"
+	ProtocolTurnCompleted  = "turn_completed"
+	ProtocolError          = "error"
+)
+
+var ProtocolEventTypes = []string{
+	ProtocolTurnStarted, ProtocolAssistantDelta, ProtocolThinkingDelta, ProtocolToolCall,
+	ProtocolApproval, ProtocolToolResult, ProtocolUsage, ProtocolTurnCompleted, ProtocolError,
+}
+
+type ProtocolEvent struct {
+	Version int       `json:"v"`
+	Seq     int64     `json:"seq"`
+	Type    string    `json:"type"`
+	Session string    `json:"session,omitempty"`
+	Turn    int       `json:"turn,omitempty"`
+	Time    time.Time `json:"time"`
+
+	// assistant_delta, thinking_delta
+	Delta string `json:"delta,omitempty"`
+	// tool_call_proposed, approval_required, tool_result
+	CallID string      `json:"callId,omitempty"`
+	Tool   string      `json:"tool,omitempty"`
+	Input  interface{} `json:"input,omitempty"`
+	// approval_required
+	Command    string   `json:"command,omitempty"`
+	Files      []string `json:"files,omitempty"`
+	Risk       string   `json:"risk,omitempty"`
+	RiskLabels []string `json:"riskLabels,omitempty"`
+	// tool_result
+	Output string `json:"output,omitempty"`
+	// usage, turn_completed
+	Usage        *types.Usage    `json:"usage,omitempty"`
+	SessionUsage *ProtocolTokens `json:"sessionUsage,omitempty"`
+	// error
+	Message string `json:"message,omitempty"`
+}
+
+type ProtocolTokens struct {
+	PromptTokens     int `json:"promptTokens"`
+	CompletionTokens int `json:"completionTokens"`
+	TotalTokens      int `json:"totalTokens"`
+}
+
+// ToProtocolEvent converts an agent event to its wire form. Events that only
+// matter to the terminal UI (spinner, notices, streamed command output)
+// report false. Providers answer in one piece, so each assistant or thinking
+// message arrives as a single delta.
+func ToProtocolEvent(event Event) (ProtocolEvent, bool) {
+	wire := ProtocolEvent{Version: ProtocolVersion, Turn: event.Turn, Time: time.Now().UTC()}
+	switch event.Kind {
+	case EventTurnStarted:
+		wire.Type = ProtocolTurnStarted
+	case EventAssistant:
+		wire.Type, wire.Delta = ProtocolAssistantDelta, event.Text
+	case EventThinking:
+		wire.Type, wire.Delta = ProtocolThinkingDelta, event.Text
+	case EventToolCall:
+		wire.Type, wire.CallID = ProtocolToolCall, event.CallID
+		if event.ToolCall != nil {
+			wire.Tool, wire.Input = event.ToolCall.Name, event.ToolCall.Input
+		}
+	case EventApproval:
+		wire.Type, wire.CallID, wire.Command = ProtocolApproval, event.CallID, event.Text
+		for _, change := range event.Changes {
+			wire.Files = append(wire.Files, change.Path)
+		}
+		if !event.Risk.Empty() {
+			wire.Risk, wire.RiskLabels = event.Risk.Risk.String(), event.Risk.Labels
+		}
+	case EventToolResult:
+		wire.Type, wire.CallID, wire.Output = ProtocolToolResult, event.CallID, event.Text
+	case EventUsage:
+		wire.Type, wire.Usage, wire.SessionUsage = ProtocolUsage, event.Usage, protocolUsage(event.Session)
+	case EventTurnCompleted:
+		wire.Type, wire.SessionUsage = ProtocolTurnCompleted, protocolUsage(event.Session)
+	case EventError:
+		wire.Type, wire.Message = ProtocolError, event.Text
+	default:
+		return wire, false
+	}
+	return wire, true
+}
+
+func protocolUsage(usage TokenUsage) *ProtocolTokens {
+	return &ProtocolTokens{PromptTokens: usage.Prompt, CompletionTokens: usage.Completion, TotalTokens: usage.Total}
+}
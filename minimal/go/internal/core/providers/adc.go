@@ -0,0 +1,289 @@
+package providers
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	cloudPlatformScope = "https://www.googleapis.com/auth/cloud-platform"
+	googleTokenURL     = "https://oauth2.googleapis.com/token"
+	metadataTimeout    = 2 * time.Second
+	// Refresh this long before the token runs out, so a slow request does
+	// not reach Google with an expired one.
+	tokenRefreshMargin = time.Minute
+)
+
+var errNoCredentials = errors.New("no Google credentials found: run `gcloud auth application-default login` or set GOOGLE_APPLICATION_CREDENTIALS to a service account key")
+
+// googleCredentials is a credentials file as written by gcloud or downloaded
+// for a service account.
+type googleCredentials struct {
+	Type         string `json:"type"`
+	ClientEmail  string `json:"client_email"`
+	PrivateKey   string `json:"private_key"`
+	TokenURI     string `json:"token_uri"`
+	ClientID     string `json:"client_id"`
+	ClientSecret string `json:"client_secret"`
+	RefreshToken string `json:"refresh_token"`
+	QuotaProject string `json:"quota_project_id"`
+	path         string
+}
+
+type googleToken struct {
+	AccessToken string `json:"access_token"`
+	ExpiresIn   int    `json:"expires_in"`
+	Error       string `json:"error"`
+	Description string `json:"error_description"`
+}
+
+// adcTokenSource finds Application Default Credentials the way the Google
+// client libraries do (GOOGLE_APPLICATION_CREDENTIALS, then gcloud's
+// well-known file, then the metadata server on GCP) and caches the access
+// token until shortly before it expires. One source serves every Vertex
+// variant, since they all use the same credentials.
+type adcTokenSource struct {
+	mu          sync.Mutex
+	credentials *googleCredentials
+	token       string
+	expiry      time.Time
+}
+
+var googleADC = &adcTokenSource{}
+
+// authorize sets the bearer token and, for user credentials, the quota
+// project Google bills the request to. client fetches the token; it must not
+// be a recording one, or the credentials would end up on disk.
+func (s *adcTokenSource) authorize(req *http.Request, client *http.Client) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	token, err := s.tokenLocked(client)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	if s.credentials != nil && s.credentials.QuotaProject != "" {
+		req.Header.Set("x-goog-user-project", s.credentials.QuotaProject)
+	}
+	return nil
+}
+
+func (s *adcTokenSource) Token(client *http.Client) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.tokenLocked(client)
+}
+
+func (s *adcTokenSource) tokenLocked(client *http.Client) (string, error) {
+	if s.token != "" && time.Now().Add(tokenRefreshMargin).Before(s.expiry) {
+		return s.token, nil
+	}
+
+	credentials, err := findGoogleCredentials()
+	var token googleToken
+	switch {
+	case err == nil:
+		s.credentials = credentials
+		token, err = credentials.exchange(client)
+	case errors.Is(err, errNoCredentials):
+		token, err = metadataToken()
+	}
+	if err != nil {
+		return "", err
+	}
+	s.token = token.AccessToken
+	s.expiry = time.Now().Add(time.Duration(token.ExpiresIn) * time.Second)
+	return s.token, nil
+}
+
+// DescribeGoogleCredentials names the credentials Vertex variants will use,
+// without contacting Google.
+func DescribeGoogleCredentials() (string, error) {
+	credentials, err := findGoogleCredentials()
+	if errors.Is(err, errNoCredentials) {
+		if os.Getenv("GCE_METADATA_HOST") != "" || onGCE() {
+			return "metadata server (running on GCP)", nil
+		}
+		return "", err
+	}
+	if err != nil {
+		return "", err
+	}
+	who := credentials.ClientEmail
+	if who == "" {
+		who = "user credentials"
+	}
+	return fmt.Sprintf("%s %s (%s)", strings.ReplaceAll(credentials.Type, "_", " "), who, credentials.path), nil
+}
+
+func findGoogleCredentials() (*googleCredentials, error) {
+	path := os.Getenv("GOOGLE_APPLICATION_CREDENTIALS")
+	if path == "" {
+		path = wellKnownCredentialsPath()
+		if _, err := os.Stat(path); err != nil {
+			return nil, errNoCredentials
+		}
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("google credentials: %w", err)
+	}
+	var credentials googleCredentials
+	if err := json.Unmarshal(data, &credentials); err != nil {
+		return nil, fmt.Errorf("google credentials %s: %w", path, err)
+	}
+	credentials.path = path
+	switch credentials.Type {
+	case "service_account", "authorized_user":
+		return &credentials, nil
+	}
+	return nil, fmt.Errorf("google credentials %s: type %q is not supported (use a service account key or `gcloud auth application-default login`)", path, credentials.Type)
+}
+
+func wellKnownCredentialsPath() string {
+	if dir := os.Getenv("CLOUDSDK_CONFIG"); dir != "" {
+		return filepath.Join(dir, "application_default_credentials.json")
+	}
+	if runtime.GOOS == "windows" {
+		return filepath.Join(os.Getenv("APPDATA"), "gcloud", "application_default_credentials.json")
+	}
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".config", "gcloud", "application_default_credentials.json")
+}
+
+func (c *googleCredentials) exchange(client *http.Client) (googleToken, error) {
+	form := url.Values{}
+	tokenURL := googleTokenURL
+	if c.Type == "authorized_user" {
+		form.Set("grant_type", "refresh_token")
+		form.Set("client_id", c.ClientID)
+		form.Set("client_secret", c.ClientSecret)
+		form.Set("refresh_token", c.RefreshToken)
+	} else {
+		if c.TokenURI != "" {
+			tokenURL = c.TokenURI
+		}
+		assertion, err := c.signedAssertion(tokenURL)
+		if err != nil {
+			return googleToken{}, err
+		}
+		form.Set("grant_type", "urn:ietf:params:oauth:grant-type:jwt-bearer")
+		form.Set("assertion", assertion)
+	}
+
+	resp, err := client.PostForm(tokenURL, form)
+	if err != nil {
+		return googleToken{}, fmt.Errorf("google token: %w", err)
+	}
+	return decodeGoogleToken(resp)
+}
+
+// signedAssertion is the RS256 JWT a service account trades for an access
+// token.
+func (c *googleCredentials) signedAssertion(audience string) (string, error) {
+	block, _ := pem.Decode([]byte(c.PrivateKey))
+	if block == nil {
+		return "", fmt.Errorf("google credentials %s: private_key is not PEM", c.path)
+	}
+	var key *rsa.PrivateKey
+	if parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes); err == nil {
+		rsaKey, ok := parsed.(*rsa.PrivateKey)
+		if !ok {
+			return "", fmt.Errorf("google credentials %s: private_key is not an RSA key", c.path)
+		}
+		key = rsaKey
+	} else if key, err = x509.ParsePKCS1PrivateKey(block.Bytes); err != nil {
+		return "", fmt.Errorf("google credentials %s: %w", c.path, err)
+	}
+
+	now := time.Now()
+	header, _ := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT"})
+	claims, _ := json.Marshal(map[string]interface{}{
+		"iss":   c.ClientEmail,
+		"scope": cloudPlatformScope,
+		"aud":   audience,
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Hour).Unix(),
+	})
+	unsigned := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(claims)
+	digest := sha256.Sum256([]byte(unsigned))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", err
+	}
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+func metadataHost() string {
+	if host := os.Getenv("GCE_METADATA_HOST"); host != "" {
+		return host
+	}
+	return "metadata.google.internal"
+}
+
+func onGCE() bool {
+	client := &http.Client{Timeout: metadataTimeout}
+	req, err := http.NewRequest(http.MethodGet, "http://"+metadataHost()+"/computeMetadata/v1/", nil)
+	if err != nil {
+		return false
+	}
+	req.Header.Set("Metadata-Flavor", "Google")
+	resp, err := client.Do(req)
+	if err != nil {
+		return false
+	}
+	resp.Body.Close()
+	return resp.Header.Get("Metadata-Flavor") == "Google"
+}
+
+func metadataToken() (googleToken, error) {
+	client := &http.Client{Timeout: metadataTimeout}
+	req, err := http.NewRequest(http.MethodGet, "http://"+metadataHost()+"/computeMetadata/v1/instance/service-accounts/default/token", nil)
+	if err != nil {
+		return googleToken{}, err
+	}
+	req.Header.Set("Metadata-Flavor", "Google")
+	resp, err := client.Do(req)
+	if err != nil {
+		return googleToken{}, errNoCredentials
+	}
+	return decodeGoogleToken(resp)
+}
+
+func decodeGoogleToken(resp *http.Response) (googleToken, error) {
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return googleToken{}, err
+	}
+	var token googleToken
+	_ = json.Unmarshal(body, &token)
+	if resp.StatusCode >= 300 || token.AccessToken == "" {
+		message := token.Description
+		if message == "" {
+			message = token.Error
+		}
+		if message == "" {
+			message = fmt.Sprintf("status %d", resp.StatusCode)
+		}
+		return googleToken{}, fmt.Errorf("google token: %s", message)
+	}
+	return token, nil
+}
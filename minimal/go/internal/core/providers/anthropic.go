@@ -19,6 +19,9 @@ type anthropicProvider struct {
 	apiKey       string
 	baseURL      string
 	providerName string
+	extras       requestExtras
+	// vertex is set when the model is served through Vertex AI.
+	vertex *vertexProvider
 }
 
 type anthropicTextBlock struct {
@@ -30,9 +33,16 @@ type anthropicTextBlock struct {
 	Name         string                 `json:"name,omitempty"`
 	Input        interface{}            `json:"input,omitempty"`
 	Content      interface{}            `json:"content,omitempty"`
+	Source       *anthropicImageSource  `json:"source,omitempty"`
 	CacheControl map[string]interface{} `json:"cache_control,omitempty"`
 }
 
+type anthropicImageSource struct {
+	Type      string `json:"type"`
+	MediaType string `json:"media_type"`
+	Data      string `json:"data"`
+}
+
 type anthropicMessage struct {
 	Role    string      `json:"role"`
 	Content interface{} `json:"content"`
@@ -45,21 +55,26 @@ type anthropicTool struct {
 }
 
 type anthropicRequest struct {
-	Model       string               `json:"model"`
-	MaxTokens   int                  `json:"max_tokens"`
-	Temperature float64              `json:"temperature"`
-	System      []anthropicTextBlock `json:"system,omitempty"`
-	Messages    []anthropicMessage   `json:"messages"`
-	Tools       []anthropicTool      `json:"tools,omitempty"`
-	ToolChoice  map[string]string    `json:"tool_choice,omitempty"`
-	Stream      bool                 `json:"stream"`
+	Model            string                 `json:"model,omitempty"`
+	AnthropicVersion string                 `json:"anthropic_version,omitempty"`
+	MaxTokens        int                    `json:"max_tokens"`
+	Temperature      float64                `json:"temperature"`
+	TopP             *float64               `json:"top_p,omitempty"`
+	TopK             *int                   `json:"top_k,omitempty"`
+	StopSequences    []string               `json:"stop_sequences,omitempty"`
+	System           []anthropicTextBlock   `json:"system,omitempty"`
+	Messages         []anthropicMessage     `json:"messages"`
+	Tools            []anthropicTool        `json:"tools,omitempty"`
+	ToolChoice       map[string]interface{} `json:"tool_choice,omitempty"`
+	Stream           bool                   `json:"stream"`
 }
 
 type anthropicResponse struct {
 	Content []anthropicTextBlock `json:"content"`
 	Usage   *struct {
-		InputTokens  int `json:"input_tokens"`
-		OutputTokens int `json:"output_tokens"`
+		InputTokens          int `json:"input_tokens"`
+		OutputTokens         int `json:"output_tokens"`
+		CacheReadInputTokens int `json:"cache_read_input_tokens"`
 	} `json:"usage"`
 	Error *struct {
 		Message string `json:"message"`
@@ -68,42 +83,62 @@ type anthropicResponse struct {
 
 func NewAnthropicProvider(cfg config.ResolvedLlmConfig) ChatProvider {
 	return &anthropicProvider{
-		client:       &http.Client{Timeout: 60 * time.Second},
+		client:       newHTTPClient(cfg),
 		apiKey:       cfg.APIKey,
 		baseURL:      normalizeAnthropicBaseURL(cfg.BaseURL),
 		providerName: cfg.Provider,
+		extras:       newRequestExtras(cfg),
 	}
 }
 
 func (p *anthropicProvider) CreateChatCompletion(params CreateChatParams) (ChatResponse, error) {
+	started := time.Now()
 	systemBlocks, messages := toAnthropicMessages(params.Messages, p.providerName != "minimax")
-	toolsSummary, _ := json.MarshalIndent(params.Tools, "", "  ")
-	systemBlocks = append(systemBlocks, anthropicTextBlock{
-		Type: "text",
-		Text: formatToolsSummary(len(systemBlocks) > 0, toolsSummary),
-		CacheControl: map[string]interface{}{
-			"type": "ephemeral",
-		},
-	})
+	if len(params.Tools) > 0 {
+		toolsSummary, _ := json.MarshalIndent(params.Tools, "", "  ")
+		systemBlocks = append(systemBlocks, anthropicTextBlock{
+			Type: "text",
+			Text: formatToolsSummary(len(systemBlocks) > 0, toolsSummary),
+			CacheControl: map[string]interface{}{
+				"type": "ephemeral",
+			},
+		})
+	}
+	if params.Format != nil {
+		systemBlocks = append(systemBlocks, anthropicTextBlock{Type: "text", Text: params.Format.instruction()})
+	}
 
 	requestParams := anthropicRequest{
-		Model:       params.Model,
-		MaxTokens:   params.MaxTokens,
-		Temperature: params.Temperature,
-		System:      systemBlocks,
-		Messages:    messages,
-		Tools:       toAnthropicTools(params.Tools),
-		ToolChoice:  map[string]string{"type": "auto"},
-		Stream:      false,
+		Model:         params.Model,
+		MaxTokens:     params.MaxTokens,
+		Temperature:   params.Temperature,
+		TopP:          params.Sampling.TopP,
+		TopK:          params.Sampling.TopK,
+		StopSequences: params.Sampling.Stop,
+		System:        systemBlocks,
+		Messages:      messages,
+		Tools:         toAnthropicTools(params.Tools),
+		Stream:        false,
+	}
+	if len(params.Tools) > 0 {
+		requestParams.ToolChoice = anthropicToolChoice(params.ToolChoice, params.Parallel)
+	}
+	if p.vertex != nil {
+		// Vertex takes the model from the URL and the version from the body.
+		requestParams.Model = ""
+		requestParams.AnthropicVersion = vertexAnthropicVersion
 	}
 
 	payload, err := json.Marshal(requestParams)
 	if err != nil {
 		return ChatResponse{}, err
 	}
+	timing := RequestTiming{Serialize: time.Since(started)}
 
-	endpoint, err := url.JoinPath(p.baseURL, "v1", "messages")
-	if err != nil {
+	var endpoint string
+	if p.vertex != nil {
+		endpoint = p.vertex.endpoint("anthropic", params.Model, "rawPredict")
+	} else if endpoint, err = url.JoinPath(p.baseURL, "v1", "messages"); err != nil {
 		return ChatResponse{}, err
 	}
 
@@ -112,14 +147,21 @@ func (p *anthropicProvider) CreateChatCompletion(params CreateChatParams) (ChatR
 		return ChatResponse{}, err
 	}
 	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("x-api-key", p.apiKey)
-	req.Header.Set("anthropic-version", "2023-06-01")
+	if p.vertex != nil {
+		if err := p.vertex.authorize(req); err != nil {
+			return ChatResponse{}, err
+		}
+	} else {
+		req.Header.Set("x-api-key", p.apiKey)
+		req.Header.Set("anthropic-version", "2023-06-01")
+	}
 
 	if p.providerName == "anthropic" || p.providerName == "minimax" {
 		req.Header.Set("anthropic-beta", "prompt-caching-2024-07-31")
 	}
+	p.extras.apply(req)
 
-	resp, err := p.client.Do(req)
+	resp, err := p.client.Do(traceFirstByte(req, &timing))
 	if err != nil {
 		return ChatResponse{}, err
 	}
@@ -178,6 +220,7 @@ func (p *anthropicProvider) CreateChatCompletion(params CreateChatParams) (ChatR
 			PromptTokens:     decoded.Usage.InputTokens,
 			CompletionTokens: decoded.Usage.OutputTokens,
 			TotalTokens:      decoded.Usage.InputTokens + decoded.Usage.OutputTokens,
+			CacheReadTokens:  decoded.Usage.CacheReadInputTokens,
 		}
 	}
 
@@ -192,6 +235,8 @@ func (p *anthropicProvider) CreateChatCompletion(params CreateChatParams) (ChatR
 		RawUsage:   decoded.Usage,
 		RawRequest: requestParams,
 		RawHeaders: headers,
+		Timing:     timing,
+		RateLimit:  parseRateLimit(resp.Header, time.Now()),
 	}, nil
 }
 
@@ -203,6 +248,24 @@ func normalizeAnthropicBaseURL(baseURL string) string {
 	return trimmed
 }
 
+func anthropicToolChoice(choice string, parallel *bool) map[string]interface{} {
+	var toolChoice map[string]interface{}
+	switch choice {
+	case "", config.ToolChoiceAuto:
+		toolChoice = map[string]interface{}{"type": "auto"}
+	case config.ToolChoiceNone:
+		return map[string]interface{}{"type": "none"}
+	case config.ToolChoiceRequired:
+		toolChoice = map[string]interface{}{"type": "any"}
+	default:
+		toolChoice = map[string]interface{}{"type": "tool", "name": choice}
+	}
+	if parallel != nil && !*parallel {
+		toolChoice["disable_parallel_tool_use"] = true
+	}
+	return toolChoice
+}
+
 func toAnthropicTools(tools []types.Tool) []anthropicTool {
 	result := make([]anthropicTool, 0, len(tools))
 	for _, tool := range tools {
@@ -259,7 +322,7 @@ func toAnthropicMessages(messages []types.Message, enableMessageCache bool) ([]a
 			content := []anthropicTextBlock{
 				{
 					Type:      "tool_result",
-					Content:   message.Content,
+					Content:   anthropicToolResultContent(message),
 					ToolUseID: message.ToolCallID,
 				},
 			}
@@ -306,6 +369,20 @@ func toAnthropicMessages(messages []types.Message, enableMessageCache bool) ([]a
 	return systemBlocks, out
 }
 
+func anthropicToolResultContent(message types.Message) interface{} {
+	if len(message.Images) == 0 {
+		return message.Content
+	}
+	blocks := []anthropicTextBlock{{Type: "text", Text: message.Content}}
+	for _, image := range message.Images {
+		blocks = append(blocks, anthropicTextBlock{
+			Type:   "image",
+			Source: &anthropicImageSource{Type: "base64", MediaType: image.MediaType, Data: image.Data},
+		})
+	}
+	return blocks
+}
+
 func formatToolsSummary(hasSystem bool, toolsSummary []byte) string {
 	if hasSystem {
 		return fmt.Sprintf("\n\n## Available Tools\n\n%s", string(toolsSummary))
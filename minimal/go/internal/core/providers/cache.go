@@ -0,0 +1,95 @@
+package providers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"minimal-go/internal/config"
+	"minimal-go/internal/types"
+	"minimal-go/internal/vault"
+)
+
+type cachingProvider struct {
+	inner    ChatProvider
+	endpoint string
+	ttl      time.Duration
+}
+
+type cacheEntry struct {
+	Created time.Time     `json:"created"`
+	Message types.Message `json:"message"`
+}
+
+// NewCachingProvider answers a request identical to an earlier one (same
+// endpoint, model, messages, tools and parameters) from ~/.minimal/cache
+// while the entry is younger than cfg.Cache.TTL. Cached responses carry no
+// usage, since nothing was billed for them.
+func NewCachingProvider(inner ChatProvider, cfg config.ResolvedLlmConfig) ChatProvider {
+	return &cachingProvider{
+		inner:    inner,
+		endpoint: string(cfg.SchemaType) + " " + cfg.BaseURL,
+		ttl:      time.Duration(cfg.Cache.TTL) * time.Second,
+	}
+}
+
+func (p *cachingProvider) CreateChatCompletion(params CreateChatParams) (ChatResponse, error) {
+	path, err := p.entryPath(params)
+	if err != nil {
+		return p.inner.CreateChatCompletion(params)
+	}
+	if entry, ok := p.lookup(path); ok {
+		return ChatResponse{Message: entry.Message, Cached: true}, nil
+	}
+
+	response, err := p.inner.CreateChatCompletion(params)
+	if err == nil {
+		if storeErr := p.store(path, cacheEntry{Created: time.Now(), Message: response.Message}); storeErr != nil {
+			fmt.Fprintf(os.Stderr, "failed to cache response: %v\n", storeErr)
+		}
+	}
+	return response, err
+}
+
+func (p *cachingProvider) entryPath(params CreateChatParams) (string, error) {
+	key, err := json.Marshal(struct {
+		Endpoint string
+		Params   CreateChatParams
+	}{p.endpoint, params})
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(key)
+	return filepath.Join(config.CacheDir, hex.EncodeToString(sum[:])+".json"), nil
+}
+
+func (p *cachingProvider) lookup(path string) (cacheEntry, bool) {
+	data, err := vault.ReadFile(path)
+	if err != nil {
+		return cacheEntry{}, false
+	}
+	var entry cacheEntry
+	if json.Unmarshal(data, &entry) != nil {
+		return cacheEntry{}, false
+	}
+	if time.Since(entry.Created) > p.ttl {
+		os.Remove(path)
+		return cacheEntry{}, false
+	}
+	return entry, true
+}
+
+func (p *cachingProvider) store(path string, entry cacheEntry) error {
+	if err := os.MkdirAll(config.CacheDir, 0o700); err != nil {
+		return err
+	}
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	return vault.WriteFile(path, data, 0o600)
+}
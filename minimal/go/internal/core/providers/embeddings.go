@@ -0,0 +1,80 @@
+package providers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"minimal-go/internal/config"
+)
+
+const embeddingsTimeout = 20 * time.Second
+
+type embeddingsResponse struct {
+	Data []struct {
+		Index     int       `json:"index"`
+		Embedding []float64 `json:"embedding"`
+	} `json:"data"`
+	Error json.RawMessage `json:"error"`
+}
+
+// Embed returns one vector per input from an OpenAI-compatible
+// /embeddings endpoint, in input order.
+func Embed(cfg config.RetrievalConfig, inputs []string) ([][]float64, error) {
+	if len(inputs) == 0 {
+		return nil, nil
+	}
+	endpoint, err := url.JoinPath(cfg.BaseURL, "embeddings")
+	if err != nil {
+		return nil, err
+	}
+	payload, err := json.Marshal(map[string]interface{}{"model": cfg.Model, "input": inputs})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest(http.MethodPost, endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if key := os.Getenv(cfg.APIKeyEnv); key != "" {
+		req.Header.Set("Authorization", "Bearer "+key)
+	}
+
+	client := &http.Client{Timeout: embeddingsTimeout}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 64<<20))
+	if err != nil {
+		return nil, err
+	}
+
+	var decoded embeddingsResponse
+	decodeErr := json.Unmarshal(body, &decoded)
+	if resp.StatusCode >= 300 {
+		return nil, &StatusError{Code: resp.StatusCode, Message: errorMessage(decoded.Error)}
+	}
+	if decodeErr != nil {
+		return nil, decodeErr
+	}
+	vectors := make([][]float64, len(inputs))
+	for _, item := range decoded.Data {
+		if item.Index >= 0 && item.Index < len(vectors) {
+			vectors[item.Index] = item.Embedding
+		}
+	}
+	for i, vector := range vectors {
+		if len(vector) == 0 {
+			return nil, fmt.Errorf("embeddings response has no vector for input %d", i)
+		}
+	}
+	return vectors, nil
+}
@@ -0,0 +1,122 @@
+package providers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+const (
+	FormatJSONObject = "json_object"
+	FormatJSONSchema = "json_schema"
+)
+
+type ResponseFormat struct {
+	Type   string
+	Name   string
+	Schema map[string]interface{}
+}
+
+var trailingCommaPattern = regexp.MustCompile(`,\s*([}\]])`)
+
+func (f *ResponseFormat) openAI() map[string]interface{} {
+	if f == nil {
+		return nil
+	}
+	if f.Type == FormatJSONSchema && f.Schema != nil {
+		return map[string]interface{}{
+			"type": FormatJSONSchema,
+			"json_schema": map[string]interface{}{
+				"name":   f.name(),
+				"schema": f.Schema,
+				"strict": true,
+			},
+		}
+	}
+	return map[string]interface{}{"type": FormatJSONObject}
+}
+
+func (f *ResponseFormat) instruction() string {
+	text := "Respond with a single JSON object and nothing else: no prose, no code fences."
+	if f.Schema != nil {
+		schema, _ := json.Marshal(f.Schema)
+		text += "\nThe object must match this JSON schema:\n" + string(schema)
+	}
+	return text
+}
+
+func (f *ResponseFormat) name() string {
+	if f.Name != "" {
+		return f.Name
+	}
+	return "response"
+}
+
+func DecodeJSON(content string, format *ResponseFormat, target interface{}) error {
+	var object map[string]interface{}
+	candidate := stripCodeFence(strings.TrimSpace(content))
+	if candidate == "" {
+		return errors.New("model returned an empty response")
+	}
+	if err := json.Unmarshal([]byte(candidate), &object); err != nil {
+		repaired := repairJSON(candidate)
+		if repairErr := json.Unmarshal([]byte(repaired), &object); repairErr != nil {
+			return fmt.Errorf("model did not return valid JSON: %v", err)
+		}
+		candidate = repaired
+	}
+	if format != nil && format.Schema != nil {
+		for _, key := range requiredFields(format.Schema) {
+			if _, present := object[key]; !present {
+				return fmt.Errorf("model JSON is missing required field %q", key)
+			}
+		}
+	}
+	if target == nil {
+		return nil
+	}
+	if err := json.Unmarshal([]byte(candidate), target); err != nil {
+		return fmt.Errorf("model JSON does not match the expected shape: %w", err)
+	}
+	return nil
+}
+
+func requiredFields(schema map[string]interface{}) []string {
+	switch required := schema["required"].(type) {
+	case []string:
+		return required
+	case []interface{}:
+		fields := make([]string, 0, len(required))
+		for _, field := range required {
+			if name, ok := field.(string); ok {
+				fields = append(fields, name)
+			}
+		}
+		return fields
+	}
+	return nil
+}
+
+func stripCodeFence(text string) string {
+	if !strings.HasPrefix(text, "```") {
+		return text
+	}
+	text = strings.TrimPrefix(text, "```")
+	if newline := strings.IndexByte(text, '\n'); newline >= 0 {
+		text = text[newline+1:]
+	}
+	return strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(text), "```"))
+}
+
+func repairJSON(text string) string {
+	start := strings.IndexByte(text, '{')
+	end := strings.LastIndexByte(text, '}')
+	if start >= 0 && end > start {
+		text = text[start : end+1]
+	} else if start >= 0 {
+		text = text[start:] + strings.Repeat("}", strings.Count(text[start:], "{")-strings.Count(text[start:], "}"))
+	}
+	return trailingCommaPattern.ReplaceAllString(text, "$1")
+}
@@ -0,0 +1,154 @@
+package providers
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"minimal-go/internal/types"
+)
+
+const mockExhaustedMessage = "(mock provider has no more scripted responses)"
+
+type FixtureEntry struct {
+	Message types.Message `json:"message"`
+	Usage   *types.Usage  `json:"usage,omitempty"`
+	Error   string        `json:"error,omitempty"`
+}
+
+type mockProvider struct {
+	mu          sync.Mutex
+	entries     []FixtureEntry
+	next        int
+	fixturePath string
+	loaded      bool
+}
+
+func NewMockProvider(responses []types.Message) ChatProvider {
+	entries := make([]FixtureEntry, 0, len(responses))
+	for _, response := range responses {
+		entries = append(entries, FixtureEntry{Message: response})
+	}
+	return &mockProvider{entries: entries, loaded: true}
+}
+
+func NewFixtureProvider(path string) ChatProvider {
+	return &mockProvider{fixturePath: path}
+}
+
+func LoadFixture(path string) ([]FixtureEntry, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var entries []FixtureEntry
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "//") {
+			continue
+		}
+		var entry FixtureEntry
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, lineNumber, err)
+		}
+		entries = append(entries, entry)
+	}
+	return entries, scanner.Err()
+}
+
+func (p *mockProvider) CreateChatCompletion(params CreateChatParams) (ChatResponse, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if !p.loaded {
+		if p.fixturePath == "" {
+			return ChatResponse{}, errors.New("mock provider requires a fixture path")
+		}
+		entries, err := LoadFixture(p.fixturePath)
+		if err != nil {
+			return ChatResponse{}, fmt.Errorf("failed to load mock fixture: %w", err)
+		}
+		p.entries = entries
+		p.loaded = true
+	}
+
+	entry := FixtureEntry{Message: types.Message{Content: mockExhaustedMessage}}
+	if p.next < len(p.entries) {
+		entry = p.entries[p.next]
+		p.next++
+	}
+	if entry.Error != "" {
+		return ChatResponse{}, errors.New(entry.Error)
+	}
+
+	message := entry.Message
+	message.Role = types.RoleAssistant
+	usage := entry.Usage
+	if usage == nil {
+		promptChars := 0
+		for _, m := range params.Messages {
+			promptChars += len(m.Content)
+		}
+		usage = &types.Usage{
+			PromptTokens:     promptChars / 4,
+			CompletionTokens: len(message.Content) / 4,
+		}
+		usage.TotalTokens = usage.PromptTokens + usage.CompletionTokens
+	}
+
+	return ChatResponse{Message: message, Usage: usage, RawRequest: params}, nil
+}
+
+type recordingProvider struct {
+	mu    sync.Mutex
+	inner ChatProvider
+	path  string
+}
+
+func NewRecordingProvider(inner ChatProvider, path string) ChatProvider {
+	return &recordingProvider{inner: inner, path: path}
+}
+
+func (p *recordingProvider) CreateChatCompletion(params CreateChatParams) (ChatResponse, error) {
+	response, err := p.inner.CreateChatCompletion(params)
+
+	entry := FixtureEntry{Message: response.Message, Usage: response.Usage}
+	if err != nil {
+		entry = FixtureEntry{Error: err.Error()}
+	}
+	if recordErr := p.append(entry); recordErr != nil {
+		fmt.Fprintf(os.Stderr, "failed to record fixture: %v\n", recordErr)
+	}
+	return response, err
+}
+
+func (p *recordingProvider) append(entry FixtureEntry) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(p.path), 0o755); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(p.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	_, err = file.Write(append(line, '\n'))
+	return err
+}
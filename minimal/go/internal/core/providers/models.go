@@ -0,0 +1,129 @@
+package providers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"time"
+
+	"minimal-go/internal/config"
+)
+
+const pingTimeout = 10 * time.Second
+
+var ErrNoModelsEndpoint = errors.New("provider has no models endpoint")
+
+type StatusError struct {
+	Code    int
+	Message string
+}
+
+func (e *StatusError) Error() string {
+	if e.Message != "" {
+		return fmt.Sprintf("%s (status %d)", e.Message, e.Code)
+	}
+	return fmt.Sprintf("request failed with status %d", e.Code)
+}
+
+func (e *StatusError) Unauthorized() bool {
+	return e.Code == http.StatusUnauthorized || e.Code == http.StatusForbidden
+}
+
+func ListModels(cfg config.ResolvedLlmConfig) ([]string, error) {
+	if cfg.SchemaType == config.SchemaMock {
+		return []string{cfg.Model}, nil
+	}
+	if cfg.SchemaType == config.SchemaVertex {
+		// Vertex lists no publisher models per project; fetching a token at
+		// least proves the credentials work.
+		if _, err := googleADC.Token(&http.Client{Timeout: pingTimeout, Transport: sharedTransport(cfg)}); err != nil {
+			return nil, err
+		}
+		return nil, ErrNoModelsEndpoint
+	}
+
+	var endpoint string
+	var err error
+	if cfg.SchemaType == config.SchemaAnthropic {
+		endpoint, err = url.JoinPath(normalizeAnthropicBaseURL(cfg.BaseURL), "v1", "models")
+		endpoint += "?limit=1000"
+	} else {
+		endpoint, err = url.JoinPath(cfg.BaseURL, "models")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest(http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.SchemaType == config.SchemaAnthropic {
+		req.Header.Set("x-api-key", cfg.APIKey)
+		req.Header.Set("anthropic-version", "2023-06-01")
+	} else {
+		req.Header.Set("Authorization", "Bearer "+cfg.APIKey)
+	}
+	newRequestExtras(cfg).apply(req)
+
+	client := &http.Client{Timeout: pingTimeout, Transport: sharedTransport(cfg)}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 4<<20))
+	if err != nil {
+		return nil, err
+	}
+
+	var decoded struct {
+		Data []struct {
+			ID string `json:"id"`
+		} `json:"data"`
+		Error json.RawMessage `json:"error"`
+	}
+	_ = json.Unmarshal(body, &decoded)
+	if resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusMethodNotAllowed {
+		return nil, ErrNoModelsEndpoint
+	}
+	if resp.StatusCode >= 300 {
+		return nil, &StatusError{Code: resp.StatusCode, Message: errorMessage(decoded.Error)}
+	}
+
+	models := make([]string, 0, len(decoded.Data))
+	for _, model := range decoded.Data {
+		if model.ID != "" {
+			models = append(models, model.ID)
+		}
+	}
+	sort.Strings(models)
+	return models, nil
+}
+
+func Ping(cfg config.ResolvedLlmConfig) (time.Duration, error) {
+	started := time.Now()
+	_, err := ListModels(cfg)
+	if errors.Is(err, ErrNoModelsEndpoint) {
+		err = nil
+	}
+	return time.Since(started), err
+}
+
+func errorMessage(raw json.RawMessage) string {
+	var text string
+	if json.Unmarshal(raw, &text) == nil {
+		return text
+	}
+	var object struct {
+		Message string `json:"message"`
+	}
+	if json.Unmarshal(raw, &object) == nil {
+		return object.Message
+	}
+	return ""
+}
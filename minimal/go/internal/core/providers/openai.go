@@ -17,15 +17,27 @@ type openAIProvider struct {
 	client  *http.Client
 	apiKey  string
 	baseURL string
+	quirks  config.ProviderQuirks
+	extras  requestExtras
 }
 
 type openAIMessage struct {
 	Role       string           `json:"role"`
-	Content    string           `json:"content,omitempty"`
+	Content    interface{}      `json:"content,omitempty"`
 	ToolCallID string           `json:"tool_call_id,omitempty"`
 	ToolCalls  []openAIToolCall `json:"tool_calls,omitempty"`
 }
 
+type openAIContentPart struct {
+	Type     string          `json:"type"`
+	Text     string          `json:"text,omitempty"`
+	ImageURL *openAIImageURL `json:"image_url,omitempty"`
+}
+
+type openAIImageURL struct {
+	URL string `json:"url"`
+}
+
 type openAIToolCall struct {
 	ID       string             `json:"id"`
 	Type     string             `json:"type"`
@@ -49,12 +61,19 @@ type openAIToolSchema struct {
 }
 
 type openAIRequest struct {
-	Model       string          `json:"model"`
-	Temperature float64         `json:"temperature"`
-	MaxTokens   int             `json:"max_tokens"`
-	Messages    []openAIMessage `json:"messages"`
-	Tools       []openAITool    `json:"tools,omitempty"`
-	ToolChoice  string          `json:"tool_choice,omitempty"`
+	Model            string                 `json:"model"`
+	Temperature      float64                `json:"temperature"`
+	MaxTokens        int                    `json:"max_tokens"`
+	TopP             *float64               `json:"top_p,omitempty"`
+	FrequencyPenalty *float64               `json:"frequency_penalty,omitempty"`
+	PresencePenalty  *float64               `json:"presence_penalty,omitempty"`
+	Stop             []string               `json:"stop,omitempty"`
+	Seed             *int64                 `json:"seed,omitempty"`
+	Messages         []openAIMessage        `json:"messages"`
+	Tools            []openAITool           `json:"tools,omitempty"`
+	ToolChoice       interface{}            `json:"tool_choice,omitempty"`
+	Parallel         *bool                  `json:"parallel_tool_calls,omitempty"`
+	Format           map[string]interface{} `json:"response_format,omitempty"`
 }
 
 type openAIResponse struct {
@@ -66,9 +85,12 @@ type openAIResponse struct {
 		} `json:"message"`
 	} `json:"choices"`
 	Usage *struct {
-		PromptTokens     int `json:"prompt_tokens"`
-		CompletionTokens int `json:"completion_tokens"`
-		TotalTokens      int `json:"total_tokens"`
+		PromptTokens        int `json:"prompt_tokens"`
+		CompletionTokens    int `json:"completion_tokens"`
+		TotalTokens         int `json:"total_tokens"`
+		PromptTokensDetails *struct {
+			CachedTokens int `json:"cached_tokens"`
+		} `json:"prompt_tokens_details"`
 	} `json:"usage"`
 	Error *struct {
 		Message string `json:"message"`
@@ -79,26 +101,46 @@ type openAIResponse struct {
 
 func NewOpenAIProvider(cfg config.ResolvedLlmConfig) ChatProvider {
 	return &openAIProvider{
-		client:  &http.Client{Timeout: 60 * time.Second},
+		client:  newHTTPClient(cfg),
 		apiKey:  cfg.APIKey,
 		baseURL: cfg.BaseURL,
+		quirks:  quirksFor(cfg),
+		extras:  newRequestExtras(cfg),
 	}
 }
 
 func (p *openAIProvider) CreateChatCompletion(params CreateChatParams) (ChatResponse, error) {
+	started := time.Now()
 	requestParams := openAIRequest{
-		Model:       params.Model,
-		Temperature: params.Temperature,
-		MaxTokens:   params.MaxTokens,
-		Messages:    toOpenAIMessages(params.Messages),
-		Tools:       toOpenAITools(params.Tools),
-		ToolChoice:  "auto",
+		Model:            params.Model,
+		Temperature:      params.Temperature,
+		MaxTokens:        params.MaxTokens,
+		TopP:             params.Sampling.TopP,
+		FrequencyPenalty: params.Sampling.FrequencyPenalty,
+		PresencePenalty:  params.Sampling.PresencePenalty,
+		Stop:             params.Sampling.Stop,
+		Seed:             params.Sampling.Seed,
+		Messages:         applyMessageQuirks(toOpenAIMessages(params.Messages), p.quirks),
+		Tools:            toOpenAITools(params.Tools, p.quirks.MaxToolNameLength),
+		Format:           params.Format.openAI(),
+	}
+	if len(requestParams.Tools) > 0 {
+		requestParams.ToolChoice = openAIToolChoice(params.ToolChoice, p.quirks.MaxToolNameLength)
+		requestParams.Parallel = params.Parallel
+		if p.quirks.NoParallelToolCalls {
+			parallel := false
+			requestParams.Parallel = &parallel
+		}
+		if p.quirks.OmitParallelFlag {
+			requestParams.Parallel = nil
+		}
 	}
 
 	payload, err := json.Marshal(requestParams)
 	if err != nil {
 		return ChatResponse{}, err
 	}
+	timing := RequestTiming{Serialize: time.Since(started)}
 
 	endpoint, err := url.JoinPath(p.baseURL, "chat", "completions")
 	if err != nil {
@@ -111,8 +153,9 @@ func (p *openAIProvider) CreateChatCompletion(params CreateChatParams) (ChatResp
 	}
 	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", p.apiKey))
 	req.Header.Set("Content-Type", "application/json")
+	p.extras.apply(req)
 
-	resp, err := p.client.Do(req)
+	resp, err := p.client.Do(traceFirstByte(req, &timing))
 	if err != nil {
 		return ChatResponse{}, err
 	}
@@ -142,15 +185,23 @@ func (p *openAIProvider) CreateChatCompletion(params CreateChatParams) (ChatResp
 	if len(decoded.Choices) > 0 {
 		choice := decoded.Choices[0]
 		message.Content = choice.Message.Content
+		names := toolNameLookup(params.Tools, p.quirks.MaxToolNameLength)
 		for _, call := range choice.Message.ToolCalls {
 			if call.Type != "function" {
 				continue
 			}
+			name := call.Function.Name
+			if original, ok := names[name]; ok {
+				name = original
+			}
 			toolCalls = append(toolCalls, types.ToolCall{
 				ID:    call.ID,
-				Name:  call.Function.Name,
+				Name:  name,
 				Input: parseToolInput(call.Function.Arguments),
 			})
+			if p.quirks.NoParallelToolCalls {
+				break
+			}
 		}
 	}
 	if len(toolCalls) > 0 {
@@ -164,6 +215,9 @@ func (p *openAIProvider) CreateChatCompletion(params CreateChatParams) (ChatResp
 			CompletionTokens: decoded.Usage.CompletionTokens,
 			TotalTokens:      decoded.Usage.TotalTokens,
 		}
+		if decoded.Usage.PromptTokensDetails != nil {
+			usage.CacheReadTokens = decoded.Usage.PromptTokensDetails.CachedTokens
+		}
 	}
 
 	return ChatResponse{
@@ -171,16 +225,31 @@ func (p *openAIProvider) CreateChatCompletion(params CreateChatParams) (ChatResp
 		Usage:      usage,
 		RawUsage:   decoded.Usage,
 		RawRequest: requestParams,
+		Timing:     timing,
+		RateLimit:  parseRateLimit(resp.Header, time.Now()),
 	}, nil
 }
 
-func toOpenAITools(tools []types.Tool) []openAITool {
+func openAIToolChoice(choice string, maxNameLength int) interface{} {
+	switch choice {
+	case "", config.ToolChoiceAuto:
+		return config.ToolChoiceAuto
+	case config.ToolChoiceNone, config.ToolChoiceRequired:
+		return choice
+	}
+	return map[string]interface{}{
+		"type":     "function",
+		"function": map[string]string{"name": shortToolName(choice, maxNameLength)},
+	}
+}
+
+func toOpenAITools(tools []types.Tool, maxNameLength int) []openAITool {
 	result := make([]openAITool, 0, len(tools))
 	for _, tool := range tools {
 		result = append(result, openAITool{
 			Type: "function",
 			Function: openAIToolSchema{
-				Name:        tool.Name,
+				Name:        shortToolName(tool.Name, maxNameLength),
 				Description: tool.Description,
 				Parameters:  tool.InputSchema,
 			},
@@ -191,7 +260,18 @@ func toOpenAITools(tools []types.Tool) []openAITool {
 
 func toOpenAIMessages(messages []types.Message) []openAIMessage {
 	result := make([]openAIMessage, 0, len(messages))
+	var pendingImages []openAIContentPart
+	flushImages := func() {
+		if len(pendingImages) == 0 {
+			return
+		}
+		result = append(result, openAIMessage{Role: "user", Content: pendingImages})
+		pendingImages = nil
+	}
 	for _, message := range messages {
+		if message.Role != types.RoleTool {
+			flushImages()
+		}
 		switch message.Role {
 		case types.RoleTool:
 			result = append(result, openAIMessage{
@@ -199,6 +279,16 @@ func toOpenAIMessages(messages []types.Message) []openAIMessage {
 				ToolCallID: message.ToolCallID,
 				Content:    message.Content,
 			})
+			for _, image := range message.Images {
+				label := "Image returned by tool call " + message.ToolCallID
+				if image.Path != "" {
+					label += " (" + image.Path + ")"
+				}
+				pendingImages = append(pendingImages,
+					openAIContentPart{Type: "text", Text: label + ":"},
+					openAIContentPart{Type: "image_url", ImageURL: &openAIImageURL{URL: "data:" + image.MediaType + ";base64," + image.Data}},
+				)
+			}
 		case types.RoleAssistant:
 			if len(message.ToolCalls) > 0 {
 				calls := make([]openAIToolCall, 0, len(message.ToolCalls))
@@ -215,25 +305,33 @@ func toOpenAIMessages(messages []types.Message) []openAIMessage {
 				}
 				result = append(result, openAIMessage{
 					Role:      "assistant",
-					Content:   message.Content,
+					Content:   optionalText(message.Content),
 					ToolCalls: calls,
 				})
 			} else {
 				result = append(result, openAIMessage{
 					Role:    "assistant",
-					Content: message.Content,
+					Content: optionalText(message.Content),
 				})
 			}
 		default:
 			result = append(result, openAIMessage{
 				Role:    string(message.Role),
-				Content: message.Content,
+				Content: optionalText(message.Content),
 			})
 		}
 	}
+	flushImages()
 	return result
 }
 
+func optionalText(text string) interface{} {
+	if text == "" {
+		return nil
+	}
+	return text
+}
+
 func parseToolInput(raw string) interface{} {
 	if raw == "" {
 		return map[string]interface{}{}
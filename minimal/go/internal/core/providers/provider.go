@@ -9,8 +9,17 @@ type CreateChatParams struct {
 	Model       string
 	Temperature float64
 	MaxTokens   int
-	Messages    []types.Message
-	Tools       []types.Tool
+	// Sampling is usually the variant's, with any overrides for this call
+	// applied (config.Sampling.Override).
+	Sampling config.Sampling
+	Messages []types.Message
+	Tools    []types.Tool
+	Format   *ResponseFormat
+	// ToolChoice is "auto" (the default when empty), "none", "required" or
+	// the name of one tool the model must call. Parallel, when set, allows
+	// or forbids several tool calls in one response.
+	ToolChoice string
+	Parallel   *bool
 }
 
 type ChatResponse struct {
@@ -19,6 +28,11 @@ type ChatResponse struct {
 	RawUsage   interface{}
 	RawRequest interface{}
 	RawHeaders map[string]string
+	Timing     RequestTiming
+	Cached     bool
+	RateLimit  *RateLimit
+	// Repairs lists what RepairHistory changed in the request's messages.
+	Repairs []string
 }
 
 type ChatProvider interface {
@@ -26,8 +40,23 @@ type ChatProvider interface {
 }
 
 func CreateProvider(cfg config.ResolvedLlmConfig) ChatProvider {
-	if cfg.SchemaType == config.SchemaAnthropic {
-		return NewAnthropicProvider(cfg)
+	var provider ChatProvider
+	switch cfg.SchemaType {
+	case config.SchemaAnthropic:
+		provider = NewAnthropicProvider(cfg)
+	case config.SchemaVertex:
+		provider = NewVertexProvider(cfg)
+	case config.SchemaMock:
+		provider = NewFixtureProvider(cfg.Fixture)
+	default:
+		provider = NewOpenAIProvider(cfg)
 	}
-	return NewOpenAIProvider(cfg)
+	provider = NewRepairingProvider(provider)
+	if cfg.Cache.Enabled && cfg.SchemaType != config.SchemaMock && cfg.ReplayDir == "" {
+		provider = NewCachingProvider(provider, cfg)
+	}
+	if cfg.RecordTo != "" {
+		provider = NewRecordingProvider(provider, cfg.RecordTo)
+	}
+	return provider
 }
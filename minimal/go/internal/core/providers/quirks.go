@@ -0,0 +1,147 @@
+package providers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/url"
+	"strings"
+
+	"minimal-go/internal/config"
+	"minimal-go/internal/types"
+)
+
+var builtinQuirks = map[string]config.ProviderQuirks{
+	"openai":   {MaxToolNameLength: 64},
+	"mistral":  {StrictAlternation: true, SystemFirstOnly: true, MaxToolNameLength: 64},
+	"gemini":   {SystemFirstOnly: true, MaxToolNameLength: 64},
+	"groq":     {MaxToolNameLength: 64},
+	"deepseek": {MaxToolNameLength: 64},
+	"ollama":   {NoParallelToolCalls: true},
+	"xai":      {MaxToolNameLength: 64},
+	// The open-weight models these hosts serve mostly emit one call per turn
+	// and garble the rest when asked for several. Cerebras lists
+	// parallel_tool_calls among the fields it rejects, so it is not sent there.
+	"fireworks": {MaxToolNameLength: 64, NoParallelToolCalls: true},
+	"together":  {MaxToolNameLength: 64, NoParallelToolCalls: true},
+	"cerebras":  {MaxToolNameLength: 64, NoParallelToolCalls: true, OmitParallelFlag: true},
+}
+
+var quirkHosts = map[string]string{
+	"api.openai.com":                    "openai",
+	"api.mistral.ai":                    "mistral",
+	"generativelanguage.googleapis.com": "gemini",
+	"api.groq.com":                      "groq",
+	"api.deepseek.com":                  "deepseek",
+	"api.x.ai":                          "xai",
+	"api.fireworks.ai":                  "fireworks",
+	"api.together.xyz":                  "together",
+	"api.together.ai":                   "together",
+	"api.cerebras.ai":                   "cerebras",
+}
+
+func quirksFor(cfg config.ResolvedLlmConfig) config.ProviderQuirks {
+	if cfg.Quirks != nil {
+		return *cfg.Quirks
+	}
+	if quirks, ok := builtinQuirks[strings.ToLower(cfg.Provider)]; ok {
+		return quirks
+	}
+	if parsed, err := url.Parse(cfg.BaseURL); err == nil {
+		if name, ok := quirkHosts[parsed.Hostname()]; ok {
+			return builtinQuirks[name]
+		}
+	}
+	return config.ProviderQuirks{}
+}
+
+func applyMessageQuirks(messages []openAIMessage, quirks config.ProviderQuirks) []openAIMessage {
+	if quirks.MaxToolNameLength > 0 {
+		for i := range messages {
+			for j := range messages[i].ToolCalls {
+				messages[i].ToolCalls[j].Function.Name = shortToolName(messages[i].ToolCalls[j].Function.Name, quirks.MaxToolNameLength)
+			}
+		}
+	}
+	if quirks.SystemFirstOnly {
+		for i := range messages {
+			if i > 0 && messages[i].Role == "system" {
+				messages[i].Role = "user"
+				messages[i].Content = prefixContent("[system] ", messages[i].Content)
+			}
+		}
+	}
+	if !quirks.StrictAlternation {
+		return messages
+	}
+
+	result := make([]openAIMessage, 0, len(messages))
+	for _, message := range messages {
+		if len(result) > 0 {
+			last := &result[len(result)-1]
+			if message.Role == "user" && last.Role == "user" {
+				last.Content = mergeContent(last.Content, message.Content)
+				continue
+			}
+			if message.Role == "user" && last.Role == "tool" {
+				result = append(result, openAIMessage{Role: "assistant", Content: "Tool results received."})
+			}
+			if message.Role == "assistant" && last.Role == "assistant" && len(last.ToolCalls) == 0 {
+				message.Content = mergeContent(last.Content, message.Content)
+				result[len(result)-1] = message
+				continue
+			}
+		}
+		result = append(result, message)
+	}
+	return result
+}
+
+func shortToolName(name string, limit int) string {
+	if limit <= 0 || len(name) <= limit {
+		return name
+	}
+	sum := sha256.Sum256([]byte(name))
+	return name[:limit-9] + "_" + hex.EncodeToString(sum[:4])
+}
+
+func toolNameLookup(tools []types.Tool, limit int) map[string]string {
+	names := map[string]string{}
+	for _, tool := range tools {
+		names[shortToolName(tool.Name, limit)] = tool.Name
+	}
+	return names
+}
+
+func prefixContent(prefix string, content interface{}) interface{} {
+	switch value := content.(type) {
+	case string:
+		return prefix + value
+	case []openAIContentPart:
+		return append([]openAIContentPart{{Type: "text", Text: prefix}}, value...)
+	}
+	return content
+}
+
+func mergeContent(first interface{}, second interface{}) interface{} {
+	firstText, firstIsText := first.(string)
+	secondText, secondIsText := second.(string)
+	switch {
+	case first == nil:
+		return second
+	case second == nil:
+		return first
+	case firstIsText && secondIsText:
+		return firstText + "\n\n" + secondText
+	}
+	return append(contentParts(first), contentParts(second)...)
+}
+
+func contentParts(content interface{}) []openAIContentPart {
+	switch value := content.(type) {
+	case string:
+		return []openAIContentPart{{Type: "text", Text: value}}
+	case []openAIContentPart:
+		return value
+	}
+	return nil
+}
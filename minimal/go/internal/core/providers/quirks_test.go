@@ -0,0 +1,85 @@
+package providers
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"minimal-go/internal/config"
+	"minimal-go/internal/types"
+)
+
+func TestPresetQuirks(t *testing.T) {
+	presets := []struct {
+		provider, host string
+		want           config.ProviderQuirks
+	}{
+		{"xai", "api.x.ai", config.ProviderQuirks{MaxToolNameLength: 64}},
+		{"fireworks", "api.fireworks.ai", config.ProviderQuirks{MaxToolNameLength: 64, NoParallelToolCalls: true}},
+		{"together", "api.together.xyz", config.ProviderQuirks{MaxToolNameLength: 64, NoParallelToolCalls: true}},
+		{"cerebras", "api.cerebras.ai", config.ProviderQuirks{MaxToolNameLength: 64, NoParallelToolCalls: true, OmitParallelFlag: true}},
+	}
+	for _, preset := range presets {
+		t.Run(preset.provider, func(t *testing.T) {
+			if got := quirksFor(config.ResolvedLlmConfig{Provider: preset.provider}); got != preset.want {
+				t.Errorf("by provider = %+v, want %+v", got, preset.want)
+			}
+			// A variant named after the model rather than the host is still
+			// matched through its base URL.
+			byHost := config.ResolvedLlmConfig{Provider: "custom", BaseURL: "https://" + preset.host + "/v1"}
+			if got := quirksFor(byHost); got != preset.want {
+				t.Errorf("by host = %+v, want %+v", got, preset.want)
+			}
+		})
+	}
+}
+
+func TestParallelFlagQuirks(t *testing.T) {
+	cases := []struct {
+		provider string
+		want     string
+	}{
+		{"xai", "absent"},
+		{"fireworks", "false"},
+		{"cerebras", "absent"},
+	}
+	for _, c := range cases {
+		t.Run(c.provider, func(t *testing.T) {
+			var request map[string]json.RawMessage
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ := io.ReadAll(r.Body)
+				_ = json.Unmarshal(body, &request)
+				_, _ = io.WriteString(w, `{"choices":[{"message":{"role":"assistant","tool_calls":[`+
+					`{"id":"1","type":"function","function":{"name":"read_file","arguments":"{}"}},`+
+					`{"id":"2","type":"function","function":{"name":"read_file","arguments":"{}"}}]}}]}`)
+			}))
+			defer server.Close()
+
+			provider := NewOpenAIProvider(config.ResolvedLlmConfig{Provider: c.provider, BaseURL: server.URL, Timeout: 10})
+			response, err := provider.CreateChatCompletion(CreateChatParams{
+				Model:    "some-model",
+				Messages: []types.Message{{Role: types.RoleUser, Content: "read it"}},
+				Tools:    []types.Tool{{Name: "read_file"}},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := "absent"
+			if flag, ok := request["parallel_tool_calls"]; ok {
+				got = string(flag)
+			}
+			if got != c.want {
+				t.Errorf("parallel_tool_calls = %s, want %s", got, c.want)
+			}
+			wantCalls := 1
+			if c.provider == "xai" {
+				wantCalls = 2
+			}
+			if len(response.Message.ToolCalls) != wantCalls {
+				t.Errorf("tool calls = %d, want %d", len(response.Message.ToolCalls), wantCalls)
+			}
+		})
+	}
+}
@@ -0,0 +1,81 @@
+package providers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RateLimit is the quota a provider reported with its last response. A zero
+// limit means the provider did not report that dimension.
+type RateLimit struct {
+	RequestsLimit     int
+	RequestsRemaining int
+	RequestsReset     time.Time
+	TokensLimit       int
+	TokensRemaining   int
+	TokensReset       time.Time
+	Observed          time.Time
+}
+
+func (r RateLimit) Known() bool {
+	return r.RequestsLimit > 0 || r.TokensLimit > 0
+}
+
+// parseRateLimit reads the OpenAI-style x-ratelimit-* headers (also sent by
+// Groq and most compatible servers) and Anthropic's anthropic-ratelimit-*.
+func parseRateLimit(header http.Header, now time.Time) *RateLimit {
+	limit := RateLimit{Observed: now}
+	limit.RequestsLimit, limit.RequestsRemaining, limit.RequestsReset = rateLimitDimension(header, now,
+		"x-ratelimit-limit-requests", "x-ratelimit-remaining-requests", "x-ratelimit-reset-requests")
+	limit.TokensLimit, limit.TokensRemaining, limit.TokensReset = rateLimitDimension(header, now,
+		"x-ratelimit-limit-tokens", "x-ratelimit-remaining-tokens", "x-ratelimit-reset-tokens")
+	if limit.RequestsLimit == 0 {
+		limit.RequestsLimit, limit.RequestsRemaining, limit.RequestsReset = rateLimitDimension(header, now,
+			"anthropic-ratelimit-requests-limit", "anthropic-ratelimit-requests-remaining", "anthropic-ratelimit-requests-reset")
+	}
+	// Anthropic reports the combined token limit when one applies and the
+	// input limit otherwise; input tokens are what a long session uses up.
+	for _, prefix := range []string{"anthropic-ratelimit-tokens", "anthropic-ratelimit-input-tokens"} {
+		if limit.TokensLimit == 0 {
+			limit.TokensLimit, limit.TokensRemaining, limit.TokensReset = rateLimitDimension(header, now,
+				prefix+"-limit", prefix+"-remaining", prefix+"-reset")
+		}
+	}
+	if !limit.Known() {
+		return nil
+	}
+	return &limit
+}
+
+func rateLimitDimension(header http.Header, now time.Time, limitKey string, remainingKey string, resetKey string) (int, int, time.Time) {
+	limit, err := strconv.Atoi(strings.TrimSpace(header.Get(limitKey)))
+	if err != nil || limit <= 0 {
+		return 0, 0, time.Time{}
+	}
+	remaining, err := strconv.Atoi(strings.TrimSpace(header.Get(remainingKey)))
+	if err != nil {
+		remaining = limit
+	}
+	return limit, remaining, parseRateLimitReset(header.Get(resetKey), now)
+}
+
+// Resets come as a duration ("6m0s", "20ms") from OpenAI-style servers, as
+// an RFC 3339 time from Anthropic and occasionally as plain seconds.
+func parseRateLimitReset(value string, now time.Time) time.Time {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return time.Time{}
+	}
+	if at, err := time.Parse(time.RFC3339, value); err == nil {
+		return at
+	}
+	if duration, err := time.ParseDuration(value); err == nil {
+		return now.Add(duration)
+	}
+	if seconds, err := strconv.ParseFloat(value, 64); err == nil {
+		return now.Add(time.Duration(seconds * float64(time.Second)))
+	}
+	return time.Time{}
+}
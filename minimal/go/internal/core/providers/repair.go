@@ -0,0 +1,138 @@
+package providers
+
+import (
+	"fmt"
+	"strings"
+
+	"minimal-go/internal/types"
+)
+
+const missingResultStub = `{"error": "No result was recorded for this call."}`
+
+// repairingProvider fixes the shape of the history before any conversion,
+// so an edited or truncated session does not come back as a provider 400.
+type repairingProvider struct {
+	inner ChatProvider
+}
+
+func NewRepairingProvider(inner ChatProvider) ChatProvider {
+	return &repairingProvider{inner: inner}
+}
+
+func (p *repairingProvider) CreateChatCompletion(params CreateChatParams) (ChatResponse, error) {
+	messages, repairs := RepairHistory(params.Messages)
+	params.Messages = messages
+	response, err := p.inner.CreateChatCompletion(params)
+	response.Repairs = repairs
+	return response, err
+}
+
+// RepairHistory returns the messages in the order every provider accepts:
+// each assistant tool call is followed directly by exactly one result, and no
+// two user or plain assistant messages are adjacent. Results are moved next
+// to their call, calls without one get a stub, results for unknown calls
+// become user text, and adjacent messages of one role are merged. repairs
+// describes each change; it is empty for a well-formed history.
+func RepairHistory(messages []types.Message) ([]types.Message, []string) {
+	var repairs []string
+
+	callAt := map[string]int{}
+	for i, message := range messages {
+		if message.Role == types.RoleAssistant {
+			for _, call := range message.ToolCalls {
+				if _, seen := callAt[call.ID]; !seen {
+					callAt[call.ID] = i
+				}
+			}
+		}
+	}
+	resultAt := map[string]int{}
+	for i, message := range messages {
+		if message.Role != types.RoleTool {
+			continue
+		}
+		if _, known := callAt[message.ToolCallID]; !known {
+			continue
+		}
+		if _, seen := resultAt[message.ToolCallID]; seen {
+			repairs = append(repairs, fmt.Sprintf("dropped a second result for tool call %s", message.ToolCallID))
+			continue
+		}
+		resultAt[message.ToolCallID] = i
+	}
+
+	ordered := make([]types.Message, 0, len(messages))
+	for i, message := range messages {
+		switch {
+		case message.Role == types.RoleTool:
+			if _, known := callAt[message.ToolCallID]; !known {
+				repairs = append(repairs, fmt.Sprintf("turned the result of unknown tool call %s into a user message", message.ToolCallID))
+				ordered = append(ordered, types.Message{Role: types.RoleUser, Content: "[tool result] " + message.Content, Images: message.Images})
+			}
+		case message.Role == types.RoleAssistant && len(message.ToolCalls) > 0:
+			ordered = append(ordered, message)
+			for _, call := range message.ToolCalls {
+				j, ok := resultAt[call.ID]
+				if !ok || callAt[call.ID] != i {
+					repairs = append(repairs, fmt.Sprintf("added a placeholder result for tool call %s (%s)", call.ID, call.Name))
+					ordered = append(ordered, types.Message{Role: types.RoleTool, ToolCallID: call.ID, Content: missingResultStub})
+					continue
+				}
+				if !followsDirectly(messages, i, j) {
+					repairs = append(repairs, fmt.Sprintf("moved the result of tool call %s next to its call", call.ID))
+				}
+				ordered = append(ordered, messages[j])
+			}
+		case message.Role == types.RoleAssistant && strings.TrimSpace(message.Content) == "":
+			repairs = append(repairs, "dropped an empty assistant message")
+		default:
+			ordered = append(ordered, message)
+		}
+	}
+
+	merged := make([]types.Message, 0, len(ordered))
+	for _, message := range ordered {
+		if n := len(merged); n > 0 && mergeable(merged[n-1], message) {
+			last := &merged[n-1]
+			repairs = append(repairs, fmt.Sprintf("merged two consecutive %s messages", message.Role))
+			last.Content = joinNonEmpty(last.Content, message.Content)
+			last.Thinking = joinNonEmpty(last.Thinking, message.Thinking)
+			last.Images = append(append([]types.Image{}, last.Images...), message.Images...)
+			last.ToolCalls = message.ToolCalls
+			continue
+		}
+		merged = append(merged, message)
+	}
+	return merged, repairs
+}
+
+// followsDirectly reports whether only other tool results sit between the
+// call at i and its result at j.
+func followsDirectly(messages []types.Message, i int, j int) bool {
+	if j <= i {
+		return false
+	}
+	for _, between := range messages[i+1 : j] {
+		if between.Role != types.RoleTool {
+			return false
+		}
+	}
+	return true
+}
+
+func mergeable(previous types.Message, next types.Message) bool {
+	if previous.Role != next.Role {
+		return false
+	}
+	return next.Role == types.RoleUser || (next.Role == types.RoleAssistant && len(previous.ToolCalls) == 0)
+}
+
+func joinNonEmpty(first string, second string) string {
+	switch {
+	case first == "":
+		return second
+	case second == "":
+		return first
+	}
+	return first + "\n\n" + second
+}
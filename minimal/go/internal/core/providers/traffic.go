@@ -0,0 +1,146 @@
+package providers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+var (
+	trafficMu         sync.Mutex
+	trafficTransports = map[string]*trafficTransport{}
+)
+
+type trafficMeta struct {
+	Method  string            `json:"method"`
+	URL     string            `json:"url"`
+	Status  int               `json:"status"`
+	Headers map[string]string `json:"headers,omitempty"`
+}
+
+type trafficTransport struct {
+	mu     sync.Mutex
+	dir    string
+	replay bool
+	next   int
+	inner  http.RoundTripper
+}
+
+func sharedTrafficTransport(dir string, replay bool, inner http.RoundTripper) *trafficTransport {
+	trafficMu.Lock()
+	defer trafficMu.Unlock()
+	if existing, ok := trafficTransports[dir]; ok {
+		return existing
+	}
+	transport := &trafficTransport{dir: dir, replay: replay, next: 1, inner: inner}
+	trafficTransports[dir] = transport
+	return transport
+}
+
+func PrepareRecordDir(dir string) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	if existing, _ := filepath.Glob(filepath.Join(dir, "*-request.json")); len(existing) > 0 {
+		return fmt.Errorf("record directory %s already contains a recording; choose an empty directory", dir)
+	}
+	return nil
+}
+
+func CheckReplayDir(dir string) error {
+	if _, err := os.Stat(trafficFile(dir, 1, "response")); err != nil {
+		return fmt.Errorf("no recording found in %s (expected 0001-response.json)", dir)
+	}
+	return nil
+}
+
+func trafficFile(dir string, sequence int, kind string) string {
+	return filepath.Join(dir, fmt.Sprintf("%04d-%s.json", sequence, kind))
+}
+
+func (t *trafficTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	sequence := t.next
+	t.next++
+
+	var requestBody []byte
+	if req.Body != nil {
+		body, err := io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		requestBody = body
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	if t.replay {
+		return t.replayResponse(req, sequence, requestBody)
+	}
+	return t.recordResponse(req, sequence, requestBody)
+}
+
+func (t *trafficTransport) recordResponse(req *http.Request, sequence int, requestBody []byte) (*http.Response, error) {
+	if err := os.WriteFile(trafficFile(t.dir, sequence, "request"), requestBody, 0o600); err != nil {
+		return nil, err
+	}
+	resp, err := t.inner.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	responseBody, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(responseBody))
+
+	meta := trafficMeta{Method: req.Method, URL: req.URL.String(), Status: resp.StatusCode, Headers: map[string]string{}}
+	for key := range resp.Header {
+		meta.Headers[key] = resp.Header.Get(key)
+	}
+	metaPayload, _ := json.MarshalIndent(meta, "", "  ")
+	if err := os.WriteFile(trafficFile(t.dir, sequence, "meta"), metaPayload, 0o600); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(trafficFile(t.dir, sequence, "response"), responseBody, 0o600); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (t *trafficTransport) replayResponse(req *http.Request, sequence int, requestBody []byte) (*http.Response, error) {
+	responseBody, err := os.ReadFile(trafficFile(t.dir, sequence, "response"))
+	if err != nil {
+		return nil, fmt.Errorf("replay: no recorded response #%d in %s", sequence, t.dir)
+	}
+	meta := trafficMeta{Status: http.StatusOK}
+	if data, err := os.ReadFile(trafficFile(t.dir, sequence, "meta")); err == nil {
+		_ = json.Unmarshal(data, &meta)
+	}
+	if recorded, err := os.ReadFile(trafficFile(t.dir, sequence, "request")); err == nil && !bytes.Equal(recorded, requestBody) {
+		fmt.Fprintf(os.Stderr, "[replay] request #%d differs from %s\n", sequence, trafficFile(t.dir, sequence, "request"))
+	}
+
+	header := http.Header{}
+	for key, value := range meta.Headers {
+		header.Set(key, value)
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", meta.Status, http.StatusText(meta.Status)),
+		StatusCode:    meta.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(responseBody)),
+		ContentLength: int64(len(responseBody)),
+		Request:       req,
+	}, nil
+}
@@ -0,0 +1,216 @@
+package providers
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"minimal-go/internal/config"
+)
+
+var (
+	transportsMu sync.Mutex
+	transports   = map[transportKey]*http.Transport{}
+)
+
+// Providers that agree on these settings share one connection pool, so
+// switching variants or models keeps warm connections.
+type transportKey struct {
+	proxy    string
+	caBundle string
+	insecure bool
+	http     config.HTTPConfig
+}
+
+// The client has no overall timeout, which would cut off a long generation
+// that is still arriving. Instead the variant's timeout bounds the wait for
+// the response to start and readIdleTimeout bounds each stall while reading.
+func newHTTPClient(cfg config.ResolvedLlmConfig) *http.Client {
+	var transport http.RoundTripper = &timeoutTransport{
+		inner:   sharedTransport(cfg),
+		request: seconds(cfg.Timeout),
+		idle:    seconds(cfg.HTTP.ReadIdleTimeout),
+		label:   fmt.Sprintf("llm.variants.%s.timeout or http.requestTimeout", cfg.Provider),
+	}
+	switch {
+	case cfg.ReplayDir != "":
+		transport = sharedTrafficTransport(cfg.ReplayDir, true, transport)
+	case cfg.RecordDir != "":
+		transport = sharedTrafficTransport(cfg.RecordDir, false, transport)
+	}
+	return &http.Client{Transport: transport}
+}
+
+func sharedTransport(cfg config.ResolvedLlmConfig) *http.Transport {
+	key := transportKey{proxy: cfg.Proxy, caBundle: cfg.CABundle, insecure: cfg.Insecure, http: cfg.HTTP}
+	transportsMu.Lock()
+	defer transportsMu.Unlock()
+	if existing, ok := transports[key]; ok {
+		return existing
+	}
+	transport := newTransport(cfg)
+	transports[key] = transport
+	return transport
+}
+
+func newTransport(cfg config.ResolvedLlmConfig) *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = http.ProxyFromEnvironment
+	if proxy := config.ProxyURL(cfg.Proxy); proxy != nil {
+		transport.Proxy = http.ProxyURL(proxy)
+	}
+	dialer := &net.Dialer{Timeout: seconds(cfg.HTTP.DialTimeout), KeepAlive: 30 * time.Second}
+	transport.DialContext = dialer.DialContext
+	transport.ForceAttemptHTTP2 = true
+	transport.TLSHandshakeTimeout = seconds(cfg.HTTP.TLSHandshakeTimeout)
+	transport.IdleConnTimeout = seconds(cfg.HTTP.IdleConnTimeout)
+	transport.MaxIdleConnsPerHost = cfg.HTTP.MaxIdleConnsPerHost
+
+	if cfg.CABundle == "" && !cfg.Insecure {
+		return transport
+	}
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	if cfg.CABundle != "" {
+		pool, err := caPool(cfg.CABundle)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v; using system certificates\n", err)
+		} else {
+			tlsConfig.RootCAs = pool
+		}
+	}
+	if cfg.Insecure {
+		tlsConfig.InsecureSkipVerify = true
+	}
+	transport.TLSClientConfig = tlsConfig
+	return transport
+}
+
+func seconds(value int) time.Duration {
+	return time.Duration(value) * time.Second
+}
+
+type timeoutTransport struct {
+	inner   http.RoundTripper
+	request time.Duration
+	idle    time.Duration
+	label   string
+}
+
+func (t *timeoutTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx, cancel := context.WithCancel(req.Context())
+	var expired atomic.Bool
+	timer := time.AfterFunc(t.request, func() {
+		expired.Store(true)
+		cancel()
+	})
+	resp, err := t.inner.RoundTrip(req.WithContext(ctx))
+	timer.Stop()
+	if err != nil {
+		cancel()
+		if expired.Load() {
+			return nil, fmt.Errorf("no response within %s (raise %s)", t.request, t.label)
+		}
+		return nil, err
+	}
+	if expired.Load() {
+		resp.Body.Close()
+		cancel()
+		return nil, fmt.Errorf("no response within %s (raise %s)", t.request, t.label)
+	}
+
+	body := &timedBody{ReadCloser: resp.Body, cancel: cancel, idle: t.idle}
+	body.timer = time.AfterFunc(t.idle, func() {
+		body.expired.Store(true)
+		cancel()
+	})
+	resp.Body = body
+	return resp, nil
+}
+
+// timedBody cancels the request when no data arrives for idle, however long
+// the response takes overall.
+type timedBody struct {
+	io.ReadCloser
+	timer   *time.Timer
+	cancel  context.CancelFunc
+	idle    time.Duration
+	expired atomic.Bool
+}
+
+func (b *timedBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if err != nil && err != io.EOF && b.expired.Load() {
+		return n, fmt.Errorf("response stalled for %s (http.readIdleTimeout)", b.idle)
+	}
+	if n > 0 {
+		b.timer.Reset(b.idle)
+	}
+	return n, err
+}
+
+func (b *timedBody) Close() error {
+	b.timer.Stop()
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
+
+func caPool(path string) (*x509.CertPool, error) {
+	pem, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read ca_bundle: %w", err)
+	}
+	pool, err := x509.SystemCertPool()
+	if err != nil || pool == nil {
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("ca_bundle %s contains no PEM certificates", path)
+	}
+	return pool, nil
+}
+
+// RequestTiming splits a provider call into building the request body and
+// waiting for the first byte of the response. Both are zero when unknown.
+type RequestTiming struct {
+	Serialize time.Duration
+	FirstByte time.Duration
+}
+
+func traceFirstByte(req *http.Request, timing *RequestTiming) *http.Request {
+	sent := time.Now()
+	trace := &httptrace.ClientTrace{GotFirstResponseByte: func() { timing.FirstByte = time.Since(sent) }}
+	return req.WithContext(httptrace.WithClientTrace(req.Context(), trace))
+}
+
+type requestExtras struct {
+	headers map[string]string
+	query   map[string]string
+}
+
+func newRequestExtras(cfg config.ResolvedLlmConfig) requestExtras {
+	return requestExtras{headers: cfg.Headers, query: cfg.Query}
+}
+
+func (e requestExtras) apply(req *http.Request) {
+	for key, value := range e.headers {
+		req.Header.Set(key, value)
+	}
+	if len(e.query) == 0 {
+		return
+	}
+	query := req.URL.Query()
+	for key, value := range e.query {
+		query.Set(key, value)
+	}
+	req.URL.RawQuery = query.Encode()
+}
@@ -0,0 +1,413 @@
+package providers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"minimal-go/internal/config"
+	"minimal-go/internal/types"
+)
+
+// Sent in the body instead of the anthropic-version header on Vertex.
+const vertexAnthropicVersion = "vertex-2023-10-16"
+
+// Gemini accepts only this subset of JSON schema in function parameters.
+var geminiSchemaKeys = map[string]bool{
+	"type": true, "format": true, "description": true, "nullable": true, "enum": true,
+	"properties": true, "required": true, "items": true, "minItems": true, "maxItems": true,
+	"minimum": true, "maximum": true, "minLength": true, "maxLength": true, "pattern": true,
+	"anyOf": true, "title": true,
+}
+
+// vertexProvider serves a Vertex AI variant. Claude models are published by
+// Anthropic on Vertex and take the Messages API body, so they go through the
+// Anthropic conversion; everything else is treated as Gemini.
+type vertexProvider struct {
+	client     *http.Client
+	authClient *http.Client
+	baseURL    string
+	project    string
+	location   string
+	replay     bool
+	extras     requestExtras
+	claude     *anthropicProvider
+}
+
+type geminiPart struct {
+	Text             string                  `json:"text,omitempty"`
+	Thought          bool                    `json:"thought,omitempty"`
+	InlineData       *geminiBlob             `json:"inlineData,omitempty"`
+	FunctionCall     *geminiFunctionCall     `json:"functionCall,omitempty"`
+	FunctionResponse *geminiFunctionResponse `json:"functionResponse,omitempty"`
+	ThoughtSignature string                  `json:"thoughtSignature,omitempty"`
+}
+
+type geminiBlob struct {
+	MimeType string `json:"mimeType"`
+	Data     string `json:"data"`
+}
+
+type geminiFunctionCall struct {
+	Name string                 `json:"name"`
+	Args map[string]interface{} `json:"args"`
+}
+
+type geminiFunctionResponse struct {
+	Name     string                 `json:"name"`
+	Response map[string]interface{} `json:"response"`
+}
+
+type geminiContent struct {
+	Role  string       `json:"role,omitempty"`
+	Parts []geminiPart `json:"parts"`
+}
+
+type geminiFunction struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description"`
+	Parameters  map[string]interface{} `json:"parameters,omitempty"`
+}
+
+type geminiTool struct {
+	FunctionDeclarations []geminiFunction `json:"functionDeclarations"`
+}
+
+type geminiRequest struct {
+	Contents          []geminiContent        `json:"contents"`
+	SystemInstruction *geminiContent         `json:"systemInstruction,omitempty"`
+	Tools             []geminiTool           `json:"tools,omitempty"`
+	ToolConfig        map[string]interface{} `json:"toolConfig,omitempty"`
+	GenerationConfig  map[string]interface{} `json:"generationConfig"`
+}
+
+type geminiUsage struct {
+	PromptTokenCount        int `json:"promptTokenCount"`
+	CandidatesTokenCount    int `json:"candidatesTokenCount"`
+	TotalTokenCount         int `json:"totalTokenCount"`
+	CachedContentTokenCount int `json:"cachedContentTokenCount"`
+	ThoughtsTokenCount      int `json:"thoughtsTokenCount"`
+}
+
+type geminiResponse struct {
+	Candidates []struct {
+		Content      geminiContent `json:"content"`
+		FinishReason string        `json:"finishReason"`
+	} `json:"candidates"`
+	UsageMetadata  *geminiUsage `json:"usageMetadata"`
+	PromptFeedback *struct {
+		BlockReason string `json:"blockReason"`
+	} `json:"promptFeedback"`
+	Error *struct {
+		Message string `json:"message"`
+		Status  string `json:"status"`
+	} `json:"error"`
+}
+
+func NewVertexProvider(cfg config.ResolvedLlmConfig) ChatProvider {
+	p := &vertexProvider{
+		client:     newHTTPClient(cfg),
+		authClient: &http.Client{Timeout: pingTimeout, Transport: sharedTransport(cfg)},
+		baseURL:    strings.TrimRight(cfg.BaseURL, "/"),
+		project:    cfg.Project,
+		location:   cfg.Location,
+		replay:     cfg.ReplayDir != "",
+		extras:     newRequestExtras(cfg),
+	}
+	p.claude = &anthropicProvider{
+		client:       p.client,
+		providerName: cfg.Provider,
+		extras:       p.extras,
+		vertex:       p,
+	}
+	return p
+}
+
+func isClaudeModel(model string) bool {
+	return strings.HasPrefix(strings.ToLower(model), "claude")
+}
+
+func (p *vertexProvider) CreateChatCompletion(params CreateChatParams) (ChatResponse, error) {
+	if isClaudeModel(params.Model) {
+		return p.claude.CreateChatCompletion(params)
+	}
+	return p.createGeminiCompletion(params)
+}
+
+func (p *vertexProvider) endpoint(publisher string, model string, method string) string {
+	return fmt.Sprintf("%s/v1/projects/%s/locations/%s/publishers/%s/models/%s:%s", p.baseURL, p.project, p.location, publisher, model, method)
+}
+
+// authorize is skipped when replaying a recording, which needs no network.
+func (p *vertexProvider) authorize(req *http.Request) error {
+	if p.replay {
+		return nil
+	}
+	return googleADC.authorize(req, p.authClient)
+}
+
+func (p *vertexProvider) createGeminiCompletion(params CreateChatParams) (ChatResponse, error) {
+	started := time.Now()
+	system, contents := toGeminiContents(params.Messages)
+	generation := map[string]interface{}{
+		"temperature":     params.Temperature,
+		"maxOutputTokens": params.MaxTokens,
+	}
+	sampling := params.Sampling
+	if sampling.TopP != nil {
+		generation["topP"] = *sampling.TopP
+	}
+	if sampling.TopK != nil {
+		generation["topK"] = *sampling.TopK
+	}
+	if sampling.FrequencyPenalty != nil {
+		generation["frequencyPenalty"] = *sampling.FrequencyPenalty
+	}
+	if sampling.PresencePenalty != nil {
+		generation["presencePenalty"] = *sampling.PresencePenalty
+	}
+	if len(sampling.Stop) > 0 {
+		generation["stopSequences"] = sampling.Stop
+	}
+	if sampling.Seed != nil {
+		generation["seed"] = *sampling.Seed
+	}
+	if params.Format != nil {
+		generation["responseMimeType"] = "application/json"
+		if system == nil {
+			system = &geminiContent{}
+		}
+		system.Parts = append(system.Parts, geminiPart{Text: params.Format.instruction()})
+	}
+	requestParams := geminiRequest{
+		Contents:          contents,
+		SystemInstruction: system,
+		GenerationConfig:  generation,
+	}
+	if len(params.Tools) > 0 {
+		requestParams.Tools = []geminiTool{{FunctionDeclarations: toGeminiFunctions(params.Tools)}}
+		requestParams.ToolConfig = geminiToolConfig(params.ToolChoice)
+	}
+
+	payload, err := json.Marshal(requestParams)
+	if err != nil {
+		return ChatResponse{}, err
+	}
+	timing := RequestTiming{Serialize: time.Since(started)}
+
+	req, err := http.NewRequest(http.MethodPost, p.endpoint("google", params.Model, "generateContent"), bytes.NewBuffer(payload))
+	if err != nil {
+		return ChatResponse{}, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if err := p.authorize(req); err != nil {
+		return ChatResponse{}, err
+	}
+	p.extras.apply(req)
+
+	resp, err := p.client.Do(traceFirstByte(req, &timing))
+	if err != nil {
+		return ChatResponse{}, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return ChatResponse{}, err
+	}
+
+	var decoded geminiResponse
+	decodeErr := json.Unmarshal(body, &decoded)
+	if resp.StatusCode >= 300 {
+		if decoded.Error != nil && decoded.Error.Message != "" {
+			return ChatResponse{}, fmt.Errorf("%s (status %d)", decoded.Error.Message, resp.StatusCode)
+		}
+		return ChatResponse{}, fmt.Errorf("request failed with status %d", resp.StatusCode)
+	}
+	if decodeErr != nil {
+		return ChatResponse{}, decodeErr
+	}
+	if len(decoded.Candidates) == 0 {
+		if decoded.PromptFeedback != nil && decoded.PromptFeedback.BlockReason != "" {
+			return ChatResponse{}, fmt.Errorf("gemini blocked the prompt: %s", decoded.PromptFeedback.BlockReason)
+		}
+		return ChatResponse{}, fmt.Errorf("gemini returned no candidates")
+	}
+
+	message := fromGeminiContent(decoded.Candidates[0].Content)
+	var usage *types.Usage
+	if decoded.UsageMetadata != nil {
+		usage = &types.Usage{
+			PromptTokens:     decoded.UsageMetadata.PromptTokenCount,
+			CompletionTokens: decoded.UsageMetadata.CandidatesTokenCount + decoded.UsageMetadata.ThoughtsTokenCount,
+			TotalTokens:      decoded.UsageMetadata.TotalTokenCount,
+			CacheReadTokens:  decoded.UsageMetadata.CachedContentTokenCount,
+		}
+	}
+	return ChatResponse{
+		Message:    message,
+		Usage:      usage,
+		RawUsage:   decoded.UsageMetadata,
+		RawRequest: requestParams,
+		Timing:     timing,
+	}, nil
+}
+
+// toGeminiContents maps the history onto Gemini's two roles. Tool results
+// become functionResponse parts named after the call they answer, and
+// consecutive messages of one role are merged, since Gemini wants all the
+// responses to a turn's calls in a single content.
+func toGeminiContents(messages []types.Message) (*geminiContent, []geminiContent) {
+	callNames := map[string]string{}
+	var systemParts []geminiPart
+	var contents []geminiContent
+	add := func(role string, parts []geminiPart) {
+		if len(parts) == 0 {
+			return
+		}
+		if last := len(contents) - 1; last >= 0 && contents[last].Role == role {
+			contents[last].Parts = append(contents[last].Parts, parts...)
+			return
+		}
+		contents = append(contents, geminiContent{Role: role, Parts: parts})
+	}
+
+	for _, message := range messages {
+		switch message.Role {
+		case types.RoleSystem:
+			if strings.TrimSpace(message.Content) != "" {
+				systemParts = append(systemParts, geminiPart{Text: message.Content})
+			}
+		case types.RoleAssistant:
+			var parts []geminiPart
+			if message.Content != "" {
+				parts = append(parts, geminiPart{Text: message.Content})
+			}
+			for _, call := range message.ToolCalls {
+				callNames[call.ID] = call.Name
+				args, _ := call.Input.(map[string]interface{})
+				if args == nil {
+					args = map[string]interface{}{}
+				}
+				parts = append(parts, geminiPart{FunctionCall: &geminiFunctionCall{Name: call.Name, Args: args}, ThoughtSignature: call.ThoughtSignature})
+			}
+			add("model", parts)
+		case types.RoleTool:
+			name := callNames[message.ToolCallID]
+			if name == "" {
+				name = "unknown_tool"
+			}
+			parts := []geminiPart{{FunctionResponse: &geminiFunctionResponse{
+				Name:     name,
+				Response: map[string]interface{}{"content": message.Content},
+			}}}
+			add("user", append(parts, geminiImageParts(message)...))
+		default:
+			var parts []geminiPart
+			if message.Content != "" {
+				parts = append(parts, geminiPart{Text: message.Content})
+			}
+			add("user", append(parts, geminiImageParts(message)...))
+		}
+	}
+
+	if len(systemParts) == 0 {
+		return nil, contents
+	}
+	return &geminiContent{Parts: systemParts}, contents
+}
+
+func geminiImageParts(message types.Message) []geminiPart {
+	var parts []geminiPart
+	for _, image := range message.Images {
+		parts = append(parts, geminiPart{InlineData: &geminiBlob{MimeType: image.MediaType, Data: image.Data}})
+	}
+	return parts
+}
+
+func fromGeminiContent(content geminiContent) types.Message {
+	message := types.Message{Role: types.RoleAssistant}
+	var text, thinking []string
+	stamp := time.Now().UnixNano()
+	for i, part := range content.Parts {
+		switch {
+		case part.FunctionCall != nil:
+			message.ToolCalls = append(message.ToolCalls, types.ToolCall{
+				// Gemini calls carry no id; the history needs one to pair
+				// each result with its call.
+				ID:               fmt.Sprintf("gemini-%x-%d", stamp, i),
+				Name:             part.FunctionCall.Name,
+				Input:            part.FunctionCall.Args,
+				ThoughtSignature: part.ThoughtSignature,
+			})
+		case part.Thought:
+			thinking = append(thinking, part.Text)
+		case part.Text != "":
+			text = append(text, part.Text)
+		}
+	}
+	message.Content = strings.Join(text, "")
+	message.Thinking = strings.Join(thinking, "")
+	return message
+}
+
+func toGeminiFunctions(tools []types.Tool) []geminiFunction {
+	functions := make([]geminiFunction, 0, len(tools))
+	for _, tool := range tools {
+		parameters, _ := geminiSchema(tool.InputSchema).(map[string]interface{})
+		functions = append(functions, geminiFunction{Name: tool.Name, Description: tool.Description, Parameters: parameters})
+	}
+	return functions
+}
+
+// geminiSchema drops the JSON schema keywords Gemini rejects, such as
+// additionalProperties, at every level.
+func geminiSchema(value interface{}) interface{} {
+	switch typed := value.(type) {
+	case map[string]interface{}:
+		cleaned := map[string]interface{}{}
+		for key, child := range typed {
+			if !geminiSchemaKeys[key] {
+				continue
+			}
+			if key == "properties" {
+				properties := map[string]interface{}{}
+				if children, ok := child.(map[string]interface{}); ok {
+					for name, schema := range children {
+						properties[name] = geminiSchema(schema)
+					}
+				}
+				cleaned[key] = properties
+				continue
+			}
+			cleaned[key] = geminiSchema(child)
+		}
+		return cleaned
+	case []interface{}:
+		cleaned := make([]interface{}, len(typed))
+		for i, child := range typed {
+			cleaned[i] = geminiSchema(child)
+		}
+		return cleaned
+	}
+	return value
+}
+
+func geminiToolConfig(choice string) map[string]interface{} {
+	calling := map[string]interface{}{}
+	switch choice {
+	case "", config.ToolChoiceAuto:
+		calling["mode"] = "AUTO"
+	case config.ToolChoiceNone:
+		calling["mode"] = "NONE"
+	case config.ToolChoiceRequired:
+		calling["mode"] = "ANY"
+	default:
+		calling["mode"] = "ANY"
+		calling["allowedFunctionNames"] = []string{choice}
+	}
+	return map[string]interface{}{"functionCallingConfig": calling}
+}
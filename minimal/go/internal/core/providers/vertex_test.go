@@ -0,0 +1,29 @@
+package providers
+
+import (
+	"encoding/json"
+	"testing"
+
+	"minimal-go/internal/types"
+)
+
+func TestGeminiThoughtSignatureRoundTrip(t *testing.T) {
+	var content geminiContent
+	response := `{"role":"model","parts":[{"functionCall":{"name":"read_file","args":{"path":"a.go"}},"thoughtSignature":"c2lnbmF0dXJl"}]}`
+	if err := json.Unmarshal([]byte(response), &content); err != nil {
+		t.Fatal(err)
+	}
+	message := fromGeminiContent(content)
+	if len(message.ToolCalls) != 1 || message.ToolCalls[0].ThoughtSignature != "c2lnbmF0dXJl" {
+		t.Fatalf("tool calls = %+v", message.ToolCalls)
+	}
+
+	result := types.Message{Role: types.RoleTool, ToolCallID: message.ToolCalls[0].ID, Content: "package a"}
+	_, contents := toGeminiContents([]types.Message{{Role: types.RoleUser, Content: "read a.go"}, message, result})
+	if len(contents) != 3 {
+		t.Fatalf("contents = %+v", contents)
+	}
+	if part := contents[1].Parts[0]; part.FunctionCall == nil || part.ThoughtSignature != "c2lnbmF0dXJl" {
+		t.Fatalf("model part = %+v", part)
+	}
+}
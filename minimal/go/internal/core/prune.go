@@ -0,0 +1,105 @@
+package core
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"minimal-go/internal/config"
+	"minimal-go/internal/types"
+	"minimal-go/internal/vault"
+)
+
+const prunedToolResultPrefix = "[tool output pruned to save context:"
+
+func newOutputDir() string {
+	return filepath.Join(config.OutputsDir, time.Now().Format("20060102-150405")+"-"+strconv.Itoa(os.Getpid()))
+}
+
+func (a *agent) pruneStaleToolResults() {
+	settings := a.config.Context
+	if !settings.PruneToolResults {
+		return
+	}
+	a.mu.Lock()
+	pruned, saved := a.pruneLocked(settings)
+	a.mu.Unlock()
+	if pruned > 0 {
+		a.notice(LevelInfo, fmt.Sprintf("[context] pruned %d stale tool result(s), ~%d tokens", pruned, (saved+charsPerToken-1)/charsPerToken))
+	}
+}
+
+func (a *agent) pruneLocked(settings config.ContextConfig) (int, int) {
+	cutoff := userTurnStart(a.messages, settings.KeepTurns)
+	if cutoff <= 0 {
+		return 0, 0
+	}
+
+	var stale []int
+	for i := 0; i < cutoff; i++ {
+		message := a.messages[i]
+		if message.Role == types.RoleTool && len(message.Content) >= settings.PruneMinChars && !isPruned(message) {
+			stale = append(stale, i)
+		}
+	}
+	if len(stale) < settings.KeepTurns {
+		return 0, 0
+	}
+
+	if err := os.MkdirAll(a.outputDir, 0o700); err != nil {
+		a.debugLog("Prune tool results", err.Error())
+		return 0, 0
+	}
+	saved := 0
+	for _, index := range stale {
+		message := a.messages[index]
+		path := filepath.Join(a.outputDir, fmt.Sprintf("%03d-%s.txt", index, sanitizeFileName(message.ToolCallID)))
+		if err := vault.WriteFile(path, []byte(message.Content), 0o600); err != nil {
+			a.debugLog("Prune tool results", err.Error())
+			continue
+		}
+		saved += len(message.Content)
+		a.messages[index].Content = fmt.Sprintf("%s %d chars saved to %s; read it with read_file if you need it again]", prunedToolResultPrefix, len(message.Content), path)
+		a.messages[index].Images = nil
+	}
+	return len(stale), saved
+}
+
+func userTurnStart(messages []types.Message, keep int) int {
+	seen := 0
+	for i := len(messages) - 1; i >= 0; i-- {
+		if messages[i].Role == types.RoleUser {
+			seen++
+			if seen == keep {
+				return i
+			}
+		}
+	}
+	return 0
+}
+
+// isPrunedOutput reports whether path is under the pruned outputs, which
+// are encrypted like the rest of ~/.minimal when storage.encrypt is set.
+func isPrunedOutput(path string) bool {
+	relative, err := filepath.Rel(config.OutputsDir, path)
+	return err == nil && relative != ".." && !strings.HasPrefix(relative, ".."+string(filepath.Separator))
+}
+
+func isPruned(message types.Message) bool {
+	return strings.HasPrefix(message.Content, prunedToolResultPrefix)
+}
+
+func sanitizeFileName(name string) string {
+	clean := make([]rune, 0, len(name))
+	for _, r := range name {
+		if r == '-' || r == '_' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			clean = append(clean, r)
+		} else {
+			clean = append(clean, '_')
+		}
+	}
+	return string(clean)
+}
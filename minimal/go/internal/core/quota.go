@@ -0,0 +1,118 @@
+package core
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"minimal-go/internal/core/providers"
+	"minimal-go/internal/ui"
+)
+
+// quotaWarnShare is the fraction of a provider limit left at which the
+// agent warns, so a long task can be paused before requests start failing.
+const quotaWarnShare = 0.1
+
+func (a *agent) recordRateLimit(response providers.ChatResponse) {
+	limit := response.RateLimit
+	if limit == nil {
+		return
+	}
+	lastTokens := 0
+	if response.Usage != nil {
+		lastTokens = response.Usage.TotalTokens
+	}
+	a.mu.Lock()
+	provider := a.llmConfig.Provider
+	if a.rateLimits == nil {
+		a.rateLimits = map[string]providers.RateLimit{}
+	}
+	a.rateLimits[provider] = *limit
+	var warnings []string
+	if limit.RequestsLimit > 0 && quotaLow(limit.RequestsRemaining, limit.RequestsLimit, 1) && a.shouldWarnQuota(provider+":requests", limit.RequestsReset, limit.Observed) {
+		warnings = append(warnings, fmt.Sprintf("%d of %d requests left%s", limit.RequestsRemaining, limit.RequestsLimit, resetsIn(limit.RequestsReset, limit.Observed)))
+	}
+	if limit.TokensLimit > 0 && quotaLow(limit.TokensRemaining, limit.TokensLimit, lastTokens) && a.shouldWarnQuota(provider+":tokens", limit.TokensReset, limit.Observed) {
+		warnings = append(warnings, fmt.Sprintf("%d of %d tokens left%s", limit.TokensRemaining, limit.TokensLimit, resetsIn(limit.TokensReset, limit.Observed)))
+	}
+	a.mu.Unlock()
+	for _, warning := range warnings {
+		a.notice(LevelWarning, fmt.Sprintf("[quota] %s: %s; the next requests may be rate limited.", provider, warning))
+	}
+}
+
+// shouldWarnQuota warns once per rate-limit window; without a reported
+// reset a window is taken to last a minute. Callers hold a.mu.
+func (a *agent) shouldWarnQuota(key string, reset time.Time, observed time.Time) bool {
+	if observed.Before(a.quotaWarned[key]) {
+		return false
+	}
+	if reset.IsZero() {
+		reset = observed.Add(time.Minute)
+	}
+	a.quotaWarned[key] = reset
+	return true
+}
+
+// quotaLow also counts the quota as low when less is left than one more
+// request like the last one needs.
+func quotaLow(remaining int, limit int, next int) bool {
+	return float64(remaining) <= float64(limit)*quotaWarnShare || remaining < next
+}
+
+func resetsIn(reset time.Time, observed time.Time) string {
+	if reset.IsZero() {
+		return ""
+	}
+	return ", resets in " + reset.Sub(observed).Round(time.Second).String()
+}
+
+func (a *agent) GetRateLimits() map[string]providers.RateLimit {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	limits := make(map[string]providers.RateLimit, len(a.rateLimits))
+	for provider, limit := range a.rateLimits {
+		limits[provider] = limit
+	}
+	return limits
+}
+
+func printRateLimits(limits map[string]providers.RateLimit) {
+	if len(limits) == 0 {
+		return
+	}
+	names := make([]string, 0, len(limits))
+	for name := range limits {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fmt.Println(ui.Bold("Provider quota") + ui.Muted(" (from the last response's rate-limit headers)"))
+	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "PROVIDER\tREQUESTS\tTOKENS\tAS OF")
+	for _, name := range names {
+		limit := limits[name]
+		fmt.Fprintf(writer, "%s\t%s\t%s\t%s\n", ui.Cyan(name),
+			formatQuota(limit.RequestsRemaining, limit.RequestsLimit, limit.RequestsReset),
+			formatQuota(limit.TokensRemaining, limit.TokensLimit, limit.TokensReset),
+			ui.Muted(limit.Observed.Format("15:04:05")))
+	}
+	writer.Flush()
+	fmt.Println("")
+}
+
+func formatQuota(remaining int, limit int, reset time.Time) string {
+	if limit == 0 {
+		return "-"
+	}
+	text := fmt.Sprintf("%d/%d", remaining, limit)
+	if !reset.IsZero() {
+		text += " (reset " + reset.Local().Format("15:04:05") + ")"
+	}
+	if quotaLow(remaining, limit, 1) {
+		return ui.Warning(text)
+	}
+	return text
+}
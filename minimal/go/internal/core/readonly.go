@@ -0,0 +1,44 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+
+	"minimal-go/internal/ui"
+)
+
+const readOnlyNotice = "[read-only] file writes are off and only commands that inspect the workspace can run."
+
+// /readonly toggles the mode; /readonly on|off sets it. The base environment
+// is updated too, so switching agent profiles keeps the choice.
+func (s *replState) handleReadOnlyCommand(args string) error {
+	cfg := s.agent.GetConfig()
+	enabled := !cfg.Policy.ReadOnly
+	switch args {
+	case "":
+	case "on":
+		enabled = true
+	case "off":
+		enabled = false
+	default:
+		return errors.New("Usage: /readonly [on|off]")
+	}
+
+	cfg.Policy.ReadOnly = enabled
+	if err := s.agent.ApplyConfig(cfg); err != nil {
+		return err
+	}
+	s.env.config.Policy.ReadOnly = enabled
+	if s.env.base != nil {
+		base := *s.env.base
+		base.config.Policy.ReadOnly = enabled
+		s.env.base = &base
+	}
+	if enabled {
+		printSuccess("✓ Read-only mode on.")
+		fmt.Println(ui.Muted(readOnlyNotice))
+		return nil
+	}
+	printSuccess("✓ Read-only mode off; writes and commands follow the normal policy again.")
+	return nil
+}
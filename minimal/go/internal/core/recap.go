@@ -0,0 +1,109 @@
+package core
+
+import (
+	"fmt"
+	"strings"
+
+	"minimal-go/internal/core/providers"
+	"minimal-go/internal/session"
+	"minimal-go/internal/types"
+	"minimal-go/internal/ui"
+)
+
+const (
+	recapMaxTokens = 400
+	// With context.summarizeOnClear, shorter conversations are cleared
+	// without a summary: rereading them costs less than summarizing.
+	recapMinTokens     = 2000
+	recapResultPreview = 300
+	maxRecapTranscript = 64 * 1024
+	recapPrefix        = "[Summary of the previous conversation]"
+)
+
+const recapInstructions = `Below is the transcript of a coding session that is about to be cleared. Write one paragraph (at most 150 words) for the assistant that continues the work in a fresh conversation: what the user wanted, what was done (files created or edited, commands that mattered), decisions taken and anything left open. Name files and identifiers exactly. Reply with the paragraph only.`
+
+func (a *agent) SummarizeConversation() (string, error) {
+	transcript := recapTranscript(a.GetMessages())
+	if transcript == "" {
+		return "", nil
+	}
+	response, err := a.provider.CreateChatCompletion(providers.CreateChatParams{
+		Model:     a.llmConfig.Model,
+		MaxTokens: recapMaxTokens,
+		Messages: []types.Message{
+			{Role: types.RoleSystem, Content: recapInstructions},
+			{Role: types.RoleUser, Content: transcript},
+		},
+	})
+	if err != nil {
+		return "", mapProviderError(err)
+	}
+	if response.Usage != nil {
+		a.recordUsage(response.Usage)
+	}
+	return strings.TrimSpace(response.Message.Content), nil
+}
+
+// recapTranscript flattens the history to text, with tool results cut to a
+// preview. Long sessions keep their end, where the current state is.
+func recapTranscript(messages []types.Message) string {
+	var b strings.Builder
+	for _, message := range messages {
+		switch message.Role {
+		case types.RoleUser:
+			fmt.Fprintf(&b, "User: %s\n\n", message.Content)
+		case types.RoleAssistant:
+			if message.Content != "" {
+				fmt.Fprintf(&b, "Assistant: %s\n\n", message.Content)
+			}
+			for _, call := range message.ToolCalls {
+				fmt.Fprintf(&b, "Tool call: %s\n", describeToolCall(call, recapResultPreview))
+			}
+		case types.RoleTool:
+			fmt.Fprintf(&b, "Tool result: %s\n\n", previewLine(message.Content, recapResultPreview))
+		}
+	}
+	transcript := b.String()
+	if len(transcript) > maxRecapTranscript {
+		transcript = "… (earlier turns omitted)\n" + strings.ToValidUTF8(transcript[len(transcript)-maxRecapTranscript:], "")
+	}
+	return strings.TrimSpace(transcript)
+}
+
+// clearConversation handles /clear [summary|full]. A summary is kept when
+// asked for, or by default with context.summarizeOnClear once the
+// conversation is long enough to be worth it.
+func (s *replState) clearConversation(args string) error {
+	switch args {
+	case "", "summary", "full":
+	default:
+		return fmt.Errorf("Usage: /clear [summary|full]")
+	}
+	messages := s.agent.GetMessages()
+	summarize := args == "summary"
+	if args == "" && s.agent.GetConfig().Context.SummarizeOnClear {
+		summarize = estimateRequestTokens(messages, nil) >= recapMinTokens
+	}
+	summary := ""
+	if summarize && len(messages) > 1 {
+		fmt.Println(ui.Muted("Summarizing the conversation…"))
+		var err error
+		if summary, err = s.agent.SummarizeConversation(); err != nil {
+			fmt.Println(ui.Warning("Summary skipped: " + err.Error()))
+		}
+	}
+
+	s.agent.Clear()
+	s.buffer.clear()
+	s.session = session.New("", s.agent.GetModel(), s.workspaceRoot)
+	s.session.Agent = s.env.config.Agent
+	s.costMark = sessionCost(s.agent)
+	if summary == "" {
+		printSuccess("✓ Conversation cleared.")
+		return nil
+	}
+	s.agent.SetMessages(append(s.agent.GetMessages(), types.Message{Role: types.RoleUser, Content: recapPrefix + "\n" + summary}))
+	printSuccess(fmt.Sprintf("✓ Conversation cleared; kept a summary (~%d tokens):", estimateTextTokens(summary)))
+	fmt.Println(ui.Muted(summary))
+	return nil
+}
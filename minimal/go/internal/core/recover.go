@@ -0,0 +1,91 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"minimal-go/internal/config"
+	"minimal-go/internal/session"
+	"minimal-go/internal/ui"
+	"minimal-go/internal/vault"
+)
+
+// RecoverCommand handles `mini-go recover [session]`: without an argument
+// it lists the journals a crashed session left behind, with one it rebuilds
+// that session from its journal and saves it.
+func RecoverCommand(args []string) error {
+	if len(args) > 1 {
+		printError("Usage: mini-go recover [session]")
+		return errors.New("too many arguments")
+	}
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		printError(err.Error())
+		return err
+	}
+	if cfg.Storage.Encrypt != "" {
+		if err := vault.Enable(cfg.Storage.Encrypt); err != nil {
+			printError("Encrypted storage: " + err.Error())
+			return err
+		}
+	}
+	if len(args) == 0 {
+		return printJournals()
+	}
+
+	recovery, err := session.Recover(args[0])
+	if err != nil {
+		printError(err.Error())
+		return err
+	}
+	s := recovery.Session
+	if err := session.Save(s); err != nil {
+		printError(err.Error())
+		return err
+	}
+	if recovery.Skipped > 0 {
+		fmt.Println(ui.Warning(fmt.Sprintf("Skipped %s (usually the message being written at the crash).", plural(recovery.Skipped, "unreadable journal line"))))
+	}
+	printSuccess(fmt.Sprintf("✓ Recovered %s (%d messages)", s.Label(), len(s.Messages)))
+	fmt.Println(ui.Muted("Continue it with /resume " + s.ID + " in mini-go."))
+	return nil
+}
+
+func printJournals() error {
+	ids, err := session.Journals()
+	if err != nil {
+		printError(err.Error())
+		return err
+	}
+	fmt.Println("")
+	fmt.Println(ui.Bold("Recoverable sessions:"))
+	if len(ids) == 0 {
+		fmt.Println(ui.Muted("  (none)"))
+		return nil
+	}
+	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "  ID\tTITLE\tLAST SAVED\tMESSAGES")
+	for _, id := range ids {
+		recovery, err := session.Recover(id)
+		if err != nil {
+			fmt.Fprintf(writer, "  %s\t%s\t\t\n", ui.Cyan(id), ui.Muted(err.Error()))
+			continue
+		}
+		s := recovery.Session
+		title := s.Title
+		if title == "" {
+			title = session.TitleFrom(s.Messages)
+		}
+		saved := "never"
+		if recovery.Saved {
+			saved = s.UpdatedAt.Format("2006-01-02 15:04")
+		}
+		fmt.Fprintf(writer, "  %s\t%s\t%s\t%d\n", ui.Cyan(id), title, ui.Muted(saved), len(s.Messages))
+	}
+	writer.Flush()
+	fmt.Println(ui.Muted("\nA session that is still running also has a journal; recover only crashed ones."))
+	fmt.Println(ui.Muted("Usage: mini-go recover <id>"))
+	return nil
+}
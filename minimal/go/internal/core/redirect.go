@@ -0,0 +1,135 @@
+package core
+
+import (
+	"os"
+	"regexp"
+	"strings"
+
+	"minimal-go/internal/policy"
+)
+
+var (
+	heredocPattern  = regexp.MustCompile(`<<(-?)\s*['"]?([A-Za-z_][A-Za-z0-9_]*)['"]?`)
+	redirectPattern = regexp.MustCompile(`(?:^|[^0-9&<>])(>>?)\s*([^\s&|;<>()]+)`)
+	teePattern      = regexp.MustCompile(`\btee\s+(-a\s+)?([^\s&|;<>()]+)`)
+	echoPattern     = regexp.MustCompile(`^(?:echo|printf)\s+(?:-[ne]+\s+)?("(?:[^"\\]|\\.)*"|'[^']*')\s*(>>?)\s*([^\s&|;<>()]+)$`)
+)
+
+type redirectWrite struct {
+	target   string
+	content  string
+	known    bool
+	appendTo bool
+}
+
+func detectRedirectWrites(command string) []redirectWrite {
+	lines := strings.Split(command, "\n")
+	header := lines[0]
+	var writes []redirectWrite
+
+	if match := heredocPattern.FindStringSubmatchIndex(header); match != nil && len(lines) > 1 {
+		delimiter := header[match[4]:match[5]]
+		trimTabs := header[match[2]:match[3]] == "-"
+		var body []string
+		for _, line := range lines[1:] {
+			check := line
+			if trimTabs {
+				check = strings.TrimLeft(line, "\t")
+			}
+			if check == delimiter {
+				break
+			}
+			body = append(body, line)
+		}
+		content := strings.Join(body, "\n") + "\n"
+		rest := header[:match[0]] + header[match[1]:]
+		for _, write := range headerTargets(rest) {
+			write.content, write.known = content, true
+			writes = append(writes, write)
+		}
+		return writes
+	}
+
+	if match := echoPattern.FindStringSubmatch(strings.TrimSpace(command)); match != nil {
+		return []redirectWrite{{
+			target:   unquote(match[3]),
+			content:  echoContent(command, match[1]),
+			known:    true,
+			appendTo: match[2] == ">>",
+		}}
+	}
+
+	for _, line := range lines {
+		writes = append(writes, headerTargets(line)...)
+	}
+	return writes
+}
+
+func headerTargets(line string) []redirectWrite {
+	var writes []redirectWrite
+	for _, match := range redirectPattern.FindAllStringSubmatch(line, -1) {
+		if target := unquote(match[2]); !isSpecialTarget(target) {
+			writes = append(writes, redirectWrite{target: target, appendTo: match[1] == ">>"})
+		}
+	}
+	for _, match := range teePattern.FindAllStringSubmatch(line, -1) {
+		if target := unquote(match[2]); !isSpecialTarget(target) {
+			writes = append(writes, redirectWrite{target: target, appendTo: match[1] != ""})
+		}
+	}
+	return writes
+}
+
+func echoContent(command string, quoted string) string {
+	content := quoted[1 : len(quoted)-1]
+	if quoted[0] == '"' {
+		content = strings.NewReplacer(`\"`, `"`, `\\`, `\`, `\$`, `$`).Replace(content)
+	}
+	if strings.HasPrefix(strings.TrimSpace(command), "printf") {
+		return strings.ReplaceAll(content, `\n`, "\n")
+	}
+	if strings.Contains(command, "echo -n") {
+		return content
+	}
+	return content + "\n"
+}
+
+func isSpecialTarget(target string) bool {
+	return target == "" || strings.HasPrefix(target, "/dev/") || strings.HasPrefix(target, "&") || strings.Contains(target, "$")
+}
+
+func unquote(value string) string {
+	if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+		return value[1 : len(value)-1]
+	}
+	return value
+}
+
+func (a *agent) redirectChanges(command string, dir string) []FileChange {
+	var changes []FileChange
+	seen := map[string]bool{}
+	for _, write := range detectRedirectWrites(command) {
+		if seen[write.target] {
+			continue
+		}
+		seen[write.target] = true
+
+		fullPath, err := policy.ResolvePathFrom(write.target, dir, a.workspaceRoot, a.config.Policy.AllowedPaths)
+		if err != nil {
+			changes = append(changes, FileChange{Path: write.target, Unknown: true})
+			continue
+		}
+		before, err := os.ReadFile(fullPath)
+		created := os.IsNotExist(err)
+		if !write.known {
+			changes = append(changes, FileChange{Path: write.target, Created: created, Unknown: true})
+			continue
+		}
+		after := write.content
+		if write.appendTo {
+			after = string(before) + write.content
+		}
+		changes = append(changes, newFileChange(write.target, string(before), after, created))
+	}
+	return changes
+}
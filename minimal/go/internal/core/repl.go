@@ -6,17 +6,86 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"os/exec"
 	"os/signal"
 	"path/filepath"
+	"strconv"
 	"strings"
+	"text/tabwriter"
+	"time"
 
 	"minimal-go/internal/config"
+	"minimal-go/internal/core/providers"
+	"minimal-go/internal/history"
+	"minimal-go/internal/memory"
 	"minimal-go/internal/policy"
+	"minimal-go/internal/prompt"
+	"minimal-go/internal/session"
+	"minimal-go/internal/share"
+	"minimal-go/internal/skills"
+	"minimal-go/internal/types"
 	"minimal-go/internal/ui"
+	"minimal-go/internal/vault"
 )
 
 type MainOptions struct {
-	Debug bool
+	Debug     bool
+	RecordDir string
+	ReplayDir string
+	NoCache   bool
+	Agent     string
+	ReadOnly  bool
+	Auto      bool
+}
+
+func (o MainOptions) apply(env *environment) error {
+	if o.RecordDir != "" && o.ReplayDir != "" {
+		err := errors.New("--record and --replay cannot be combined")
+		printError(err.Error())
+		return err
+	}
+	if o.RecordDir != "" {
+		if err := providers.PrepareRecordDir(o.RecordDir); err != nil {
+			printError(err.Error())
+			return err
+		}
+		env.config.RecordDir = o.RecordDir
+		fmt.Println(ui.Muted("[record] provider traffic → " + o.RecordDir))
+	}
+	if o.ReplayDir != "" {
+		if err := providers.CheckReplayDir(o.ReplayDir); err != nil {
+			printError(err.Error())
+			return err
+		}
+		env.config.ReplayDir = o.ReplayDir
+		fmt.Println(ui.Muted("[replay] serving provider responses from " + o.ReplayDir))
+	}
+	if o.NoCache {
+		env.config.Cache.Enabled = false
+	}
+	if o.ReadOnly {
+		env.config.Policy.ReadOnly = true
+		fmt.Println(ui.Muted(readOnlyNotice))
+	}
+	if o.Auto {
+		guard, err := autoGuardrail(env.workspaceRoot)
+		if err != nil {
+			printError(err.Error())
+			return err
+		}
+		env.config.Auto = true
+		fmt.Println(ui.Warning("[auto] approval prompts are off; only denied commands are stopped (" + guard + ")."))
+	}
+	if o.Agent != "" {
+		profiled, err := applyAgentProfile(*env, o.Agent)
+		if err != nil {
+			printError(err.Error())
+			return err
+		}
+		*env = profiled
+		fmt.Println(ui.Muted(describeAgent(env.config)))
+	}
+	return nil
 }
 
 func Main(options MainOptions) error {
@@ -25,74 +94,58 @@ func Main(options MainOptions) error {
 		if !debug {
 			return
 		}
-		fmt.Println(ui.Magenta("[DEBUG] " + label))
+		fmt.Println(ui.Debug("[DEBUG] " + label))
 		if data != nil {
 			payload, _ := json.MarshalIndent(data, "", "  ")
-			fmt.Println(ui.Magenta(string(payload)))
+			fmt.Println(ui.Debug(string(payload)))
 		}
 	}
 
-	if err := config.EnsureMinimalDir(); err != nil {
-		printError("~/.minimal directory not found.")
-		fmt.Println(ui.Gray("Run the following to initialize:"))
-		fmt.Println(ui.Gray("  mkdir -p ~/.minimal/skills"))
-		fmt.Println(ui.Gray("  echo \"You are a helpful coding assistant.\" > ~/.minimal/system.md"))
-		return err
-	}
-
-	cfg, err := config.LoadConfig()
+	env, err := loadEnvironment()
 	if err != nil {
-		printError(err.Error())
 		return err
 	}
-
-	systemPrompt, err := config.LoadSystemPrompt()
-	if err != nil {
-		printError("~/.minimal/system.md not found or empty.")
-		fmt.Println(ui.Gray("Run: mkdir -p ~/.minimal && touch ~/.minimal/system.md"))
+	if err := options.apply(&env); err != nil {
 		return err
 	}
-
-	workspaceRoot := os.Getenv("WORKSPACE_ROOT")
-	if workspaceRoot == "" {
-		cwd, err := os.Getwd()
-		if err != nil {
-			return err
-		}
-		workspaceRoot = cwd
-	}
-	workspaceRoot, _ = filepath.Abs(workspaceRoot)
+	cfg := env.config
+	systemPrompt := env.systemPrompt
+	workspaceRoot := env.workspaceRoot
 
 	reader := bufio.NewReader(os.Stdin)
 	sigCh := make(chan os.Signal, 1)
 	signal.Notify(sigCh, os.Interrupt)
 
-	promptApproval := func(command string) (bool, error) {
-		fmt.Println("")
-		fmt.Println(ui.Yellow("Command:"))
-		fmt.Println(ui.Bold("  " + command))
-		fmt.Println("")
-		fmt.Println(ui.Gray("  [enter/y] Run"))
-		fmt.Println(ui.Gray("  [n]       Reject"))
-		fmt.Println(ui.Gray("  [ctrl+c]  Cancel"))
-		fmt.Println("")
-
-		line, cancelled, err := readLine(reader, ui.Cyan("> "), sigCh, true)
-		if err != nil {
-			return false, err
-		}
-		if cancelled {
-			fmt.Println(ui.Yellow("\n✗ Cancelled"))
-			return false, nil
-		}
-
-		answer := strings.ToLower(strings.TrimSpace(line))
-		if answer == "" || answer == "y" {
-			printSuccess("✓ Running...")
-			return true, nil
-		}
-		fmt.Println(ui.Yellow("✗ Rejected"))
-		return false, nil
+	approver := &approverRecord{}
+	// The journal callback needs the session, which exists once the agent
+	// does.
+	var state *replState
+	callbacks := AgentCallbacks{
+		PromptApproval:       newPromptApproval(reader, sigCh, cfg.Approval, approver),
+		PromptCommand:        newPromptCommand(reader, sigCh, cfg.Approval, approver),
+		PromptFileChanges:    newPromptFileChanges(reader, sigCh, cfg.Approval, approver),
+		PromptRemainingCalls: newPromptRemainingCalls(reader, sigCh),
+		ConfirmDangerous:     newConfirmDangerous(reader, sigCh, approver),
+		AskUser:              newAskUser(reader, sigCh),
+		OnAutoApproved:       printAutoApproved,
+		OnDenied:             printDenied,
+		OnDebugLog:           debugLog,
+		Approver:             approver.get,
+		OnMessages: func(messages []types.Message, from int) {
+			if state == nil || len(messages) <= 1 {
+				return
+			}
+			if err := session.Journal(state.session, messages, from); err != nil {
+				debugLog("Session journal", err.Error())
+			}
+		},
+	}
+	if cfg.Approval.Strategy != config.ApprovalPrompt {
+		callbacks.PromptApproval, callbacks.PromptFileChanges = unattendedCallbacks(cfg.Approval, cfg.Approval.Strategy, cfg.Approval.Strategy, approver)
+		callbacks.PromptCommand = nil
+		callbacks.PromptRemainingCalls = nil
+		callbacks.ConfirmDangerous = nil
+		callbacks.AskUser = nil
 	}
 
 	agent, err := CreateAgent(AgentOptions{
@@ -100,100 +153,259 @@ func Main(options MainOptions) error {
 		SystemPrompt:  systemPrompt,
 		WorkspaceRoot: workspaceRoot,
 		Debug:         debug,
-		Callbacks: AgentCallbacks{
-			PromptApproval: promptApproval,
-			OnAutoApproved: printAutoApproved,
-			OnDenied:       printDenied,
-			OnDebugLog:     debugLog,
-		},
+		Callbacks:     callbacks,
+		OnEvent:       RenderEvents(os.Stdout),
 	})
 	if err != nil {
 		printError(err.Error())
 		return err
 	}
 
-	fmt.Println(ui.Bold("Minimal Agent") + ui.Gray(fmt.Sprintf(" (%s)", agent.GetModel())))
+	fmt.Println(ui.Bold("Minimal Agent") + ui.Muted(fmt.Sprintf(" (%s)", agent.GetModel())))
 	if debug {
-		fmt.Println(ui.Magenta("[DEBUG MODE ENABLED]"))
+		fmt.Println(ui.Debug("[DEBUG MODE ENABLED]"))
+	}
+	if llmConfig := agent.GetLlmConfig(); llmConfig.SchemaType != config.SchemaMock {
+		var statusErr *providers.StatusError
+		if err := pingProvider(llmConfig); errors.As(err, &statusErr) && statusErr.Unauthorized() {
+			return err
+		}
+	}
+	if isTerminal(os.Stdin) {
+		offerPromptTrim(reader, agent, env.prompt, cfg.Context.SystemPromptBudget)
 	}
-	fmt.Println(ui.Gray("Type /help for commands, /exit to quit."))
+	fmt.Println(ui.Muted("Type /help for commands, /exit to quit."))
 	fmt.Println("")
 
-	bufferedShellOutput := ""
-
-	for {
-		tokens := agent.GetTokens()
-		if tokens.Total > 0 {
-			fmt.Println(ui.Gray(fmt.Sprintf("[session] %d tokens", tokens.Total)))
-		}
+	state = &replState{
+		reader:        reader,
+		sigCh:         sigCh,
+		agent:         agent,
+		workspaceRoot: workspaceRoot,
+		session:       session.New("", agent.GetModel(), workspaceRoot),
+		models:        cfg.Models,
+		prompt:        env.prompt,
+		env:           env,
+	}
+	state.session.Agent = cfg.Agent
+	defer agent.Shutdown()
+	defer handleTermination(func(sig os.Signal) {
+		agent.Shutdown()
+		state.saveSession()
+		printShutdown(sig, "session saved as "+state.session.ID)
+	})()
 
-		line, cancelled, err := readLine(reader, ui.Cyan("> "), sigCh, false)
-		if err != nil {
-			return err
-		}
-		if cancelled {
-			return nil
-		}
+	// A panic ends only the current iteration: the session is saved, a
+	// crash report written, and the loop starts over if the user agrees.
+	repl := func() error {
+		for {
+			tokens := agent.GetTokens()
+			if tokens.Total > 0 {
+				fmt.Println(ui.Muted(fmt.Sprintf("[session] %d tokens", tokens.Total)))
+			}
 
-		line = strings.TrimSpace(line)
-		if line == "" {
-			continue
-		}
+			line := state.queued
+			state.queued = ""
+			if line == "" {
+				input, cancelled, err := readLine(reader, state.buffer.indicator()+ui.Prompt("> "), sigCh, false)
+				if err != nil {
+					return err
+				}
+				if cancelled {
+					return nil
+				}
+				line = input
+			}
 
-		if strings.HasPrefix(line, "!") {
-			command := strings.TrimSpace(strings.TrimPrefix(line, "!"))
-			if command == "" {
+			line = strings.TrimSpace(line)
+			if line == "" {
 				continue
 			}
-			result := policy.RunBash(command, workspaceRoot)
-			if strings.TrimSpace(result.Stdout) != "" {
-				fmt.Println(strings.TrimRight(result.Stdout, "\n"))
-			}
-			if strings.TrimSpace(result.Stderr) != "" {
-				if result.Code != 0 {
-					fmt.Println(ui.Red(strings.TrimRight(result.Stderr, "\n")))
-				} else {
-					fmt.Println(strings.TrimRight(result.Stderr, "\n"))
+			if !strings.HasPrefix(line, "/history") {
+				if err := history.Append(workspaceRoot, line); err != nil {
+					debugLog("Prompt history", err.Error())
 				}
 			}
 
-			formatted := policy.FormatCommandResult(command, result)
-			if bufferedShellOutput == "" {
-				bufferedShellOutput = formatted
-			} else {
-				bufferedShellOutput += "\n\n" + formatted
+			if strings.HasPrefix(line, "!") {
+				command := strings.TrimSpace(strings.TrimPrefix(line, "!"))
+				if command == "" {
+					continue
+				}
+				result := policy.RunBash(command, workspaceRoot)
+				if strings.TrimSpace(result.Stdout) != "" {
+					fmt.Println(strings.TrimRight(result.Stdout, "\n"))
+				}
+				if strings.TrimSpace(result.Stderr) != "" {
+					if result.Code != 0 {
+						fmt.Println(ui.Error(strings.TrimRight(result.Stderr, "\n")))
+					} else {
+						fmt.Println(strings.TrimRight(result.Stderr, "\n"))
+					}
+				}
+
+				evicted := state.buffer.evicted
+				state.buffer.add(policy.FormatCommandResult(command, result))
+				if state.buffer.evicted > evicted {
+					fmt.Println(ui.Muted(fmt.Sprintf("[buffer] dropped oldest output to stay under %s (/buffer show)", formatChars(maxShellBufferChars))))
+				}
+				continue
 			}
-			continue
-		}
 
-		if strings.HasPrefix(line, "/") {
-			shouldContinue, err := handleSlashCommand(line, reader, agent, &bufferedShellOutput)
-			if err != nil {
-				printError(err.Error())
+			if strings.HasPrefix(line, "/") {
+				shouldContinue, err := handleSlashCommand(line, state)
+				if err != nil {
+					printError(err.Error())
+					continue
+				}
+				if !shouldContinue {
+					break
+				}
 				continue
 			}
-			if !shouldContinue {
-				break
+
+			expanded, mentions, warnings := expandFileMentions(line, workspaceRoot)
+			printMentions(mentions, warnings)
+			if retrieved := state.retrieveContext(line); retrieved != "" {
+				expanded += "\n\n" + retrieved
+			}
+
+			userContent := state.buffer.prepend(expanded)
+			untitled := state.session.Title == ""
+			if untitled {
+				state.session.Title = session.Title(line)
+			}
+			agent.AddUserMessage(userContent)
+
+			state.runTurn()
+			if untitled && cfg.UI.ModelTitles {
+				if title, err := agent.SuggestTitle(line); err != nil {
+					debugLog("Session title", err.Error())
+				} else if title != "" {
+					state.session.Title = session.Title(title)
+				}
 			}
-			continue
+			state.saveSession()
 		}
 
-		userContent := line
-		if bufferedShellOutput != "" {
-			userContent = bufferedShellOutput + "\n\n" + line
-			bufferedShellOutput = ""
+		return nil
+	}
+	for {
+		crashed, err := state.guard("the REPL", repl)
+		if !crashed {
+			return err
 		}
-		agent.AddUserMessage(userContent)
+	}
+}
 
-		if err := agent.RunAgentTurn(); err != nil {
-			printError(err.Error())
+type environment struct {
+	config        config.Config
+	systemPrompt  string
+	workspaceRoot string
+	prompt        *prompt.Builder
+	// base is the environment before an agent profile was applied.
+	base *environment
+}
+
+func loadEnvironment() (environment, error) {
+	if err := config.EnsureMinimalDir(); err != nil {
+		printError("~/.minimal directory not found.")
+		fmt.Println(ui.Muted("Run mini-go init to create it."))
+		return environment{}, err
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		printError(err.Error())
+		warnConfigIssues()
+		return environment{}, err
+	}
+	warnConfigIssues()
+	if cfg.Storage.Encrypt != "" {
+		if err := vault.Enable(cfg.Storage.Encrypt); err != nil {
+			printError("Encrypted storage: " + err.Error())
+			return environment{}, err
 		}
 	}
+	theme, err := ui.BuildTheme(ui.CurrentTheme().Enabled, cfg.UI.ThemePreset, cfg.UI.ThemeStyles)
+	ui.SetTheme(theme)
+	if err != nil {
+		fmt.Println(ui.Warning("Warning: " + err.Error()))
+	}
 
-	return nil
+	systemPrompt, err := config.LoadSystemPrompt()
+	if err != nil {
+		printError("~/.minimal/system.md not found or empty.")
+		fmt.Println(ui.Muted("Run: mkdir -p ~/.minimal && touch ~/.minimal/system.md"))
+		return environment{}, err
+	}
+
+	workspaceRoot, err := resolveWorkspaceRoot()
+	if err != nil {
+		return environment{}, err
+	}
+	if profile := detectProjectProfile(workspaceRoot); len(profile.toolchains) > 0 {
+		note := "[project] " + profile.names()
+		if cfg.Policy.AutoProjectCommands {
+			cfg.Policy.AutoCommands = append(append([]string{}, cfg.Policy.AutoCommands...), profile.autoCommands()...)
+			note += " · auto-approving " + strings.Join(profile.autoCommands(), ", ")
+		}
+		fmt.Println(ui.Muted(note))
+	}
+	sections := buildPromptSections(systemPrompt, workspaceRoot)
+	if promptTokens(sections) > cfg.Context.SystemPromptBudget {
+		printPromptBreakdown(sections, cfg.Context.SystemPromptBudget)
+	}
+
+	return environment{config: cfg, systemPrompt: sections.Build(), workspaceRoot: workspaceRoot, prompt: sections}, nil
 }
 
-func handleSlashCommand(line string, reader *bufio.Reader, agent Agent, bufferedShellOutput *string) (bool, error) {
+func resolveWorkspaceRoot() (string, error) {
+	workspaceRoot := os.Getenv("WORKSPACE_ROOT")
+	if workspaceRoot == "" {
+		cwd, err := os.Getwd()
+		if err != nil {
+			return "", err
+		}
+		workspaceRoot = cwd
+	}
+	return filepath.Abs(workspaceRoot)
+}
+
+type replState struct {
+	reader        *bufio.Reader
+	agent         Agent
+	workspaceRoot string
+	buffer        shellBuffer
+	session       *session.Session
+	queued        string
+	sigCh         <-chan os.Signal
+	costMark      float64
+	models        map[string]config.ModelInfo
+	prompt        *prompt.Builder
+	env           environment
+	review        *reviewReport
+}
+
+func (s *replState) saveSession() {
+	messages := s.agent.GetMessages()
+	if len(messages) <= 1 {
+		return
+	}
+	s.session.Messages = messages
+	s.session.Model = s.agent.GetModel()
+	cost := sessionCost(s.agent)
+	s.session.Cost += cost - s.costMark
+	s.costMark = cost
+	if err := session.Save(s.session); err != nil {
+		fmt.Println(ui.Muted("[session] save failed: " + err.Error()))
+	}
+}
+
+func handleSlashCommand(line string, state *replState) (bool, error) {
+	reader := state.reader
+	agent := state.agent
+
 	parts := strings.Fields(strings.TrimPrefix(line, "/"))
 	if len(parts) == 0 {
 		return true, nil
@@ -206,45 +418,131 @@ func handleSlashCommand(line string, reader *bufio.Reader, agent Agent, buffered
 	case "exit", "quit":
 		return false, nil
 	case "clear", "new":
-		agent.Clear()
-		*bufferedShellOutput = ""
-		printSuccess("✓ Conversation cleared.")
+		return true, state.clearConversation(args)
+	case "fork":
+		if args == "" {
+			return true, errors.New("Usage: /fork <name>")
+		}
+		state.saveSession()
+		state.session = state.session.Fork(args)
+		state.costMark = sessionCost(agent)
+		state.session.Messages = agent.GetMessages()
+		if err := session.Save(state.session); err != nil {
+			return true, err
+		}
+		printSuccess(fmt.Sprintf("✓ Forked into %s", state.session.Label()))
+		return true, nil
+	case "resume":
+		if args == "" {
+			printSessionList()
+			return true, nil
+		}
+		loaded, err := session.Load(args)
+		if err != nil {
+			return true, err
+		}
+		state.saveSession()
+		state.session = loaded
+		state.costMark = sessionCost(agent)
+		agent.SetMessages(loaded.Messages)
+		state.buffer.clear()
+		printSuccess(fmt.Sprintf("✓ Resumed %s (%d messages)", loaded.Label(), len(loaded.Messages)))
+		return true, nil
+	case "sessions":
+		printSessionList()
+		return true, nil
+	case "search":
+		if args == "" {
+			return true, errors.New("Usage: /search <query>")
+		}
+		return true, printSearchResults(args, defaultSearchLimit)
+	case "memory":
+		return true, handleMemoryCommand(parts[1:])
+	case "share":
+		return true, state.share(args == "gist")
+	case "stats":
+		printTurnStats(agent.GetTurnStats())
+		printRateLimits(agent.GetRateLimits())
+		return true, nil
+	case "ping":
+		pingProvider(agent.GetLlmConfig())
+		return true, nil
+	case "models":
+		return true, state.handleModelsCommand(args)
+	case "config":
+		return true, state.handleConfigCommand()
+	case "history":
+		return true, state.handleHistoryCommand(args)
+	case "messages":
+		return true, state.handleMessagesCommand()
+	case "drop":
+		return true, state.handleDropCommand(args)
+	case "context":
+		return true, state.handleContextCommand(args)
+	case "retry":
+		return true, state.handleRetryCommand(args)
+	case "review":
+		return true, state.handleReviewCommand(args)
+	case "agent":
+		return true, state.handleAgentCommand(args)
+	case "readonly":
+		return true, state.handleReadOnlyCommand(args)
+	case "cmd":
+		return true, state.handleCmdCommand(args)
+	case "pr-desc":
+		return true, state.handlePRDescCommand(args)
+	case "copy":
+		return true, state.handleCopyCommand(args)
+	case "buffer":
+		return true, state.handleBufferCommand(args)
+	case "thinking-display":
+		return true, handleThinkingDisplay(args, state)
+	case "thinking":
+		expandThinking(agent)
+		return true, nil
+	case "expand":
+		return true, expandOutput(agent, args)
+	case "budget":
+		if args == "override" {
+			agent.OverrideBudget()
+			fmt.Println(ui.Warning("Budget limits overridden for this session."))
+			return true, nil
+		}
+		printBudgetStatus(agent.GetBudgetStatus())
 		return true, nil
 	case "help":
 		printHelp()
 		return true, nil
 	case "skill":
 		if args == "" {
-			printSkillList(listSkills())
+			printSkillList(skills.List())
 			return true, nil
 		}
-		skillContent, err := loadSkill(args)
+		skill, err := skills.Load(args)
 		if err != nil {
 			printError(fmt.Sprintf("Skill not found: %s", args))
-			printSkillList(listSkills())
+			printSkillList(skills.List())
 			return true, nil
 		}
 
-		printSkillLoaded(args, skillContent)
-		fmt.Print(ui.Gray("Additional input (optional): "))
+		printSkillLoaded(args, skill.Content)
+		if skill.Dir != "" {
+			agent.GrantSkill(skill.Dir, state.confirmSkillScripts(skill))
+		}
+		fmt.Print(ui.Muted("Additional input (optional): "))
 		additional, _ := reader.ReadString('\n')
 		additional = strings.TrimSpace(additional)
 
-		baseContent := skillContent
+		baseContent := skill.Prompt()
 		if additional != "" {
 			baseContent = baseContent + "\n\n" + additional
 		}
 
-		userContent := baseContent
-		if *bufferedShellOutput != "" {
-			userContent = *bufferedShellOutput + "\n\n" + baseContent
-			*bufferedShellOutput = ""
-		}
+		userContent := state.buffer.prepend(baseContent)
 
 		agent.AddUserMessage(userContent)
-		if err := agent.RunAgentTurn(); err != nil {
-			printError(err.Error())
-		}
+		state.runTurn()
+		state.saveSession()
 		return true, nil
 	default:
 		printError(fmt.Sprintf("Unknown command: /%s", cmd))
@@ -253,74 +551,481 @@ func handleSlashCommand(line string, reader *bufio.Reader, agent Agent, buffered
 	}
 }
 
-func readLine(reader *bufio.Reader, prompt string, sigCh <-chan os.Signal, allowCancel bool) (string, bool, error) {
-	fmt.Print(prompt)
+func newPromptApproval(reader *bufio.Reader, sigCh <-chan os.Signal, approval config.ApprovalConfig, approver *approverRecord) func(command string) (bool, error) {
+	return func(command string) (bool, error) {
+		approved, _, err := promptCommand(reader, sigCh, approval, approver, command, false)
+		return approved, err
+	}
+}
+
+func newConfirmDangerous(reader *bufio.Reader, sigCh <-chan os.Signal, approver *approverRecord) func(command string, reason string) (bool, error) {
+	return func(command string, reason string) (bool, error) {
+		fmt.Println("")
+		fmt.Println(ui.Error("Dangerous command:"))
+		fmt.Println(ui.Bold("  " + command))
+		fmt.Println(ui.Warning("  This " + reason + "; it is normally denied and only runs because policy.confirmDangerous allows it."))
+		fmt.Println("")
+		line, cancelled, err := readLine(reader, ui.Prompt(fmt.Sprintf("Type %s to run it, anything else rejects: ", policy.ConfirmPhrase)), sigCh, false)
+		if err != nil {
+			return false, err
+		}
+		approver.set(approverName())
+		if cancelled || strings.TrimSpace(line) != policy.ConfirmPhrase {
+			fmt.Println(ui.Warning("✗ Rejected"))
+			return false, nil
+		}
+		return true, nil
+	}
+}
 
-	lineCh := make(chan string, 1)
-	errCh := make(chan error, 1)
+func newPromptCommand(reader *bufio.Reader, sigCh <-chan os.Signal, approval config.ApprovalConfig, approver *approverRecord) func(command string) (bool, string, error) {
+	return func(command string) (bool, string, error) {
+		return promptCommand(reader, sigCh, approval, approver, command, true)
+	}
+}
 
-	go func() {
-		text, err := reader.ReadString('\n')
+func promptCommand(reader *bufio.Reader, sigCh <-chan os.Signal, approval config.ApprovalConfig, approver *approverRecord, command string, allowEdit bool) (bool, string, error) {
+	fmt.Println("")
+	fmt.Println(ui.Warning("Command:"))
+	fmt.Println(ui.Bold("  " + command))
+	fmt.Println("")
+	fmt.Println(ui.Muted("  [enter/y] Run"))
+	if allowEdit {
+		fmt.Println(ui.Muted("  [e]       Edit before running"))
+	}
+	fmt.Println(ui.Muted("  [n]       Reject"))
+	fmt.Println(ui.Muted("  [ctrl+c]  Cancel"))
+	printApprovalTimeout(approval)
+	fmt.Println("")
+
+	line, cancelled, err := readLineWithin(reader, ui.Prompt("> "), sigCh, approvalTimeout(approval))
+	if errors.Is(err, errPromptTimeout) {
+		return timeoutDecision(approval, approver, command, nil), "", nil
+	}
+	if err != nil {
+		return false, "", err
+	}
+	approver.set(approverName())
+	if cancelled {
+		fmt.Println(ui.Warning("\n✗ Cancelled"))
+		return false, "", nil
+	}
+
+	answer := strings.ToLower(strings.TrimSpace(line))
+	if answer == "" || answer == "y" {
+		printSuccess("✓ Running...")
+		return true, "", nil
+	}
+	if allowEdit && answer == "e" {
+		edited, err := editInput(reader, sigCh, command)
 		if err != nil {
-			errCh <- err
-			return
+			return false, "", err
+		}
+		if edited == "" {
+			fmt.Println(ui.Warning("✗ Rejected"))
+			return false, "", nil
+		}
+		if edited != command {
+			fmt.Println(ui.Bold("  " + edited))
 		}
-		lineCh <- text
-	}()
+		printSuccess("✓ Running...")
+		return true, edited, nil
+	}
+	fmt.Println(ui.Warning("✗ Rejected"))
+	return false, "", nil
+}
 
-	select {
-	case sig := <-sigCh:
-		_ = sig
-		if allowCancel {
-			return "", true, nil
+func newPromptFileChanges(reader *bufio.Reader, sigCh <-chan os.Signal, approval config.ApprovalConfig, approver *approverRecord) func(command string, changes []FileChange) ([]FileApproval, error) {
+	return func(command string, changes []FileChange) ([]FileApproval, error) {
+		fmt.Println("")
+		if command != "" {
+			fmt.Println(ui.Warning("Command:"))
+			fmt.Println(ui.Bold("  " + strings.SplitN(command, "\n", 2)[0]))
+			fmt.Println("")
 		}
-		return "", true, nil
-	case err := <-errCh:
-		if errors.Is(err, os.ErrClosed) {
-			return "", true, nil
+		for _, change := range changes {
+			printFileChange(os.Stdout, change)
+			fmt.Println("")
 		}
-		return "", false, err
-	case line := <-lineCh:
-		return line, false, nil
+		fmt.Println(ui.Muted("  [enter/y] Approve"))
+		if command == "" {
+			fmt.Println(ui.Muted("  [a]       Approve and allow further writes to this file this session"))
+		}
+		if len(changes) > 1 {
+			fmt.Println(ui.Muted("  [p]       Decide per file"))
+		}
+		fmt.Println(ui.Muted("  [n]       Reject"))
+		fmt.Println(ui.Muted("  [ctrl+c]  Cancel"))
+		printApprovalTimeout(approval)
+		fmt.Println("")
+
+		answers := make([]FileApproval, len(changes))
+		line, cancelled, err := readLineWithin(reader, ui.Prompt("> "), sigCh, approvalTimeout(approval))
+		if errors.Is(err, errPromptTimeout) {
+			if timeoutDecision(approval, approver, command, changes) {
+				fillApprovals(answers, FileApproved)
+			}
+			return answers, nil
+		}
+		if err != nil {
+			return answers, err
+		}
+		approver.set(approverName())
+		if cancelled {
+			fmt.Println(ui.Warning("\n✗ Cancelled"))
+			return answers, nil
+		}
+
+		switch strings.ToLower(strings.TrimSpace(line)) {
+		case "", "y":
+			fillApprovals(answers, FileApproved)
+		case "a":
+			if command != "" {
+				fmt.Println(ui.Warning("✗ [a] only applies to write_file/edit_file; rejected"))
+				return answers, nil
+			}
+			fillApprovals(answers, FileApprovedAlways)
+		case "p":
+			for i, change := range changes {
+				answer, cancelled, err := readLine(reader, ui.Prompt("  "+change.Path+" [y/n] "), sigCh, true)
+				if err != nil || cancelled {
+					return make([]FileApproval, len(changes)), err
+				}
+				if answer = strings.ToLower(strings.TrimSpace(answer)); answer == "" || answer == "y" {
+					answers[i] = FileApproved
+				} else if command != "" {
+					fmt.Println(ui.Warning("✗ Rejected (the command writes every file at once)"))
+					return make([]FileApproval, len(changes)), nil
+				}
+			}
+		default:
+			fmt.Println(ui.Warning("✗ Rejected"))
+			return answers, nil
+		}
+		printSuccess("✓ Approved")
+		return answers, nil
 	}
 }
 
-func listSkills() []string {
-	entries, err := os.ReadDir(config.SkillsDir)
-	if err != nil {
-		return nil
+func newPromptRemainingCalls(reader *bufio.Reader, sigCh <-chan os.Signal) func(remaining []types.ToolCall) bool {
+	return func(remaining []types.ToolCall) bool {
+		fmt.Println("")
+		fmt.Println(ui.Warning(fmt.Sprintf("%d more tool call(s) in this batch:", len(remaining))))
+		for _, call := range remaining {
+			fmt.Println(ui.Bold("  " + describeToolCall(call, 60)))
+		}
+		fmt.Println("")
+		fmt.Println(ui.Muted("  [y]       Continue with them"))
+		fmt.Println(ui.Muted("  [enter/n] Skip them; the model is told they did not run"))
+		fmt.Println("")
+		line, cancelled, err := readLine(reader, ui.Prompt("> "), sigCh, false)
+		if err != nil || cancelled || strings.ToLower(strings.TrimSpace(line)) != "y" {
+			fmt.Println(ui.Warning(fmt.Sprintf("✗ Skipped %d call(s)", len(remaining))))
+			return false
+		}
+		return true
 	}
-	var skills []string
-	for _, entry := range entries {
-		if entry.IsDir() {
-			continue
+}
+
+// newAskUser shows an ask_user question as a numbered menu. A number picks
+// that option; other text is taken as the answer when the question allows it.
+func newAskUser(reader *bufio.Reader, sigCh <-chan os.Signal) func(question Question) (string, bool, error) {
+	return func(question Question) (string, bool, error) {
+		fmt.Println("")
+		fmt.Println(ui.Warning("Question:"))
+		for _, line := range strings.Split(question.Text, "\n") {
+			fmt.Println(ui.Bold("  " + line))
 		}
-		name := entry.Name()
-		if strings.HasSuffix(name, ".md") {
-			skills = append(skills, strings.TrimSuffix(name, ".md"))
+		fmt.Println("")
+		for i, option := range question.Options {
+			fmt.Printf("  [%d]       %s\n", i+1, option)
+		}
+		switch {
+		case len(question.Options) == 0:
+			fmt.Println(ui.Muted("  Type your answer"))
+		case question.AllowOther:
+			fmt.Println(ui.Muted("  Pick a number, or type your own answer"))
+		}
+		fmt.Println(ui.Muted("  [enter]   Skip the question"))
+		fmt.Println("")
+		for {
+			line, cancelled, err := readLine(reader, ui.Prompt("> "), sigCh, true)
+			if err != nil {
+				return "", false, err
+			}
+			line = strings.TrimSpace(line)
+			if cancelled || line == "" {
+				fmt.Println(ui.Warning("✗ Skipped"))
+				return "", false, nil
+			}
+			if n, err := strconv.Atoi(line); err == nil && n >= 1 && n <= len(question.Options) {
+				printSuccess("✓ " + question.Options[n-1])
+				return question.Options[n-1], true, nil
+			}
+			if question.AllowOther {
+				return line, true, nil
+			}
+			fmt.Println(ui.Warning(fmt.Sprintf("Pick a number from 1 to %d.", len(question.Options))))
 		}
 	}
-	return skills
 }
 
-func loadSkill(name string) (string, error) {
-	path := filepath.Join(config.SkillsDir, name+".md")
-	data, err := os.ReadFile(path)
+func fillApprovals(answers []FileApproval, value FileApproval) {
+	for i := range answers {
+		answers[i] = value
+	}
+}
+
+func editInput(reader *bufio.Reader, sigCh <-chan os.Signal, text string) (string, error) {
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	multiline := strings.Contains(text, "\n")
+	if !multiline && isTerminal(os.Stdin) {
+		return editLine(text)
+	}
+	if editor == "" || !isTerminal(os.Stdin) {
+		fmt.Println(ui.Muted("Type the replacement (empty keeps it unchanged):"))
+		line, cancelled, err := readLine(reader, ui.Prompt("edit> "), sigCh, true)
+		if err != nil || cancelled {
+			return "", err
+		}
+		if strings.TrimSpace(line) == "" {
+			return text, nil
+		}
+		return strings.TrimSpace(line), nil
+	}
+
+	file, err := os.CreateTemp("", "mini-go-edit-*.txt")
 	if err != nil {
 		return "", err
 	}
-	return string(data), nil
+	defer os.Remove(file.Name())
+	if _, err := file.WriteString(text + "\n"); err != nil {
+		file.Close()
+		return "", err
+	}
+	file.Close()
+
+	cmd := exec.Command("sh", "-c", editor+` "$1"`, "sh", file.Name())
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("editor failed: %w", err)
+	}
+	data, err := os.ReadFile(file.Name())
+	if err != nil {
+		return "", err
+	}
+	edited := strings.TrimSpace(string(data))
+	if edited == "" {
+		fmt.Println(ui.Muted("Empty input; cancelled."))
+	}
+	return edited, nil
+}
+
+func editLine(text string) (string, error) {
+	cmd := exec.Command("bash", "-c", `IFS= read -r -e -i "$1" -p "$2" line && printf '%s' "$line"`, "bash", text, "edit> ")
+	cmd.Stdin, cmd.Stderr = os.Stdin, os.Stderr
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("edit cancelled: %w", err)
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+func isTerminal(file *os.File) bool {
+	info, err := file.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+func readLine(reader *bufio.Reader, prompt string, sigCh <-chan os.Signal, allowCancel bool) (string, bool, error) {
+	return readLineWithin(reader, prompt, sigCh, 0)
+}
+
+func readLineWithin(reader *bufio.Reader, prompt string, sigCh <-chan os.Signal, timeout time.Duration) (string, bool, error) {
+	fmt.Print(prompt)
+
+	read := startRead(reader)
+	var deadline <-chan time.Time
+	if timeout > 0 {
+		deadline = time.After(timeout)
+	}
+
+	select {
+	case <-sigCh:
+		return "", true, nil
+	case <-deadline:
+		return "", false, errPromptTimeout
+	case err := <-read.errs:
+		finishRead(reader)
+		if errors.Is(err, os.ErrClosed) {
+			return "", true, nil
+		}
+		return "", false, err
+	case line := <-read.lines:
+		finishRead(reader)
+		return line, false, nil
+	}
 }
 
 func printHelp() {
 	fmt.Println("")
 	fmt.Println(ui.Bold("Commands:"))
-	fmt.Println(ui.Cyan("  /skill <name>") + ui.Gray("   Load skill from ~/.minimal/skills/"))
-	fmt.Println(ui.Cyan("  /clear, /new") + ui.Gray("    Reset conversation"))
-	fmt.Println(ui.Cyan("  /help") + ui.Gray("           Show this help"))
-	fmt.Println(ui.Cyan("  /exit, /quit") + ui.Gray("    Exit"))
+	fmt.Println(ui.Cyan("  /skill <name>") + ui.Muted("   Load skill from ~/.minimal/skills/"))
+	fmt.Println(ui.Cyan("  /clear, /new") + ui.Muted("    Reset conversation (summary: keep a recap, full: never)"))
+	fmt.Println(ui.Cyan("  /fork <name>") + ui.Muted("    Branch conversation into a new session"))
+	fmt.Println(ui.Cyan("  /resume <id>") + ui.Muted("    Resume a saved session (by id or name)"))
+	fmt.Println(ui.Cyan("  /sessions") + ui.Muted("       List saved sessions"))
+	fmt.Println(ui.Cyan("  /search <q>") + ui.Muted("     Search past session transcripts"))
+	fmt.Println(ui.Cyan("  /memory") + ui.Muted("         Show memory (add <fact> | forget <n> | clear)"))
+	fmt.Println(ui.Cyan("  /share [gist]") + ui.Muted("   Export a redacted transcript (optionally as a private gist)"))
+	fmt.Println(ui.Cyan("  /stats") + ui.Muted("          Per-request tokens, latency, tools and cost"))
+	fmt.Println(ui.Cyan("  /ping") + ui.Muted("           Check the provider is reachable and the key works"))
+	fmt.Println(ui.Cyan("  /models [filter]") + ui.Muted(" List the provider's models and switch to one"))
+	fmt.Println(ui.Cyan("  /config") + ui.Muted("         Edit variant, temperature, max tokens and policy in config.json"))
+	fmt.Println(ui.Cyan("  /budget") + ui.Muted("         Show spend vs. limits (override to continue past a cap)"))
+	fmt.Println(ui.Cyan("  /buffer [clear]") + ui.Muted("  Show or drop !command output queued for the next prompt"))
+	fmt.Println(ui.Cyan("  /history [n|q]") + ui.Muted("  List recent prompts, recall #n, or search for q"))
+	fmt.Println(ui.Cyan("  /history search <q>") + ui.Muted(" Recall the newest prompt containing q"))
+	fmt.Println(ui.Cyan("  /messages") + ui.Muted("       List the conversation with indices and token sizes"))
+	fmt.Println(ui.Cyan("  /drop <n>[-m]") + ui.Muted("   Remove messages from history (or /drop tool-results)"))
+	fmt.Println(ui.Cyan("  /context [full]") + ui.Muted("  Show the AGENTS.md and project instructions in the prompt"))
+	fmt.Println(ui.Cyan("  /retry [variant]") + ui.Muted(" Re-run the last prompt, optionally on another variant"))
+	fmt.Println(ui.Cyan("  /review [ref]") + ui.Muted("    Review git diff <ref> per file (/review export [path] saves it)"))
+	fmt.Println(ui.Cyan("  /agent [name]") + ui.Muted("    List agent profiles or switch to one (/agent none for the base)"))
+	fmt.Println(ui.Cyan("  /readonly [on|off]") + ui.Muted(" Toggle read-only mode: no file writes, only inspecting commands"))
+	fmt.Println(ui.Cyan("  /cmd <name> [args]") + ui.Muted(" Send a prompt template from ~/.minimal/commands (/cmd lists them)"))
+	fmt.Println(ui.Cyan("  /copy [n]") + ui.Muted("        Copy the nth (default: last) code block of the last answer"))
+	fmt.Println(ui.Cyan("  /pr-desc [base]") + ui.Muted("  Draft a PR description from the staged diff or base...HEAD"))
+	fmt.Println(ui.Cyan("  /thinking") + ui.Muted("       Show the last thinking block in full"))
+	fmt.Println(ui.Cyan("  /expand [n]") + ui.Muted("     Show a command's full output (default: the last one)"))
+	fmt.Println(ui.Cyan("  /thinking-display on|off|collapsed") + ui.Muted("  How thinking output is shown (saved)"))
+	fmt.Println(ui.Cyan("  /help") + ui.Muted("           Show this help"))
+	fmt.Println(ui.Cyan("  /exit, /quit") + ui.Muted("    Exit"))
+	fmt.Println("")
+	fmt.Println(ui.Cyan("  !<command>") + ui.Muted("      Execute shell command directly"))
+	fmt.Println(ui.Cyan("  @file[:a-b]") + ui.Muted("     Inline a file (or line range) into your prompt"))
+	fmt.Println("")
+}
+
+func (s *replState) share(uploadGist bool) error {
+	s.saveSession()
+	s.session.Messages = s.agent.GetMessages()
+	rendered := share.RenderMarkdown(s.session)
+
+	if err := os.MkdirAll(config.SharedDir, 0o755); err != nil {
+		return err
+	}
+	path := filepath.Join(config.SharedDir, s.session.ID+".md")
+	if err := os.WriteFile(path, []byte(rendered), 0o600); err != nil {
+		return err
+	}
+	printSuccess("✓ Redacted transcript written to " + path)
+
+	if !uploadGist {
+		fmt.Println(ui.Muted("Review it, then run /share gist to upload it as a private gist."))
+		return nil
+	}
+	url, err := share.UploadGist("mini-go-"+s.session.ID+".md", rendered, "mini-go session "+s.session.Label())
+	if err != nil {
+		return err
+	}
+	printSuccess("✓ Shared: " + url)
+	return nil
+}
+
+func handleMemoryCommand(args []string) error {
+	if len(args) == 0 {
+		entries, err := memory.Entries()
+		if err != nil {
+			return err
+		}
+		fmt.Println("")
+		fmt.Println(ui.Bold("Memory:") + ui.Muted(" "+config.MemoryPath))
+		if len(entries) == 0 {
+			fmt.Println(ui.Muted("  (empty)"))
+		}
+		for i, entry := range entries {
+			fmt.Println(ui.Cyan(fmt.Sprintf("  %d.", i+1)) + " " + entry)
+		}
+		fmt.Println(ui.Muted("\nChanges apply to new sessions."))
+		fmt.Println("")
+		return nil
+	}
+
+	switch args[0] {
+	case "add":
+		fact := strings.Join(args[1:], " ")
+		if err := memory.Append(fact); err != nil {
+			return err
+		}
+		printSuccess("✓ Remembered: " + fact)
+	case "forget":
+		if len(args) < 2 {
+			return errors.New("Usage: /memory forget <n>")
+		}
+		index, err := strconv.Atoi(args[1])
+		if err != nil {
+			return fmt.Errorf("invalid entry number: %s", args[1])
+		}
+		removed, err := memory.Forget(index)
+		if err != nil {
+			return err
+		}
+		printSuccess("✓ Forgot: " + removed)
+	case "clear":
+		if err := memory.Clear(); err != nil {
+			return err
+		}
+		printSuccess("✓ Memory cleared.")
+	default:
+		return fmt.Errorf("Unknown memory command: %s", args[0])
+	}
+	return nil
+}
+
+func printSessionList() {
+	sessions, err := session.List()
 	fmt.Println("")
-	fmt.Println(ui.Cyan("  !<command>") + ui.Gray("      Execute shell command directly"))
+	fmt.Println(ui.Bold("Sessions:"))
+	if err != nil {
+		printError(err.Error())
+	} else if len(sessions) == 0 {
+		fmt.Println(ui.Muted("  (none)"))
+	}
+	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	if len(sessions) > 0 {
+		fmt.Fprintln(writer, "  ID\tTITLE\tUPDATED\tMODEL\tTURNS\tTOOLS\tFILES\tCOST")
+	}
+	for _, s := range sessions {
+		title := s.Title
+		if title == "" {
+			title = session.TitleFrom(s.Messages)
+		}
+		if s.Name != "" {
+			title = "[" + s.Name + "] " + title
+		}
+		if s.ParentID != "" {
+			title += " ← " + s.ParentID
+		}
+		cost := "-"
+		if s.Cost > 0 {
+			cost = fmt.Sprintf("$%.4f", s.Cost)
+		}
+		tools, files := "-", "-"
+		if len(s.TurnLog) > 0 {
+			var toolCalls, changed int
+			for _, turn := range s.TurnLog {
+				toolCalls += turn.ToolCalls
+				changed += turn.FilesChanged
+			}
+			tools, files = fmt.Sprint(toolCalls), fmt.Sprint(changed)
+		}
+		fmt.Fprintf(writer, "  %s\t%s\t%s\t%s\t%d\t%s\t%s\t%s\n", ui.Cyan(s.ID), title, ui.Muted(s.UpdatedAt.Format("2006-01-02 15:04")), ui.Muted(s.Model), s.Turns(), tools, files, ui.Muted(cost))
+	}
+	writer.Flush()
+	fmt.Println(ui.Muted("\nUsage: /resume <id|name>"))
 	fmt.Println("")
 }
 
@@ -328,42 +1033,73 @@ func printSkillList(skills []string) {
 	fmt.Println("")
 	fmt.Println(ui.Bold("Available skills:"))
 	if len(skills) == 0 {
-		fmt.Println(ui.Gray("  (none)"))
+		fmt.Println(ui.Muted("  (none)"))
 	} else {
 		for i, skill := range skills {
 			fmt.Println(ui.Cyan(fmt.Sprintf("  %d.", i+1)) + " " + skill)
 		}
 	}
-	fmt.Println(ui.Gray("\nUsage: /skill <name>"))
+	fmt.Println(ui.Muted("\nUsage: /skill <name>"))
 	fmt.Println("")
 }
 
+func (s *replState) confirmSkillScripts(skill skills.Skill) []string {
+	fmt.Println(ui.Muted(fmt.Sprintf("  read_file may read %s for this session", skill.Dir)))
+	if len(skill.Scripts) == 0 {
+		return nil
+	}
+	fmt.Println(ui.Warning(fmt.Sprintf("This skill bundles %d script(s):", len(skill.Scripts))))
+	for _, script := range skill.Scripts {
+		fmt.Println(ui.Muted("  " + script))
+	}
+	fmt.Print(ui.Warning("Run them without asking for the rest of this session? [y/N] "))
+	answer, _ := s.reader.ReadString('\n')
+	if strings.ToLower(strings.TrimSpace(answer)) != "y" {
+		return nil
+	}
+	printSuccess("✓ Skill scripts auto-approved for this session.")
+	return skill.Scripts
+}
+
 func printSkillLoaded(name string, content string) {
-	fmt.Println(ui.Green(fmt.Sprintf("✓ Loaded: %s", name)))
-	fmt.Println(ui.Gray(strings.Repeat("─", 40)))
+	fmt.Println(ui.Success(fmt.Sprintf("✓ Loaded: %s", name)))
+	fmt.Println(ui.Muted(strings.Repeat("─", 40)))
 	preview := content
 	if len(preview) > 200 {
 		preview = preview[:200] + "..."
 	}
-	fmt.Println(ui.Gray(preview))
-	fmt.Println(ui.Gray(strings.Repeat("─", 40)))
+	fmt.Println(ui.Muted(preview))
+	fmt.Println(ui.Muted(strings.Repeat("─", 40)))
+}
+
+func printMentions(mentions []fileMention, warnings []string) {
+	for _, mention := range mentions {
+		note := "✓ attached " + mention.label()
+		if mention.Truncated {
+			note += " (truncated)"
+		}
+		fmt.Println(ui.Muted(note))
+	}
+	for _, warning := range warnings {
+		fmt.Println(ui.Warning("! " + warning))
+	}
 }
 
 func printDenied(command string) {
 	fmt.Println("")
-	fmt.Println(ui.Bold(ui.Red("✗ Denied by policy:")))
-	fmt.Println(ui.Gray("  " + command))
+	fmt.Println(ui.Bold(ui.Error("✗ Denied by policy:")))
+	fmt.Println(ui.Muted("  " + command))
 	fmt.Println("")
 }
 
 func printAutoApproved(command string) {
-	fmt.Println(ui.Green("✓ " + command))
+	fmt.Println(ui.Success("✓ " + command))
 }
 
 func printError(msg string) {
-	fmt.Println(ui.Red("Error: " + msg))
+	fmt.Println(ui.Error("Error: " + msg))
 }
 
 func printSuccess(msg string) {
-	fmt.Println(ui.Green(msg))
+	fmt.Println(ui.Success(msg))
 }
@@ -0,0 +1,231 @@
+package core
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+
+	"minimal-go/internal/config"
+	"minimal-go/internal/policy"
+	"minimal-go/internal/session"
+	"minimal-go/internal/tools"
+	"minimal-go/internal/types"
+	"minimal-go/internal/ui"
+	"minimal-go/internal/vault"
+)
+
+const replayPreviewLength = 100
+
+type replayer struct {
+	reader *bufio.Reader
+	sigCh  <-chan os.Signal
+	pause  bool
+	// rerun re-executes read-only calls; nil when --rerun is off.
+	rerun *agent
+}
+
+// Replay prints a saved session turn by turn, pausing between turns when
+// run in a terminal.
+func Replay(args []string) error {
+	flags := flag.NewFlagSet("replay", flag.ContinueOnError)
+	rerun := flags.Bool("rerun", false, "re-run read-only tool calls (read_file, safe commands) and compare the results")
+	all := flags.Bool("all", false, "print every turn without pausing")
+	var positional []string
+	for rest := args; ; rest = flags.Args()[1:] {
+		if err := flags.Parse(rest); err != nil {
+			return err
+		}
+		if flags.NArg() == 0 {
+			break
+		}
+		positional = append(positional, flags.Arg(0))
+	}
+	if len(positional) != 1 {
+		printError("Usage: mini-go replay [--rerun] [--all] <session>")
+		return errors.New("session required")
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		printError(err.Error())
+		return err
+	}
+	if cfg.Storage.Encrypt != "" {
+		if err := vault.Enable(cfg.Storage.Encrypt); err != nil {
+			printError("Encrypted storage: " + err.Error())
+			return err
+		}
+	}
+	recorded, err := session.Load(positional[0])
+	if err != nil {
+		printError(err.Error())
+		return err
+	}
+
+	r := &replayer{reader: bufio.NewReader(os.Stdin), pause: !*all && isTerminal(os.Stdin)}
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, os.Interrupt)
+	defer signal.Stop(sigCh)
+	r.sigCh = sigCh
+	if *rerun {
+		if r.rerun, err = newRerunAgent(cfg, recorded.Workspace); err != nil {
+			fmt.Println(ui.Warning("--rerun is off: " + err.Error()))
+		}
+	}
+	r.play(recorded)
+	return nil
+}
+
+// The rerun agent never talks to a provider and rejects anything that would
+// need approval, so only auto-approved calls run.
+func newRerunAgent(cfg config.Config, workspace string) (*agent, error) {
+	if info, err := os.Stat(workspace); err != nil || !info.IsDir() {
+		return nil, fmt.Errorf("the session's workspace %s is gone", workspace)
+	}
+	created, err := CreateAgent(AgentOptions{
+		Config:        cfg,
+		WorkspaceRoot: workspace,
+		Callbacks: AgentCallbacks{
+			PromptApproval: func(string) (bool, error) { return false, nil },
+		},
+		OnEvent: func(Event) {},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return created.(*agent), nil
+}
+
+func (r *replayer) play(recorded *session.Session) {
+	title := recorded.Title
+	if title == "" {
+		title = session.TitleFrom(recorded.Messages)
+	}
+	turns := splitTurns(recorded.Messages)
+	fmt.Println(ui.Bold("Session "+recorded.Label()) + "  " + title)
+	details := []string{recorded.Model, recorded.Workspace, recorded.CreatedAt.Format("2006-01-02 15:04"), fmt.Sprintf("%d turn(s)", len(turns))}
+	if recorded.Cost > 0 {
+		details = append(details, formatCost(recorded.Cost, true))
+	}
+	fmt.Println(ui.Muted(strings.Join(details, " · ")))
+
+	results := map[string]types.Message{}
+	for _, message := range recorded.Messages {
+		if message.Role == types.RoleTool {
+			results[message.ToolCallID] = message
+		}
+	}
+	for i, turn := range turns {
+		fmt.Println(ui.Muted(fmt.Sprintf("\n─── turn %d/%d ───\n", i+1, len(turns))))
+		for _, message := range turn {
+			r.printMessage(message, results)
+		}
+		if !r.pause || i == len(turns)-1 {
+			continue
+		}
+		fmt.Println("")
+		answer, cancelled, err := readLine(r.reader, ui.Muted("[enter] next turn · [c] continue to the end · [q] quit: "), r.sigCh, false)
+		if err != nil || cancelled || strings.EqualFold(strings.TrimSpace(answer), "q") {
+			return
+		}
+		if strings.EqualFold(strings.TrimSpace(answer), "c") {
+			r.pause = false
+		}
+	}
+	fmt.Println("")
+	fmt.Println(ui.Muted("End of session."))
+}
+
+// splitTurns groups the history by user message; the system prompt is
+// left out.
+func splitTurns(messages []types.Message) [][]types.Message {
+	var turns [][]types.Message
+	for _, message := range messages {
+		switch {
+		case message.Role == types.RoleSystem:
+		case message.Role == types.RoleUser || len(turns) == 0:
+			turns = append(turns, []types.Message{message})
+		default:
+			turns[len(turns)-1] = append(turns[len(turns)-1], message)
+		}
+	}
+	return turns
+}
+
+func (r *replayer) printMessage(message types.Message, results map[string]types.Message) {
+	switch message.Role {
+	case types.RoleUser:
+		fmt.Println(ui.Prompt("> ") + message.Content)
+		if len(message.Images) > 0 {
+			fmt.Println(ui.Muted(fmt.Sprintf("  [%d image(s)]", len(message.Images))))
+		}
+	case types.RoleAssistant:
+		if message.Thinking != "" {
+			fmt.Println(ui.Thinking(fmt.Sprintf("▸ thought for %d tokens", estimateTextTokens(message.Thinking))))
+		}
+		if message.Content != "" {
+			fmt.Println(message.Content)
+		}
+		for _, call := range message.ToolCalls {
+			result, ok := results[call.ID]
+			if !ok {
+				fmt.Println(ui.Warning("→ " + describeToolCall(call, replayPreviewLength) + " (no result recorded)"))
+				continue
+			}
+			printRecordedCall(call, result.Content)
+			r.rerunCall(call, result.Content)
+		}
+	}
+}
+
+func printRecordedCall(call types.ToolCall, result string) {
+	if command, ok := bashCommand(&call); ok {
+		var payload struct {
+			ExitCode *int   `json:"exitCode"`
+			Stdout   string `json:"stdout"`
+			Stderr   string `json:"stderr"`
+		}
+		if json.Unmarshal([]byte(result), &payload) == nil && payload.ExitCode != nil {
+			var lines []string
+			for _, text := range []string{payload.Stdout, payload.Stderr} {
+				if strings.TrimSpace(text) != "" {
+					lines = append(lines, strings.Split(strings.TrimRight(text, "\n"), "\n")...)
+				}
+			}
+			printCommandFrame(os.Stdout, command, lines, *payload.ExitCode, 0, collapsedOutputLines)
+			return
+		}
+	}
+	fmt.Println(ui.Cyan("→ " + describeToolCall(call, replayPreviewLength)))
+	fmt.Println(ui.Muted("  ↳ " + previewLine(result, replayPreviewLength)))
+}
+
+func (r *replayer) rerunCall(call types.ToolCall, recorded string) {
+	if r.rerun == nil || !readOnlyCall(call) {
+		return
+	}
+	result, _ := r.rerun.handleToolCall(call)
+	switch {
+	case result.Content == recorded:
+		fmt.Println(ui.Success("  ✓ same result when re-run"))
+	case result.Content == "User rejected command.":
+		fmt.Println(ui.Muted("  - not re-run: the command would need approval now"))
+	default:
+		fmt.Println(ui.Warning("  ≠ different result when re-run: ") + previewLine(result.Content, replayPreviewLength))
+	}
+}
+
+func readOnlyCall(call types.ToolCall) bool {
+	switch call.Name {
+	case tools.ReadFileTool.Name, tools.SearchCodeTool.Name:
+		return true
+	case tools.BashTool.Name:
+		return policy.ReadOnlyCommand(extractCommand(call.Input))
+	}
+	return false
+}
@@ -0,0 +1,237 @@
+package core
+
+import (
+	"encoding/csv"
+	"errors"
+	"flag"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"minimal-go/internal/session"
+	"minimal-go/internal/ui"
+	"minimal-go/internal/usage"
+)
+
+const untrackedModel = "(not broken down)"
+
+type reportKey struct {
+	day     string
+	model   string
+	project string
+}
+
+type reportRow struct {
+	reportKey
+	sessions int
+	tokens   int
+	cost     float64
+}
+
+// Report prints spend per day, model and project. Tokens and cost come from
+// usage.json; session counts come from the saved sessions, by the day they
+// were last updated.
+func Report(args []string) error {
+	flags := flag.NewFlagSet("report", flag.ContinueOnError)
+	since := flags.String("since", "7d", "period to cover: Nd, Nw or a YYYY-MM-DD start date")
+	asCSV := flags.Bool("csv", false, "write CSV to stdout instead of a table")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() > 0 {
+		return fmt.Errorf("unexpected argument %q", flags.Arg(0))
+	}
+	start, err := parseSince(*since, time.Now())
+	if err != nil {
+		printError(err.Error())
+		return err
+	}
+
+	rows, err := collectReport(start)
+	if err != nil {
+		printError(err.Error())
+		return err
+	}
+	if *asCSV {
+		return writeReportCSV(rows)
+	}
+	printReport(rows, start)
+	return nil
+}
+
+func parseSince(value string, now time.Time) (string, error) {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	if day, err := time.ParseInLocation("2006-01-02", value, now.Location()); err == nil {
+		return day.Format("2006-01-02"), nil
+	}
+	if value == "today" {
+		return today.Format("2006-01-02"), nil
+	}
+	usageErr := fmt.Errorf("--since %q: use Nd, Nw, today or YYYY-MM-DD", value)
+	if len(value) < 2 {
+		return "", usageErr
+	}
+	count, err := strconv.Atoi(value[:len(value)-1])
+	if err != nil || count < 1 {
+		return "", usageErr
+	}
+	switch value[len(value)-1] {
+	case 'd':
+	case 'w':
+		count *= 7
+	default:
+		return "", usageErr
+	}
+	return today.AddDate(0, 0, 1-count).Format("2006-01-02"), nil
+}
+
+func collectReport(start string) ([]reportRow, error) {
+	days, entries, err := usage.Since(start)
+	if err != nil {
+		return nil, err
+	}
+	rows := map[reportKey]*reportRow{}
+	row := func(key reportKey) *reportRow {
+		if rows[key] == nil {
+			rows[key] = &reportRow{reportKey: key}
+		}
+		return rows[key]
+	}
+
+	attributed := map[string]usage.Totals{}
+	for _, entry := range entries {
+		r := row(reportKey{entry.Day, entry.Model, entry.Project})
+		r.tokens += entry.Tokens
+		r.cost += entry.Cost
+		sum := attributed[entry.Day]
+		sum.Tokens += entry.Tokens
+		sum.Cost += entry.Cost
+		attributed[entry.Day] = sum
+	}
+	// Days recorded before the ledger kept a breakdown only have totals.
+	for day, totals := range days {
+		rest := usage.Totals{Tokens: totals.Tokens - attributed[day].Tokens, Cost: totals.Cost - attributed[day].Cost}
+		if rest.Tokens > 0 || rest.Cost > 1e-9 {
+			r := row(reportKey{day: day, model: untrackedModel})
+			r.tokens += rest.Tokens
+			r.cost += math.Max(rest.Cost, 0)
+		}
+	}
+
+	sessions, err := session.List()
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range sessions {
+		day := s.UpdatedAt.Format("2006-01-02")
+		if day >= start {
+			row(reportKey{day, s.Model, s.Workspace}).sessions++
+		}
+	}
+
+	sorted := make([]reportRow, 0, len(rows))
+	for _, r := range rows {
+		sorted = append(sorted, *r)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		a, b := sorted[i], sorted[j]
+		if a.day != b.day {
+			return a.day > b.day
+		}
+		if a.cost != b.cost {
+			return a.cost > b.cost
+		}
+		return a.model+a.project < b.model+b.project
+	})
+	return sorted, nil
+}
+
+func printReport(rows []reportRow, start string) {
+	fmt.Println("")
+	fmt.Println(ui.Bold("Usage since " + start + ":"))
+	if len(rows) == 0 {
+		fmt.Println(ui.Muted("  (no usage recorded)"))
+		return
+	}
+	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "  DAY\tMODEL\tPROJECT\tSESSIONS\tTOKENS\tCOST")
+	var total reportRow
+	byModel := map[string]*reportRow{}
+	byProject := map[string]*reportRow{}
+	for _, r := range rows {
+		fmt.Fprintf(writer, "  %s\t%s\t%s\t%d\t%d\t%s\n", r.day, r.model, displayProject(r.project), r.sessions, r.tokens, formatCost(r.cost, true))
+		total.add(r)
+		addTo(byModel, r.model, r)
+		addTo(byProject, displayProject(r.project), r)
+	}
+	writer.Flush()
+
+	for _, group := range []struct {
+		title  string
+		totals map[string]*reportRow
+	}{{"By model", byModel}, {"By project", byProject}} {
+		if len(group.totals) < 2 {
+			continue
+		}
+		names := make([]string, 0, len(group.totals))
+		for name := range group.totals {
+			names = append(names, name)
+		}
+		sort.Slice(names, func(i, j int) bool { return group.totals[names[i]].cost > group.totals[names[j]].cost })
+		fmt.Println("")
+		fmt.Println(ui.Bold(group.title + ":"))
+		writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		for _, name := range names {
+			totals := group.totals[name]
+			fmt.Fprintf(writer, "  %s\t%d tokens\t%s\n", name, totals.tokens, formatCost(totals.cost, true))
+		}
+		writer.Flush()
+	}
+	fmt.Println("")
+	fmt.Println(ui.Bold(fmt.Sprintf("Total: %d tokens, %s across %d session(s)", total.tokens, formatCost(total.cost, true), total.sessions)))
+	fmt.Println(ui.Muted("Costs use the prices under \"models\" in config.json; --csv exports the rows."))
+}
+
+func (r *reportRow) add(other reportRow) {
+	r.sessions += other.sessions
+	r.tokens += other.tokens
+	r.cost += other.cost
+}
+
+func addTo(totals map[string]*reportRow, name string, r reportRow) {
+	if totals[name] == nil {
+		totals[name] = &reportRow{}
+	}
+	totals[name].add(r)
+}
+
+func writeReportCSV(rows []reportRow) error {
+	writer := csv.NewWriter(os.Stdout)
+	writer.Write([]string{"day", "model", "project", "sessions", "tokens", "cost"})
+	for _, r := range rows {
+		writer.Write([]string{r.day, r.model, r.project, strconv.Itoa(r.sessions), strconv.Itoa(r.tokens), strconv.FormatFloat(r.cost, 'f', 6, 64)})
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return errors.New("writing CSV: " + err.Error())
+	}
+	return nil
+}
+
+func displayProject(path string) string {
+	if path == "" {
+		return "-"
+	}
+	if home, err := os.UserHomeDir(); err == nil && home != "/" {
+		if rel, err := filepath.Rel(home, path); err == nil && !strings.HasPrefix(rel, "..") {
+			return filepath.Join("~", rel)
+		}
+	}
+	return path
+}
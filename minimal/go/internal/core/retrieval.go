@@ -0,0 +1,209 @@
+package core
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"minimal-go/internal/config"
+	"minimal-go/internal/core/providers"
+	"minimal-go/internal/memory"
+	"minimal-go/internal/skills"
+	"minimal-go/internal/types"
+	"minimal-go/internal/ui"
+	"minimal-go/internal/vault"
+)
+
+const (
+	// Only the start of a skill is embedded; it says what the skill is for.
+	skillEmbedChars        = 2000
+	maxRetrievedSkillChars = 6000
+	minRetrievalPrompt     = 12
+	retrievedContextIntro  = "[Retrieved automatically as possibly relevant to this message; ignore whatever does not apply.]"
+)
+
+var embeddingCachePath = filepath.Join(config.CacheDir, "embeddings.json")
+
+type retrievalCandidate struct {
+	kind  string
+	name  string
+	embed string
+	body  string
+	score float64
+}
+
+// retrieveContext finds the skills and memory entries closest to prompt and
+// returns them as text to append to the user's message, printing what was
+// attached. Entries already in the system prompt are not offered again.
+// Failures only cost the extra context, so they are reported and skipped.
+func (s *replState) retrieveContext(prompt string) string {
+	retrieval := s.env.config.Retrieval
+	if !retrieval.Enabled() || len(strings.TrimSpace(prompt)) < minRetrievalPrompt {
+		return ""
+	}
+	candidates := retrievalCandidates(retrieval, s.systemPromptText())
+	if len(candidates) == 0 {
+		return ""
+	}
+	matches, err := rankCandidates(retrieval, prompt, candidates)
+	if err != nil {
+		fmt.Println(ui.Muted("[retrieval] skipped: " + err.Error()))
+		return ""
+	}
+	if len(matches) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	var labels []string
+	var facts []string
+	b.WriteString(retrievedContextIntro)
+	for _, match := range matches {
+		switch match.kind {
+		case config.RetrievalSkills:
+			labels = append(labels, fmt.Sprintf("skill %s (%.2f)", match.name, match.score))
+			fmt.Fprintf(&b, "\n\n### Skill %q\n\n%s", match.name, match.body)
+		case config.RetrievalMemory:
+			labels = append(labels, fmt.Sprintf("memory %q (%.2f)", previewLine(match.name, 40), match.score))
+			facts = append(facts, "- "+match.body)
+		}
+	}
+	if len(facts) > 0 {
+		b.WriteString("\n\n### Memory\n\n" + strings.Join(facts, "\n"))
+	}
+	fmt.Println(ui.Muted("✓ auto-attached " + strings.Join(labels, ", ")))
+	return b.String()
+}
+
+func (s *replState) systemPromptText() string {
+	for _, message := range s.agent.GetMessages() {
+		if message.Role == types.RoleSystem {
+			return message.Content
+		}
+	}
+	return ""
+}
+
+func retrievalCandidates(retrieval config.RetrievalConfig, systemPrompt string) []retrievalCandidate {
+	var candidates []retrievalCandidate
+	if retrieval.Searches(config.RetrievalSkills) {
+		for _, name := range skills.List() {
+			skill, err := skills.Load(name)
+			if err != nil || strings.TrimSpace(skill.Content) == "" {
+				continue
+			}
+			body := skill.Prompt()
+			if len(body) > maxRetrievedSkillChars {
+				body = strings.ToValidUTF8(body[:maxRetrievedSkillChars], "") + "\n… (truncated; load it in full with /skill " + name + ")"
+			}
+			embed := skill.Content
+			if len(embed) > skillEmbedChars {
+				embed = strings.ToValidUTF8(embed[:skillEmbedChars], "")
+			}
+			candidates = append(candidates, retrievalCandidate{kind: config.RetrievalSkills, name: name, embed: name + "\n\n" + embed, body: body})
+		}
+	}
+	if retrieval.Searches(config.RetrievalMemory) {
+		entries, _ := memory.Entries()
+		for _, entry := range entries {
+			if strings.Contains(systemPrompt, entry) {
+				continue
+			}
+			candidates = append(candidates, retrievalCandidate{kind: config.RetrievalMemory, name: entry, embed: entry, body: entry})
+		}
+	}
+	return candidates
+}
+
+// rankCandidates embeds the prompt together with any candidate whose vector
+// is not cached yet, and returns the closest candidates above the threshold.
+func rankCandidates(retrieval config.RetrievalConfig, prompt string, candidates []retrievalCandidate) ([]retrievalCandidate, error) {
+	cache := loadEmbeddingCache()
+	fresh := map[string][]float64{}
+	keys := make([]string, len(candidates))
+	inputs := []string{prompt}
+	var missing []string
+	for i, candidate := range candidates {
+		keys[i] = embeddingKey(retrieval.Model, candidate.embed)
+		if vector, ok := cache[keys[i]]; ok {
+			fresh[keys[i]] = vector
+		} else if _, queued := fresh[keys[i]]; !queued {
+			fresh[keys[i]] = nil
+			missing = append(missing, keys[i])
+			inputs = append(inputs, candidate.embed)
+		}
+	}
+
+	vectors, err := providers.Embed(retrieval, inputs)
+	if err != nil {
+		return nil, err
+	}
+	for i, key := range missing {
+		fresh[key] = vectors[i+1]
+	}
+	if len(missing) > 0 || len(fresh) != len(cache) {
+		// Keeping only the current entries drops vectors of edited or
+		// removed skills and facts.
+		saveEmbeddingCache(fresh)
+	}
+
+	query := vectors[0]
+	var matches []retrievalCandidate
+	for i, candidate := range candidates {
+		candidate.score = cosine(query, fresh[keys[i]])
+		if candidate.score >= retrieval.MinScore {
+			matches = append(matches, candidate)
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].score > matches[j].score })
+	if len(matches) > retrieval.TopK {
+		matches = matches[:retrieval.TopK]
+	}
+	return matches, nil
+}
+
+func embeddingKey(model string, text string) string {
+	sum := sha256.Sum256([]byte(model + "\x00" + text))
+	return hex.EncodeToString(sum[:16])
+}
+
+func loadEmbeddingCache() map[string][]float64 {
+	cache := map[string][]float64{}
+	if data, err := vault.ReadFile(embeddingCachePath); err == nil {
+		_ = json.Unmarshal(data, &cache)
+	}
+	return cache
+}
+
+func saveEmbeddingCache(cache map[string][]float64) {
+	data, err := json.Marshal(cache)
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(embeddingCachePath), 0o700); err != nil {
+		return
+	}
+	_ = vault.WriteFile(embeddingCachePath, data, 0o600)
+}
+
+func cosine(a []float64, b []float64) float64 {
+	if len(a) == 0 || len(a) != len(b) {
+		return 0
+	}
+	var dot, normA, normB float64
+	for i := range a {
+		dot += a[i] * b[i]
+		normA += a[i] * a[i]
+		normB += b[i] * b[i]
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
@@ -0,0 +1,62 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"minimal-go/internal/types"
+	"minimal-go/internal/ui"
+)
+
+func (s *replState) handleRetryCommand(variant string) error {
+	messages := s.agent.GetMessages()
+	last := lastUserMessage(messages)
+	if last < 0 {
+		return errors.New("Nothing to retry yet.")
+	}
+
+	if variant != "" {
+		cfg := s.agent.GetConfig()
+		if _, ok := cfg.LLM.Variants[variant]; !ok {
+			names := make([]string, 0, len(cfg.LLM.Variants))
+			for name := range cfg.LLM.Variants {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			return fmt.Errorf("Unknown variant %q (configured: %s)", variant, strings.Join(names, ", "))
+		}
+		if variant != cfg.LLM.CurrentProvider {
+			cfg.LLM.CurrentProvider = variant
+			cfg.LLM.CurrentModel = ""
+			if err := s.agent.ApplyConfig(cfg); err != nil {
+				return err
+			}
+		}
+	}
+
+	discarded := messages[last+1:]
+	for _, message := range discarded {
+		if len(message.ToolCalls) > 0 {
+			fmt.Println(ui.Warning("Commands and file edits from the discarded attempt are not undone."))
+			break
+		}
+	}
+	s.agent.SetMessages(messages[:last+1])
+	llmConfig := s.agent.GetLlmConfig()
+	fmt.Println(ui.Muted(fmt.Sprintf("↻ Retrying with %s (%s); discarded %d message(s).", llmConfig.Provider, llmConfig.Model, len(discarded))))
+
+	s.runTurn()
+	s.saveSession()
+	return nil
+}
+
+func lastUserMessage(messages []types.Message) int {
+	for i := len(messages) - 1; i > 0; i-- {
+		if messages[i].Role == types.RoleUser {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,297 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"minimal-go/internal/config"
+	"minimal-go/internal/core/providers"
+	"minimal-go/internal/types"
+	"minimal-go/internal/ui"
+)
+
+const (
+	reviewMaxTokens  = 2000
+	maxReviewChunk   = 24 * 1024
+	reviewSeverities = "issue, suggestion, question or nit"
+)
+
+var diffFileHeader = regexp.MustCompile(`^diff --git a/(.+) b/(.+)$`)
+
+type ReviewFinding struct {
+	Hunk     int    `json:"hunk"`
+	Line     int    `json:"line"`
+	Severity string `json:"severity"`
+	Comment  string `json:"comment"`
+}
+
+type reviewFile struct {
+	path     string
+	hunks    []string
+	findings []ReviewFinding
+}
+
+type reviewReport struct {
+	ref   string
+	files []reviewFile
+	done  time.Time
+}
+
+var reviewFormat = &providers.ResponseFormat{
+	Type: providers.FormatJSONSchema,
+	Name: "review_findings",
+	Schema: map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"findings": map[string]interface{}{
+				"type": "array",
+				"items": map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"hunk":     map[string]interface{}{"type": "integer"},
+						"line":     map[string]interface{}{"type": "integer"},
+						"severity": map[string]interface{}{"type": "string", "enum": []string{"issue", "suggestion", "question", "nit"}},
+						"comment":  map[string]interface{}{"type": "string"},
+					},
+					"required":             []string{"hunk", "line", "severity", "comment"},
+					"additionalProperties": false,
+				},
+			},
+		},
+		"required":             []string{"findings"},
+		"additionalProperties": false,
+	},
+}
+
+const reviewInstructions = `You are reviewing a change to %s. The diff is split into numbered hunks. Comment only where it matters: bugs, missing error handling, races, security problems, unclear names, missing tests. Do not restate what the code does and do not praise it.
+
+Return JSON {"findings": [...]}, one entry per comment: "hunk" is the hunk number, "line" the line number in the new file (0 if it is about the hunk as a whole), "severity" one of ` + reviewSeverities + `, "comment" one or two sentences. Return {"findings": []} when there is nothing worth saying.`
+
+func (a *agent) ReviewDiff(path string, diff string) ([]ReviewFinding, error) {
+	var result struct {
+		Findings []ReviewFinding `json:"findings"`
+	}
+	if err := a.completeJSON(fmt.Sprintf(reviewInstructions, path), diff, reviewFormat, reviewMaxTokens, &result); err != nil {
+		return nil, err
+	}
+	return result.Findings, nil
+}
+
+// /review [ref] reviews `git diff <ref>` (the working tree against HEAD by
+// default) file by file. /review export [path] writes the last report.
+func (s *replState) handleReviewCommand(args string) error {
+	fields := strings.Fields(args)
+	if len(fields) > 0 && fields[0] == "export" {
+		return s.exportReview(strings.TrimSpace(strings.TrimPrefix(args, "export")))
+	}
+	if len(fields) > 1 {
+		return errors.New("Usage: /review [ref] or /review export [path]")
+	}
+	ref := "HEAD"
+	if len(fields) == 1 {
+		ref = fields[0]
+	}
+
+	files, err := collectDiff(s.workspaceRoot, ref)
+	if err != nil {
+		return err
+	}
+	if len(files) == 0 {
+		fmt.Println(ui.Muted("No changes against " + ref + "."))
+		return nil
+	}
+
+	report := &reviewReport{ref: ref}
+	fmt.Println(ui.Muted(fmt.Sprintf("Reviewing %d file(s) changed against %s.", len(files), ref)))
+	for i, file := range files {
+		fmt.Println("")
+		fmt.Println(ui.Bold(fmt.Sprintf("[%d/%d] %s", i+1, len(files), file.path)) + ui.Muted(fmt.Sprintf(" · %d hunk(s)", len(file.hunks))))
+		for _, chunk := range chunkHunks(file.hunks) {
+			findings, err := s.agent.ReviewDiff(file.path, chunk.text)
+			if err != nil {
+				printError(err.Error())
+				break
+			}
+			for _, finding := range findings {
+				finding.Hunk += chunk.first
+				file.findings = append(file.findings, finding)
+			}
+		}
+		printFindings(file)
+		report.files = append(report.files, file)
+
+		if i == len(files)-1 {
+			break
+		}
+		answer, cancelled, err := readLine(s.reader, ui.Muted("[enter] next file · [s] stop here: "), s.sigCh, false)
+		if err != nil || cancelled || strings.EqualFold(strings.TrimSpace(answer), "s") {
+			fmt.Println(ui.Muted(fmt.Sprintf("Stopped after %d of %d file(s).", i+1, len(files))))
+			break
+		}
+	}
+	report.done = time.Now()
+	s.review = report
+
+	// The report joins the conversation so follow-ups like "fix the first
+	// issue" have it in context.
+	s.agent.SetMessages(append(s.agent.GetMessages(),
+		types.Message{Role: types.RoleUser, Content: "Review the changes against " + ref + "."},
+		types.Message{Role: types.RoleAssistant, Content: report.markdown()},
+	))
+	s.saveSession()
+	count := report.findingCount()
+	fmt.Println("")
+	printSuccess(fmt.Sprintf("✓ Review done: %d finding(s) in %d file(s). /review export [path] saves the report.", count, len(report.files)))
+	return nil
+}
+
+func collectDiff(workspaceRoot string, ref string) ([]reviewFile, error) {
+	if strings.HasPrefix(ref, "-") {
+		return nil, fmt.Errorf("invalid ref %q", ref)
+	}
+	output, err := exec.Command("git", "-C", workspaceRoot, "diff", "--no-color", "--no-ext-diff", ref, "--").CombinedOutput()
+	if err != nil {
+		return nil, fmt.Errorf("git diff %s: %s", ref, strings.TrimSpace(string(output)))
+	}
+
+	var files []reviewFile
+	var current *reviewFile
+	var hunk strings.Builder
+	flush := func() {
+		if current != nil && hunk.Len() > 0 {
+			current.hunks = append(current.hunks, hunk.String())
+		}
+		hunk.Reset()
+	}
+	for _, line := range strings.Split(string(output), "\n") {
+		if match := diffFileHeader.FindStringSubmatch(line); match != nil {
+			flush()
+			files = append(files, reviewFile{path: match[2]})
+			current = &files[len(files)-1]
+			continue
+		}
+		if current == nil {
+			continue
+		}
+		if strings.HasPrefix(line, "@@") {
+			flush()
+		}
+		if hunk.Len() > 0 || strings.HasPrefix(line, "@@") {
+			hunk.WriteString(line + "\n")
+		}
+	}
+	flush()
+
+	// Binary files and pure renames have no hunks to comment on.
+	reviewable := files[:0]
+	for _, file := range files {
+		if len(file.hunks) > 0 {
+			reviewable = append(reviewable, file)
+		}
+	}
+	return reviewable, nil
+}
+
+type hunkChunk struct {
+	first int
+	text  string
+}
+
+// chunkHunks numbers the hunks and groups them into requests of at most
+// maxReviewChunk bytes; the model numbers hunks within each request from 1,
+// and first maps them back.
+func chunkHunks(hunks []string) []hunkChunk {
+	var chunks []hunkChunk
+	var b strings.Builder
+	first := 0
+	for i, hunk := range hunks {
+		if b.Len() > 0 && b.Len()+len(hunk) > maxReviewChunk {
+			chunks = append(chunks, hunkChunk{first: first, text: b.String()})
+			b.Reset()
+			first = i
+		}
+		if len(hunk) > maxReviewChunk {
+			hunk = hunk[:maxReviewChunk] + "\n… (hunk truncated)\n"
+		}
+		fmt.Fprintf(&b, "### Hunk %d\n%s\n", i-first+1, hunk)
+	}
+	if b.Len() > 0 {
+		chunks = append(chunks, hunkChunk{first: first, text: b.String()})
+	}
+	return chunks
+}
+
+func printFindings(file reviewFile) {
+	if len(file.findings) == 0 {
+		fmt.Println(ui.Success("  ✓ nothing to flag"))
+		return
+	}
+	for _, finding := range file.findings {
+		if finding.Hunk >= 1 && finding.Hunk <= len(file.hunks) {
+			header, _, _ := strings.Cut(file.hunks[finding.Hunk-1], "\n")
+			fmt.Println(ui.Accent("  " + header))
+		}
+		label := fmt.Sprintf("  %s", finding.Severity)
+		if finding.Line > 0 {
+			label += fmt.Sprintf(" (line %d)", finding.Line)
+		}
+		style := ui.Muted
+		if finding.Severity == "issue" {
+			style = ui.Warning
+		}
+		fmt.Println(style(label) + " " + finding.Comment)
+	}
+}
+
+func (r *reviewReport) findingCount() int {
+	count := 0
+	for _, file := range r.files {
+		count += len(file.findings)
+	}
+	return count
+}
+
+func (r *reviewReport) markdown() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Code review against %s\n\n%d finding(s) in %d file(s), %s.\n", r.ref, r.findingCount(), len(r.files), r.done.Format("2006-01-02 15:04"))
+	for _, file := range r.files {
+		fmt.Fprintf(&b, "\n## %s\n\n", file.path)
+		if len(file.findings) == 0 {
+			b.WriteString("Nothing to flag.\n")
+			continue
+		}
+		for _, finding := range file.findings {
+			location := fmt.Sprintf("hunk %d", finding.Hunk)
+			if finding.Line > 0 {
+				location = fmt.Sprintf("line %d", finding.Line)
+			}
+			fmt.Fprintf(&b, "- **%s** (%s): %s\n", finding.Severity, location, finding.Comment)
+		}
+	}
+	return b.String()
+}
+
+func (s *replState) exportReview(path string) error {
+	if s.review == nil {
+		return errors.New("No review yet. Run /review [ref] first.")
+	}
+	if path == "" {
+		path = filepath.Join(config.MinimalDir, "reviews", s.review.done.Format("20060102-150405")+".md")
+	} else if !filepath.IsAbs(path) {
+		path = filepath.Join(s.workspaceRoot, config.ExpandHome(path))
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, []byte(s.review.markdown()), 0o644); err != nil {
+		return err
+	}
+	printSuccess("✓ Review saved to " + path)
+	return nil
+}
@@ -0,0 +1,42 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+
+	"minimal-go/internal/config"
+	"minimal-go/internal/templates"
+	"minimal-go/internal/ui"
+)
+
+func NewWorkspace(args []string) error {
+	if len(args) == 0 {
+		fmt.Println(ui.Bold("Usage: mini-go new <template> [dir]"))
+		fmt.Println("")
+		fmt.Println(ui.Bold("Templates:") + ui.Muted(" (user templates live in "+config.TemplatesDir+")"))
+		for _, name := range templates.List() {
+			fmt.Println(ui.Cyan("  " + name))
+		}
+		return errors.New("template name required")
+	}
+
+	target := "."
+	if len(args) > 1 {
+		target = args[1]
+	}
+
+	result, err := templates.Scaffold(args[0], target)
+	for _, path := range result.Created {
+		printSuccess("✓ created " + path)
+	}
+	for _, path := range result.Skipped {
+		fmt.Println(ui.Muted("- skipped " + path + " (already exists)"))
+	}
+	if err != nil {
+		printError(err.Error())
+		return err
+	}
+	fmt.Println("")
+	fmt.Println(ui.Muted(fmt.Sprintf("Workspace ready from template %q.", args[0])))
+	return nil
+}
@@ -0,0 +1,111 @@
+package core
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"strings"
+
+	"minimal-go/internal/session"
+	"minimal-go/internal/ui"
+)
+
+const defaultSearchLimit = 20
+
+func SessionsCommand(args []string) error {
+	if len(args) > 0 && args[0] != "list" {
+		printError("Usage: mini-go sessions [list]")
+		return errors.New("unknown sessions command")
+	}
+	printSessionList()
+	return nil
+}
+
+func SearchCommand(args []string) error {
+	flags := flag.NewFlagSet("search", flag.ContinueOnError)
+	limit := flags.Int("limit", defaultSearchLimit, "maximum number of matches")
+	flags.IntVar(limit, "n", defaultSearchLimit, "shorthand for --limit")
+	var terms []string
+	for rest := args; ; rest = flags.Args()[1:] {
+		if err := flags.Parse(rest); err != nil {
+			return err
+		}
+		if flags.NArg() == 0 {
+			break
+		}
+		terms = append(terms, flags.Arg(0))
+	}
+	if *limit <= 0 {
+		printError("--limit must be a positive number")
+		return errors.New("invalid limit")
+	}
+	if len(terms) == 0 {
+		printError(`Usage: mini-go search [--limit N] "query"`)
+		return errors.New("missing query")
+	}
+	return printSearchResults(strings.Join(terms, " "), *limit)
+}
+
+func printSearchResults(query string, limit int) error {
+	matches, total, err := session.Search(query, limit)
+	if err != nil {
+		printError(err.Error())
+		return err
+	}
+	fmt.Println("")
+	if len(matches) == 0 {
+		fmt.Println(ui.Muted(fmt.Sprintf("No past sessions mention %q.", query)))
+		fmt.Println("")
+		return nil
+	}
+
+	current := ""
+	for _, match := range matches {
+		if match.SessionID != current {
+			current = match.SessionID
+			fmt.Println(ui.Cyan(match.SessionID) + "  " + ui.Bold(match.Title) + "  " + ui.Muted(match.UpdatedAt.Format("2006-01-02 15:04")))
+		}
+		fmt.Printf("  %s %s\n", ui.Muted(fmt.Sprintf("#%-3d %-9s", match.Index, match.Role)), highlightTerms(match.Snippet, query))
+	}
+	fmt.Println("")
+	if total > len(matches) {
+		fmt.Println(ui.Muted(fmt.Sprintf("Showing %d of %d matching messages.", len(matches), total)))
+	}
+	fmt.Println(ui.Muted("Open one with /resume <id>."))
+	fmt.Println("")
+	return nil
+}
+
+func highlightTerms(text string, query string) string {
+	lower := strings.ToLower(text)
+	if len(lower) != len(text) {
+		return text
+	}
+	marked := make([]bool, len(text))
+	for _, term := range strings.Fields(strings.ToLower(query)) {
+		for offset := 0; ; {
+			position := strings.Index(lower[offset:], term)
+			if position < 0 {
+				break
+			}
+			for i := offset + position; i < offset+position+len(term); i++ {
+				marked[i] = true
+			}
+			offset += position + len(term)
+		}
+	}
+	var result strings.Builder
+	for start := 0; start < len(text); {
+		end := start
+		for end < len(text) && marked[end] == marked[start] {
+			end++
+		}
+		if marked[start] {
+			result.WriteString(ui.Warning(text[start:end]))
+		} else {
+			result.WriteString(text[start:end])
+		}
+		start = end
+	}
+	return result.String()
+}
@@ -0,0 +1,188 @@
+package core
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"minimal-go/internal/config"
+	"minimal-go/internal/core/providers"
+	"minimal-go/internal/policy"
+	"minimal-go/internal/types"
+	"minimal-go/internal/ui"
+)
+
+const selftestConfig = `{
+  "llm": {
+    "current_provider": "mock",
+    "variants": {
+      "mock": {"schema_type": "mock", "model": "selftest-mock"}
+    }
+  },
+  "policy": {"defaultAction": "ask", "autoCommands": ["pwd"]}
+}`
+
+const selftestReply = "selftest: pipeline ok"
+
+type selftestCheck struct {
+	name string
+	run  func() error
+}
+
+func SelfTest() error {
+	var cfg config.Config
+	var agent Agent
+	var output string
+	workspaceRoot, err := os.MkdirTemp("", "mini-go-selftest-")
+	if err != nil {
+		printError(err.Error())
+		return err
+	}
+	defer os.RemoveAll(workspaceRoot)
+
+	checks := []selftestCheck{
+		{"config load", func() error {
+			parsed, err := config.ParseConfig([]byte(selftestConfig))
+			if err != nil {
+				return err
+			}
+			resolved, err := config.ResolveLlmConfig(parsed)
+			if err != nil {
+				return err
+			}
+			if resolved.Model != "selftest-mock" {
+				return fmt.Errorf("resolved model %q", resolved.Model)
+			}
+			cfg = parsed
+			return nil
+		}},
+		{"policy", func() error {
+			expectations := map[string]policy.PolicyResult{
+				"pwd":          policy.PolicyAuto,
+				"rm -rf /":     policy.PolicyDeny,
+				"make install": policy.PolicyAsk,
+			}
+			for command, expected := range expectations {
+				if got := policy.CheckPolicy(command, cfg); got != expected {
+					return fmt.Errorf("%q: expected %s, got %s", command, expected, got)
+				}
+			}
+			return nil
+		}},
+		{"agent setup", func() error {
+			script := []types.Message{
+				{ToolCalls: []types.ToolCall{
+					{ID: "selftest-1", Name: "bash", Input: map[string]interface{}{"command": "pwd"}},
+					{ID: "selftest-2", Name: "bash", Input: map[string]interface{}{"command": "rm -rf /"}},
+				}},
+				{Content: selftestReply},
+			}
+			created, err := CreateAgent(AgentOptions{
+				Config:        cfg,
+				SystemPrompt:  "selftest",
+				WorkspaceRoot: workspaceRoot,
+				Provider:      providers.NewMockProvider(script),
+				Output:        currentStdout{},
+				Callbacks: AgentCallbacks{
+					PromptApproval: func(command string) (bool, error) {
+						return false, fmt.Errorf("unexpected approval prompt for %q", command)
+					},
+					OnAutoApproved: printAutoApproved,
+					OnDenied:       printDenied,
+				},
+			})
+			agent = created
+			return err
+		}},
+		{"agent turn", func() error {
+			agent.AddUserMessage("Run the selftest.")
+			captured, err := captureStdout(agent.RunAgentTurn)
+			output = captured
+			return err
+		}},
+		{"tool calls", func() error {
+			var results []types.Message
+			for _, message := range agent.GetMessages() {
+				if message.Role == types.RoleTool {
+					results = append(results, message)
+				}
+			}
+			if len(results) != 2 {
+				return fmt.Errorf("expected 2 tool results, got %d", len(results))
+			}
+			var payload struct {
+				ExitCode int    `json:"exitCode"`
+				Stdout   string `json:"stdout"`
+			}
+			if err := json.Unmarshal([]byte(results[0].Content), &payload); err != nil {
+				return fmt.Errorf("auto-approved result is not JSON: %w", err)
+			}
+			if payload.ExitCode != 0 || strings.TrimSpace(payload.Stdout) == "" {
+				return fmt.Errorf("auto-approved command failed: %s", results[0].Content)
+			}
+			if !strings.Contains(results[1].Content, "denied") {
+				return fmt.Errorf("deny result missing: %s", results[1].Content)
+			}
+			return nil
+		}},
+		{"rendering", func() error {
+			for _, expected := range []string{selftestReply, "pwd", "Denied by policy"} {
+				if !strings.Contains(output, expected) {
+					return fmt.Errorf("output is missing %q", expected)
+				}
+			}
+			return nil
+		}},
+	}
+
+	failed := 0
+	for _, check := range checks {
+		if failed > 0 {
+			fmt.Println(ui.Muted("- " + check.name + " (skipped)"))
+			continue
+		}
+		if err := check.run(); err != nil {
+			failed++
+			fmt.Println(ui.Error("✗ " + check.name + ": " + err.Error()))
+			continue
+		}
+		printSuccess("✓ " + check.name)
+	}
+
+	if failed > 0 {
+		return errors.New("selftest failed")
+	}
+	printSuccess("All checks passed.")
+	return nil
+}
+
+type currentStdout struct{}
+
+func (currentStdout) Write(p []byte) (int, error) {
+	return os.Stdout.Write(p)
+}
+
+func captureStdout(run func() error) (string, error) {
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		return "", err
+	}
+	original := os.Stdout
+	os.Stdout = writer
+
+	done := make(chan string, 1)
+	go func() {
+		data, _ := io.ReadAll(reader)
+		done <- string(data)
+	}()
+
+	runErr := run()
+	os.Stdout = original
+	writer.Close()
+	output := <-done
+	reader.Close()
+	return output, runErr
+}
@@ -0,0 +1,464 @@
+package core
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"mime"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"minimal-go/internal/config"
+	"minimal-go/internal/session"
+	"minimal-go/internal/ui"
+)
+
+const (
+	defaultServeAddr   = "127.0.0.1:8791"
+	serveBacklog       = 512
+	serveSubscriberBuf = 256
+	serveKeepAlive     = 15 * time.Second
+)
+
+type serveAnswer struct {
+	Approve  bool
+	Approver string
+}
+
+type serveSession struct {
+	id       string
+	agent    Agent
+	record   *session.Session
+	policy   config.ApprovalConfig
+	approver approverRecord
+
+	mu          sync.Mutex
+	seq         int64
+	backlog     []ProtocolEvent
+	subscribers map[chan ProtocolEvent]bool
+	running     bool
+	pendingCall string
+	approval    chan serveAnswer
+}
+
+func (s *serveSession) publish(event Event) {
+	wire, ok := ToProtocolEvent(event)
+	if !ok {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.seq++
+	wire.Seq, wire.Session = s.seq, s.id
+	if wire.Type == ProtocolApproval {
+		s.pendingCall = wire.CallID
+		s.approval = make(chan serveAnswer, 1)
+	}
+	s.backlog = append(s.backlog, wire)
+	if len(s.backlog) > serveBacklog {
+		s.backlog = s.backlog[len(s.backlog)-serveBacklog:]
+	}
+	for ch := range s.subscribers {
+		select {
+		case ch <- wire:
+		default:
+			// A client that stops reading is dropped; it can reconnect with
+			// Last-Event-ID and replay from the backlog.
+			delete(s.subscribers, ch)
+			close(ch)
+		}
+	}
+}
+
+func (s *serveSession) subscribe(after int64) (chan ProtocolEvent, []ProtocolEvent) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var replay []ProtocolEvent
+	for _, event := range s.backlog {
+		if event.Seq > after {
+			replay = append(replay, event)
+		}
+	}
+	ch := make(chan ProtocolEvent, serveSubscriberBuf)
+	s.subscribers[ch] = true
+	return ch, replay
+}
+
+func (s *serveSession) unsubscribe(ch chan ProtocolEvent) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.subscribers[ch] {
+		delete(s.subscribers, ch)
+		close(ch)
+	}
+}
+
+func (s *serveSession) awaitApproval(command string, changes []FileChange) (bool, error) {
+	s.mu.Lock()
+	answers := s.approval
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		s.pendingCall, s.approval = "", nil
+		s.mu.Unlock()
+	}()
+
+	var deadline <-chan time.Time
+	if timeout := approvalTimeout(s.policy); timeout > 0 {
+		deadline = time.After(timeout)
+	}
+	select {
+	case answer := <-answers:
+		s.approver.set(answer.Approver)
+		return answer.Approve, nil
+	case <-deadline:
+		outcome, err := unattendedDecision(s.policy, s.policy.OnTimeout, command, changes)
+		s.approver.set("timeout:" + outcome.Approver)
+		return outcome.Approved, err
+	}
+}
+
+func (s *serveSession) answer(callID string, answer serveAnswer) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.approval == nil || (callID != "" && callID != s.pendingCall) {
+		return false
+	}
+	select {
+	case s.approval <- answer:
+	default:
+	}
+	return true
+}
+
+func (s *serveSession) run(text string) {
+	s.agent.AddUserMessage(text)
+	_ = s.agent.RunAgentTurn()
+	s.record.Messages = s.agent.GetMessages()
+	s.record.Model = s.agent.GetModel()
+	_ = session.Save(s.record)
+
+	s.mu.Lock()
+	s.running = false
+	s.mu.Unlock()
+}
+
+type serveServer struct {
+	env   environment
+	debug bool
+	token string
+	// host and port are the listen address; requests must name it in
+	// their Host header (see checkRequest).
+	host     string
+	port     string
+	mu       sync.Mutex
+	sessions map[string]*serveSession
+}
+
+func (srv *serveServer) newSession() (*serveSession, error) {
+	suffix := make([]byte, 4)
+	_, _ = rand.Read(suffix)
+	s := &serveSession{
+		id:          "srv-" + hex.EncodeToString(suffix),
+		policy:      srv.env.config.Approval,
+		subscribers: map[chan ProtocolEvent]bool{},
+	}
+	promptApproval := func(command string) (bool, error) {
+		return s.awaitApproval(command, nil)
+	}
+	promptFileChanges := func(command string, changes []FileChange) ([]FileApproval, error) {
+		answers := make([]FileApproval, len(changes))
+		approved, err := s.awaitApproval(command, changes)
+		if approved {
+			fillApprovals(answers, FileApproved)
+		}
+		return answers, err
+	}
+	if s.policy.Strategy != config.ApprovalPrompt {
+		promptApproval, promptFileChanges = unattendedCallbacks(s.policy, s.policy.Strategy, "serve", &s.approver)
+	}
+	created, err := CreateAgent(AgentOptions{
+		Config:        srv.env.config,
+		SystemPrompt:  srv.env.systemPrompt,
+		WorkspaceRoot: srv.env.workspaceRoot,
+		Debug:         srv.debug,
+		OnEvent:       s.publish,
+		Callbacks: AgentCallbacks{
+			PromptApproval:    promptApproval,
+			PromptFileChanges: promptFileChanges,
+			Approver:          s.approver.get,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.agent = created
+	s.record = session.New(s.id, created.GetModel(), srv.env.workspaceRoot)
+
+	srv.mu.Lock()
+	srv.sessions[s.id] = s
+	srv.mu.Unlock()
+	return s, nil
+}
+
+func (srv *serveServer) lookup(w http.ResponseWriter, r *http.Request) *serveSession {
+	srv.mu.Lock()
+	s := srv.sessions[r.PathValue("id")]
+	srv.mu.Unlock()
+	if s == nil {
+		writeServeError(w, http.StatusNotFound, "unknown session")
+	}
+	return s
+}
+
+func (srv *serveServer) routes() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /v1/protocol", func(w http.ResponseWriter, r *http.Request) {
+		writeServeJSON(w, http.StatusOK, map[string]interface{}{"version": ProtocolVersion, "events": ProtocolEventTypes})
+	})
+	mux.HandleFunc("POST /v1/sessions", func(w http.ResponseWriter, r *http.Request) {
+		s, err := srv.newSession()
+		if err != nil {
+			writeServeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		writeServeJSON(w, http.StatusCreated, map[string]interface{}{"session": s.id, "model": s.agent.GetModel(), "version": ProtocolVersion})
+	})
+	mux.HandleFunc("GET /v1/sessions/{id}", func(w http.ResponseWriter, r *http.Request) {
+		s := srv.lookup(w, r)
+		if s == nil {
+			return
+		}
+		s.mu.Lock()
+		status := map[string]interface{}{"session": s.id, "model": s.agent.GetModel(), "running": s.running, "seq": s.seq, "pendingApproval": s.pendingCall}
+		s.mu.Unlock()
+		writeServeJSON(w, http.StatusOK, status)
+	})
+	mux.HandleFunc("POST /v1/sessions/{id}/messages", func(w http.ResponseWriter, r *http.Request) {
+		s := srv.lookup(w, r)
+		if s == nil {
+			return
+		}
+		var body struct {
+			Text string `json:"text"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil || strings.TrimSpace(body.Text) == "" {
+			writeServeError(w, http.StatusBadRequest, `expected {"text": "..."}`)
+			return
+		}
+		s.mu.Lock()
+		if s.running {
+			s.mu.Unlock()
+			writeServeError(w, http.StatusConflict, "a turn is already running in this session")
+			return
+		}
+		s.running = true
+		seq := s.seq
+		s.mu.Unlock()
+		go s.run(body.Text)
+		writeServeJSON(w, http.StatusAccepted, map[string]interface{}{"session": s.id, "after": seq})
+	})
+	mux.HandleFunc("POST /v1/sessions/{id}/approval", func(w http.ResponseWriter, r *http.Request) {
+		s := srv.lookup(w, r)
+		if s == nil {
+			return
+		}
+		var body struct {
+			CallID  string `json:"callId"`
+			Approve bool   `json:"approve"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			writeServeError(w, http.StatusBadRequest, `expected {"callId": "...", "approve": true}`)
+			return
+		}
+		// The audit log records where the answer came from, not a name the
+		// client could choose.
+		approver := "http"
+		if remote, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+			approver += ":" + remote
+		}
+		if !s.answer(body.CallID, serveAnswer{Approve: body.Approve, Approver: approver}) {
+			writeServeError(w, http.StatusConflict, "no matching approval is pending")
+			return
+		}
+		writeServeJSON(w, http.StatusOK, map[string]interface{}{"callId": body.CallID, "approved": body.Approve})
+	})
+	mux.HandleFunc("GET /v1/sessions/{id}/events", srv.streamEvents)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if status, message := srv.checkRequest(r); status != 0 {
+			writeServeError(w, status, message)
+			return
+		}
+		mux.ServeHTTP(w, r)
+	})
+}
+
+// checkRequest keeps browsers out: pages can send simple cross-origin POSTs
+// to a loopback port, and DNS rebinding lets them read the replies. Browsers
+// always send Origin on those requests and a rebinding page's own name in
+// Host, while API clients send neither an Origin nor another Host.
+func (srv *serveServer) checkRequest(r *http.Request) (int, string) {
+	if r.Header.Get("Origin") != "" {
+		return http.StatusForbidden, "browser requests are not accepted"
+	}
+	host, port, err := net.SplitHostPort(r.Host)
+	if err != nil || port != srv.port || !srv.allowedHost(host) {
+		return http.StatusMisdirectedRequest, "Host must be the listen address"
+	}
+	given, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || subtle.ConstantTimeCompare([]byte(given), []byte(srv.token)) != 1 {
+		return http.StatusUnauthorized, "missing or wrong bearer token"
+	}
+	if r.Method == http.MethodPost {
+		if mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type")); err != nil || mediaType != "application/json" {
+			return http.StatusUnsupportedMediaType, "Content-Type must be application/json"
+		}
+	}
+	return 0, ""
+}
+
+// allowedHost accepts the listen host, localhost and IP literals; a DNS
+// name that rebinds to this machine is none of them.
+func (srv *serveServer) allowedHost(host string) bool {
+	host = strings.Trim(host, "[]")
+	return strings.EqualFold(host, srv.host) || strings.EqualFold(host, "localhost") || net.ParseIP(host) != nil
+}
+
+func (srv *serveServer) streamEvents(w http.ResponseWriter, r *http.Request) {
+	s := srv.lookup(w, r)
+	if s == nil {
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeServeError(w, http.StatusInternalServerError, "streaming unsupported")
+		return
+	}
+	after := r.Header.Get("Last-Event-ID")
+	if after == "" {
+		after = r.URL.Query().Get("after")
+	}
+	since, _ := strconv.ParseInt(after, 10, 64)
+	ch, replay := s.subscribe(since)
+	defer s.unsubscribe(ch)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Mini-Go-Protocol", strconv.Itoa(ProtocolVersion))
+	w.WriteHeader(http.StatusOK)
+	for _, event := range replay {
+		writeSSE(w, event)
+	}
+	flusher.Flush()
+
+	keepAlive := time.NewTicker(serveKeepAlive)
+	defer keepAlive.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case event, open := <-ch:
+			if !open {
+				return
+			}
+			writeSSE(w, event)
+		case <-keepAlive.C:
+			fmt.Fprint(w, ": keep-alive\n\n")
+		}
+		flusher.Flush()
+	}
+}
+
+func writeSSE(w http.ResponseWriter, event ProtocolEvent) {
+	payload, _ := json.Marshal(event)
+	fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.Seq, event.Type, payload)
+}
+
+func writeServeJSON(w http.ResponseWriter, status int, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(value)
+}
+
+func writeServeError(w http.ResponseWriter, status int, message string) {
+	writeServeJSON(w, status, map[string]string{"error": message})
+}
+
+func Serve(args []string, options MainOptions) error {
+	flags := flag.NewFlagSet("serve", flag.ContinueOnError)
+	addrFlag := flags.String("addr", defaultServeAddr, "listen address (host:port)")
+	tokenFlag := flags.String("token", os.Getenv("MINI_GO_SERVE_TOKEN"), "bearer token clients must send (default $MINI_GO_SERVE_TOKEN)")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() > 0 {
+		printError("Usage: mini-go serve [--addr host:port] [--token secret]")
+		return errors.New("invalid serve arguments")
+	}
+	addr, token := *addrFlag, *tokenFlag
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		printError(err.Error())
+		return err
+	}
+	if ip := net.ParseIP(host); token == "" && (ip == nil || !ip.IsLoopback()) && host != "localhost" {
+		err := errors.New("refusing to listen on " + addr + " without --token (or MINI_GO_SERVE_TOKEN)")
+		printError(err.Error())
+		return err
+	}
+	generated := token == ""
+	if generated {
+		secret := make([]byte, 24)
+		if _, err := rand.Read(secret); err != nil {
+			return err
+		}
+		token = hex.EncodeToString(secret)
+	}
+
+	env, err := loadEnvironment()
+	if err != nil {
+		return err
+	}
+	if err := options.apply(&env); err != nil {
+		return err
+	}
+
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		printError(err.Error())
+		return err
+	}
+	_, port, _ := net.SplitHostPort(listener.Addr().String())
+	srv := &serveServer{env: env, debug: options.Debug, token: token, host: strings.Trim(host, "[]"), port: port, sessions: map[string]*serveSession{}}
+	httpServer := &http.Server{Handler: srv.routes(), ReadHeaderTimeout: 10 * time.Second}
+
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-sigCh
+		httpServer.Close()
+	}()
+
+	fmt.Println(ui.Bold("mini-go serve") + ui.Muted(fmt.Sprintf(" listening on http://%s (protocol v%d)", listener.Addr(), ProtocolVersion)))
+	if generated {
+		fmt.Println(ui.Muted("Bearer token for this run (set --token or MINI_GO_SERVE_TOKEN to choose one): ") + token)
+	}
+	if err := httpServer.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		printError(err.Error())
+		return err
+	}
+	fmt.Println(ui.Muted("serve stopped"))
+	return nil
+}
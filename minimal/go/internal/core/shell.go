@@ -0,0 +1,177 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"minimal-go/internal/policy"
+	"minimal-go/internal/shell"
+	"minimal-go/internal/types"
+)
+
+var (
+	cdPattern      = regexp.MustCompile(`(?s)^\s*cd\s+("[^"]*"|'[^']*'|[^\s;&|]+)\s*(?:&&\s*(.*))?$`)
+	envNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+)
+
+type bashRequest struct {
+	Command string
+	Cwd     string
+	Env     map[string]string
+}
+
+type bashPlan struct {
+	command string
+	dir     string
+	nextCwd string
+	env     map[string]string
+}
+
+func parseBashRequest(input interface{}) (bashRequest, error) {
+	args := toolArgs(input)
+	request := bashRequest{Command: extractCommand(input), Env: map[string]string{}}
+	request.Cwd, _ = args["cwd"].(string)
+
+	if rawEnv, ok := args["env"]; ok && rawEnv != nil {
+		values, ok := rawEnv.(map[string]interface{})
+		if !ok {
+			return request, fmt.Errorf("env must be an object of string values")
+		}
+		for key, value := range values {
+			if !envNamePattern.MatchString(key) {
+				return request, fmt.Errorf("invalid environment variable name %q", key)
+			}
+			text, ok := value.(string)
+			if !ok {
+				return request, fmt.Errorf("env.%s must be a string", key)
+			}
+			request.Env[key] = text
+		}
+	}
+	return request, policy.CheckEnv(request.Env)
+}
+
+func (a *agent) planBash(request bashRequest) (bashPlan, error) {
+	plan := bashPlan{command: request.Command, dir: a.shellCwd, env: request.Env}
+	if request.Cwd != "" {
+		dir, err := a.resolveDir(request.Cwd, plan.dir)
+		if err != nil {
+			return plan, err
+		}
+		plan.dir = dir
+	}
+
+	if a.config.Shell.Persistent {
+		return plan, nil
+	}
+	if match := cdPattern.FindStringSubmatch(request.Command); match != nil {
+		dir, err := a.resolveDir(unquote(match[1]), plan.dir)
+		if err != nil {
+			return plan, err
+		}
+		plan.dir = dir
+		plan.nextCwd = dir
+		plan.command = match[2]
+	}
+	return plan, nil
+}
+
+func (a *agent) resolveDir(path string, base string) (string, error) {
+	dir, err := policy.ResolvePathFrom(path, base, a.workspaceRoot, a.config.Policy.AllowedPaths)
+	if err != nil {
+		return "", err
+	}
+	info, err := os.Stat(dir)
+	if err != nil {
+		return "", err
+	}
+	if !info.IsDir() {
+		return "", fmt.Errorf("%s is not a directory", path)
+	}
+	return dir, nil
+}
+
+func (a *agent) relativeToWorkspace(path string) string {
+	relative, err := filepath.Rel(a.workspaceRoot, path)
+	if err != nil {
+		return path
+	}
+	return relative
+}
+
+// envAssignments renders env as the assignments bash would take before a
+// command, so an approval shows the values and not just the names.
+func envAssignments(env map[string]string) string {
+	var b strings.Builder
+	for _, key := range envKeys(env) {
+		b.WriteString(key + "=" + shell.Quote(env[key]) + " ")
+	}
+	return b.String()
+}
+
+func envKeys(env map[string]string) []string {
+	keys := make([]string, 0, len(env))
+	for key := range env {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (a *agent) runPersistent(plan bashPlan) policy.BashResult {
+	if a.shell == nil {
+		session, err := shell.Start(a.workspaceRoot)
+		if err != nil {
+			return policy.BashResult{Stderr: "failed to start shell: " + err.Error(), Code: 1}
+		}
+		a.shell = session
+		a.shellCwd = a.workspaceRoot
+	}
+
+	dir := ""
+	if plan.dir != a.shellCwd {
+		dir = plan.dir
+	}
+	result, err := a.shell.Run(plan.command, dir, plan.env)
+	if err != nil {
+		a.resetShell()
+		code := 1
+		if errors.Is(err, shell.ErrTimeout) {
+			code = 124
+		}
+		return policy.BashResult{Stdout: result.Stdout, Stderr: strings.TrimSpace(result.Stderr + "\n" + err.Error()), Code: code}
+	}
+
+	bashResult := policy.BashResult{Stdout: result.Stdout, Stderr: result.Stderr, Code: result.Code}
+	if result.Cwd == "" {
+		return bashResult
+	}
+	if !policy.IsInsideAllowed(result.Cwd, a.workspaceRoot, a.config.Policy.AllowedPaths) {
+		a.notice(LevelWarning, "[shell] left the workspace ("+result.Cwd+"); returning to the workspace root")
+		_, _ = a.shell.Run("cd "+shell.Quote(a.workspaceRoot), "", nil)
+		a.shellCwd = a.workspaceRoot
+		bashResult.Stderr = strings.TrimSpace(bashResult.Stderr + "\n[shell] cwd moved outside the workspace and was reset to the workspace root")
+		return bashResult
+	}
+	a.shellCwd = result.Cwd
+	return bashResult
+}
+
+func (a *agent) resetShell() {
+	if a.shell != nil {
+		a.shell.Close()
+		a.shell = nil
+	}
+	a.shellCwd = a.workspaceRoot
+}
+
+func (a *agent) handleResetShell(callID string) types.Message {
+	a.resetShell()
+	a.notice(LevelInfo, "[shell] reset")
+	return types.Message{Role: types.RoleTool, ToolCallID: callID, Content: "Shell reset: environment variables, functions, activated environments and cwd were discarded."}
+}
@@ -0,0 +1,65 @@
+package core
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"sync"
+	"syscall"
+
+	"minimal-go/internal/policy"
+	"minimal-go/internal/ui"
+)
+
+var shutdownOnce sync.Once
+
+func (a *agent) Shutdown() {
+	if a.shell != nil {
+		a.shell.Kill()
+	}
+	a.flushAudit()
+	a.debugFile.close()
+}
+
+func handleTermination(cleanup func(os.Signal)) func() {
+	termCh := make(chan os.Signal, 1)
+	signal.Notify(termCh, syscall.SIGTERM, syscall.SIGHUP)
+	done := make(chan struct{})
+	go func() {
+		select {
+		case sig := <-termCh:
+			shutdownOnce.Do(func() {
+				signal.Ignore(syscall.SIGTERM, syscall.SIGHUP, os.Interrupt)
+				fmt.Fprint(os.Stdout, "\r\033[K")
+				policy.KillRunning()
+				cleanup(sig)
+				restoreTerminal()
+				code := 1
+				if number, ok := sig.(syscall.Signal); ok {
+					code = 128 + int(number)
+				}
+				os.Exit(code)
+			})
+		case <-done:
+		}
+	}()
+	return func() {
+		signal.Stop(termCh)
+		close(done)
+	}
+}
+
+func restoreTerminal() {
+	if !isTerminal(os.Stdin) {
+		return
+	}
+	fmt.Fprint(os.Stdout, "\033[?25h\033[0m")
+	stty := exec.Command("stty", "sane")
+	stty.Stdin = os.Stdin
+	_ = stty.Run()
+}
+
+func printShutdown(sig os.Signal, detail string) {
+	fmt.Fprintln(os.Stderr, ui.Warning(fmt.Sprintf("[shutdown] %v: %s", sig, detail)))
+}
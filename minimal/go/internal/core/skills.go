@@ -0,0 +1,139 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+
+	"minimal-go/internal/config"
+	"minimal-go/internal/policy"
+	"minimal-go/internal/skills"
+	"minimal-go/internal/ui"
+)
+
+var scriptInterpreters = map[string]bool{"bash": true, "sh": true, "python": true, "python3": true, "node": true, "ruby": true, "perl": true}
+
+func (a *agent) GrantSkill(dir string, scripts []string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.readablePaths = append(a.readablePaths, dir)
+	a.skillScripts = append(a.skillScripts, scripts...)
+}
+
+func (a *agent) resolveReadPath(path string) (string, error) {
+	a.mu.Lock()
+	allowed := append(append([]string{}, a.config.Policy.AllowedPaths...), a.readablePaths...)
+	a.mu.Unlock()
+	return policy.ResolvePath(path, a.workspaceRoot, allowed)
+}
+
+func (a *agent) isSkillScript(command string, dir string) bool {
+	if strings.ContainsAny(command, ";&|`$<>\n") {
+		return false
+	}
+	fields := strings.Fields(command)
+	if len(fields) > 1 && scriptInterpreters[fields[0]] {
+		fields = fields[1:]
+	}
+	if len(fields) == 0 {
+		return false
+	}
+	target := fields[0]
+	if !filepath.IsAbs(target) {
+		target = filepath.Join(dir, target)
+	}
+	target = filepath.Clean(target)
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for _, script := range a.skillScripts {
+		if script == target {
+			return true
+		}
+	}
+	return false
+}
+
+func SkillsCommand(args []string) error {
+	if len(args) == 0 || args[0] == "list" {
+		return listSkillSources()
+	}
+	if args[0] != "sync" {
+		printError("Usage: mini-go skills [list] | sync")
+		return errors.New("unknown skills command")
+	}
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		printError(err.Error())
+		return err
+	}
+	stop := ui.StartSpinner("syncing " + cfg.Skills.Repo)
+	changes, err := skills.Sync(cfg.Skills)
+	stop.Stop()
+	printSkillChanges(changes)
+	if err != nil {
+		printError(err.Error())
+		return err
+	}
+	return nil
+}
+
+func printSkillChanges(changes []skills.Change) {
+	counts := map[string]int{}
+	for _, change := range changes {
+		counts[change.Kind]++
+		pin := ""
+		if change.Pin != "" {
+			pin = ui.Muted(" (pinned " + change.Pin + ")")
+		}
+		switch change.Kind {
+		case skills.ChangeAdded:
+			fmt.Println(ui.Success("  + "+change.Name) + ui.Muted(" @ "+shortCommit(change.To)) + pin)
+		case skills.ChangeUpdated:
+			fmt.Println(ui.Warning("  ~ "+change.Name) + ui.Muted(fmt.Sprintf(" %s → %s", shortCommit(change.From), shortCommit(change.To))) + pin)
+			for _, line := range change.Log {
+				fmt.Println(ui.Muted("      " + line))
+			}
+		case skills.ChangeRemoved:
+			fmt.Println(ui.Error("  - " + change.Name))
+		case skills.ChangeSkipped:
+			fmt.Println(ui.Muted("  ! "+change.Name+": "+change.Note) + pin)
+		}
+	}
+	fmt.Println(ui.Muted(fmt.Sprintf("%d added, %d updated, %d removed, %d unchanged, %d skipped", counts[skills.ChangeAdded], counts[skills.ChangeUpdated], counts[skills.ChangeRemoved], counts[skills.ChangeUnchanged], counts[skills.ChangeSkipped])))
+}
+
+func listSkillSources() error {
+	state, err := skills.LoadSyncState()
+	if err != nil {
+		printError(err.Error())
+		return err
+	}
+	names := skills.List()
+	if len(names) == 0 {
+		fmt.Println(ui.Muted("No skills in " + config.SkillsDir))
+		return nil
+	}
+	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for _, name := range names {
+		source := "local"
+		if installed, ok := state.Skills[name]; ok {
+			source = "synced @ " + shortCommit(installed.Commit)
+			if installed.Pin != "" {
+				source += " (pinned " + installed.Pin + ")"
+			}
+		}
+		fmt.Fprintf(writer, "%s\t%s\n", ui.Cyan(name), ui.Muted(source))
+	}
+	writer.Flush()
+	return nil
+}
+
+func shortCommit(commit string) string {
+	if len(commit) > 7 {
+		return commit[:7]
+	}
+	return commit
+}
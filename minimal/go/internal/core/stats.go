@@ -0,0 +1,242 @@
+package core
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"minimal-go/internal/session"
+	"minimal-go/internal/types"
+	"minimal-go/internal/ui"
+)
+
+type TurnRecord struct {
+	Index            int
+	PromptTokens     int
+	CompletionTokens int
+	CacheReadTokens  int
+	Latency          time.Duration
+	Tools            []string
+	Cost             float64
+	CumulativeCost   float64
+	Priced           bool
+	Breakdown        TokenBreakdown
+}
+
+type TokenBreakdown struct {
+	System      int
+	ToolSchemas int
+	ToolResults int
+	User        int
+	History     int
+	Output      int
+}
+
+func (b TokenBreakdown) input() int {
+	return b.System + b.ToolSchemas + b.ToolResults + b.User + b.History
+}
+
+func (b *TokenBreakdown) add(other TokenBreakdown) {
+	b.System += other.System
+	b.ToolSchemas += other.ToolSchemas
+	b.ToolResults += other.ToolResults
+	b.User += other.User
+	b.History += other.History
+	b.Output += other.Output
+}
+
+func estimateBreakdown(messages []types.Message, tools []types.Tool) TokenBreakdown {
+	var breakdown TokenBreakdown
+	for _, message := range messages {
+		tokens := estimateMessageTokens(message)
+		switch message.Role {
+		case types.RoleSystem:
+			breakdown.System += tokens
+		case types.RoleTool:
+			breakdown.ToolResults += tokens
+		case types.RoleUser:
+			breakdown.User += tokens
+		default:
+			breakdown.History += tokens
+		}
+	}
+	breakdown.ToolSchemas = estimateRequestTokens(nil, tools)
+	return breakdown
+}
+
+func (a *agent) recordTurn(latency time.Duration, responseUsage *types.Usage, cost float64, toolCalls []types.ToolCall, breakdown TokenBreakdown) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	record := TurnRecord{
+		Index:     len(a.turns) + 1,
+		Latency:   latency,
+		Cost:      cost,
+		Priced:    a.llmConfig.ModelKnown,
+		Breakdown: breakdown,
+	}
+	if responseUsage != nil {
+		record.PromptTokens = responseUsage.PromptTokens
+		record.CompletionTokens = responseUsage.CompletionTokens
+		record.CacheReadTokens = responseUsage.CacheReadTokens
+		record.Breakdown.Output = responseUsage.CompletionTokens
+	}
+	for _, call := range toolCalls {
+		record.Tools = append(record.Tools, call.Name)
+	}
+	record.CumulativeCost = cost
+	if len(a.turns) > 0 {
+		record.CumulativeCost += a.turns[len(a.turns)-1].CumulativeCost
+	}
+	a.turns = append(a.turns, record)
+}
+
+func (a *agent) currentCost() float64 {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.sessionCost
+}
+
+func (a *agent) summarizeTurn(started time.Time, tokens TokenUsage, cost float64, err error) {
+	summary := session.TurnSummary{
+		ToolCalls:    a.turnToolCalls,
+		FilesChanged: len(a.turnFiles),
+		Tokens:       a.GetTokens().Total - tokens.Total,
+		DurationMs:   time.Since(started).Milliseconds(),
+		Failed:       err != nil,
+	}
+	if a.llmConfig.ModelKnown {
+		summary.Cost = a.currentCost() - cost
+	}
+	a.mu.Lock()
+	a.turnSummary = summary
+	a.mu.Unlock()
+}
+
+func (a *agent) GetTurnSummary() session.TurnSummary {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.turnSummary
+}
+
+// formatTurnSummary reads like "[turn] 3 tool calls · 2 files changed ·
+// 4.2k tokens · $0.0123 · 12.4s".
+func formatTurnSummary(summary session.TurnSummary, priced bool) string {
+	parts := []string{
+		plural(summary.ToolCalls, "tool call"),
+		plural(summary.FilesChanged, "file") + " changed",
+		formatTokenCount(summary.Tokens) + " tokens",
+	}
+	if priced {
+		parts = append(parts, formatCost(summary.Cost, true))
+	}
+	parts = append(parts, formatMs(float64(summary.DurationMs)))
+	label := "[turn] "
+	if summary.Failed {
+		label = "[turn failed] "
+	}
+	return label + strings.Join(parts, " · ")
+}
+
+func formatTokenCount(n int) string {
+	if n < 1000 {
+		return fmt.Sprintf("%d", n)
+	}
+	return fmt.Sprintf("%.1fk", float64(n)/1000)
+}
+
+func (a *agent) GetTurnStats() []TurnRecord {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return append([]TurnRecord{}, a.turns...)
+}
+
+func sessionCost(agent Agent) float64 {
+	turns := agent.GetTurnStats()
+	if len(turns) == 0 {
+		return 0
+	}
+	return turns[len(turns)-1].CumulativeCost
+}
+
+func printTurnStats(turns []TurnRecord) {
+	if len(turns) == 0 {
+		fmt.Println(ui.Muted("No requests yet."))
+		return
+	}
+
+	fmt.Println("")
+	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(writer, "#\tIN\tOUT\tCACHE\tLATENCY\tCOST\tTOTAL\t TOOLS")
+	var prompt, completion, cache int
+	var latency time.Duration
+	for _, turn := range turns {
+		prompt += turn.PromptTokens
+		completion += turn.CompletionTokens
+		cache += turn.CacheReadTokens
+		latency += turn.Latency
+		tools := strings.Join(turn.Tools, ",")
+		if tools == "" {
+			tools = "-"
+		}
+		fmt.Fprintf(writer, "%d\t%d\t%d\t%d\t%.2fs\t%s\t%s\t %s\n", turn.Index, turn.PromptTokens, turn.CompletionTokens, turn.CacheReadTokens, turn.Latency.Seconds(), formatCost(turn.Cost, turn.Priced), formatCost(turn.CumulativeCost, turn.Priced), tools)
+	}
+	last := turns[len(turns)-1]
+	fmt.Fprintf(writer, "Σ\t%d\t%d\t%d\t%.2fs\t\t%s\t\n", prompt, completion, cache, latency.Seconds(), formatCost(last.CumulativeCost, last.Priced))
+	writer.Flush()
+	printTokenBreakdown(turns)
+	fmt.Println("")
+}
+
+func printTokenBreakdown(turns []TurnRecord) {
+	var total TokenBreakdown
+	for _, turn := range turns {
+		total.add(turn.Breakdown)
+	}
+	current := turns[len(turns)-1].Breakdown
+	if total.input() == 0 {
+		return
+	}
+
+	fmt.Println("")
+	fmt.Println(ui.Bold("Where the tokens go") + ui.Muted(" (estimated input, all requests / last request)"))
+	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+	rows := []struct {
+		label   string
+		total   int
+		current int
+	}{
+		{"system prompt", total.System, current.System},
+		{"tool schemas", total.ToolSchemas, current.ToolSchemas},
+		{"tool results", total.ToolResults, current.ToolResults},
+		{"user content", total.User, current.User},
+		{"assistant history", total.History, current.History},
+	}
+	for _, row := range rows {
+		fmt.Fprintf(writer, "  %s\t%d\t%s\t%d\t\n", row.label, row.total, percentOf(row.total, total.input()), row.current)
+	}
+	fmt.Fprintf(writer, "  assistant output\t%d\t\t%d\t\n", total.Output, current.Output)
+	writer.Flush()
+}
+
+func percentOf(part int, whole int) string {
+	if whole == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%.0f%%", float64(part)*100/float64(whole))
+}
+
+func formatCost(cost float64, priced bool) string {
+	if !priced {
+		return "-"
+	}
+	return fmt.Sprintf("$%.4f", cost)
+}
+
+func plural(n int, noun string) string {
+	if n == 1 {
+		return "1 " + noun
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
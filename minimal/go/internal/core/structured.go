@@ -0,0 +1,57 @@
+package core
+
+import (
+	"strings"
+
+	"minimal-go/internal/core/providers"
+	"minimal-go/internal/types"
+)
+
+const structuredMaxTokens = 300
+
+var titleFormat = &providers.ResponseFormat{
+	Type: providers.FormatJSONSchema,
+	Name: "session_title",
+	Schema: map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"title": map[string]interface{}{"type": "string"},
+		},
+		"required":             []string{"title"},
+		"additionalProperties": false,
+	},
+}
+
+func (a *agent) completeJSON(instructions string, input string, format *providers.ResponseFormat, maxTokens int, target interface{}) error {
+	return a.completeJSONWith(a.llmConfig.Model, instructions, input, format, maxTokens, target)
+}
+
+func (a *agent) completeJSONWith(model string, instructions string, input string, format *providers.ResponseFormat, maxTokens int, target interface{}) error {
+	response, err := a.provider.CreateChatCompletion(providers.CreateChatParams{
+		Model:     model,
+		MaxTokens: maxTokens,
+		Messages: []types.Message{
+			{Role: types.RoleSystem, Content: instructions},
+			{Role: types.RoleUser, Content: input},
+		},
+		Format: format,
+	})
+	if err != nil {
+		return mapProviderError(err)
+	}
+	if response.Usage != nil {
+		a.recordUsage(response.Usage)
+	}
+	return providers.DecodeJSON(response.Message.Content, format, target)
+}
+
+func (a *agent) SuggestTitle(prompt string) (string, error) {
+	var result struct {
+		Title string `json:"title"`
+	}
+	instructions := "Write a short title (at most 8 words) for a coding session that starts with the user's request below. Return JSON: {\"title\": \"...\"}."
+	if err := a.completeJSON(instructions, prompt, titleFormat, structuredMaxTokens, &result); err != nil {
+		return "", err
+	}
+	return strings.Trim(strings.TrimSpace(result.Title), "\"."), nil
+}
@@ -0,0 +1,334 @@
+package core
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"minimal-go/internal/policy"
+	"minimal-go/internal/shell"
+	"minimal-go/internal/types"
+)
+
+const (
+	testTimeout       = 5 * time.Minute
+	maxTestFailures   = 20
+	maxFailureOutput  = 1500
+	maxTestOutputTail = 3000
+)
+
+var (
+	testCountPattern    = regexp.MustCompile(`(\d+) (passed|failed|skipped|passing|failing|pending|ignored)`)
+	pytestFailedPattern = regexp.MustCompile(`(?m)^FAILED (\S+)`)
+	jestFailedPattern   = regexp.MustCompile(`(?m)^\s*● (.+)$`)
+	mochaFailedPattern  = regexp.MustCompile(`(?m)^\s+\d+\) (.+)$`)
+	cargoFailedPattern  = regexp.MustCompile(`(?m)^test (\S+) \.\.\. FAILED$`)
+)
+
+type testRunner struct {
+	name    string
+	command string
+}
+
+type testFailure struct {
+	Name   string `json:"name"`
+	Output string `json:"output,omitempty"`
+}
+
+type testSummary struct {
+	Passed   int
+	Failed   int
+	Skipped  int
+	Failures []testFailure
+	Parsed   bool
+}
+
+func detectTestRunner(dir string, target string, filter string) (testRunner, bool) {
+	switch {
+	case fileExists(filepath.Join(dir, "go.mod")):
+		pkg := "./..."
+		if target != "" {
+			pkg = "./" + filepath.ToSlash(target) + "/..."
+		}
+		command := "go test -json " + shell.Quote(pkg)
+		if filter != "" {
+			command += " -run " + shell.Quote(filter)
+		}
+		return testRunner{name: "go", command: command}, true
+	case hasNpmTestScript(filepath.Join(dir, "package.json")):
+		command := "npm test --silent"
+		var extra []string
+		if target != "" {
+			extra = append(extra, shell.Quote(target))
+		}
+		if filter != "" {
+			extra = append(extra, "-t", shell.Quote(filter))
+		}
+		if len(extra) > 0 {
+			command += " -- " + strings.Join(extra, " ")
+		}
+		return testRunner{name: "npm", command: command}, true
+	case fileExists(filepath.Join(dir, "pytest.ini")) || fileExists(filepath.Join(dir, "pyproject.toml")) ||
+		fileExists(filepath.Join(dir, "setup.cfg")) || fileExists(filepath.Join(dir, "tox.ini")) ||
+		fileExists(filepath.Join(dir, "conftest.py")):
+		command := "python3 -m pytest -q -rf --color=no"
+		if target != "" {
+			command += " " + shell.Quote(target)
+		}
+		if filter != "" {
+			command += " -k " + shell.Quote(filter)
+		}
+		return testRunner{name: "pytest", command: command}, true
+	case fileExists(filepath.Join(dir, "Cargo.toml")):
+		command := "cargo test --color never"
+		if filter != "" {
+			command += " " + shell.Quote(filter)
+		}
+		return testRunner{name: "cargo", command: command}, true
+	}
+	return testRunner{}, false
+}
+
+func hasNpmTestScript(path string) bool {
+	scripts, _ := npmScripts(path)
+	script := scripts["test"]
+	return script != "" && !strings.Contains(script, "no test specified")
+}
+
+func (a *agent) handleRunTests(input interface{}, callID string) types.Message {
+	args := toolArgs(input)
+	target, _ := args["path"].(string)
+	filter, _ := args["filter"].(string)
+
+	if target != "" {
+		fullPath, err := a.resolvePath(target)
+		if err != nil {
+			return a.pathDenied(callID, target, err)
+		}
+		target = a.relativeToWorkspace(fullPath)
+		if target == "." {
+			target = ""
+		}
+	}
+
+	runner, ok := detectTestRunner(a.workspaceRoot, target, filter)
+	if !ok {
+		return toolError(callID, "Could not detect a test runner: expected go.mod, package.json with a test script, a pytest config or Cargo.toml in the workspace root. Run the tests with bash instead.")
+	}
+
+	proposed := runner.command
+	edited, rejection := a.authorizeCommand(runner.command, runner.command, a.workspaceRoot, nil, callID)
+	if rejection != nil {
+		return *rejection
+	}
+	if edited != "" {
+		runner.command = edited
+	}
+
+	a.notice(LevelInfo, "[tests] "+runner.command)
+	start := time.Now()
+	result := policy.RunBashWithOptions(runner.command, policy.BashOptions{Dir: a.workspaceRoot, Timeout: testTimeout, Scrub: true})
+	elapsed := time.Since(start).Round(time.Millisecond)
+
+	var summary testSummary
+	if runner.name == "go" {
+		summary = parseGoTestJSON(result.Stdout)
+	} else {
+		summary = parseTestOutput(runner.name, result.Stdout+"\n"+result.Stderr)
+	}
+
+	status := "passed"
+	if result.Code == 124 {
+		status = "timeout"
+	} else if result.Code != 0 || summary.Failed > 0 {
+		status = "failed"
+	}
+
+	fields := map[string]interface{}{
+		"status":   status,
+		"runner":   runner.name,
+		"command":  runner.command,
+		"exitCode": result.Code,
+		"passed":   summary.Passed,
+		"failed":   summary.Failed,
+		"skipped":  summary.Skipped,
+		"duration": elapsed.String(),
+	}
+	if edited != "" {
+		fields["proposedCommand"] = proposed
+		fields["note"] = "The user edited the test command before approving it; the command above is what actually ran."
+	}
+	failures := summary.Failures
+	if len(failures) > maxTestFailures {
+		fields["truncatedFailures"] = len(failures) - maxTestFailures
+		failures = failures[:maxTestFailures]
+	}
+	if len(failures) > 0 {
+		fields["failures"] = failures
+	}
+	if status != "passed" && (!summary.Parsed || len(failures) == 0) {
+		output := result.Stdout + "\n" + result.Stderr
+		if runner.name == "go" {
+			output = result.Stderr
+		}
+		fields["outputTail"] = tailText(strings.TrimSpace(output), maxTestOutputTail)
+	}
+
+	line := fmt.Sprintf("[tests] %s: %d passed, %d failed, %d skipped (%s)", status, summary.Passed, summary.Failed, summary.Skipped, elapsed)
+	if status == "passed" {
+		a.notice(LevelSuccess, "✓ "+strings.TrimPrefix(line, "[tests] "))
+	} else {
+		a.notice(LevelWarning, line)
+	}
+
+	payload, _ := json.MarshalIndent(fields, "", "  ")
+	return types.Message{Role: types.RoleTool, ToolCallID: callID, Content: string(payload)}
+}
+
+func parseGoTestJSON(output string) testSummary {
+	type event struct {
+		Action  string
+		Package string
+		Test    string
+		Output  string
+	}
+
+	summary := testSummary{}
+	outputs := map[string]*strings.Builder{}
+	var failed []string
+	failedTests := map[string]bool{}
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	scanner.Buffer(make([]byte, 1024*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var e event
+		if json.Unmarshal(scanner.Bytes(), &e) != nil {
+			continue
+		}
+		summary.Parsed = true
+		key := e.Package
+		if e.Test != "" {
+			key += "." + e.Test
+		}
+		switch e.Action {
+		case "output":
+			builder := outputs[key]
+			if builder == nil {
+				builder = &strings.Builder{}
+				outputs[key] = builder
+			}
+			if builder.Len() < maxFailureOutput*4 {
+				builder.WriteString(e.Output)
+			}
+		case "pass":
+			if e.Test != "" {
+				summary.Passed++
+			}
+		case "skip":
+			if e.Test != "" {
+				summary.Skipped++
+			}
+		case "fail":
+			if e.Test != "" {
+				failedTests[key] = true
+				failed = append(failed, key)
+			} else if !hasFailedTestIn(failed, e.Package) {
+				failed = append(failed, key)
+			}
+		}
+	}
+
+	sort.Strings(failed)
+	for _, key := range failed {
+		text := ""
+		if builder := outputs[key]; builder != nil {
+			text = cleanGoTestOutput(builder.String())
+		}
+		if isParentTest(key, failed) {
+			continue
+		}
+		if failedTests[key] {
+			summary.Failed++
+		}
+		summary.Failures = append(summary.Failures, testFailure{Name: key, Output: tailText(text, maxFailureOutput)})
+	}
+	return summary
+}
+
+func hasFailedTestIn(failed []string, pkg string) bool {
+	for _, key := range failed {
+		if strings.HasPrefix(key, pkg+".") {
+			return true
+		}
+	}
+	return false
+}
+
+func isParentTest(key string, failed []string) bool {
+	for _, other := range failed {
+		if strings.HasPrefix(other, key+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+func cleanGoTestOutput(text string) string {
+	var lines []string
+	for _, line := range strings.Split(text, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "=== ") || strings.HasPrefix(trimmed, "--- ") ||
+			trimmed == "FAIL" || trimmed == "PASS" || strings.HasPrefix(trimmed, "ok ") {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func parseTestOutput(runner string, output string) testSummary {
+	summary := testSummary{}
+	for _, match := range testCountPattern.FindAllStringSubmatch(output, -1) {
+		count, _ := strconv.Atoi(match[1])
+		summary.Parsed = true
+		switch match[2] {
+		case "passed", "passing":
+			summary.Passed += count
+		case "failed", "failing":
+			summary.Failed += count
+		default:
+			summary.Skipped += count
+		}
+	}
+
+	var pattern *regexp.Regexp
+	switch runner {
+	case "pytest":
+		pattern = pytestFailedPattern
+	case "cargo":
+		pattern = cargoFailedPattern
+	case "npm":
+		pattern = jestFailedPattern
+		if !pattern.MatchString(output) {
+			pattern = mochaFailedPattern
+		}
+	}
+	if pattern == nil {
+		return summary
+	}
+	seen := map[string]bool{}
+	for _, match := range pattern.FindAllStringSubmatch(output, -1) {
+		name := strings.TrimSpace(match[1])
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		summary.Failures = append(summary.Failures, testFailure{Name: name})
+	}
+	return summary
+}
@@ -0,0 +1,76 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"minimal-go/internal/config"
+	"minimal-go/internal/ui"
+)
+
+func (a *agent) SetThinkingDisplay(mode string) {
+	a.turnMu.Lock()
+	defer a.turnMu.Unlock()
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.config.UI.Thinking = mode
+}
+
+func (a *agent) GetThinkingDisplay() string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.config.UI.Thinking
+}
+
+func (a *agent) GetLastThinking() string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.lastThinking
+}
+
+func (a *agent) printThinking(thinking string) {
+	a.mu.Lock()
+	a.lastThinking = thinking
+	a.mu.Unlock()
+	switch a.config.UI.Thinking {
+	case config.ThinkingOff:
+		return
+	case config.ThinkingCollapsed:
+		a.emit(Event{Kind: EventThinking, Text: thinking, Collapsed: true})
+		return
+	}
+	a.emit(Event{Kind: EventThinking, Text: thinking})
+}
+
+func printThinkingBlock(out io.Writer, thinking string) {
+	fmt.Fprintln(out, ui.Thinking("─── thinking ───"))
+	fmt.Fprintln(out, ui.Thinking(thinking))
+	fmt.Fprintln(out, ui.Thinking("────────────────"))
+}
+
+func handleThinkingDisplay(args string, state *replState) error {
+	if args == "" {
+		fmt.Println(ui.Muted("Thinking display: " + state.agent.GetThinkingDisplay()))
+		return nil
+	}
+	if !config.ValidThinkingDisplay(args) {
+		return errors.New("Usage: /thinking-display on|off|collapsed")
+	}
+	state.agent.SetThinkingDisplay(args)
+	if err := config.SetUIOption("thinking", args); err != nil {
+		return fmt.Errorf("thinking display set for this session but not saved: %w", err)
+	}
+	printSuccess("✓ Thinking display: " + args)
+	return nil
+}
+
+func expandThinking(agent Agent) {
+	thinking := agent.GetLastThinking()
+	if thinking == "" {
+		fmt.Println(ui.Muted("No thinking output in this session yet."))
+		return
+	}
+	printThinkingBlock(os.Stdout, thinking)
+}
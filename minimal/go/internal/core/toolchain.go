@@ -0,0 +1,123 @@
+package core
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+type toolchain struct {
+	name   string
+	marker string
+	build  string
+	test   string
+	lint   string
+}
+
+// projectProfile is what the workspace root says about how to build, test
+// and lint the project. A repository can hold several stacks (a Go service
+// with a package.json for its frontend), so every marker found counts.
+type projectProfile struct {
+	toolchains []toolchain
+}
+
+func detectProjectProfile(workspaceRoot string) projectProfile {
+	var profile projectProfile
+	if fileExists(filepath.Join(workspaceRoot, "go.mod")) {
+		profile.toolchains = append(profile.toolchains, toolchain{name: "Go", marker: "go.mod", build: "go build ./...", test: "go test ./...", lint: "go vet ./..."})
+	}
+	if scripts, ok := npmScripts(filepath.Join(workspaceRoot, "package.json")); ok {
+		node := toolchain{name: "Node.js", marker: "package.json"}
+		if scripts["build"] != "" {
+			node.build = "npm run build"
+		}
+		if hasNpmTestScript(filepath.Join(workspaceRoot, "package.json")) {
+			node.test = "npm test"
+		}
+		if scripts["lint"] != "" {
+			node.lint = "npm run lint"
+		}
+		profile.toolchains = append(profile.toolchains, node)
+	}
+	if data, err := os.ReadFile(filepath.Join(workspaceRoot, "pyproject.toml")); err == nil {
+		python := toolchain{name: "Python", marker: "pyproject.toml", test: "python3 -m pytest"}
+		if strings.Contains(string(data), "[tool.ruff") {
+			python.lint = "ruff check ."
+		}
+		profile.toolchains = append(profile.toolchains, python)
+	}
+	if fileExists(filepath.Join(workspaceRoot, "Cargo.toml")) {
+		profile.toolchains = append(profile.toolchains, toolchain{name: "Rust", marker: "Cargo.toml", build: "cargo build", test: "cargo test", lint: "cargo clippy"})
+	}
+	return profile
+}
+
+func (t toolchain) commands() []string {
+	var commands []string
+	for _, command := range []string{t.build, t.test, t.lint} {
+		if command != "" {
+			commands = append(commands, command)
+		}
+	}
+	return commands
+}
+
+// autoCommands are the profile's commands without their path arguments:
+// policy.autoCommands matches by prefix, so "go test" also covers
+// "go test -run TestX ./pkg/...".
+func (p projectProfile) autoCommands() []string {
+	var prefixes []string
+	for _, chain := range p.toolchains {
+		for _, command := range chain.commands() {
+			var kept []string
+			for _, field := range strings.Fields(command) {
+				if strings.HasPrefix(field, ".") {
+					break
+				}
+				kept = append(kept, field)
+			}
+			prefixes = append(prefixes, strings.Join(kept, " "))
+		}
+	}
+	return prefixes
+}
+
+func (p projectProfile) names() string {
+	names := make([]string, len(p.toolchains))
+	for i, chain := range p.toolchains {
+		names[i] = chain.name + " (" + chain.marker + ")"
+	}
+	return strings.Join(names, ", ")
+}
+
+func toolchainSection(workspaceRoot string) (string, error) {
+	profile := detectProjectProfile(workspaceRoot)
+	if len(profile.toolchains) == 0 {
+		return "", nil
+	}
+	var b strings.Builder
+	b.WriteString("## Project toolchain\n\nDetected: " + profile.names() + ". Use these commands from the workspace root:\n\n")
+	for _, chain := range profile.toolchains {
+		for _, entry := range []struct{ label, command string }{{"build", chain.build}, {"test", chain.test}, {"lint", chain.lint}} {
+			if entry.command != "" {
+				b.WriteString("- " + chain.name + " " + entry.label + ": `" + entry.command + "`\n")
+			}
+		}
+	}
+	return b.String(), nil
+}
+
+func npmScripts(path string) (map[string]string, bool) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, false
+	}
+	var manifest struct {
+		Scripts map[string]string `json:"scripts"`
+	}
+	if json.Unmarshal(data, &manifest) != nil {
+		return nil, false
+	}
+	return manifest.Scripts, true
+}
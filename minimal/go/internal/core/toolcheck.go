@@ -0,0 +1,122 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"minimal-go/internal/types"
+)
+
+const maxMalformedToolTurns = 3
+
+type toolCallError struct {
+	kind    string
+	message string
+	tool    *types.Tool
+}
+
+func (a *agent) validateToolCall(call types.ToolCall) (map[string]interface{}, *toolCallError) {
+	var tool *types.Tool
+	for i := range a.tools {
+		if a.tools[i].Name == call.Name {
+			tool = &a.tools[i]
+			break
+		}
+	}
+	if tool == nil {
+		return nil, &toolCallError{kind: "unknown_tool", message: fmt.Sprintf("There is no tool named %q.", call.Name)}
+	}
+
+	var args map[string]interface{}
+	switch input := call.Input.(type) {
+	case nil:
+		args = map[string]interface{}{}
+	case map[string]interface{}:
+		args = input
+	case string:
+		if strings.TrimSpace(input) == "" {
+			args = map[string]interface{}{}
+			break
+		}
+		if err := json.Unmarshal([]byte(input), &args); err != nil {
+			return nil, &toolCallError{kind: "invalid_json", message: "Arguments are not a valid JSON object: " + err.Error(), tool: tool}
+		}
+	default:
+		return nil, &toolCallError{kind: "invalid_arguments", message: "Arguments must be a JSON object.", tool: tool}
+	}
+
+	properties, _ := tool.InputSchema["properties"].(map[string]interface{})
+	required, _ := tool.InputSchema["required"].([]string)
+	var problems []string
+	for _, name := range required {
+		if _, ok := args[name]; !ok {
+			problems = append(problems, fmt.Sprintf("missing required argument %q", name))
+		}
+	}
+	names := make([]string, 0, len(args))
+	for name := range args {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		value := args[name]
+		property, ok := properties[name].(map[string]interface{})
+		if !ok {
+			problems = append(problems, fmt.Sprintf("unknown argument %q", name))
+			continue
+		}
+		if expected, _ := property["type"].(string); !matchesSchemaType(value, expected) {
+			problems = append(problems, fmt.Sprintf("argument %q must be a %s", name, expected))
+		}
+	}
+	if len(problems) > 0 {
+		return nil, &toolCallError{kind: "invalid_arguments", message: strings.Join(problems, "; ") + ".", tool: tool}
+	}
+	return args, nil
+}
+
+func matchesSchemaType(value interface{}, expected string) bool {
+	switch expected {
+	case "string":
+		_, ok := value.(string)
+		return ok
+	case "boolean":
+		_, ok := value.(bool)
+		return ok
+	case "number", "integer":
+		_, ok := value.(float64)
+		return ok
+	case "object":
+		_, ok := value.(map[string]interface{})
+		return ok
+	case "array":
+		_, ok := value.([]interface{})
+		return ok
+	}
+	return true
+}
+
+func (a *agent) toolCallErrorResult(call types.ToolCall, failure *toolCallError) types.Message {
+	fields := map[string]interface{}{
+		"status":  "error",
+		"error":   failure.kind,
+		"tool":    call.Name,
+		"message": failure.message,
+	}
+	if failure.tool != nil {
+		fields["schema"] = failure.tool.InputSchema
+		fields["hint"] = "Call the tool again with arguments that match the schema."
+	} else {
+		names := make([]string, 0, len(a.tools))
+		for _, tool := range a.tools {
+			names = append(names, tool.Name)
+		}
+		fields["availableTools"] = names
+		fields["hint"] = "Use one of the available tools."
+	}
+	payload, _ := json.MarshalIndent(fields, "", "  ")
+	a.notice(LevelWarning, fmt.Sprintf("[tool] %s: %s", call.Name, failure.message))
+	return types.Message{Role: types.RoleTool, ToolCallID: call.ID, Content: string(payload)}
+}
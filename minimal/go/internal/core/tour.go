@@ -0,0 +1,158 @@
+package core
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+
+	"minimal-go/internal/config"
+	"minimal-go/internal/core/providers"
+	"minimal-go/internal/policy"
+	"minimal-go/internal/skills"
+	"minimal-go/internal/types"
+	"minimal-go/internal/ui"
+)
+
+const tourModel = "tour-mock"
+
+func tourScript() []types.Message {
+	bash := func(id string, command string) types.ToolCall {
+		return types.ToolCall{ID: id, Name: "bash", Input: map[string]interface{}{"command": command}}
+	}
+	return []types.Message{
+		{Content: "Hi! I'm the tour's mock model. Everything here runs offline and costs nothing.\nIn a real session your prompt goes to the configured provider and the reply streams back here."},
+		{Content: "I'd like to run a command. Commands that aren't known to be safe need your approval:", ToolCalls: []types.ToolCall{bash("tour-1", "echo 'hello from mini-go'")}},
+		{Content: "Whatever you chose was sent back to me as the tool result, so I can adapt."},
+		{Content: "Some commands run without asking, and some are never allowed:", ToolCalls: []types.ToolCall{bash("tour-2", "pwd"), bash("tour-3", "rm -rf /")}},
+		{Content: "`pwd` matched the built-in auto-approve list, while `rm -rf /` hit a deny pattern and never ran."},
+		{Content: "I received your shell output together with your message, so I can use it as context."},
+	}
+}
+
+func Tour() error {
+	reader := bufio.NewReader(os.Stdin)
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, os.Interrupt)
+	defer signal.Stop(sigCh)
+
+	workspaceRoot, err := os.MkdirTemp("", "mini-go-tour-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(workspaceRoot)
+
+	cfg := config.DefaultConfig()
+	cfg.LLM = config.LlmConfig{
+		CurrentProvider: "mock",
+		CurrentModel:    tourModel,
+		Variants: map[string]config.LlmVariant{
+			"mock": {SchemaType: config.SchemaMock},
+		},
+	}
+
+	agent, err := CreateAgent(AgentOptions{
+		Config:        cfg,
+		SystemPrompt:  "You are the mini-go tour guide.",
+		WorkspaceRoot: workspaceRoot,
+		Provider:      providers.NewMockProvider(tourScript()),
+		Callbacks: AgentCallbacks{
+			PromptApproval:    newPromptApproval(reader, sigCh, config.ApprovalConfig{}, nil),
+			PromptFileChanges: newPromptFileChanges(reader, sigCh, config.ApprovalConfig{}, nil),
+			OnAutoApproved:    printAutoApproved,
+			OnDenied:          printDenied,
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	step := func(number int, title string, lines ...string) {
+		fmt.Println("")
+		fmt.Println(ui.Accent(fmt.Sprintf("Step %d/6 · %s", number, title)))
+		for _, line := range lines {
+			fmt.Println(ui.Muted("  " + line))
+		}
+		fmt.Println("")
+	}
+	prompt := func(label string) (string, bool) {
+		line, cancelled, err := readLine(reader, ui.Prompt(label), sigCh, true)
+		if err != nil || cancelled {
+			fmt.Println(ui.Warning("\nTour cancelled."))
+			return "", false
+		}
+		return strings.TrimSpace(line), true
+	}
+	runTurn := func(content string) {
+		agent.AddUserMessage(content)
+		if err := agent.RunAgentTurn(); err != nil {
+			printError(err.Error())
+		}
+	}
+
+	fmt.Println(ui.Bold("Welcome to mini-go!") + ui.Muted(" This tour uses a mock provider: no API key, no network."))
+	fmt.Println(ui.Muted("Commands run in a throwaway directory: " + workspaceRoot))
+
+	step(1, "Prompts", "Type anything and press enter, just like in a normal session.")
+	input, ok := prompt("> ")
+	if !ok {
+		return nil
+	}
+	if input == "" {
+		input = "Hello!"
+	}
+	runTurn(input)
+
+	step(2, "Approvals", "The model proposes commands through the bash tool.", "Press enter (or y) to run it, n to reject it.")
+	if _, ok := prompt(ui.Muted("(press enter to continue) ")); !ok {
+		return nil
+	}
+	runTurn("Show me how approvals work.")
+
+	step(3, "Policy", "Safe read-only commands are auto-approved; destructive ones are denied outright.")
+	if _, ok := prompt(ui.Muted("(press enter to continue) ")); !ok {
+		return nil
+	}
+	runTurn("Show me auto-approval and denial.")
+
+	step(4, "! commands", "Lines starting with ! run directly in your shell, without the model.", "Their output is attached to your next prompt. Try: !date")
+	command, ok := prompt("> ")
+	if !ok {
+		return nil
+	}
+	command = strings.TrimSpace(strings.TrimPrefix(command, "!"))
+	if command == "" {
+		command = "date"
+	}
+	result := policy.RunBash(command, workspaceRoot)
+	if strings.TrimSpace(result.Stdout) != "" {
+		fmt.Println(strings.TrimRight(result.Stdout, "\n"))
+	}
+	if strings.TrimSpace(result.Stderr) != "" {
+		fmt.Println(ui.Error(strings.TrimRight(result.Stderr, "\n")))
+	}
+	fmt.Println(ui.Muted("Now type a prompt; the command output is sent along with it."))
+	followUp, ok := prompt("> ")
+	if !ok {
+		return nil
+	}
+	if followUp == "" {
+		followUp = "What did that command print?"
+	}
+	runTurn(policy.FormatCommandResult(command, result) + "\n\n" + followUp)
+
+	step(5, "Skills", "Skills are reusable prompts stored as Markdown in "+config.SkillsDir+".", "Load one with /skill <name>; run /skill alone to list them.")
+	printSkillList(skills.List())
+
+	step(6, "Policy configuration", "Tune approvals in "+config.ConfigPath+":")
+	fmt.Println(ui.Muted(`  "policy": {
+    "defaultAction": "ask",
+    "autoCommands": ["go test", "make lint"],
+    "denyPatterns": ["git\\s+push\\s+--force"]
+  }`))
+
+	fmt.Println("")
+	printSuccess("✓ Tour complete. Run mini-go to start a real session.")
+	return nil
+}
@@ -0,0 +1,132 @@
+package core
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"minimal-go/internal/policy"
+)
+
+// With policy.confirmUntracked, files git does not track (.env, local
+// configs, anything ignored) are treated as the user's own: an
+// auto-approved command that names one is asked about first, "always allow"
+// does not cover them, and a command that changes one anyway, like a
+// `go run` writing its own config, is reported afterwards.
+
+// guardsUntracked is also true under --auto outside a sandbox, since git
+// cannot restore what it does not track.
+func (a *agent) guardsUntracked() bool {
+	return a.config.Policy.ConfirmUntracked || a.config.Auto && !sandboxed()
+}
+
+func (a *agent) untrackedTouches(command string, dir string) []string {
+	if !a.guardsUntracked() {
+		return nil
+	}
+	var candidates []string
+	seen := map[string]bool{}
+	add := func(target string) {
+		fullPath, err := policy.ResolvePathFrom(target, dir, a.workspaceRoot, a.config.Policy.AllowedPaths)
+		if err != nil || seen[fullPath] {
+			return
+		}
+		if info, err := os.Stat(fullPath); err == nil && info.Mode().IsRegular() {
+			seen[fullPath] = true
+			candidates = append(candidates, fullPath)
+		}
+	}
+	for _, write := range detectRedirectWrites(command) {
+		add(write.target)
+	}
+	for _, field := range strings.Fields(command) {
+		field = strings.Trim(field, `"'`)
+		if _, value, ok := strings.Cut(field, "="); ok && strings.HasPrefix(field, "-") {
+			field = value
+		}
+		if field != "" && !strings.HasPrefix(field, "-") {
+			add(field)
+		}
+	}
+	return a.untracked(candidates)
+}
+
+func (a *agent) isUntracked(fullPath string) bool {
+	return a.guardsUntracked() && len(a.untracked([]string{fullPath})) > 0
+}
+
+// untracked returns the workspace-relative paths among fullPaths that git
+// does not track. Outside a git repository nothing is reported.
+func (a *agent) untracked(fullPaths []string) []string {
+	var inside []string
+	for _, fullPath := range fullPaths {
+		if rel, err := filepath.Rel(a.workspaceRoot, fullPath); err == nil && !strings.HasPrefix(rel, "..") {
+			inside = append(inside, rel)
+		}
+	}
+	if len(inside) == 0 {
+		return nil
+	}
+	output, err := exec.Command("git", append([]string{"-C", a.workspaceRoot, "ls-files", "-z", "--cached", "--"}, inside...)...).Output()
+	if err != nil {
+		return nil
+	}
+	tracked := map[string]bool{}
+	for _, path := range bytes.Split(output, []byte{0}) {
+		tracked[filepath.FromSlash(string(path))] = true
+	}
+	var result []string
+	for _, rel := range inside {
+		if !tracked[rel] {
+			result = append(result, filepath.ToSlash(rel))
+		}
+	}
+	return result
+}
+
+// untrackedSnapshot stamps the untracked and ignored files that exist now.
+// Untracked directories are listed as one entry by git and skipped, so a
+// large node_modules costs nothing.
+func (a *agent) untrackedSnapshot() map[string]fileStamp {
+	if !a.guardsUntracked() {
+		return nil
+	}
+	stamps := map[string]fileStamp{}
+	for _, extra := range [][]string{nil, {"--ignored", "--exclude-standard"}} {
+		args := append([]string{"-C", a.workspaceRoot, "ls-files", "-z", "--others", "--directory"}, extra...)
+		output, err := exec.Command("git", args...).Output()
+		if err != nil {
+			return nil
+		}
+		for _, path := range bytes.Split(output, []byte{0}) {
+			rel := string(path)
+			if rel == "" || strings.HasSuffix(rel, "/") {
+				continue
+			}
+			if info, err := os.Stat(filepath.Join(a.workspaceRoot, rel)); err == nil && info.Mode().IsRegular() {
+				stamps[rel] = fileStamp{modTime: info.ModTime(), size: info.Size()}
+			}
+		}
+	}
+	return stamps
+}
+
+// untrackedModified lists snapshot files that were changed or removed;
+// files the command created are new work, not the user's state.
+func (a *agent) untrackedModified(before map[string]fileStamp) []string {
+	var modified []string
+	for rel, stamp := range before {
+		info, err := os.Stat(filepath.Join(a.workspaceRoot, rel))
+		switch {
+		case err != nil:
+			modified = append(modified, rel+" (deleted)")
+		case !info.ModTime().Equal(stamp.modTime) || info.Size() != stamp.size:
+			modified = append(modified, rel)
+		}
+	}
+	sort.Strings(modified)
+	return modified
+}
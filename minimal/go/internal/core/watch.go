@@ -0,0 +1,137 @@
+package core
+
+import (
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"minimal-go/internal/types"
+)
+
+const (
+	maxWatchedFiles  = 5000
+	maxChangesInNote = 10
+)
+
+type fileStamp struct {
+	modTime time.Time
+	size    int64
+}
+
+// workspaceScan is truncated when the workspace has more than
+// maxWatchedFiles files; which ones were left out depends on walk order, so
+// a file missing from a truncated scan is not known to be new or deleted.
+type workspaceScan struct {
+	stamps    map[string]fileStamp
+	truncated bool
+}
+
+// The workspace is compared between turns rather than watched live: edits
+// made while a turn runs are the agent's own, and everything that happens
+// between turns (an editor, a git checkout, a ! command) is what the model
+// has not seen.
+func (a *agent) snapshotWorkspace() {
+	if !a.config.Context.WatchFiles || a.workspaceRoot == "" {
+		return
+	}
+	a.watched = scanWorkspace(a.workspaceRoot)
+}
+
+func (a *agent) noteExternalChanges() {
+	if !a.config.Context.WatchFiles || a.workspaceRoot == "" {
+		return
+	}
+	if a.watched == nil {
+		a.watched = scanWorkspace(a.workspaceRoot)
+		return
+	}
+	changes := diffWorkspace(a.watched, scanWorkspace(a.workspaceRoot))
+	if len(changes) == 0 {
+		return
+	}
+
+	shown := changes
+	if len(shown) > maxChangesInNote {
+		shown = shown[:maxChangesInNote]
+	}
+	summary := strings.Join(shown, ", ")
+	if len(changes) > len(shown) {
+		summary += fmt.Sprintf(", … %d more", len(changes)-len(shown))
+	}
+	note := fmt.Sprintf("%d file(s) changed outside this session since your last turn: %s", len(changes), summary)
+	a.notice(LevelInfo, "[watch] "+note)
+
+	// The note goes in as a message of its own before the prompt, so the
+	// user's text stays as typed; adjacent user messages are merged when the
+	// request is sent.
+	a.mu.Lock()
+	last := len(a.messages) - 1
+	if last <= 0 || a.messages[last].Role != types.RoleUser {
+		a.mu.Unlock()
+		return
+	}
+	message := types.Message{Role: types.RoleUser, Content: "[Workspace note: " + note + ". Re-read them before relying on earlier contents.]"}
+	a.messages = append(a.messages[:last], message, a.messages[last])
+	current := append([]types.Message{}, a.messages...)
+	a.mu.Unlock()
+	a.messagesChanged(current, last)
+}
+
+func scanWorkspace(root string) *workspaceScan {
+	scan := &workspaceScan{stamps: map[string]fileStamp{}}
+	stamps := scan.stamps
+	filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if entry.IsDir() {
+			name := entry.Name()
+			if path != root && (strings.HasPrefix(name, ".") || workspaceMapSkip[name]) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if len(stamps) >= maxWatchedFiles {
+			scan.truncated = true
+			return filepath.SkipAll
+		}
+		info, err := entry.Info()
+		if err != nil || !info.Mode().IsRegular() {
+			return nil
+		}
+		rel, _ := filepath.Rel(root, path)
+		stamps[filepath.ToSlash(rel)] = fileStamp{modTime: info.ModTime(), size: info.Size()}
+		return nil
+	})
+	return scan
+}
+
+// diffWorkspace lists the files that changed between two scans. When either
+// scan is truncated, only files present in both are compared.
+func diffWorkspace(before *workspaceScan, after *workspaceScan) []string {
+	partial := before.truncated || after.truncated
+	var changes []string
+	for path, stamp := range after.stamps {
+		previous, ok := before.stamps[path]
+		switch {
+		case !ok:
+			if !partial {
+				changes = append(changes, path+" (new)")
+			}
+		case !previous.modTime.Equal(stamp.modTime) || previous.size != stamp.size:
+			changes = append(changes, path)
+		}
+	}
+	if !partial {
+		for path := range before.stamps {
+			if _, ok := after.stamps[path]; !ok {
+				changes = append(changes, path+" (deleted)")
+			}
+		}
+	}
+	sort.Strings(changes)
+	return changes
+}
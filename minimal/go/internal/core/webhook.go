@@ -0,0 +1,177 @@
+package core
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"minimal-go/internal/config"
+	"minimal-go/internal/ui"
+)
+
+const (
+	defaultWebhookTimeout = 5 * time.Minute
+	webhookPollInterval   = 2 * time.Second
+	webhookSignature      = "X-Mini-Go-Signature"
+)
+
+type webhookRequest struct {
+	Type      string    `json:"type"`
+	ID        string    `json:"id"`
+	Command   string    `json:"command,omitempty"`
+	Files     []string  `json:"files,omitempty"`
+	Workspace string    `json:"workspace"`
+	Host      string    `json:"host"`
+	User      string    `json:"user"`
+	ExpiresAt time.Time `json:"expiresAt"`
+	Text      string    `json:"text"`
+}
+
+type webhookReply struct {
+	Decision  string `json:"decision"`
+	Approver  string `json:"approver"`
+	StatusURL string `json:"statusUrl"`
+}
+
+func webhookApproval(cfg config.ApprovalConfig, command string, changes []FileChange) (approvalOutcome, error) {
+	request, path, err := createPendingApproval(command, changes)
+	if err != nil {
+		return approvalOutcome{}, err
+	}
+	defer os.Remove(path)
+
+	timeout := approvalTimeout(cfg)
+	if timeout <= 0 {
+		timeout = defaultWebhookTimeout
+	}
+	host, _ := os.Hostname()
+	payload := webhookRequest{
+		Type:      "approval_request",
+		ID:        request.ID,
+		Command:   request.Command,
+		Files:     request.Files,
+		Workspace: request.Workspace,
+		Host:      host,
+		User:      approverName(),
+		ExpiresAt: request.CreatedAt.Add(timeout),
+	}
+	payload.Text = webhookText(payload)
+
+	client := &http.Client{Timeout: 30 * time.Second}
+	reply, err := postWebhook(client, cfg.Webhook, payload)
+	if err != nil {
+		return approvalOutcome{Reason: "approval webhook failed: " + err.Error(), Approver: "webhook"}, nil
+	}
+	if outcome, done := webhookOutcome(reply); done {
+		return outcome, nil
+	}
+
+	fmt.Println(ui.Warning("⏸ Waiting for approval "+request.ID+" via webhook: ") + ui.Muted("mini-go approvals approve|deny "+request.ID))
+	var remote func() (approvalOutcome, bool)
+	if reply.StatusURL != "" {
+		lastPoll := time.Now()
+		remote = func() (approvalOutcome, bool) {
+			if time.Since(lastPoll) < webhookPollInterval {
+				return approvalOutcome{}, false
+			}
+			lastPoll = time.Now()
+			status, err := pollWebhook(client, cfg.Webhook, reply.StatusURL)
+			if err != nil {
+				return approvalOutcome{}, false
+			}
+			return webhookOutcome(status)
+		}
+	}
+	return awaitPendingApproval(path, timeout, remote), nil
+}
+
+func webhookText(request webhookRequest) string {
+	var text strings.Builder
+	text.WriteString(fmt.Sprintf("mini-go on %s wants approval (%s):\n", request.Host, request.Workspace))
+	if request.Command != "" {
+		text.WriteString("```\n" + request.Command + "\n```\n")
+	}
+	if len(request.Files) > 0 {
+		text.WriteString("Writes: " + strings.Join(request.Files, ", ") + "\n")
+	}
+	text.WriteString(fmt.Sprintf("Reply before %s, or run `mini-go approvals approve %s` / `mini-go approvals deny %s`.", request.ExpiresAt.Format(time.Kitchen), request.ID, request.ID))
+	return text.String()
+}
+
+func postWebhook(client *http.Client, hook config.WebhookConfig, payload webhookRequest) (webhookReply, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return webhookReply{}, err
+	}
+	req, err := http.NewRequest(http.MethodPost, hook.URL, bytes.NewReader(body))
+	if err != nil {
+		return webhookReply{}, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Mini-Go-Approval-Id", payload.ID)
+	if hook.Secret != "" {
+		req.Header.Set(webhookSignature, signWebhook(hook.Secret, body))
+	}
+	return doWebhook(client, hook, req)
+}
+
+func pollWebhook(client *http.Client, hook config.WebhookConfig, statusURL string) (webhookReply, error) {
+	req, err := http.NewRequest(http.MethodGet, statusURL, nil)
+	if err != nil {
+		return webhookReply{}, err
+	}
+	if hook.Secret != "" {
+		req.Header.Set(webhookSignature, signWebhook(hook.Secret, []byte(statusURL)))
+	}
+	return doWebhook(client, hook, req)
+}
+
+func doWebhook(client *http.Client, hook config.WebhookConfig, req *http.Request) (webhookReply, error) {
+	for name, value := range hook.Headers {
+		req.Header.Set(name, value)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return webhookReply{}, err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return webhookReply{}, err
+	}
+	if resp.StatusCode >= 300 {
+		return webhookReply{}, fmt.Errorf("status %d", resp.StatusCode)
+	}
+	// Chat webhooks answer with plain "ok"; only a JSON reply carries a decision.
+	var reply webhookReply
+	_ = json.Unmarshal(data, &reply)
+	return reply, nil
+}
+
+func webhookOutcome(reply webhookReply) (approvalOutcome, bool) {
+	approver := reply.Approver
+	if approver == "" {
+		approver = "webhook"
+	}
+	switch strings.ToLower(strings.TrimSpace(reply.Decision)) {
+	case "approve", "approved", "allow", "yes":
+		return approvalOutcome{Approved: true, Reason: "approved by " + approver, Approver: approver}, true
+	case "deny", "denied", "reject", "rejected", "no":
+		return approvalOutcome{Reason: "denied by " + approver, Approver: approver}, true
+	}
+	return approvalOutcome{}, false
+}
+
+func signWebhook(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,208 @@
+package diff
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	contextLines = 3
+	maxLCSCells  = 4_000_000
+)
+
+type opKind int
+
+const (
+	opEqual opKind = iota
+	opDelete
+	opInsert
+)
+
+type op struct {
+	kind opKind
+	line string
+}
+
+func Unified(path string, before string, after string) string {
+	if before == after {
+		return ""
+	}
+	oldLines := splitLines(before)
+	newLines := splitLines(after)
+	ops := lineOps(oldLines, newLines)
+
+	var builder strings.Builder
+	oldName := "a/" + path
+	if before == "" {
+		oldName = "/dev/null"
+	}
+	fmt.Fprintf(&builder, "--- %s\n+++ b/%s\n", oldName, path)
+
+	for _, hunk := range hunks(ops) {
+		builder.WriteString(hunk)
+	}
+	return builder.String()
+}
+
+func Stats(unified string) (int, int) {
+	added, removed := 0, 0
+	for _, line := range strings.Split(unified, "\n") {
+		switch {
+		case strings.HasPrefix(line, "+++"), strings.HasPrefix(line, "---"):
+		case strings.HasPrefix(line, "+"):
+			added++
+		case strings.HasPrefix(line, "-"):
+			removed++
+		}
+	}
+	return added, removed
+}
+
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+}
+
+func lineOps(oldLines []string, newLines []string) []op {
+	prefix := 0
+	for prefix < len(oldLines) && prefix < len(newLines) && oldLines[prefix] == newLines[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(oldLines)-prefix && suffix < len(newLines)-prefix &&
+		oldLines[len(oldLines)-1-suffix] == newLines[len(newLines)-1-suffix] {
+		suffix++
+	}
+
+	var ops []op
+	for _, line := range oldLines[:prefix] {
+		ops = append(ops, op{opEqual, line})
+	}
+	ops = append(ops, middleOps(oldLines[prefix:len(oldLines)-suffix], newLines[prefix:len(newLines)-suffix])...)
+	for _, line := range oldLines[len(oldLines)-suffix:] {
+		ops = append(ops, op{opEqual, line})
+	}
+	return ops
+}
+
+func middleOps(oldLines []string, newLines []string) []op {
+	var ops []op
+	if len(oldLines)*len(newLines) > maxLCSCells {
+		for _, line := range oldLines {
+			ops = append(ops, op{opDelete, line})
+		}
+		for _, line := range newLines {
+			ops = append(ops, op{opInsert, line})
+		}
+		return ops
+	}
+
+	rows, cols := len(oldLines), len(newLines)
+	lengths := make([][]int, rows+1)
+	for i := range lengths {
+		lengths[i] = make([]int, cols+1)
+	}
+	for i := rows - 1; i >= 0; i-- {
+		for j := cols - 1; j >= 0; j-- {
+			if oldLines[i] == newLines[j] {
+				lengths[i][j] = lengths[i+1][j+1] + 1
+			} else {
+				lengths[i][j] = max(lengths[i+1][j], lengths[i][j+1])
+			}
+		}
+	}
+
+	i, j := 0, 0
+	for i < rows && j < cols {
+		switch {
+		case oldLines[i] == newLines[j]:
+			ops = append(ops, op{opEqual, oldLines[i]})
+			i++
+			j++
+		case lengths[i+1][j] >= lengths[i][j+1]:
+			ops = append(ops, op{opDelete, oldLines[i]})
+			i++
+		default:
+			ops = append(ops, op{opInsert, newLines[j]})
+			j++
+		}
+	}
+	for ; i < rows; i++ {
+		ops = append(ops, op{opDelete, oldLines[i]})
+	}
+	for ; j < cols; j++ {
+		ops = append(ops, op{opInsert, newLines[j]})
+	}
+	return ops
+}
+
+func hunks(ops []op) []string {
+	var result []string
+	index := 0
+	oldLine, newLine := 1, 1
+	for index < len(ops) {
+		if ops[index].kind == opEqual {
+			index++
+			oldLine++
+			newLine++
+			continue
+		}
+
+		start := max(0, index-contextLines)
+		oldStart := oldLine - (index - start)
+		newStart := newLine - (index - start)
+		end := index
+		for end < len(ops) {
+			if ops[end].kind != opEqual {
+				end++
+				continue
+			}
+			run := end
+			for run < len(ops) && ops[run].kind == opEqual {
+				run++
+			}
+			if run == len(ops) || run-end > 2*contextLines {
+				end = min(run, end+contextLines)
+				break
+			}
+			end = run
+		}
+
+		var body strings.Builder
+		oldCount, newCount := 0, 0
+		for _, current := range ops[start:end] {
+			switch current.kind {
+			case opEqual:
+				body.WriteString(" " + current.line + "\n")
+				oldCount++
+				newCount++
+			case opDelete:
+				body.WriteString("-" + current.line + "\n")
+				oldCount++
+			case opInsert:
+				body.WriteString("+" + current.line + "\n")
+				newCount++
+			}
+		}
+		if oldCount == 0 {
+			oldStart--
+		}
+		if newCount == 0 {
+			newStart--
+		}
+		result = append(result, fmt.Sprintf("@@ -%d,%d +%d,%d @@\n", oldStart, oldCount, newStart, newCount)+body.String())
+
+		for _, current := range ops[index:end] {
+			if current.kind != opInsert {
+				oldLine++
+			}
+			if current.kind != opDelete {
+				newLine++
+			}
+		}
+		index = end
+	}
+	return result
+}
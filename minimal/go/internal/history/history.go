@@ -0,0 +1,102 @@
+package history
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"minimal-go/internal/config"
+	"minimal-go/internal/vault"
+)
+
+const maxEntries = 1000
+
+func path(workspaceRoot string) string {
+	sum := sha256.Sum256([]byte(workspaceRoot))
+	return filepath.Join(config.HistoryDir, hex.EncodeToString(sum[:8])+".jsonl")
+}
+
+func Load(workspaceRoot string) ([]string, error) {
+	file, err := os.Open(path(workspaceRoot))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var entries []string
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	for scanner.Scan() {
+		line, err := vault.OpenLine(scanner.Bytes())
+		if errors.Is(err, vault.ErrLocked) {
+			return nil, err
+		}
+		var entry string
+		if err == nil && json.Unmarshal(line, &entry) == nil && entry != "" {
+			entries = append(entries, entry)
+		}
+	}
+	return entries, scanner.Err()
+}
+
+func Append(workspaceRoot string, prompt string) error {
+	prompt = strings.TrimSpace(prompt)
+	if prompt == "" {
+		return nil
+	}
+	entries, err := Load(workspaceRoot)
+	if err != nil {
+		return err
+	}
+	if len(entries) > 0 && entries[len(entries)-1] == prompt {
+		return nil
+	}
+	if err := os.MkdirAll(config.HistoryDir, 0o755); err != nil {
+		return err
+	}
+
+	line, _ := json.Marshal(prompt)
+	if line, err = vault.SealLine(line); err != nil {
+		return err
+	}
+	if len(entries) < maxEntries {
+		file, err := os.OpenFile(path(workspaceRoot), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		_, err = file.Write(append(line, '\n'))
+		return err
+	}
+
+	entries = append(entries[len(entries)-maxEntries+1:], prompt)
+	var b strings.Builder
+	for _, entry := range entries {
+		encoded, _ := json.Marshal(entry)
+		if encoded, err = vault.SealLine(encoded); err != nil {
+			return err
+		}
+		b.Write(encoded)
+		b.WriteByte('\n')
+	}
+	return os.WriteFile(path(workspaceRoot), []byte(b.String()), 0o600)
+}
+
+func Search(entries []string, query string) []int {
+	query = strings.ToLower(query)
+	var matches []int
+	for i := len(entries) - 1; i >= 0; i-- {
+		if query == "" || strings.Contains(strings.ToLower(entries[i]), query) {
+			matches = append(matches, i)
+		}
+	}
+	return matches
+}
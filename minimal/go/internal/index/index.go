@@ -0,0 +1,216 @@
+// Package index keeps a small symbol and term index of a workspace so code
+// can be found by name or topic without a grep per guess. Files are
+// re-indexed only when their size or modification time changes, and the
+// index is cached between sessions.
+package index
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"minimal-go/internal/vault"
+)
+
+const (
+	cacheVersion = 1
+	maxFiles     = 20000
+	maxFileBytes = 512 * 1024
+)
+
+var skipDirs = map[string]bool{
+	"node_modules": true,
+	"vendor":       true,
+	"dist":         true,
+	"build":        true,
+	"target":       true,
+	"__pycache__":  true,
+}
+
+// Generated or lock files that match every query and answer none.
+var skipFiles = map[string]bool{
+	"package-lock.json": true,
+	"yarn.lock":         true,
+	"pnpm-lock.yaml":    true,
+	"go.sum":            true,
+	"Cargo.lock":        true,
+	"poetry.lock":       true,
+	"composer.lock":     true,
+}
+
+type Symbol struct {
+	Name string `json:"name"`
+	Kind string `json:"kind"`
+	Line int    `json:"line"`
+}
+
+type fileEntry struct {
+	ModTime time.Time      `json:"modTime"`
+	Size    int64          `json:"size"`
+	Terms   map[string]int `json:"terms"`
+	Length  int            `json:"length"`
+	Symbols []Symbol       `json:"symbols,omitempty"`
+}
+
+type cacheFile struct {
+	Version int                   `json:"version"`
+	Root    string                `json:"root"`
+	Files   map[string]*fileEntry `json:"files"`
+}
+
+// Stats describes what a Refresh did.
+type Stats struct {
+	Files   int
+	Updated int
+	Removed int
+}
+
+type Index struct {
+	root  string
+	cache string
+	skip  func(rel string) bool
+
+	mu    sync.Mutex
+	files map[string]*fileEntry
+}
+
+// Open returns the index of root, starting from the copy cached in cacheDir
+// when there is one. skip, when set, excludes files by workspace-relative
+// path (slash-separated) before they are read.
+func Open(root string, cacheDir string, skip func(rel string) bool) *Index {
+	sum := sha256.Sum256([]byte(root))
+	x := &Index{
+		root:  root,
+		cache: filepath.Join(cacheDir, "index", hex.EncodeToString(sum[:8])+".json"),
+		skip:  skip,
+		files: map[string]*fileEntry{},
+	}
+	if data, err := vault.ReadFile(x.cache); err == nil {
+		var cached cacheFile
+		if json.Unmarshal(data, &cached) == nil && cached.Version == cacheVersion && cached.Root == root && cached.Files != nil {
+			x.files = cached.Files
+		}
+	}
+	return x
+}
+
+// Refresh walks the workspace and re-indexes the files that changed since
+// the last call, dropping the ones that are gone.
+func (x *Index) Refresh() (Stats, error) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	var stats Stats
+	seen := map[string]bool{}
+	err := filepath.WalkDir(x.root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			if path == x.root {
+				return err
+			}
+			return nil
+		}
+		name := entry.Name()
+		if entry.IsDir() {
+			if path != x.root && (strings.HasPrefix(name, ".") || skipDirs[name]) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if strings.HasPrefix(name, ".") || skipFiles[name] {
+			return nil
+		}
+		if len(seen) >= maxFiles {
+			return filepath.SkipAll
+		}
+		rel, _ := filepath.Rel(x.root, path)
+		rel = filepath.ToSlash(rel)
+		if x.skip != nil && x.skip(rel) {
+			return nil
+		}
+		info, err := entry.Info()
+		if err != nil || !info.Mode().IsRegular() || info.Size() > maxFileBytes {
+			return nil
+		}
+		seen[rel] = true
+		if current, ok := x.files[rel]; ok && current.Size == info.Size() && current.ModTime.Equal(info.ModTime()) {
+			return nil
+		}
+		if indexed := indexFile(path, info); indexed != nil {
+			x.files[rel] = indexed
+		} else {
+			delete(x.files, rel)
+		}
+		stats.Updated++
+		return nil
+	})
+	if err != nil {
+		return stats, err
+	}
+	for rel := range x.files {
+		if !seen[rel] {
+			delete(x.files, rel)
+			stats.Removed++
+		}
+	}
+	stats.Files = len(x.files)
+	if stats.Updated > 0 || stats.Removed > 0 {
+		err = x.save()
+	}
+	return stats, err
+}
+
+// Update re-indexes one workspace-relative file right away, e.g. after the
+// agent wrote it, so the next search does not have to notice the change.
+func (x *Index) Update(rel string) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	rel = filepath.ToSlash(rel)
+	path := filepath.Join(x.root, filepath.FromSlash(rel))
+	info, err := os.Stat(path)
+	if err != nil || !info.Mode().IsRegular() || info.Size() > maxFileBytes || (x.skip != nil && x.skip(rel)) {
+		delete(x.files, rel)
+		return
+	}
+	if indexed := indexFile(path, info); indexed != nil {
+		x.files[rel] = indexed
+	} else {
+		delete(x.files, rel)
+	}
+}
+
+func (x *Index) save() error {
+	data, err := json.Marshal(cacheFile{Version: cacheVersion, Root: x.root, Files: x.files})
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(x.cache), 0o700); err != nil {
+		return err
+	}
+	return vault.WriteFile(x.cache, data, 0o600)
+}
+
+// indexFile returns nil for binary files, which are left out of the index.
+func indexFile(path string, info fs.FileInfo) *fileEntry {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	if bytes.IndexByte(data[:min(len(data), 8000)], 0) >= 0 {
+		return nil
+	}
+	text := string(data)
+	entry := &fileEntry{ModTime: info.ModTime(), Size: info.Size(), Terms: map[string]int{}}
+	for _, term := range Terms(text) {
+		entry.Terms[term]++
+		entry.Length++
+	}
+	entry.Symbols = extractSymbols(filepath.Ext(path), text)
+	return entry
+}
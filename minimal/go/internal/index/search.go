@@ -0,0 +1,217 @@
+package index
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const (
+	defaultLimit   = 8
+	snippetBefore  = 2
+	snippetAfter   = 4
+	maxSnippetLine = 200
+	// BM25 parameters.
+	termSaturation = 1.2
+	lengthWeight   = 0.75
+)
+
+type SearchOptions struct {
+	Limit int
+	// Path limits the search to files under this directory or matching this
+	// glob, relative to the workspace root.
+	Path string
+}
+
+type Result struct {
+	Path    string  `json:"path"`
+	Line    int     `json:"line"`
+	Symbol  string  `json:"symbol,omitempty"`
+	Kind    string  `json:"kind,omitempty"`
+	Score   float64 `json:"score"`
+	Snippet string  `json:"snippet"`
+}
+
+type candidate struct {
+	path   string
+	score  float64
+	symbol *Symbol
+}
+
+// Search ranks the indexed files for query and returns a snippet from each
+// of the best ones, anchored at the best matching symbol definition or, for
+// files without one, at the line that mentions the most query terms.
+func (x *Index) Search(query string, options SearchOptions) []Result {
+	queryTerms := uniqueTerms(query)
+	if len(queryTerms) == 0 {
+		return nil
+	}
+	limit := options.Limit
+	if limit <= 0 {
+		limit = defaultLimit
+	}
+	whole := strings.ToLower(strings.Join(strings.Fields(query), ""))
+
+	x.mu.Lock()
+	var candidates []candidate
+	documentFrequency := map[string]int{}
+	totalLength := 0
+	for _, entry := range x.files {
+		totalLength += entry.Length
+		for _, term := range queryTerms {
+			if entry.Terms[term] > 0 {
+				documentFrequency[term]++
+			}
+		}
+	}
+	averageLength := float64(totalLength) / math.Max(1, float64(len(x.files)))
+	weight := map[string]float64{}
+	for _, term := range queryTerms {
+		df := float64(documentFrequency[term])
+		weight[term] = math.Log(1 + (float64(len(x.files))-df+0.5)/(df+0.5))
+	}
+
+	for rel, entry := range x.files {
+		if !withinPath(rel, options.Path) {
+			continue
+		}
+		score := 0.0
+		lowerPath := strings.ToLower(rel)
+		for _, term := range queryTerms {
+			if tf := float64(entry.Terms[term]); tf > 0 {
+				norm := termSaturation * (1 - lengthWeight + lengthWeight*float64(entry.Length)/averageLength)
+				score += weight[term] * tf * (termSaturation + 1) / (tf + norm)
+			}
+			if strings.Contains(lowerPath, term) {
+				score += weight[term] * 0.5
+			}
+		}
+		if score == 0 {
+			continue
+		}
+		best, bonus := bestSymbol(entry.Symbols, queryTerms, whole, weight)
+		candidates = append(candidates, candidate{path: rel, score: score + bonus, symbol: best})
+	}
+	x.mu.Unlock()
+
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].score != candidates[j].score {
+			return candidates[i].score > candidates[j].score
+		}
+		return candidates[i].path < candidates[j].path
+	})
+	if len(candidates) > limit {
+		candidates = candidates[:limit]
+	}
+
+	results := make([]Result, 0, len(candidates))
+	for _, match := range candidates {
+		data, err := os.ReadFile(filepath.Join(x.root, filepath.FromSlash(match.path)))
+		if err != nil {
+			continue
+		}
+		lines := strings.Split(string(data), "\n")
+		result := Result{Path: match.path, Score: math.Round(match.score*100) / 100}
+		if match.symbol != nil {
+			result.Line, result.Symbol, result.Kind = match.symbol.Line, match.symbol.Name, match.symbol.Kind
+		} else {
+			result.Line = bestLine(lines, queryTerms)
+		}
+		result.Snippet = snippet(lines, result.Line)
+		results = append(results, result)
+	}
+	return results
+}
+
+// bestSymbol picks the definition that matches the query best: an exact
+// name match outweighs any number of shared terms.
+func bestSymbol(symbols []Symbol, queryTerms []string, whole string, weight map[string]float64) (*Symbol, float64) {
+	var best *Symbol
+	bestScore := 0.0
+	for i := range symbols {
+		name := strings.ToLower(symbols[i].Name)
+		score := 0.0
+		if name == whole {
+			score = 10
+		} else {
+			nameTerms := map[string]bool{}
+			for _, term := range Terms(symbols[i].Name) {
+				nameTerms[term] = true
+			}
+			for _, term := range queryTerms {
+				if nameTerms[term] {
+					score += weight[term]
+				}
+			}
+		}
+		if score > bestScore {
+			best, bestScore = &symbols[i], score
+		}
+	}
+	return best, bestScore
+}
+
+func bestLine(lines []string, queryTerms []string) int {
+	best, bestHits := 1, 0
+	for i, line := range lines {
+		lineTerms := map[string]bool{}
+		for _, term := range Terms(line) {
+			lineTerms[term] = true
+		}
+		hits := 0
+		for _, term := range queryTerms {
+			if lineTerms[term] {
+				hits++
+			}
+		}
+		if hits > bestHits {
+			best, bestHits = i+1, hits
+		}
+	}
+	return best
+}
+
+func snippet(lines []string, line int) string {
+	start := max(1, line-snippetBefore)
+	end := min(len(lines), line+snippetAfter)
+	var b strings.Builder
+	for n := start; n <= end; n++ {
+		text := strings.TrimRight(lines[n-1], "\r")
+		if len(text) > maxSnippetLine {
+			text = strings.ToValidUTF8(text[:maxSnippetLine], "") + "…"
+		}
+		fmt.Fprintf(&b, "%d: %s\n", n, text)
+	}
+	return b.String()
+}
+
+func withinPath(rel string, filter string) bool {
+	filter = strings.Trim(filepath.ToSlash(filter), "/")
+	if filter == "" || filter == "." {
+		return true
+	}
+	if strings.ContainsAny(filter, "*?[") {
+		if ok, _ := path.Match(filter, rel); ok {
+			return true
+		}
+		ok, _ := path.Match(filter, path.Base(rel))
+		return ok
+	}
+	return rel == filter || strings.HasPrefix(rel, filter+"/")
+}
+
+func uniqueTerms(query string) []string {
+	seen := map[string]bool{}
+	var terms []string
+	for _, term := range Terms(query) {
+		if !seen[term] {
+			seen[term] = true
+			terms = append(terms, term)
+		}
+	}
+	return terms
+}
@@ -0,0 +1,145 @@
+package index
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+type symbolPattern struct {
+	kind    string
+	pattern *regexp.Regexp
+}
+
+var (
+	goSymbols = []symbolPattern{
+		{"func", regexp.MustCompile(`^func\s+(?:\([^)]*\)\s*)?([A-Za-z_]\w*)`)},
+		{"type", regexp.MustCompile(`^type\s+([A-Za-z_]\w*)`)},
+		{"var", regexp.MustCompile(`^(?:var|const)\s+([A-Za-z_]\w*)`)},
+	}
+	pythonSymbols = []symbolPattern{
+		{"func", regexp.MustCompile(`^\s*(?:async\s+)?def\s+([A-Za-z_]\w*)`)},
+		{"class", regexp.MustCompile(`^\s*class\s+([A-Za-z_]\w*)`)},
+	}
+	scriptSymbols = []symbolPattern{
+		{"func", regexp.MustCompile(`^\s*(?:export\s+)?(?:default\s+)?(?:async\s+)?function\*?\s+([A-Za-z_$][\w$]*)`)},
+		{"class", regexp.MustCompile(`^\s*(?:export\s+)?(?:default\s+)?(?:abstract\s+)?class\s+([A-Za-z_$][\w$]*)`)},
+		{"type", regexp.MustCompile(`^\s*(?:export\s+)?(?:declare\s+)?(?:interface|type|enum)\s+([A-Za-z_$][\w$]*)`)},
+		{"var", regexp.MustCompile(`^\s*(?:export\s+)?(?:const|let|var)\s+([A-Za-z_$][\w$]*)\s*[=:]`)},
+	}
+	rustSymbols = []symbolPattern{
+		{"func", regexp.MustCompile(`^\s*(?:pub(?:\([^)]*\))?\s+)?(?:async\s+)?(?:unsafe\s+)?fn\s+([A-Za-z_]\w*)`)},
+		{"type", regexp.MustCompile(`^\s*(?:pub(?:\([^)]*\))?\s+)?(?:struct|enum|trait|type|mod)\s+([A-Za-z_]\w*)`)},
+	}
+	rubySymbols = []symbolPattern{
+		{"func", regexp.MustCompile(`^\s*def\s+(?:self\.)?([A-Za-z_]\w*[?!]?)`)},
+		{"class", regexp.MustCompile(`^\s*(?:class|module)\s+([A-Z]\w*)`)},
+	}
+	// Java, Kotlin, C#, C++, Swift and similar brace languages.
+	classSymbols = []symbolPattern{
+		{"class", regexp.MustCompile(`^\s*(?:(?:public|private|protected|internal|static|final|abstract|sealed|data|open|partial)\s+)*(?:class|interface|struct|enum|record|object|protocol)\s+([A-Za-z_]\w*)`)},
+		{"func", regexp.MustCompile(`^\s*(?:(?:public|private|protected|internal|static|final|override|suspend|async)\s+)*fun\s+([A-Za-z_]\w*)`)},
+	}
+)
+
+var symbolsByExt = map[string][]symbolPattern{
+	".go":    goSymbols,
+	".py":    pythonSymbols,
+	".js":    scriptSymbols,
+	".jsx":   scriptSymbols,
+	".mjs":   scriptSymbols,
+	".cjs":   scriptSymbols,
+	".ts":    scriptSymbols,
+	".tsx":   scriptSymbols,
+	".rs":    rustSymbols,
+	".rb":    rubySymbols,
+	".java":  classSymbols,
+	".kt":    classSymbols,
+	".cs":    classSymbols,
+	".cpp":   classSymbols,
+	".cc":    classSymbols,
+	".hpp":   classSymbols,
+	".h":     classSymbols,
+	".swift": classSymbols,
+	".scala": classSymbols,
+}
+
+func extractSymbols(ext string, text string) []Symbol {
+	patterns := symbolsByExt[strings.ToLower(ext)]
+	if len(patterns) == 0 {
+		return nil
+	}
+	var symbols []Symbol
+	for i, line := range strings.Split(text, "\n") {
+		for _, candidate := range patterns {
+			if match := candidate.pattern.FindStringSubmatch(line); match != nil {
+				symbols = append(symbols, Symbol{Name: match[1], Kind: candidate.kind, Line: i + 1})
+				break
+			}
+		}
+	}
+	return symbols
+}
+
+// Terms splits text into lower-case search terms: every identifier as a
+// whole, plus its camelCase and snake_case parts, so "parseToolInput" is
+// found by "parse tool input" and by its full name.
+func Terms(text string) []string {
+	var terms []string
+	start := -1
+	flush := func(end int) {
+		if start < 0 {
+			return
+		}
+		word := text[start:end]
+		start = -1
+		if len(word) < 2 || len(word) > 64 {
+			return
+		}
+		terms = append(terms, strings.ToLower(word))
+		parts := identifierParts(word)
+		if len(parts) > 1 {
+			for _, part := range parts {
+				if len(part) >= 2 {
+					terms = append(terms, strings.ToLower(part))
+				}
+			}
+		}
+	}
+	for i, r := range text {
+		if r == '_' || r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			if start < 0 {
+				start = i
+			}
+			continue
+		}
+		flush(i)
+	}
+	flush(len(text))
+	return terms
+}
+
+func identifierParts(word string) []string {
+	var parts []string
+	runes := []rune(word)
+	begin := 0
+	for i := 1; i <= len(runes); i++ {
+		if i == len(runes) || runes[i] == '_' {
+			if i > begin {
+				parts = append(parts, string(runes[begin:i]))
+			}
+			begin = i + 1
+			continue
+		}
+		if i == begin {
+			continue
+		}
+		prev, cur := runes[i-1], runes[i]
+		nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+		if unicode.IsUpper(cur) && (unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextLower)) {
+			parts = append(parts, string(runes[begin:i]))
+			begin = i
+		}
+	}
+	return parts
+}
@@ -0,0 +1,90 @@
+package memory
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"minimal-go/internal/config"
+	"minimal-go/internal/vault"
+)
+
+func Load() (string, error) {
+	data, err := vault.ReadFile(config.MemoryPath)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return "", nil
+		}
+		return "", err
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+func Entries() ([]string, error) {
+	content, err := Load()
+	if err != nil || content == "" {
+		return nil, err
+	}
+	var entries []string
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(line), "- "))
+		if line != "" {
+			entries = append(entries, line)
+		}
+	}
+	return entries, nil
+}
+
+func Append(fact string) error {
+	fact = strings.Join(strings.Fields(fact), " ")
+	if fact == "" {
+		return errors.New("fact is empty")
+	}
+	if vault.Enabled() {
+		entries, err := Entries()
+		if err != nil {
+			return err
+		}
+		return write(append(entries, fact))
+	}
+	file, err := os.OpenFile(config.MemoryPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	_, err = fmt.Fprintf(file, "- %s\n", fact)
+	return err
+}
+
+func Forget(index int) (string, error) {
+	entries, err := Entries()
+	if err != nil {
+		return "", err
+	}
+	if index < 1 || index > len(entries) {
+		return "", fmt.Errorf("no memory entry #%d", index)
+	}
+	removed := entries[index-1]
+	entries = append(entries[:index-1], entries[index:]...)
+	return removed, write(entries)
+}
+
+func Clear() error {
+	return write(nil)
+}
+
+func write(entries []string) error {
+	var b strings.Builder
+	for _, entry := range entries {
+		b.WriteString("- " + entry + "\n")
+	}
+	return vault.WriteFile(config.MemoryPath, []byte(b.String()), 0o644)
+}
+
+func Section(memory string) string {
+	if memory == "" {
+		return ""
+	}
+	return "## Memory\n\nDurable facts saved from earlier sessions:\n\n" + memory
+}
@@ -2,13 +2,17 @@ package policy
 
 import (
 	"context"
+	"fmt"
+	"os"
 	"os/exec"
 	"regexp"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"minimal-go/internal/config"
+	"minimal-go/internal/shell"
 )
 
 type PolicyResult string
@@ -17,47 +21,84 @@ const (
 	PolicyAuto PolicyResult = "auto"
 	PolicyAsk  PolicyResult = "ask"
 	PolicyDeny PolicyResult = "deny"
+	// PolicyConfirm runs only after the user types ConfirmPhrase. Built-in
+	// rules land here instead of PolicyDeny when their class is listed in
+	// policy.confirmDangerous.
+	PolicyConfirm PolicyResult = "confirm"
 )
 
+const ConfirmPhrase = "yes-run-it"
+
+type DenyClass string
+
+const (
+	DenyDestructive      DenyClass = "destructive"
+	DenyCloudMutation    DenyClass = "cloud_mutation"
+	DenyRemoteExec       DenyClass = "remote_exec"
+	DenyRecursiveListing DenyClass = "recursive_listing"
+	DenyInPlaceEdit      DenyClass = "in_place_edit"
+	DenySensitiveFile    DenyClass = "sensitive_file"
+	DenyUserPattern      DenyClass = "user_pattern"
+	DenyDefaultAction    DenyClass = "default_deny"
+	DenyOutsideWorkspace DenyClass = "outside_workspace"
+	DenyReadOnly         DenyClass = "read_only"
+	DenyEnvironment      DenyClass = "environment"
+)
+
+type Decision struct {
+	Result       PolicyResult
+	Class        DenyClass
+	Reason       string
+	Rule         string
+	Match        string
+	Suggestion   string
+	OutsidePaths []string
+}
+
+type denyRule struct {
+	pattern    *regexp.Regexp
+	class      DenyClass
+	reason     string
+	suggestion string
+}
+
 const bashTimeout = 30 * time.Second
 
+var denySuggestions = map[DenyClass]string{
+	DenyDestructive:      "Do not retry. Explain what should be removed and let the user run it with !<command>.",
+	DenyCloudMutation:    "Do not retry. Describe the cloud change; destructive cloud commands must be run by the user.",
+	DenyRemoteExec:       "Download the script to a file (curl -o script.sh URL), show it with cat, and ask before running it.",
+	DenyRecursiveListing: "Use `find <dir> -maxdepth 2` or `tree -L 2` (auto-approved) instead of recursive ls.",
+	DenyInPlaceEdit:      "Show the intended change with `sed -n` or a diff and ask the user to apply it.",
+	DenySensitiveFile:    "Secrets, lock files and vendored dependencies are off limits; inspect manifests or templates instead.",
+	DenyUserPattern:      "This command matches a project deny rule; choose a different approach or ask the user.",
+	DenyDefaultAction:    "Only auto-approved commands may run (e.g. ls, cat, rg, find, git status, git diff).",
+	DenyOutsideWorkspace: "Use paths inside the workspace, or ask the user to add the directory to policy.allowedPaths.",
+	DenyEnvironment:      "Drop the variable from env; set only plain configuration values.",
+	DenyReadOnly:         "Read-only mode is on. Inspect with read_file, rg or git log and describe the change instead of making it; the user can leave the mode with /readonly off.",
+}
+
 var (
-	dangerousFilePatterns = []*regexp.Regexp{
-		regexp.MustCompile(`\.env$`),
-		regexp.MustCompile(`\.env\.`),
-		regexp.MustCompile(`\.dev\.vars$`),
-		regexp.MustCompile(`credentials`),
-		regexp.MustCompile(`secret`),
-		regexp.MustCompile(`\.pem$`),
-		regexp.MustCompile(`\.key$`),
-		regexp.MustCompile(`id_rsa`),
-		regexp.MustCompile(`id_ed25519`),
-		regexp.MustCompile(`package-lock\.json$`),
-		regexp.MustCompile(`yarn\.lock$`),
-		regexp.MustCompile(`pnpm-lock\.yaml$`),
-		regexp.MustCompile(`\.DS_Store$`),
-		regexp.MustCompile(`node_modules`),
-	}
-	builtinDenyPatterns = []*regexp.Regexp{
-		regexp.MustCompile(`rm\s+(-[rf]+\s+)*\/`),
-		regexp.MustCompile(`rm\s+-rf?\s+\*`),
-		regexp.MustCompile(`rm\s+-rf?\s+\.\*`),
-		regexp.MustCompile(`mkfs`),
-		regexp.MustCompile(`dd\s+if=.*of=\/dev`),
-		regexp.MustCompile(`>\s*\/dev\/sd`),
-		regexp.MustCompile(`gcloud\s+.*delete`),
-		regexp.MustCompile(`gcloud\s+.*destroy`),
-		regexp.MustCompile(`aws\s+.*delete`),
-		regexp.MustCompile(`aws\s+.*terminate`),
-		regexp.MustCompile(`kubectl\s+delete`),
-		regexp.MustCompile(`:\(\)\s*\{.*\|.*&.*\}`),
-		regexp.MustCompile(`chmod\s+-R\s+777\s+\/`),
-		regexp.MustCompile(`chown\s+-R.*\/`),
-		regexp.MustCompile(`curl.*\|\s*(ba)?sh`),
-		regexp.MustCompile(`wget.*\|\s*(ba)?sh`),
-		regexp.MustCompile(`ls\s+-[^\s]*R`),
-		regexp.MustCompile(`ls\s+-R`),
-		regexp.MustCompile(`sed\s.*-i`),
+	builtinDenyRules = []denyRule{
+		{regexp.MustCompile(`rm\s+(-[rf]+\s+)*\/`), DenyDestructive, "deletes paths from the filesystem root", ""},
+		{regexp.MustCompile(`rm\s+-rf?\s+\*`), DenyDestructive, "recursively deletes everything in the directory", ""},
+		{regexp.MustCompile(`rm\s+-rf?\s+\.\*`), DenyDestructive, "recursively deletes hidden files", ""},
+		{regexp.MustCompile(`mkfs`), DenyDestructive, "formats a filesystem", ""},
+		{regexp.MustCompile(`dd\s+if=.*of=\/dev`), DenyDestructive, "writes raw data to a device", ""},
+		{regexp.MustCompile(`>\s*\/dev\/sd`), DenyDestructive, "writes raw data to a disk", ""},
+		{regexp.MustCompile(`gcloud\s+.*delete`), DenyCloudMutation, "deletes Google Cloud resources", ""},
+		{regexp.MustCompile(`gcloud\s+.*destroy`), DenyCloudMutation, "destroys Google Cloud resources", ""},
+		{regexp.MustCompile(`aws\s+.*delete`), DenyCloudMutation, "deletes AWS resources", ""},
+		{regexp.MustCompile(`aws\s+.*terminate`), DenyCloudMutation, "terminates AWS resources", ""},
+		{regexp.MustCompile(`kubectl\s+delete`), DenyCloudMutation, "deletes Kubernetes resources", "Use `kubectl get` or `kubectl describe` to inspect; leave deletions to the user."},
+		{regexp.MustCompile(`:\(\)\s*\{.*\|.*&.*\}`), DenyDestructive, "is a fork bomb", ""},
+		{regexp.MustCompile(`chmod\s+-R\s+777\s+\/`), DenyDestructive, "makes the whole filesystem world-writable", ""},
+		{regexp.MustCompile(`chown\s+-R.*\/`), DenyDestructive, "recursively changes ownership of absolute paths", "Change ownership of specific workspace files only, or ask the user."},
+		{regexp.MustCompile(`curl.*\|\s*(ba)?sh`), DenyRemoteExec, "pipes a downloaded script straight into a shell", ""},
+		{regexp.MustCompile(`wget.*\|\s*(ba)?sh`), DenyRemoteExec, "pipes a downloaded script straight into a shell", ""},
+		{regexp.MustCompile(`ls\s+-[^\s]*R`), DenyRecursiveListing, "recursive ls floods the context", ""},
+		{regexp.MustCompile(`ls\s+-R`), DenyRecursiveListing, "recursive ls floods the context", ""},
+		{regexp.MustCompile(`sed\s.*-i`), DenyInPlaceEdit, "edits files in place without review", "Use edit_file (or write_file) so the user sees the diff before it is applied."},
 	}
 	builtinAutoCommands = []string{
 		"ls",
@@ -87,55 +128,117 @@ var (
 		"git log",
 		"git branch",
 	}
-	forceAskPattern = regexp.MustCompile("[|;&`$()]")
+	forceAskPattern = regexp.MustCompile("[|;&`$()>\n\r]")
 )
 
 func CheckPolicy(command string, cfg config.Config) PolicyResult {
+	return EvaluatePolicy(command, cfg).Result
+}
+
+// EvaluatePolicy decides without knowing where the command runs, so
+// arguments bash would expand as globs make it ask; EvaluateInWorkspace
+// expands them.
+func EvaluatePolicy(command string, cfg config.Config) Decision {
+	return evaluatePolicy(command, cfg, "", "")
+}
+
+func evaluatePolicy(command string, cfg config.Config, workspaceRoot string, dir string) Decision {
 	cmd := strings.TrimSpace(command)
-	denyPatterns := append([]*regexp.Regexp{}, builtinDenyPatterns...)
+	denyRules := append([]denyRule{}, builtinDenyRules...)
 	for _, pattern := range cfg.Policy.DenyPatterns {
 		if pattern == "" {
 			continue
 		}
 		if re, err := regexp.Compile(pattern); err == nil {
-			denyPatterns = append(denyPatterns, re)
+			denyRules = append(denyRules, denyRule{re, DenyUserPattern, fmt.Sprintf("matches policy.denyPatterns entry %q", pattern), ""})
 		}
 	}
 
-	autoCommands := append([]string{}, builtinAutoCommands...)
+	// In read-only mode only the project's own autoCommands skip the
+	// prompt, and only once ReadOnlyCommand has vetted their arguments.
+	var autoCommands []string
+	if !cfg.Policy.ReadOnly {
+		autoCommands = append(autoCommands, builtinAutoCommands...)
+	}
 	autoCommands = append(autoCommands, cfg.Policy.AutoCommands...)
 
-	for _, pattern := range denyPatterns {
-		if pattern.MatchString(cmd) {
-			return PolicyDeny
+	// A rule downgraded to confirmation only applies once no other rule
+	// denies the command outright.
+	var confirm *Decision
+	for i, rule := range denyRules {
+		match := rule.pattern.FindString(cmd)
+		if match == "" {
+			continue
 		}
+		if i < len(builtinDenyRules) && confirmable(rule.class, cfg) {
+			if confirm == nil {
+				decision := rule.decision(match)
+				decision.Result = PolicyConfirm
+				confirm = &decision
+			}
+			continue
+		}
+		return rule.decision(match)
 	}
 
 	fields := strings.Fields(cmd)
-	args := ""
-	if len(fields) > 1 {
-		args = strings.Join(fields[1:], " ")
-	}
-	for _, pattern := range dangerousFilePatterns {
-		if pattern.MatchString(args) {
-			return PolicyDeny
-		}
+	decision, denied, unresolved := commandFiles(cmd, cfg, workspaceRoot, dir)
+	if denied {
+		return decision
 	}
 
-	if forceAskPattern.MatchString(cmd) {
-		return PolicyAsk
+	if cfg.Policy.ReadOnly && !ReadOnlyCommand(cmd) {
+		decision := deny(DenyReadOnly)
+		decision.Reason = "read-only mode allows only commands that inspect files"
+		return decision
+	}
+	if confirm != nil {
+		return *confirm
+	}
+	if forceAskPattern.MatchString(cmd) || unresolved {
+		return Decision{Result: PolicyAsk}
 	}
 
 	for _, autoCmd := range autoCommands {
-		if cmd == autoCmd || strings.HasPrefix(cmd, autoCmd+" ") {
-			return PolicyAuto
+		if (cmd == autoCmd || strings.HasPrefix(cmd, autoCmd+" ")) && argsAllowed(strings.Join(fields, " ")) {
+			return Decision{Result: PolicyAuto}
 		}
 	}
 
 	if cfg.Policy.DefaultAction == "deny" {
-		return PolicyDeny
+		decision := deny(DenyDefaultAction)
+		decision.Reason = "policy.defaultAction is deny and the command is not auto-approved"
+		return decision
+	}
+	return Decision{Result: PolicyAsk}
+}
+
+func confirmable(class DenyClass, cfg config.Config) bool {
+	for _, name := range cfg.Policy.ConfirmDangerous {
+		if name == string(class) && config.ConfirmableDenyClass(name) {
+			return true
+		}
 	}
-	return PolicyAsk
+	return false
+}
+
+func SuggestionFor(class DenyClass) string {
+	return denySuggestions[class]
+}
+
+func deny(class DenyClass) Decision {
+	return Decision{Result: PolicyDeny, Class: class, Suggestion: denySuggestions[class]}
+}
+
+func (r denyRule) decision(match string) Decision {
+	decision := deny(r.class)
+	decision.Reason = r.reason
+	decision.Rule = r.pattern.String()
+	decision.Match = match
+	if r.suggestion != "" {
+		decision.Suggestion = r.suggestion
+	}
+	return decision
 }
 
 type BashResult struct {
@@ -158,19 +261,82 @@ func FormatCommandResult(command string, result BashResult) string {
 	return content
 }
 
+type BashOptions struct {
+	Dir     string
+	Env     map[string]string
+	Timeout time.Duration
+	Stdin   string
+	// Scrub drops credentials from the inherited environment; commands the
+	// model wrote or triggered set it.
+	Scrub bool
+}
+
+var running = struct {
+	sync.Mutex
+	processes map[*exec.Cmd]bool
+}{processes: map[*exec.Cmd]bool{}}
+
+func trackProcess(cmd *exec.Cmd) func() {
+	running.Lock()
+	running.processes[cmd] = true
+	running.Unlock()
+	return func() {
+		running.Lock()
+		delete(running.processes, cmd)
+		running.Unlock()
+	}
+}
+
+func KillRunning() {
+	running.Lock()
+	defer running.Unlock()
+	var processes []*os.Process
+	for cmd := range running.processes {
+		if cmd.Process != nil {
+			processes = append(processes, cmd.Process)
+		}
+	}
+	killProcesses(processes)
+}
+
 func RunBash(command string, workspaceRoot string) BashResult {
-	ctx, cancel := context.WithTimeout(context.Background(), bashTimeout)
+	return RunBashWithOptions(command, BashOptions{Dir: workspaceRoot})
+}
+
+func RunBashWithOptions(command string, options BashOptions) BashResult {
+	timeout := options.Timeout
+	if timeout == 0 {
+		timeout = bashTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
 	defer cancel()
 
 	cmd := exec.CommandContext(ctx, "bash", "-c", command)
-	cmd.Dir = workspaceRoot
+	cmd.Dir = options.Dir
+	if len(options.Env) > 0 || options.Scrub {
+		cmd.Env = os.Environ()
+		if options.Scrub {
+			cmd.Env = shell.ScrubbedEnv(cmd.Env)
+		}
+		for key, value := range options.Env {
+			cmd.Env = append(cmd.Env, key+"="+value)
+		}
+	}
+	if options.Stdin != "" {
+		cmd.Stdin = strings.NewReader(options.Stdin)
+	}
 	var stdout, stderr strings.Builder
 	cmd.Stdout = &stdout
 	cmd.Stderr = &stderr
 
-	err := cmd.Run()
+	err := cmd.Start()
+	if err == nil {
+		untrack := trackProcess(cmd)
+		err = cmd.Wait()
+		untrack()
+	}
 	if ctx.Err() == context.DeadlineExceeded {
-		return BashResult{Stdout: stdout.String(), Stderr: "Command timed out (30s)", Code: 124}
+		return BashResult{Stdout: stdout.String(), Stderr: fmt.Sprintf("Command timed out (%s)", timeout), Code: 124}
 	}
 
 	exitCode := 0
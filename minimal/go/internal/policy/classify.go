@@ -0,0 +1,155 @@
+package policy
+
+import (
+	"regexp"
+	"strings"
+
+	"minimal-go/internal/config"
+)
+
+type Risk int
+
+const (
+	RiskLow Risk = iota
+	RiskMedium
+	RiskHigh
+)
+
+var riskNames = []string{"low", "medium", "high"}
+
+func (r Risk) String() string {
+	if r < RiskLow || r > RiskHigh {
+		return "unknown"
+	}
+	return riskNames[r]
+}
+
+func ParseRisk(name string) (Risk, bool) {
+	for i, known := range riskNames {
+		if strings.EqualFold(name, known) {
+			return Risk(i), true
+		}
+	}
+	return RiskLow, false
+}
+
+// Finding is one thing a classifier noticed about a command, phrased for
+// the approval prompt ("modifies git history").
+type Finding struct {
+	Label string
+	Risk  Risk
+}
+
+// A Classifier labels what a command would do. Classifiers only inform the
+// user before approval; they never allow or deny anything.
+type Classifier interface {
+	Classify(command string) []Finding
+}
+
+type ClassifierFunc func(command string) []Finding
+
+func (f ClassifierFunc) Classify(command string) []Finding {
+	return f(command)
+}
+
+type riskRule struct {
+	pattern *regexp.Regexp
+	label   string
+	risk    Risk
+}
+
+// PatternClassifier reports the label of every rule whose pattern matches.
+type PatternClassifier []riskRule
+
+func (c PatternClassifier) Classify(command string) []Finding {
+	var findings []Finding
+	for _, rule := range c {
+		if rule.pattern.MatchString(command) {
+			findings = append(findings, Finding{Label: rule.label, Risk: rule.risk})
+		}
+	}
+	return findings
+}
+
+var builtinRiskRules = PatternClassifier{
+	{regexp.MustCompile(`\bgit\s+(?:.*\s)?(?:push\s+(?:.*\s)?(?:--force|-f\b|--force-with-lease|\+)|reset\s+--hard|rebase\b|commit\s+(?:.*\s)?--amend|filter-branch|filter-repo|reflog\s+expire|update-ref\s+-d)`), "modifies git history", RiskHigh},
+	{regexp.MustCompile(`\bgit\s+(?:.*\s)?(?:checkout\s+(?:--\s|\.(?:\s|$))|restore\b|clean\s+-[a-z]*f|stash\s+(?:drop|clear)|branch\s+-D)`), "discards uncommitted work or branches", RiskHigh},
+	{regexp.MustCompile(`\bgit\s+(?:.*\s)?push\b`), "publishes commits to a remote", RiskMedium},
+	{regexp.MustCompile(`\b(?:npm|pnpm|yarn|cargo)\s+publish\b|\btwine\s+upload\b|\bdocker\s+push\b|\bgh\s+release\s+create\b`), "publishes a package or image", RiskHigh},
+	{regexp.MustCompile(`\b(?:sudo|doas)\b|\bsu\s`), "runs with elevated privileges", RiskHigh},
+	{regexp.MustCompile(`\b(?:curl|wget)\b[^|]*\|\s*(?:sudo\s+)?(?:ba|z)?sh\b|\beval\s+"?\$\((?:curl|wget)`), "runs code downloaded from the network", RiskHigh},
+	{regexp.MustCompile(`\b(?:DROP|TRUNCATE)\s+(?:TABLE|DATABASE|SCHEMA)\b`), "drops database objects", RiskHigh},
+	{regexp.MustCompile(`\brm\s+(?:-[a-zA-Z]*[rR][a-zA-Z]*|--recursive)\b`), "deletes files recursively", RiskHigh},
+	{regexp.MustCompile(`\brm\s|\bunlink\s|\bshred\s|\bfind\b.*\s-delete\b`), "deletes files", RiskMedium},
+	{regexp.MustCompile(`\b(?:curl|wget|nc|ncat|ssh|scp|sftp|rsync|ftp|telnet)\b|\bgit\s+(?:.*\s)?(?:clone|fetch|pull|push|ls-remote)\b`), "network egress", RiskMedium},
+	{regexp.MustCompile(`\b(?:npm|pnpm|yarn|bun)\s+(?:install|i|add|ci)\b|\bpip3?\s+install\b|\bgo\s+(?:get|install)\b|\bcargo\s+(?:install|add)\b|\b(?:apt|apt-get|brew|dnf|yum|apk|gem)\s+(?:install|add)\b`), "installs packages", RiskMedium},
+	{regexp.MustCompile(`\b(?:chmod|chown|chgrp)\b`), "changes file permissions", RiskMedium},
+	{regexp.MustCompile(`\b(?:kill|pkill|killall)\b`), "stops processes", RiskMedium},
+	{regexp.MustCompile(`\b(?:systemctl|launchctl|service)\s`), "changes system services", RiskMedium},
+	{regexp.MustCompile(`\bdocker\s+(?:rm|rmi|system\s+prune|volume\s+rm|compose\s+down)\b`), "removes containers, images or volumes", RiskMedium},
+	{regexp.MustCompile(`\bcrontab\b|>>?\s*~?/?[\w./]*\.(?:bashrc|zshrc|profile|bash_profile)\b`), "changes the shell or scheduled jobs", RiskMedium},
+	{regexp.MustCompile(`\b(?:env|printenv)\b|\$\{?\w*(?:TOKEN|SECRET|PASSWORD|API_KEY)\w*`), "reads secrets from the environment", RiskMedium},
+	{regexp.MustCompile(`\b(?:psql|mysql|sqlite3|mongosh|redis-cli)\b`), "talks to a database", RiskMedium},
+}
+
+// RiskClassifier returns the built-in rules followed by the project's
+// policy.riskRules. Rules that do not compile are skipped here; config
+// validation reports them.
+func RiskClassifier(cfg config.Config) PatternClassifier {
+	rules := append(PatternClassifier{}, builtinRiskRules...)
+	for _, custom := range cfg.Policy.RiskRules {
+		pattern, err := regexp.Compile(custom.Pattern)
+		if err != nil || strings.TrimSpace(custom.Label) == "" {
+			continue
+		}
+		risk, ok := ParseRisk(custom.Risk)
+		if !ok {
+			risk = RiskMedium
+		}
+		rules = append(rules, riskRule{pattern: pattern, label: custom.Label, risk: risk})
+	}
+	return rules
+}
+
+// Assessment merges the findings of a classifier chain.
+type Assessment struct {
+	Risk   Risk
+	Labels []string
+}
+
+func (a Assessment) Empty() bool {
+	return len(a.Labels) == 0
+}
+
+// String reads like "high: modifies git history, network egress".
+func (a Assessment) String() string {
+	if a.Empty() {
+		return ""
+	}
+	return a.Risk.String() + ": " + strings.Join(a.Labels, ", ")
+}
+
+// Classify runs every classifier in order and merges their findings. The
+// overall risk is the highest one found; a label reported twice is kept
+// once, in the position it first appeared.
+func Classify(command string, chain ...Classifier) Assessment {
+	var assessment Assessment
+	seen := map[string]bool{}
+	for _, classifier := range chain {
+		if classifier == nil {
+			continue
+		}
+		for _, finding := range classifier.Classify(command) {
+			label := strings.TrimSpace(finding.Label)
+			if label == "" || seen[strings.ToLower(label)] {
+				continue
+			}
+			seen[strings.ToLower(label)] = true
+			assessment.Labels = append(assessment.Labels, label)
+			if finding.Risk > assessment.Risk {
+				assessment.Risk = finding.Risk
+			}
+		}
+	}
+	return assessment
+}
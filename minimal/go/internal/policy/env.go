@@ -0,0 +1,55 @@
+package policy
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Variables that make bash, the dynamic loader, git or a language runtime
+// run code of their own before or instead of the command: setting any of
+// them turns an approved command into a different one.
+var (
+	deniedEnvNames = map[string]bool{
+		"BASH_ENV": true, "ENV": true, "PROMPT_COMMAND": true, "SHELLOPTS": true, "BASHOPTS": true,
+		"PS4": true, "IFS": true, "CDPATH": true, "GLOBIGNORE": true, "HOME": true, "PATH": true,
+		"PYTHONSTARTUP": true, "PYTHONPATH": true, "PYTHONHOME": true, "NODE_OPTIONS": true, "NODE_PATH": true,
+		"PERL5OPT": true, "PERL5LIB": true, "RUBYOPT": true, "RUBYLIB": true, "GOFLAGS": true,
+		"JAVA_TOOL_OPTIONS": true, "_JAVA_OPTIONS": true, "LESSOPEN": true, "LESSCLOSE": true,
+		"PAGER": true, "EDITOR": true, "VISUAL": true, "SSH_ASKPASS": true, "SUDO_ASKPASS": true,
+	}
+	deniedEnvPrefixes = []string{"BASH_FUNC_", "LD_", "DYLD_", "GIT_"}
+)
+
+// CheckEnv rejects environment variables a tool call may not set.
+func CheckEnv(env map[string]string) error {
+	for name := range env {
+		upper := strings.ToUpper(name)
+		if deniedEnvNames[upper] {
+			return fmt.Errorf("env.%s is not allowed: it changes what the command runs", name)
+		}
+		for _, prefix := range deniedEnvPrefixes {
+			if strings.HasPrefix(upper, prefix) {
+				return fmt.Errorf("env.%s is not allowed: %s* variables change what the command runs", name, prefix)
+			}
+		}
+	}
+	return nil
+}
+
+// EvaluateEnv asks before a command that would otherwise run on its own when
+// the call also sets environment variables: the policy vetted the command,
+// not the environment it runs in.
+func EvaluateEnv(decision Decision, env map[string]string) Decision {
+	if len(env) == 0 {
+		return decision
+	}
+	if err := CheckEnv(env); err != nil {
+		decision = deny(DenyEnvironment)
+		decision.Reason = err.Error()
+		return decision
+	}
+	if decision.Result == PolicyAuto {
+		decision.Result = PolicyAsk
+	}
+	return decision
+}
@@ -0,0 +1,243 @@
+package policy
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"minimal-go/internal/config"
+)
+
+// fileRule denies paths matching any of its globs unless the file name
+// matches one of except. Globs use the policy.files syntax (see matchGlob).
+type fileRule struct {
+	globs      []string
+	except     []string
+	reason     string
+	suggestion string
+}
+
+// Source and documentation files about secrets (secrets.go, secret-handling.md)
+// are code, not secrets.
+var sourceFiles = []string{
+	"*.go", "*.py", "*.js", "*.jsx", "*.mjs", "*.cjs", "*.ts", "*.tsx", "*.rb", "*.rs", "*.java", "*.kt",
+	"*.cs", "*.c", "*.h", "*.cpp", "*.hpp", "*.swift", "*.scala", "*.php", "*.md", "*.rst",
+}
+
+var builtinFileRules = []fileRule{
+	{
+		globs:      []string{".env", ".env.*", "*.env"},
+		except:     []string{"*.example", "*.sample", "*.template", ".env.dist", ".env.defaults"},
+		reason:     "reads or writes an environment file that may hold secrets",
+		suggestion: "Read .env.example (or the config loader) to learn which variables exist; ask the user for values.",
+	},
+	{
+		globs:      []string{".dev.vars", ".dev.vars.*"},
+		except:     []string{".dev.vars.example"},
+		reason:     "reads or writes a local secrets file",
+		suggestion: "Reference the variable names from code or wrangler config instead of reading their values.",
+	},
+	{
+		globs:      []string{"credentials", "credentials.*", "*.credentials", ".git-credentials", ".netrc"},
+		except:     sourceFiles,
+		reason:     "touches a credentials file",
+		suggestion: "Refer to credentials by name and let the user provide them; do not read them.",
+	},
+	{
+		globs:      []string{"secret", "secrets", ".secrets", "secret.*", "secrets.*", "*.secret", "*.secrets", "*-secret.*", "*_secret.*", "*-secrets.*", "*_secrets.*", "client_secret*"},
+		except:     sourceFiles,
+		reason:     "touches a file that looks like it holds secrets",
+		suggestion: "Refer to secrets by name and let the user provide them; do not read them.",
+	},
+	{
+		globs:      []string{"*.pem", "*.p12", "*.pfx"},
+		reason:     "touches a certificate or private key",
+		suggestion: "Do not read key material; ask the user for the certificate details you need.",
+	},
+	{
+		globs:      []string{"*.key"},
+		reason:     "touches a private key",
+		suggestion: "Do not read key material; ask the user for the details you need.",
+	},
+	{
+		globs:      []string{"id_rsa", "id_rsa.*", "id_ed25519", "id_ed25519.*", "id_ecdsa", "id_ecdsa.*", "id_dsa", "id_dsa.*"},
+		except:     []string{"*.pub"},
+		reason:     "touches an SSH private key",
+		suggestion: "SSH keys are off limits; ask the user to run any command that needs them.",
+	},
+	{
+		globs:      []string{"package-lock.json"},
+		reason:     "reads or edits a generated lock file",
+		suggestion: "Inspect package.json for dependency versions, or run `npm ls <package>`.",
+	},
+	{
+		globs:      []string{"yarn.lock"},
+		reason:     "reads or edits a generated lock file",
+		suggestion: "Inspect package.json for dependency versions, or run `yarn why <package>`.",
+	},
+	{
+		globs:      []string{"pnpm-lock.yaml"},
+		reason:     "reads or edits a generated lock file",
+		suggestion: "Inspect package.json for dependency versions, or run `pnpm why <package>`.",
+	},
+	{
+		globs:      []string{".DS_Store"},
+		reason:     "touches macOS metadata",
+		suggestion: "Ignore .DS_Store files.",
+	},
+	{
+		globs:      []string{"node_modules"},
+		reason:     "node_modules is blocked",
+		suggestion: "List package.json instead, or read the package's documentation or type definitions online.",
+	},
+}
+
+// CheckFile reports whether a tool or command may not touch path, and why.
+// path is absolute or relative to workspaceRoot. The globs in
+// cfg.Policy.Files come first: a deny glob blocks the path, an allow glob
+// exempts it from the built-in rules for secrets, keys and lock files.
+// Call Files.ForWorkspace first for the project's own globs to apply.
+func CheckFile(path string, workspaceRoot string, cfg config.Config) (Decision, bool) {
+	rel := workspacePath(path, workspaceRoot)
+	if rel == "" {
+		return Decision{}, false
+	}
+	for _, glob := range cfg.Policy.Files.Deny {
+		if matchGlob(glob, rel) {
+			decision := deny(DenySensitiveFile)
+			decision.Reason = fmt.Sprintf("matches policy.files.deny entry %q", glob)
+			decision.Rule = glob
+			decision.Match = rel
+			decision.Suggestion = "This file is off limits in this project; work from other files or ask the user."
+			return decision, true
+		}
+	}
+	for _, glob := range cfg.Policy.Files.Allow {
+		if matchGlob(glob, rel) {
+			return Decision{}, false
+		}
+	}
+	for _, rule := range builtinFileRules {
+		if glob, ok := rule.match(rel); ok {
+			decision := deny(DenySensitiveFile)
+			decision.Reason = rule.reason
+			decision.Rule = glob
+			decision.Match = rel
+			decision.Suggestion = rule.suggestion
+			return decision, true
+		}
+	}
+	return Decision{}, false
+}
+
+// SensitivePath checks path against the built-in rules only.
+func SensitivePath(path string) (Decision, bool) {
+	return CheckFile(path, "", config.Config{})
+}
+
+// commandFiles checks every argument of command that could name a file,
+// relative to dir (the workspace root when empty). A glob is expanded the
+// way bash would and each match checked; unresolved is set when a word
+// cannot be expanded here, so the command must not run unasked.
+func commandFiles(command string, cfg config.Config, workspaceRoot string, dir string) (decision Decision, denied bool, unresolved bool) {
+	base := dir
+	if base == "" {
+		base = workspaceRoot
+	}
+	for i, word := range shellWords(command) {
+		token := word.value()
+		if i == 0 || token == "" || strings.HasPrefix(token, "-") || strings.Contains(token, "://") {
+			continue
+		}
+		candidates := []string{token}
+		if base != "" {
+			candidates[0] = expandPath(token, base)
+		}
+		if word.glob {
+			if base == "" || strings.Contains(token, "{") {
+				unresolved = true
+				continue
+			}
+			matches, err := filepath.Glob(candidates[0])
+			if err != nil {
+				unresolved = true
+				continue
+			}
+			candidates = append(candidates, matches...)
+		}
+		for _, candidate := range candidates {
+			if decision, denied := CheckFile(candidate, workspaceRoot, cfg); denied {
+				return decision, true, false
+			}
+		}
+	}
+	return Decision{}, false, unresolved
+}
+
+func (r fileRule) match(rel string) (string, bool) {
+	base := path.Base(rel)
+	for _, except := range r.except {
+		if ok, _ := path.Match(except, base); ok {
+			return "", false
+		}
+	}
+	for _, glob := range r.globs {
+		if matchGlob(glob, rel) {
+			return glob, true
+		}
+	}
+	return "", false
+}
+
+func workspacePath(p string, workspaceRoot string) string {
+	p = strings.TrimSpace(p)
+	if p == "" {
+		return ""
+	}
+	if workspaceRoot != "" && filepath.IsAbs(p) {
+		if rel, err := filepath.Rel(workspaceRoot, p); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			p = rel
+		}
+	}
+	return strings.TrimPrefix(path.Clean(filepath.ToSlash(p)), "./")
+}
+
+// matchGlob matches a slash-separated path. A glob without a slash matches
+// any single component, so "*.pem" finds certs/server.pem and "node_modules"
+// anything under it. A glob with a slash matches the whole path, and a **
+// segment matches any number of directories.
+func matchGlob(glob string, rel string) bool {
+	glob = strings.Trim(filepath.ToSlash(glob), "/")
+	parts := strings.Split(strings.Trim(rel, "/"), "/")
+	if !strings.Contains(glob, "/") {
+		for _, part := range parts {
+			if ok, _ := path.Match(glob, part); ok {
+				return true
+			}
+		}
+		return false
+	}
+	return matchSegments(strings.Split(glob, "/"), parts)
+}
+
+func matchSegments(glob []string, parts []string) bool {
+	for len(glob) > 0 {
+		if glob[0] == "**" {
+			for skip := 0; skip <= len(parts); skip++ {
+				if matchSegments(glob[1:], parts[skip:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(parts) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(glob[0], parts[0]); !ok {
+			return false
+		}
+		glob, parts = glob[1:], parts[1:]
+	}
+	return len(parts) == 0
+}
@@ -0,0 +1,251 @@
+package policy
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"minimal-go/internal/config"
+)
+
+func TestCheckFileBuiltinRules(t *testing.T) {
+	denied := []string{
+		".env",
+		"config/.env.local",
+		"deploy/prod.env",
+		".dev.vars",
+		"/home/dev/.aws/credentials",
+		"credentials.json",
+		".git-credentials",
+		"secrets.yaml",
+		"config/secrets/prod.yaml",
+		"client_secret.json",
+		"k8s/app-secret.yml",
+		"certs/server.pem",
+		"tls/server.key",
+		"~/.ssh/id_rsa",
+		"/home/dev/.ssh/id_ed25519",
+		"package-lock.json",
+		"web/yarn.lock",
+		"pnpm-lock.yaml",
+		".DS_Store",
+		"node_modules/react/index.js",
+	}
+	for _, path := range denied {
+		if _, ok := CheckFile(path, "", config.Config{}); !ok {
+			t.Errorf("CheckFile(%q) allowed, want denied", path)
+		}
+	}
+}
+
+// The regular expressions these rules replaced matched "secret",
+// "credentials" and ".key" anywhere in a path or command.
+func TestCheckFileAllowsLookalikes(t *testing.T) {
+	allowed := []string{
+		".env.example",
+		"config/.env.sample",
+		"internal/secretary/main.go",
+		"docs/secret-handling.md",
+		"src/secretsManager.ts",
+		"pkg/secrets/store.go",
+		"internal/auth/credentials.go",
+		"auth/credentials_test.go",
+		"~/.ssh/id_rsa.pub",
+		"ui/monkey",
+		"keys.go",
+		"internal/environment.go",
+		"README.md",
+	}
+	for _, path := range allowed {
+		if decision, ok := CheckFile(path, "", config.Config{}); ok {
+			t.Errorf("CheckFile(%q) denied (%s, rule %q), want allowed", path, decision.Reason, decision.Rule)
+		}
+	}
+}
+
+func TestCheckFileDecision(t *testing.T) {
+	decision, ok := CheckFile("app/.env", "", config.Config{})
+	if !ok {
+		t.Fatal("app/.env allowed")
+	}
+	if decision.Result != PolicyDeny || decision.Class != DenySensitiveFile {
+		t.Errorf("decision = %s/%s, want deny/sensitive_file", decision.Result, decision.Class)
+	}
+	if decision.Rule != ".env" || decision.Match != "app/.env" {
+		t.Errorf("rule %q match %q, want \".env\" and \"app/.env\"", decision.Rule, decision.Match)
+	}
+	if decision.Suggestion == "" {
+		t.Error("no suggestion")
+	}
+}
+
+func TestCheckFileRelativizesWorkspacePaths(t *testing.T) {
+	root := filepath.FromSlash("/work/app")
+	cfg := config.Config{}
+	cfg.Policy.Files.Deny = []string{"data/*.csv"}
+	if _, ok := CheckFile(filepath.Join(root, "data", "users.csv"), root, cfg); !ok {
+		t.Error("absolute path inside the workspace not matched against a workspace-relative glob")
+	}
+	if _, ok := CheckFile(filepath.FromSlash("/elsewhere/data/users.csv"), root, cfg); ok {
+		t.Error("path outside the workspace matched a workspace-relative glob")
+	}
+}
+
+func TestCheckFileConfiguredGlobs(t *testing.T) {
+	cfg := config.Config{}
+	cfg.Policy.Files.Allow = []string{".env.test", "fixtures/**"}
+	cfg.Policy.Files.Deny = []string{"*.tfstate", "data/**/raw/*.csv", "fixtures/live/*"}
+
+	cases := []struct {
+		path   string
+		denied bool
+	}{
+		{".env.test", false},
+		{".env", true},
+		{"fixtures/certs/test.pem", false},
+		{"fixtures/live/token.json", true},
+		{"infra/terraform.tfstate", true},
+		{"data/raw/users.csv", true},
+		{"data/2024/01/raw/users.csv", true},
+		{"data/clean/users.csv", false},
+	}
+	for _, c := range cases {
+		decision, denied := CheckFile(c.path, "", cfg)
+		if denied != c.denied {
+			t.Errorf("CheckFile(%q) denied = %v (%s), want %v", c.path, denied, decision.Reason, c.denied)
+		}
+	}
+}
+
+func TestFileAccessForWorkspace(t *testing.T) {
+	files := config.FileAccessConfig{
+		Deny: []string{"*.tfstate"},
+		Projects: map[string]config.FileAccessRules{
+			"/work/app":   {Allow: []string{".env.ci"}, Deny: []string{"dumps"}},
+			"/work/other": {Deny: []string{"*.sql"}},
+		},
+	}
+	cfg := config.Config{}
+	cfg.Policy.Files = files.ForWorkspace("/work/app")
+
+	if _, ok := CheckFile(".env.ci", "", cfg); ok {
+		t.Error("project allow glob not applied")
+	}
+	if _, ok := CheckFile("dumps/today.gz", "", cfg); !ok {
+		t.Error("project deny glob not applied")
+	}
+	if _, ok := CheckFile("state/prod.tfstate", "", cfg); !ok {
+		t.Error("global deny glob lost")
+	}
+	if _, ok := CheckFile("schema.sql", "", cfg); ok {
+		t.Error("another project's glob applied")
+	}
+}
+
+func TestEvaluatePolicyFileArguments(t *testing.T) {
+	cases := []struct {
+		command string
+		denied  bool
+	}{
+		{"cat .env", true},
+		{"cat config/.env.production", true},
+		{"head -n 5 secrets/prod.yaml", true},
+		{"sed -n 1,5p ~/.ssh/id_rsa", true},
+		{"docker run --env-file=.env app", true},
+		{"cat .env.example", false},
+		{"cat internal/secretary/main.go", false},
+		{"grep -n process.env.API_KEY src/config.ts", false},
+		{"cat ~/.ssh/id_ed25519.pub", false},
+		{"git diff -- src/secrets/rotate.go", false},
+	}
+	for _, c := range cases {
+		decision := EvaluatePolicy(c.command, config.DefaultConfig())
+		if denied := decision.Result == PolicyDeny; denied != c.denied {
+			t.Errorf("EvaluatePolicy(%q) = %s (%s), want denied=%v", c.command, decision.Result, decision.Reason, c.denied)
+		}
+	}
+}
+
+func TestEvaluateInWorkspaceResolvesShellSyntax(t *testing.T) {
+	root := t.TempDir()
+	for _, name := range []string{".env", "main.go", "README.md"} {
+		if err := os.WriteFile(filepath.Join(root, name), []byte("x"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cases := []struct {
+		command string
+		want    PolicyResult
+	}{
+		{"cat .e*", PolicyDeny},
+		{"cat .en?", PolicyDeny},
+		{"cat .[e]nv", PolicyDeny},
+		{"cat .e''nv", PolicyDeny},
+		{`cat .e"n"v`, PolicyDeny},
+		{`cat .e\nv`, PolicyDeny},
+		{"cat *", PolicyDeny},
+		{"cat .{env,x}", PolicyAsk},
+		{"cat *.go", PolicyAuto},
+		{"cat '.e*'", PolicyAuto},
+		{"grep -n main *.md", PolicyAuto},
+	}
+	for _, c := range cases {
+		decision := EvaluateInWorkspace(c.command, config.DefaultConfig(), root, root)
+		if decision.Result != c.want {
+			t.Errorf("EvaluateInWorkspace(%q) = %s (%s), want %s", c.command, decision.Result, decision.Reason, c.want)
+		}
+	}
+
+	// Without a directory to expand against, a glob is never auto-approved.
+	if got := EvaluatePolicy("cat *.go", config.DefaultConfig()).Result; got != PolicyAsk {
+		t.Errorf("EvaluatePolicy(cat *.go) = %s, want ask", got)
+	}
+}
+
+func TestEvaluateInWorkspaceAppliesProjectGlobs(t *testing.T) {
+	root := t.TempDir()
+	cfg := config.DefaultConfig()
+	cfg.Policy.Files.Deny = []string{"infra/*.tfstate"}
+	decision := EvaluateInWorkspace("cat infra/prod.tfstate", cfg, root, root)
+	if decision.Result != PolicyDeny {
+		t.Errorf("project deny glob: got %s, want deny", decision.Result)
+	}
+	decision = EvaluateInWorkspace("cat prod.tfstate", cfg, root, filepath.Join(root, "infra"))
+	if decision.Result != PolicyDeny {
+		t.Errorf("project deny glob from a subdirectory: got %s, want deny", decision.Result)
+	}
+}
+
+func TestBuiltinFileGlobsAreValid(t *testing.T) {
+	for _, rule := range builtinFileRules {
+		for _, glob := range append(append([]string{}, rule.globs...), rule.except...) {
+			if err := config.ValidateFileGlob(glob); err != nil {
+				t.Error(err)
+			}
+		}
+	}
+}
+
+func TestMatchGlob(t *testing.T) {
+	cases := []struct {
+		glob  string
+		path  string
+		match bool
+	}{
+		{"*.pem", "a/b/c.pem", true},
+		{"node_modules", "web/node_modules/x/y.js", true},
+		{"build/*.js", "build/app.js", true},
+		{"build/*.js", "web/build/app.js", false},
+		{"**/build/*.js", "web/build/app.js", true},
+		{"src/**", "src", true},
+		{"src/**", "src/a/b.go", true},
+		{"src/**/*.go", "src/b.go", true},
+		{"/src/*.go", "src/b.go", true},
+		{"src/*.go", "src/a/b.go", false},
+	}
+	for _, c := range cases {
+		if got := matchGlob(c.glob, c.path); got != c.match {
+			t.Errorf("matchGlob(%q, %q) = %v, want %v", c.glob, c.path, got, c.match)
+		}
+	}
+}
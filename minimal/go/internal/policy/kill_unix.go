@@ -0,0 +1,52 @@
+//go:build !windows
+
+package policy
+
+import (
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// killProcesses kills each process with everything it started. Each one is
+// stopped before its children are killed, so it cannot start new ones in
+// the meantime.
+func killProcesses(processes []*os.Process) {
+	if len(processes) == 0 {
+		return
+	}
+	children := processChildren()
+	for _, process := range processes {
+		killTree(process.Pid, children)
+	}
+}
+
+func processChildren() map[int][]int {
+	children := map[int][]int{}
+	output, err := exec.Command("ps", "-A", "-o", "pid=", "-o", "ppid=").Output()
+	if err != nil {
+		return children
+	}
+	for _, line := range strings.Split(string(output), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			continue
+		}
+		pid, err1 := strconv.Atoi(fields[0])
+		ppid, err2 := strconv.Atoi(fields[1])
+		if err1 == nil && err2 == nil {
+			children[ppid] = append(children[ppid], pid)
+		}
+	}
+	return children
+}
+
+func killTree(pid int, children map[int][]int) {
+	_ = syscall.Kill(pid, syscall.SIGSTOP)
+	for _, child := range children[pid] {
+		killTree(child, children)
+	}
+	_ = syscall.Kill(pid, syscall.SIGKILL)
+}
@@ -0,0 +1,11 @@
+package policy
+
+import "os"
+
+// killProcesses kills only the processes themselves: Windows has no signal
+// to stop a tree, and bash's children are left to exit with it.
+func killProcesses(processes []*os.Process) {
+	for _, process := range processes {
+		_ = process.Kill()
+	}
+}
@@ -0,0 +1,239 @@
+package policy
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strings"
+
+	"minimal-go/internal/config"
+)
+
+var harmlessPaths = map[string]bool{
+	"/dev/null":   true,
+	"/dev/stdin":  true,
+	"/dev/stdout": true,
+	"/dev/stderr": true,
+	"/dev/tty":    true,
+}
+
+type PathError struct {
+	Path     string
+	Resolved string
+}
+
+func (e *PathError) Error() string {
+	return fmt.Sprintf("%s resolves to %s, outside the workspace", e.Path, e.Resolved)
+}
+
+func ResolvePath(path string, workspaceRoot string, allowedPaths []string) (string, error) {
+	return ResolvePathFrom(path, workspaceRoot, workspaceRoot, allowedPaths)
+}
+
+func ResolvePathFrom(path string, base string, workspaceRoot string, allowedPaths []string) (string, error) {
+	if strings.TrimSpace(path) == "" {
+		return "", errors.New("empty path")
+	}
+	resolved := expandPath(path, base)
+	if !IsInsideAllowed(resolved, workspaceRoot, allowedPaths) {
+		return "", &PathError{Path: path, Resolved: resolved}
+	}
+	return resolved, nil
+}
+
+func IsInsideAllowed(resolved string, workspaceRoot string, allowedPaths []string) bool {
+	real := realPath(resolved)
+	roots := append([]string{workspaceRoot}, allowedPaths...)
+	for _, root := range roots {
+		if root == "" {
+			continue
+		}
+		if within(real, realPath(expandPath(root, workspaceRoot))) {
+			return true
+		}
+	}
+	return false
+}
+
+func EvaluateInWorkspace(command string, cfg config.Config, workspaceRoot string, dir string) Decision {
+	decision := evaluatePolicy(command, cfg, workspaceRoot, dir)
+	if decision.Result == PolicyDeny {
+		return decision
+	}
+	outside := OutsidePaths(command, dir, workspaceRoot, cfg.Policy.AllowedPaths)
+	if len(outside) == 0 {
+		return decision
+	}
+	if cfg.Policy.DefaultAction == "deny" {
+		decision = deny(DenyOutsideWorkspace)
+		decision.Reason = "touches paths outside the workspace (" + strings.Join(outside, ", ") + ")"
+	} else if decision.Result != PolicyConfirm {
+		decision.Result = PolicyAsk
+	}
+	decision.OutsidePaths = outside
+	return decision
+}
+
+func OutsidePaths(command string, dir string, workspaceRoot string, allowedPaths []string) []string {
+	var outside []string
+	seen := map[string]bool{}
+	for _, token := range commandTokens(command) {
+		if !looksLikePath(token) || harmlessPaths[token] || seen[token] {
+			continue
+		}
+		seen[token] = true
+		if !IsInsideAllowed(expandPath(token, dir), workspaceRoot, allowedPaths) {
+			outside = append(outside, token)
+		}
+	}
+	return outside
+}
+
+func commandTokens(command string) []string {
+	var tokens []string
+	for _, word := range shellWords(command) {
+		if token := word.value(); token != "" {
+			tokens = append(tokens, token)
+		}
+	}
+	return tokens
+}
+
+// shellWord is one word of a command with its quotes and escapes removed,
+// so .e"n"v and .e\nv read as .env. Glob is set when an unquoted *, ? or [,
+// or a brace list, leaves the word for bash to expand.
+type shellWord struct {
+	text string
+	glob bool
+}
+
+// value is the part of the word that can name a file: for --flag=path and
+// NAME=path, what follows the first =.
+func (w shellWord) value() string {
+	if index := strings.Index(w.text, "="); index >= 0 {
+		return w.text[index+1:]
+	}
+	return w.text
+}
+
+func shellWords(command string) []shellWord {
+	var words []shellWord
+	var current strings.Builder
+	var quote rune
+	started, glob, brace := false, false, false
+	flush := func() {
+		if started {
+			words = append(words, shellWord{text: current.String(), glob: glob})
+		}
+		current.Reset()
+		started, glob, brace = false, false, false
+	}
+	runes := []rune(command)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else if r == '\\' && quote == '"' && i+1 < len(runes) {
+				i++
+				current.WriteRune(runes[i])
+			} else {
+				current.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote, started = r, true
+		case r == '\\' && i+1 < len(runes):
+			i++
+			current.WriteRune(runes[i])
+			started = true
+		case strings.ContainsRune(" \t\n;|&<>()", r):
+			flush()
+		default:
+			switch {
+			case r == '*' || r == '?' || r == '[':
+				glob = true
+			case r == '{':
+				brace = true
+			case r == ',' && brace:
+				glob = true
+			}
+			current.WriteRune(r)
+			started = true
+		}
+	}
+	flush()
+	return words
+}
+
+func looksLikePath(token string) bool {
+	if strings.Contains(token, "://") {
+		return false
+	}
+	if strings.HasPrefix(token, "/") || strings.HasPrefix(token, "~") || homeVariable(token) != "" {
+		return true
+	}
+	for _, segment := range strings.Split(token, "/") {
+		if segment == ".." {
+			return true
+		}
+	}
+	return false
+}
+
+// homeVariable returns the $HOME or ${HOME} that path starts with, if any.
+func homeVariable(path string) string {
+	for _, variable := range []string{"$HOME", "${HOME}"} {
+		if path == variable || strings.HasPrefix(path, variable+"/") {
+			return variable
+		}
+	}
+	return ""
+}
+
+// expandPath resolves path the way bash would: ~, ~user, $HOME and ${HOME}
+// name home directories, and a relative path is taken from workspaceRoot.
+func expandPath(path string, workspaceRoot string) string {
+	if variable := homeVariable(path); variable != "" {
+		home, _ := os.UserHomeDir()
+		path = home + path[len(variable):]
+	} else if strings.HasPrefix(path, "~") {
+		name, rest, _ := strings.Cut(path[1:], "/")
+		if name == "" {
+			home, _ := os.UserHomeDir()
+			path = filepath.Join(home, rest)
+		} else if account, err := user.Lookup(name); err == nil {
+			path = filepath.Join(account.HomeDir, rest)
+		}
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(workspaceRoot, path)
+	}
+	return filepath.Clean(path)
+}
+
+func realPath(path string) string {
+	current := path
+	var rest []string
+	for {
+		if resolved, err := filepath.EvalSymlinks(current); err == nil {
+			return filepath.Join(append([]string{resolved}, rest...)...)
+		}
+		parent := filepath.Dir(current)
+		if parent == current {
+			return path
+		}
+		rest = append([]string{filepath.Base(current)}, rest...)
+		current = parent
+	}
+}
+
+func within(path string, root string) bool {
+	relative, err := filepath.Rel(root, path)
+	if err != nil {
+		return false
+	}
+	return relative == "." || (relative != ".." && !strings.HasPrefix(relative, ".."+string(filepath.Separator)))
+}
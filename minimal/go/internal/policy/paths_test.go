@@ -0,0 +1,39 @@
+package policy
+
+import (
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestOutsidePathsExpandsHomeDirectories(t *testing.T) {
+	root := t.TempDir()
+	t.Setenv("HOME", t.TempDir())
+	tests := []struct {
+		command string
+		want    []string
+	}{
+		{"cat ~/.bashrc", []string{"~/.bashrc"}},
+		{"cat ~root/.bashrc", []string{"~root/.bashrc"}},
+		{"cat $HOME/.bashrc", []string{"$HOME/.bashrc"}},
+		{"cat ${HOME}/.ssh/id_rsa", []string{"${HOME}/.ssh/id_rsa"}},
+		{`cat "$HOME"`, []string{"$HOME"}},
+		{"cat ./README.md", nil},
+		{"echo $HOMEPAGE", nil},
+	}
+	for _, test := range tests {
+		if got := OutsidePaths(test.command, root, root, nil); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("OutsidePaths(%q) = %q, want %q", test.command, got, test.want)
+		}
+	}
+}
+
+func TestExpandPathHomeForms(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	for _, path := range []string{"~/notes", "$HOME/notes", "${HOME}/notes"} {
+		if got := expandPath(path, "/workspace"); got != home+string(os.PathSeparator)+"notes" {
+			t.Errorf("expandPath(%q) = %q, want %q", path, got, home+"/notes")
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package policy
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	// readOnlyCommands only inspect the workspace. Builds and test runners
+	// are left out: they write artifacts and run project code, which the
+	// agent may have just written.
+	readOnlyCommands = []string{
+		"ls", "pwd", "cat", "head", "tail", "wc", "file", "stat", "tree", "du", "df",
+		"grep", "rg", "ag", "sort", "uniq", "cut", "tr", "diff", "jq", "echo", "which",
+		"git status", "git diff", "git log", "git show", "git branch", "git blame", "git rev-parse",
+		"go vet", "go list", "go version", "gofmt -l",
+	}
+	unsafeShellPattern = regexp.MustCompile("[>`]|\\$\\(|<\\(")
+	unsafeArgPattern   = regexp.MustCompile(`(^|\s)(-delete|-exec|-execdir|-ok|--fix|--write|-w)(\s|$)`)
+	// A lone & separates commands just like ;, so "ls & rm -rf build" is
+	// two commands. A trailing & leaves an empty segment and is rejected.
+	commandSeparator = regexp.MustCompile(`\|\||&&|[|;&]`)
+)
+
+// ReadOnlyCommand reports whether every part of command is a known command
+// that cannot change files or remote state.
+func ReadOnlyCommand(command string) bool {
+	return onlyCommands(command, readOnlyCommands)
+}
+
+func onlyCommands(command string, allowed []string) bool {
+	command = strings.TrimSpace(command)
+	if command == "" || strings.Contains(command, "\n") || unsafeShellPattern.MatchString(command) {
+		return false
+	}
+	for _, segment := range commandSeparator.Split(command, -1) {
+		segment = strings.Join(strings.Fields(segment), " ")
+		if segment == "" || unsafeArgPattern.MatchString(segment) || !hasPrefix(segment, allowed) || !argsAllowed(segment) {
+			return false
+		}
+	}
+	return true
+}
+
+// argChecks vet the arguments of listed commands that can write files,
+// delete refs or run other programs depending on their flags. A prefix
+// match alone would let `git branch -D main` or `sort -o out` through.
+var argChecks = map[string]func(args []string) bool{
+	"git branch": gitBranchArgs,
+	"git diff":   withoutFlags("--output", "--ext-diff"),
+	"git log":    withoutFlags("--output", "--ext-diff"),
+	"git show":   withoutFlags("--output", "--ext-diff"),
+	"sort":       withoutFlags("-o", "--output", "--compress-program"),
+	"tree":       withoutFlags("-o"),
+	"rg":         withoutFlags("--pre"),
+	"file":       withoutFlags("-C", "--compile"),
+	"go vet":     withoutFlags("-vettool", "--vettool"),
+	"find":       withoutFlags("-delete", "-exec", "-execdir", "-ok", "-okdir", "-fprint", "-fprint0", "-fprintf", "-fls"),
+}
+
+// argsAllowed applies the argument check of the longest command in
+// argChecks that segment starts with.
+func argsAllowed(segment string) bool {
+	best := ""
+	for name := range argChecks {
+		if (segment == name || strings.HasPrefix(segment, name+" ")) && len(name) > len(best) {
+			best = name
+		}
+	}
+	if best == "" {
+		return true
+	}
+	return argChecks[best](strings.Fields(strings.TrimPrefix(segment, best)))
+}
+
+// withoutFlags rejects any of flags, whether written alone, with =value or,
+// for single-letter flags, bundled with others (-ro for -r -o).
+func withoutFlags(flags ...string) func(args []string) bool {
+	return func(args []string) bool {
+		for _, arg := range args {
+			for _, flag := range flags {
+				if arg == flag || strings.HasPrefix(arg, flag+"=") {
+					return false
+				}
+				if len(flag) == 2 && len(arg) > 1 && arg[0] == '-' && arg[1] != '-' && strings.ContainsRune(arg[1:], rune(flag[1])) {
+					return false
+				}
+			}
+		}
+		return true
+	}
+}
+
+// git branch lists branches only with these flags; a bare name creates a
+// branch, and -d, -D, -m, -M, -c and -f change refs.
+var (
+	gitBranchListFlags = map[string]bool{
+		"-a": true, "--all": true, "-r": true, "--remotes": true, "-v": true, "-vv": true, "--verbose": true,
+		"--show-current": true, "--column": true, "--no-column": true, "--no-color": true, "-i": true, "--ignore-case": true,
+	}
+	// gitBranchFilters take a pattern or commit, so positional arguments
+	// are names to match rather than branches to create.
+	gitBranchFilters = map[string]bool{
+		"-l": true, "--list": true, "--contains": true, "--no-contains": true, "--merged": true, "--no-merged": true, "--points-at": true,
+	}
+)
+
+func gitBranchArgs(args []string) bool {
+	filtered := false
+	for _, arg := range args {
+		name, _, _ := strings.Cut(arg, "=")
+		if gitBranchFilters[name] {
+			filtered = true
+		}
+	}
+	for _, arg := range args {
+		name, _, _ := strings.Cut(arg, "=")
+		switch {
+		case gitBranchListFlags[arg], gitBranchFilters[name], name == "--sort", name == "--format", name == "--color":
+		case !strings.HasPrefix(arg, "-") && filtered:
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+func hasPrefix(segment string, allowed []string) bool {
+	for _, safe := range allowed {
+		if segment == safe || strings.HasPrefix(segment, safe+" ") {
+			return true
+		}
+	}
+	return false
+}
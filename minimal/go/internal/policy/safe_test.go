@@ -0,0 +1,116 @@
+package policy
+
+import (
+	"testing"
+
+	"minimal-go/internal/config"
+)
+
+func TestReadOnlyCommandRejectsWritingArguments(t *testing.T) {
+	tests := []struct {
+		command string
+		want    bool
+	}{
+		{"git branch", true},
+		{"git branch -a", true},
+		{"git branch -vv --sort=-committerdate", true},
+		{"git branch --show-current", true},
+		{"git branch --list 'feat/*'", true},
+		{"git branch --contains HEAD", true},
+		{"git branch -D main", false},
+		{"git branch -d main", false},
+		{"git branch -m old new", false},
+		{"git branch -M new", false},
+		{"git branch new-feature", false},
+		{"git branch -f main HEAD~1", false},
+		{"git diff", true},
+		{"git diff --stat HEAD~1", true},
+		{"git diff --output=x", false},
+		{"git diff --output x", false},
+		{"git log --oneline -5", true},
+		{"git log --output=x", false},
+		{"git show --ext-diff HEAD", false},
+		{"sort f", true},
+		{"sort -rn f", true},
+		{"sort -o out.txt f", false},
+		{"sort -ro out.txt f", false},
+		{"sort --output=out.txt f", false},
+		{"tree -L 2", true},
+		{"tree -o out", false},
+		{"rg --pre cat foo", false},
+		{"go vet -vettool=/tmp/x ./...", false},
+		{"cat a.txt | sort -o b.txt", false},
+		{"go test ./...", false},
+		{"make test", false},
+		{"npm run build", false},
+		{"ls & rm -rf build", false},
+		{"cat x & touch y", false},
+		{"echo hi & curl -X POST http://x", false},
+		{"ls &", false},
+		{"ls\nrm -rf build", false},
+		{"cat $(rm x)", false},
+		{"cat `rm x`", false},
+		{"cat <(rm x)", false},
+		{"ls && git status", true},
+		{"ls | wc -l", true},
+	}
+	for _, test := range tests {
+		if got := ReadOnlyCommand(test.command); got != test.want {
+			t.Errorf("ReadOnlyCommand(%q) = %v, want %v", test.command, got, test.want)
+		}
+	}
+}
+
+func TestEvaluatePolicyReadOnly(t *testing.T) {
+	cfg := config.Config{}
+	cfg.Policy.ReadOnly = true
+	tests := []struct {
+		command string
+		want    PolicyResult
+	}{
+		{"git branch -D main", PolicyDeny},
+		{"git branch -m main trunk", PolicyDeny},
+		{"sort -o out.txt f", PolicyDeny},
+		{"tree -o out", PolicyDeny},
+		{"git diff --output=x", PolicyDeny},
+		{"git log --output=x", PolicyDeny},
+		{"cat x & touch y", PolicyDeny},
+		{"ls\ntouch y", PolicyDeny},
+		{"cat $(touch y)", PolicyDeny},
+		{"cat `touch y`", PolicyDeny},
+		// The built-in auto-approve list does not apply in read-only mode.
+		{"git branch", PolicyAsk},
+		{"ls", PolicyAsk},
+	}
+	for _, test := range tests {
+		if got := EvaluatePolicy(test.command, cfg).Result; got != test.want {
+			t.Errorf("EvaluatePolicy(%q) = %s, want %s", test.command, got, test.want)
+		}
+	}
+
+	cfg.Policy.AutoCommands = []string{"ls"}
+	if got := EvaluatePolicy("ls", cfg).Result; got != PolicyAuto {
+		t.Errorf("EvaluatePolicy(ls) with autoCommands = %s, want auto", got)
+	}
+}
+
+func TestEvaluatePolicyAutoChecksArguments(t *testing.T) {
+	tests := []struct {
+		command string
+		want    PolicyResult
+	}{
+		{"git branch", PolicyAuto},
+		{"git branch -D main", PolicyAsk},
+		{"git diff --output=x", PolicyAsk},
+		{"find . -name '*.go'", PolicyAuto},
+		{"find . -delete", PolicyAsk},
+		{"ls -la\ntouch y", PolicyAsk},
+		{"ls & touch y", PolicyAsk},
+		{"cat <(touch y)", PolicyAsk},
+	}
+	for _, test := range tests {
+		if got := EvaluatePolicy(test.command, config.Config{}).Result; got != test.want {
+			t.Errorf("EvaluatePolicy(%q) = %s, want %s", test.command, got, test.want)
+		}
+	}
+}
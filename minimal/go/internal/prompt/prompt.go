@@ -0,0 +1,77 @@
+package prompt
+
+import (
+	"sort"
+	"strings"
+)
+
+const (
+	OrderBase      = 100
+	OrderTools     = 200
+	OrderContext   = 250
+	OrderWorkspace = 300
+	OrderToolchain = 350
+	OrderProject   = 400
+	OrderMemory    = 500
+)
+
+type Section struct {
+	Name    string
+	Order   int
+	Content string
+}
+
+type Builder struct {
+	sections []Section
+}
+
+func (b *Builder) Add(order int, name string, content string) {
+	content = normalize(content)
+	if content == "" {
+		return
+	}
+	for i, existing := range b.sections {
+		if existing.Name == name {
+			b.sections[i] = Section{Name: name, Order: order, Content: content}
+			return
+		}
+	}
+	b.sections = append(b.sections, Section{Name: name, Order: order, Content: content})
+}
+
+func (b *Builder) Remove(name string) {
+	for i, existing := range b.sections {
+		if existing.Name == name {
+			b.sections = append(b.sections[:i], b.sections[i+1:]...)
+			return
+		}
+	}
+}
+
+func (b *Builder) Sections() []Section {
+	sections := append([]Section{}, b.sections...)
+	sort.SliceStable(sections, func(i, j int) bool {
+		if sections[i].Order != sections[j].Order {
+			return sections[i].Order < sections[j].Order
+		}
+		return sections[i].Name < sections[j].Name
+	})
+	return sections
+}
+
+func (b *Builder) Build() string {
+	sections := b.Sections()
+	parts := make([]string, 0, len(sections))
+	for _, section := range sections {
+		parts = append(parts, section.Content)
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+func normalize(content string) string {
+	lines := strings.Split(strings.ReplaceAll(content, "\r\n", "\n"), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " \t")
+	}
+	return strings.Trim(strings.Join(lines, "\n"), "\n")
+}
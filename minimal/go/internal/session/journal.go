@@ -0,0 +1,168 @@
+package session
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"minimal-go/internal/config"
+	"minimal-go/internal/types"
+	"minimal-go/internal/vault"
+)
+
+const journalSuffix = ".journal"
+
+// journalEntry is one line of a session journal: the messages from index
+// From on. The first line of a journal is a full snapshot (From 0) and
+// carries the session's metadata, so a journal can be replayed without the
+// session file it belongs to.
+type journalEntry struct {
+	Session  *Session        `json:"session,omitempty"`
+	From     int             `json:"from"`
+	Messages []types.Message `json:"messages"`
+}
+
+// Recovery is a session rebuilt from its journal.
+type Recovery struct {
+	Session *Session
+	// Skipped counts journal lines that could not be read or applied; a
+	// line torn by the crash is the usual one.
+	Skipped int
+	// Saved is false when the session was never saved before the crash.
+	Saved bool
+}
+
+// Journal appends the change to messages starting at from to the session's
+// journal, so a crash loses at most the message being written. Save removes
+// the journal once the session file holds everything in it.
+func Journal(s *Session, messages []types.Message, from int) error {
+	if err := os.MkdirAll(config.SessionsDir, 0o755); err != nil {
+		return err
+	}
+	path := journalPath(s.ID)
+	entry := journalEntry{From: from}
+	if _, err := os.Stat(path); err != nil {
+		header := *s
+		header.Messages, header.TurnLog = nil, nil
+		entry = journalEntry{Session: &header}
+	}
+	entry.Messages = messages[min(entry.From, len(messages)):]
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	if line, err = vault.SealLine(line); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// Journals returns the IDs of sessions with a journal left behind, newest
+// first. Only a crash, or a session still running, leaves one.
+func Journals() ([]string, error) {
+	entries, err := os.ReadDir(config.SessionsDir)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var ids []string
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasSuffix(entry.Name(), journalSuffix) {
+			ids = append(ids, strings.TrimSuffix(entry.Name(), journalSuffix))
+		}
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(ids)))
+	return ids, nil
+}
+
+// Recover replays the journal of the session whose ID starts with id on
+// top of its saved metadata. It does not save the result.
+func Recover(id string) (*Recovery, error) {
+	ids, err := Journals()
+	if err != nil {
+		return nil, err
+	}
+	var match string
+	for _, candidate := range ids {
+		if candidate == id {
+			match = candidate
+			break
+		}
+		if strings.HasPrefix(candidate, id) {
+			if match != "" {
+				return nil, errors.New("more than one journal matches " + id)
+			}
+			match = candidate
+		}
+	}
+	if match == "" {
+		return nil, errors.New("no journal for session " + id)
+	}
+
+	data, err := os.ReadFile(journalPath(match))
+	if err != nil {
+		return nil, err
+	}
+	recovery := &Recovery{}
+	var messages []types.Message
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), 64*1024*1024)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		var entry journalEntry
+		opened, err := vault.OpenLine(line)
+		if errors.Is(err, vault.ErrLocked) {
+			return nil, err
+		}
+		if err != nil || json.Unmarshal(opened, &entry) != nil || entry.From > len(messages) {
+			recovery.Skipped++
+			continue
+		}
+		if entry.Session != nil && recovery.Session == nil {
+			recovery.Session = entry.Session
+		}
+		messages = append(messages[:entry.From], entry.Messages...)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if recovery.Session == nil {
+		return nil, errors.New("journal for " + match + " has no readable header")
+	}
+
+	if saved, err := vault.ReadFile(sessionPath(match)); err == nil {
+		if s, err := decode(saved); err == nil {
+			recovery.Session, recovery.Saved = s, true
+		}
+	} else if errors.Is(err, vault.ErrLocked) {
+		return nil, err
+	}
+	recovery.Session.Messages = messages
+	return recovery, nil
+}
+
+func removeJournal(id string) {
+	_ = os.Remove(journalPath(id))
+}
+
+func journalPath(id string) string {
+	return filepath.Join(config.SessionsDir, filepath.Base(id)+journalSuffix)
+}
@@ -0,0 +1,121 @@
+package session
+
+import (
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"minimal-go/internal/types"
+)
+
+const (
+	snippetRadius        = 60
+	maxMatchesPerSession = 3
+)
+
+type Match struct {
+	SessionID string
+	Title     string
+	UpdatedAt time.Time
+	Role      types.Role
+	Index     int
+	Snippet   string
+}
+
+func Search(query string, limit int) ([]Match, int, error) {
+	terms := strings.Fields(strings.ToLower(query))
+	if len(terms) == 0 {
+		return nil, 0, nil
+	}
+	sessions, err := List()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var matches []Match
+	total := 0
+	for _, s := range sessions {
+		title := s.Title
+		if title == "" {
+			title = TitleFrom(s.Messages)
+		}
+		found := 0
+		for i, message := range s.Messages {
+			if message.Role == types.RoleSystem {
+				continue
+			}
+			text := message.Content
+			if text == "" && len(message.ToolCalls) > 0 {
+				text = describeToolCalls(message.ToolCalls)
+			}
+			position := matchAll(strings.ToLower(text), terms)
+			if position < 0 {
+				continue
+			}
+			total++
+			found++
+			if found > maxMatchesPerSession || (limit > 0 && len(matches) >= limit) {
+				continue
+			}
+			matches = append(matches, Match{
+				SessionID: s.ID,
+				Title:     title,
+				UpdatedAt: s.UpdatedAt,
+				Role:      message.Role,
+				Index:     i,
+				Snippet:   snippet(text, position, len(terms[0])),
+			})
+		}
+	}
+	return matches, total, nil
+}
+
+func matchAll(text string, terms []string) int {
+	first := -1
+	for i, term := range terms {
+		position := strings.Index(text, term)
+		if position < 0 {
+			return -1
+		}
+		if i == 0 {
+			first = position
+		}
+	}
+	return first
+}
+
+func snippet(text string, position int, length int) string {
+	if position+length > len(text) {
+		position, length = 0, 0
+	}
+	start := position - snippetRadius
+	end := position + length + snippetRadius
+	prefix, suffix := "…", "…"
+	if start <= 0 {
+		start, prefix = 0, ""
+	}
+	if end >= len(text) {
+		end, suffix = len(text), ""
+	}
+	for start > 0 && !utf8.RuneStart(text[start]) {
+		start--
+	}
+	for end < len(text) && !utf8.RuneStart(text[end]) {
+		end++
+	}
+	return prefix + strings.Join(strings.Fields(text[start:end]), " ") + suffix
+}
+
+func describeToolCalls(calls []types.ToolCall) string {
+	var parts []string
+	for _, call := range calls {
+		if input, ok := call.Input.(map[string]interface{}); ok {
+			for _, key := range []string{"command", "path"} {
+				if value, ok := input[key].(string); ok {
+					parts = append(parts, call.Name+": "+value)
+				}
+			}
+		}
+	}
+	return strings.Join(parts, "\n")
+}
@@ -0,0 +1,214 @@
+package session
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"minimal-go/internal/config"
+	"minimal-go/internal/types"
+	"minimal-go/internal/vault"
+)
+
+type Session struct {
+	ID        string          `json:"id"`
+	Name      string          `json:"name,omitempty"`
+	Title     string          `json:"title,omitempty"`
+	ParentID  string          `json:"parentId,omitempty"`
+	Model     string          `json:"model"`
+	Agent     string          `json:"agent,omitempty"`
+	Cost      float64         `json:"cost,omitempty"`
+	Workspace string          `json:"workspace"`
+	CreatedAt time.Time       `json:"createdAt"`
+	UpdatedAt time.Time       `json:"updatedAt"`
+	Messages  []types.Message `json:"messages"`
+	// TurnLog has one entry per agent turn, oldest first.
+	TurnLog []TurnSummary `json:"turnLog,omitempty"`
+}
+
+// TurnSummary records what one agent turn did.
+type TurnSummary struct {
+	ToolCalls    int     `json:"toolCalls"`
+	FilesChanged int     `json:"filesChanged"`
+	Tokens       int     `json:"tokens"`
+	Cost         float64 `json:"cost,omitempty"`
+	DurationMs   int64   `json:"durationMs"`
+	Failed       bool    `json:"failed,omitempty"`
+}
+
+func New(name string, model string, workspace string) *Session {
+	now := time.Now()
+	return &Session{
+		ID:        newID(now),
+		Name:      name,
+		Model:     model,
+		Workspace: workspace,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+}
+
+func (s *Session) Fork(name string) *Session {
+	forked := New(name, s.Model, s.Workspace)
+	forked.ParentID = s.ID
+	forked.Agent = s.Agent
+	forked.Title = s.Title
+	forked.Messages = append([]types.Message{}, s.Messages...)
+	forked.TurnLog = append([]TurnSummary{}, s.TurnLog...)
+	return forked
+}
+
+func (s *Session) Label() string {
+	if s.Name != "" {
+		return fmt.Sprintf("%s (%s)", s.Name, s.ID)
+	}
+	return s.ID
+}
+
+const maxTitleLength = 60
+
+func Save(s *Session) error {
+	if err := os.MkdirAll(config.SessionsDir, 0o755); err != nil {
+		return err
+	}
+	s.UpdatedAt = time.Now()
+	if s.Title == "" {
+		s.Title = TitleFrom(s.Messages)
+	}
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	if data, err = vault.Seal(data); err != nil {
+		return err
+	}
+	path := sessionPath(s.ID)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return err
+	}
+	removeJournal(s.ID)
+	return nil
+}
+
+func (s *Session) Turns() int {
+	turns := 0
+	for _, message := range s.Messages {
+		if message.Role == types.RoleUser {
+			turns++
+		}
+	}
+	return turns
+}
+
+func Title(prompt string) string {
+	for _, line := range strings.Split(prompt, "\n") {
+		line = strings.Join(strings.Fields(line), " ")
+		if line == "" || strings.HasPrefix(line, "[command]") {
+			continue
+		}
+		runes := []rune(line)
+		if len(runes) <= maxTitleLength {
+			return line
+		}
+		head := string(runes[:maxTitleLength])
+		if cut := strings.LastIndex(head, " "); cut > len(head)/2 {
+			head = head[:cut]
+		}
+		return strings.TrimRight(head, " ,.;:") + "…"
+	}
+	return ""
+}
+
+func TitleFrom(messages []types.Message) string {
+	for _, message := range messages {
+		if message.Role != types.RoleUser {
+			continue
+		}
+		if title := Title(message.Content); title != "" {
+			return title
+		}
+	}
+	return ""
+}
+
+func Load(idOrName string) (*Session, error) {
+	if data, err := vault.ReadFile(sessionPath(idOrName)); err == nil {
+		return decode(data)
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	sessions, err := List()
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range sessions {
+		if s.Name == idOrName || strings.HasPrefix(s.ID, idOrName) {
+			return s, nil
+		}
+	}
+	return nil, fmt.Errorf("session not found: %s", idOrName)
+}
+
+func List() ([]*Session, error) {
+	entries, err := os.ReadDir(config.SessionsDir)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var sessions []*Session
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+		data, err := vault.ReadFile(filepath.Join(config.SessionsDir, entry.Name()))
+		if errors.Is(err, vault.ErrLocked) {
+			return nil, err
+		}
+		if err != nil {
+			continue
+		}
+		s, err := decode(data)
+		if err != nil {
+			continue
+		}
+		sessions = append(sessions, s)
+	}
+
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].UpdatedAt.After(sessions[j].UpdatedAt)
+	})
+	return sessions, nil
+}
+
+func decode(data []byte) (*Session, error) {
+	var s Session
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+func sessionPath(id string) string {
+	return filepath.Join(config.SessionsDir, filepath.Base(id)+".json")
+}
+
+func newID(now time.Time) string {
+	suffix := make([]byte, 3)
+	_, _ = rand.Read(suffix)
+	return now.Format("20060102-150405") + "-" + hex.EncodeToString(suffix)
+}
@@ -0,0 +1,57 @@
+package share
+
+import (
+	"os"
+	"regexp"
+	"strings"
+)
+
+const redactedPlaceholder = "[REDACTED]"
+
+var secretPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`sk-ant-[A-Za-z0-9_\-]{20,}`),
+	regexp.MustCompile(`sk-[A-Za-z0-9_\-]{20,}`),
+	regexp.MustCompile(`gsk_[A-Za-z0-9]{20,}`),
+	regexp.MustCompile(`gh[pousr]_[A-Za-z0-9]{30,}`),
+	regexp.MustCompile(`github_pat_[A-Za-z0-9_]{30,}`),
+	regexp.MustCompile(`xox[abprs]-[A-Za-z0-9\-]{10,}`),
+	regexp.MustCompile(`AKIA[0-9A-Z]{16}`),
+	regexp.MustCompile(`AIza[0-9A-Za-z_\-]{35}`),
+	regexp.MustCompile(`eyJ[A-Za-z0-9_\-]{10,}\.[A-Za-z0-9_\-]{10,}\.[A-Za-z0-9_\-]{10,}`),
+	regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----[\s\S]*?-----END [A-Z ]*PRIVATE KEY-----`),
+	regexp.MustCompile(`(?i)(bearer\s+)[A-Za-z0-9._\-]{16,}`),
+}
+
+var assignmentPattern = regexp.MustCompile(`(?i)\b([A-Z0-9_]*(?:API_KEY|TOKEN|SECRET|PASSWORD|PASSWD)[A-Z0-9_]*)(\s*[=:]\s*)("?)[^\s"']+`)
+
+func Redact(text string) string {
+	for _, pattern := range secretPatterns {
+		text = pattern.ReplaceAllStringFunc(text, func(match string) string {
+			if sub := pattern.FindStringSubmatch(match); len(sub) > 1 {
+				return sub[1] + redactedPlaceholder
+			}
+			return redactedPlaceholder
+		})
+	}
+	text = assignmentPattern.ReplaceAllString(text, "${1}${2}${3}"+redactedPlaceholder)
+
+	for _, value := range secretEnvValues() {
+		text = strings.ReplaceAll(text, value, redactedPlaceholder)
+	}
+	return text
+}
+
+func secretEnvValues() []string {
+	var values []string
+	for _, entry := range os.Environ() {
+		parts := strings.SplitN(entry, "=", 2)
+		if len(parts) != 2 || len(parts[1]) < 8 {
+			continue
+		}
+		name := strings.ToUpper(parts[0])
+		if strings.Contains(name, "KEY") || strings.Contains(name, "TOKEN") || strings.Contains(name, "SECRET") || strings.Contains(name, "PASSWORD") {
+			values = append(values, parts[1])
+		}
+	}
+	return values
+}
@@ -0,0 +1,133 @@
+package share
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+
+	"minimal-go/internal/session"
+	"minimal-go/internal/types"
+)
+
+const gistAPI = "https://api.github.com/gists"
+
+func RenderMarkdown(s *session.Session) string {
+	var b strings.Builder
+	title := s.Name
+	if title == "" {
+		title = s.ID
+	}
+	fmt.Fprintf(&b, "# mini-go session: %s\n\n", title)
+	fmt.Fprintf(&b, "- Model: `%s`\n- Started: %s\n- Messages: %d\n\n", s.Model, s.CreatedAt.Format(time.RFC3339), len(s.Messages))
+
+	for _, message := range s.Messages {
+		switch message.Role {
+		case types.RoleSystem:
+			continue
+		case types.RoleUser:
+			b.WriteString("## User\n\n" + message.Content + "\n\n")
+		case types.RoleAssistant:
+			b.WriteString("## Assistant\n\n")
+			if message.Content != "" {
+				b.WriteString(message.Content + "\n\n")
+			}
+			for _, call := range message.ToolCalls {
+				input, _ := json.MarshalIndent(call.Input, "", "  ")
+				fmt.Fprintf(&b, "**Tool call** `%s`\n\n```json\n%s\n```\n\n", call.Name, input)
+			}
+		case types.RoleTool:
+			b.WriteString("**Tool result**\n\n```\n" + strings.TrimRight(message.Content, "\n") + "\n```\n\n")
+		}
+	}
+
+	return Redact(b.String())
+}
+
+func UploadGist(filename string, content string, description string) (string, error) {
+	token, err := LookupGitHubToken()
+	if err != nil {
+		return "", err
+	}
+
+	payload, err := json.Marshal(map[string]interface{}{
+		"description": description,
+		"public":      false,
+		"files": map[string]interface{}{
+			filename: map[string]string{"content": content},
+		},
+	})
+	if err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, gistAPI, bytes.NewBuffer(payload))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("Content-Type", "application/json")
+
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	var decoded struct {
+		HTMLURL string `json:"html_url"`
+		Message string `json:"message"`
+	}
+	_ = json.Unmarshal(body, &decoded)
+	if resp.StatusCode >= 300 {
+		if decoded.Message != "" {
+			return "", fmt.Errorf("gist upload failed: %s (status %d)", decoded.Message, resp.StatusCode)
+		}
+		return "", fmt.Errorf("gist upload failed with status %d", resp.StatusCode)
+	}
+	return decoded.HTMLURL, nil
+}
+
+func LookupGitHubToken() (string, error) {
+	for _, name := range []string{"MINIMAL_GIST_TOKEN", "GITHUB_TOKEN", "GH_TOKEN"} {
+		if value := strings.TrimSpace(os.Getenv(name)); value != "" {
+			return value, nil
+		}
+	}
+
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("security", "find-generic-password", "-s", "mini-go", "-a", "github", "-w")
+	case "linux":
+		cmd = exec.Command("secret-tool", "lookup", "service", "mini-go", "account", "github")
+	}
+	if cmd != nil {
+		if out, err := cmd.Output(); err == nil {
+			if token := strings.TrimSpace(string(out)); token != "" {
+				return token, nil
+			}
+		}
+	}
+
+	if out, err := exec.Command("gh", "auth", "token").Output(); err == nil {
+		if token := strings.TrimSpace(string(out)); token != "" {
+			return token, nil
+		}
+	}
+
+	return "", errors.New("no GitHub token found (set GITHUB_TOKEN or store one in the keyring under service \"mini-go\", account \"github\")")
+}
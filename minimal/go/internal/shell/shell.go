@@ -0,0 +1,210 @@
+package shell
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const commandTimeout = 30 * time.Second
+
+var ErrTimeout = errors.New("command timed out (30s); the shell was reset")
+
+type Result struct {
+	Stdout string
+	Stderr string
+	Code   int
+	Cwd    string
+}
+
+type Session struct {
+	mu     sync.Mutex
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	stdout *bufio.Reader
+	stderr *bufio.Reader
+}
+
+// Start runs bash in a session of its own, so it has no controlling
+// terminal: nothing it runs can read from or write to the user's tty.
+func Start(dir string) (*Session, error) {
+	if err := supported(); err != nil {
+		return nil, err
+	}
+	cmd := exec.Command("bash", "--noprofile", "--norc", "-s")
+	cmd.Dir = dir
+	cmd.Env = append(ScrubbedEnv(os.Environ()), "HISTFILE=/dev/null", "PS1=", "PS2=", "TERM=dumb")
+	detach(cmd)
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+
+	return &Session{
+		cmd:    cmd,
+		stdin:  stdin,
+		stdout: bufio.NewReader(stdout),
+		stderr: bufio.NewReader(stderr),
+	}, nil
+}
+
+// ScrubbedEnv drops credentials, such as provider API keys and the storage
+// passphrase, and whatever would pull the user's shell setup into bash:
+// BASH_ENV, ENV and exported functions. Commands the model writes run with
+// it.
+func ScrubbedEnv(environ []string) []string {
+	var kept []string
+	for _, entry := range environ {
+		name, _, _ := strings.Cut(entry, "=")
+		upper := strings.ToUpper(name)
+		switch {
+		case upper == "BASH_ENV" || upper == "ENV" || upper == "PROMPT_COMMAND" || strings.HasPrefix(name, "BASH_FUNC_"):
+		case strings.HasPrefix(upper, "MINI_GO_"):
+		case strings.Contains(upper, "KEY") || strings.Contains(upper, "TOKEN") || strings.Contains(upper, "SECRET") ||
+			strings.Contains(upper, "PASSWORD") || strings.Contains(upper, "PASSPHRASE") || strings.Contains(upper, "CREDENTIAL"):
+		default:
+			kept = append(kept, entry)
+		}
+	}
+	return kept
+}
+
+func (s *Session) Run(command string, dir string, env map[string]string) (Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	token := newToken()
+	marker := "__MINIGO_DONE_" + token
+	body := command
+	if dir != "" || len(env) > 0 {
+		body = "(" + scopePrefix(dir, env) + "\n" + command + "\n)"
+	}
+	script := "{\n" + body + "\n} < /dev/null\n" +
+		"__minigo_status=$?\n" +
+		"printf '\\n%s %d %s\\n' '" + marker + "' \"$__minigo_status\" \"$PWD\"\n" +
+		"printf '\\n%s\\n' '" + marker + "' >&2\n"
+	if _, err := io.WriteString(s.stdin, script); err != nil {
+		s.kill()
+		return Result{}, fmt.Errorf("shell is not running: %w", err)
+	}
+
+	type streamResult struct {
+		text string
+		tail string
+		err  error
+	}
+	stdoutCh := make(chan streamResult, 1)
+	stderrCh := make(chan streamResult, 1)
+	go func() {
+		text, tail, err := readUntil(s.stdout, marker)
+		stdoutCh <- streamResult{text, tail, err}
+	}()
+	go func() {
+		text, tail, err := readUntil(s.stderr, marker)
+		stderrCh <- streamResult{text, tail, err}
+	}()
+
+	timeout := time.NewTimer(commandTimeout)
+	defer timeout.Stop()
+	var out, errOut streamResult
+	for received := 0; received < 2; received++ {
+		select {
+		case out = <-stdoutCh:
+		case errOut = <-stderrCh:
+		case <-timeout.C:
+			s.kill()
+			return Result{Code: 124}, ErrTimeout
+		}
+	}
+	if out.err != nil {
+		s.kill()
+		return Result{Stdout: out.text}, fmt.Errorf("shell exited (%w); a fresh shell starts on the next command", out.err)
+	}
+
+	status, cwd, _ := strings.Cut(strings.TrimSpace(out.tail), " ")
+	code, _ := strconv.Atoi(status)
+	return Result{Stdout: out.text, Stderr: errOut.text, Code: code, Cwd: cwd}, nil
+}
+
+func (s *Session) Close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.kill()
+}
+
+func (s *Session) Kill() {
+	if s.cmd.Process != nil {
+		killGroup(s.cmd.Process)
+	}
+}
+
+func (s *Session) kill() {
+	if s.cmd.Process == nil {
+		return
+	}
+	_ = s.stdin.Close()
+	killGroup(s.cmd.Process)
+	go s.cmd.Wait()
+}
+
+func readUntil(reader *bufio.Reader, marker string) (string, string, error) {
+	var builder strings.Builder
+	for {
+		line, err := reader.ReadString('\n')
+		if strings.HasPrefix(line, marker) {
+			return strings.TrimSuffix(builder.String(), "\n"), strings.TrimPrefix(line, marker), nil
+		}
+		builder.WriteString(line)
+		if err != nil {
+			return builder.String(), "", err
+		}
+	}
+}
+
+func scopePrefix(dir string, env map[string]string) string {
+	var parts []string
+	if dir != "" {
+		parts = append(parts, "cd "+Quote(dir)+" || exit $?")
+	}
+	keys := make([]string, 0, len(env))
+	for key := range env {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		parts = append(parts, "export "+key+"="+Quote(env[key]))
+	}
+	return strings.Join(parts, "\n")
+}
+
+func Quote(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+}
+
+func newToken() string {
+	buffer := make([]byte, 8)
+	_, _ = rand.Read(buffer)
+	return hex.EncodeToString(buffer)
+}
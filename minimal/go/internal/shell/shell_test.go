@@ -0,0 +1,55 @@
+package shell
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestScrubbedEnv(t *testing.T) {
+	environ := []string{
+		"PATH=/usr/bin",
+		"HOME=/home/me",
+		"VIRTUAL_ENV=/work/.venv",
+		"OPENAI_API_KEY=sk-test",
+		"GITHUB_TOKEN=ghp_test",
+		"AWS_SECRET_ACCESS_KEY=secret",
+		"MINI_GO_PASSPHRASE=hunter2",
+		"GOOGLE_APPLICATION_CREDENTIALS=/home/me/creds.json",
+		"BASH_ENV=/home/me/.bashrc",
+		"BASH_FUNC_greet%%=() { echo hi; }",
+	}
+	want := []string{"PATH=/usr/bin", "HOME=/home/me", "VIRTUAL_ENV=/work/.venv"}
+	if got := ScrubbedEnv(environ); !reflect.DeepEqual(got, want) {
+		t.Fatalf("ScrubbedEnv = %q, want %q", got, want)
+	}
+}
+
+func TestSessionHidesSecretsAndTerminal(t *testing.T) {
+	t.Setenv("MINI_GO_PASSPHRASE", "hunter2")
+	t.Setenv("ANTHROPIC_API_KEY", "sk-test")
+	session, err := Start(t.TempDir())
+	if err != nil {
+		t.Skip("bash is not available:", err)
+	}
+	defer session.Close()
+
+	result, err := session.Run("env", "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(result.Stdout, "hunter2") || strings.Contains(result.Stdout, "sk-test") {
+		t.Fatalf("secrets reached the shell:\n%s", result.Stdout)
+	}
+	if result, _ = session.Run(": < /dev/tty", "", nil); result.Code == 0 {
+		t.Fatal("the shell could open the user's terminal")
+	}
+
+	result, err = session.Run("export FOO=kept; cd /", "", nil)
+	if err != nil || result.Cwd != "/" {
+		t.Fatalf("Run = %+v, %v", result, err)
+	}
+	if result, _ = session.Run("echo $FOO", "", nil); strings.TrimSpace(result.Stdout) != "kept" {
+		t.Fatalf("environment did not persist: %q", result.Stdout)
+	}
+}
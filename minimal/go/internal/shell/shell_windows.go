@@ -0,0 +1,17 @@
+package shell
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+)
+
+func supported() error {
+	return errors.New("the persistent shell is not supported on Windows; set shell.persistent to false")
+}
+
+func detach(cmd *exec.Cmd) {}
+
+func killGroup(process *os.Process) {
+	_ = process.Kill()
+}
@@ -0,0 +1,122 @@
+package skills
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"minimal-go/internal/config"
+)
+
+const (
+	SkillFile    = "SKILL.md"
+	maxListFiles = 50
+)
+
+var scriptExtensions = map[string]bool{".sh": true, ".bash": true, ".py": true, ".js": true, ".rb": true, ".pl": true}
+
+type Skill struct {
+	Name    string
+	Dir     string
+	Content string
+	Files   []string
+	Scripts []string
+}
+
+func List() []string {
+	entries, err := os.ReadDir(config.SkillsDir)
+	if err != nil {
+		return nil
+	}
+	var names []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() {
+			if _, err := os.Stat(filepath.Join(config.SkillsDir, name, SkillFile)); err == nil {
+				names = append(names, name)
+			}
+			continue
+		}
+		if strings.HasSuffix(name, ".md") {
+			names = append(names, strings.TrimSuffix(name, ".md"))
+		}
+	}
+	return names
+}
+
+func Load(name string) (Skill, error) {
+	if name == "" || name != filepath.Base(name) {
+		return Skill{}, fmt.Errorf("invalid skill name %q", name)
+	}
+	dir := filepath.Join(config.SkillsDir, name)
+	data, err := os.ReadFile(filepath.Join(dir, SkillFile))
+	if errors.Is(err, os.ErrNotExist) {
+		data, err = os.ReadFile(dir + ".md")
+		if err != nil {
+			return Skill{}, err
+		}
+		return Skill{Name: name, Content: string(data)}, nil
+	}
+	if err != nil {
+		return Skill{}, err
+	}
+
+	skill := Skill{Name: name, Dir: dir, Content: string(data)}
+	err = filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			if path != dir && strings.HasPrefix(entry.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		rel, _ := filepath.Rel(dir, path)
+		if rel == SkillFile {
+			return nil
+		}
+		skill.Files = append(skill.Files, rel)
+		if isScript(path, entry) {
+			skill.Scripts = append(skill.Scripts, path)
+		}
+		return nil
+	})
+	sort.Strings(skill.Files)
+	return skill, err
+}
+
+// Prompt is the message sent to the model: the skill text, preceded for
+// directory skills by where its relative paths point.
+func (s Skill) Prompt() string {
+	if s.Dir == "" {
+		return s.Content
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "[Skill %q lives in %s. Relative paths in it refer to that directory; read its files with read_file using the full path.", s.Name, s.Dir)
+	if len(s.Files) > 0 {
+		b.WriteString(" Files:")
+		for i, file := range s.Files {
+			if i == maxListFiles {
+				fmt.Fprintf(&b, " … %d more", len(s.Files)-i)
+				break
+			}
+			b.WriteString(" " + file)
+		}
+	}
+	b.WriteString("]\n\n")
+	b.WriteString(s.Content)
+	return b.String()
+}
+
+func isScript(path string, entry fs.DirEntry) bool {
+	if scriptExtensions[strings.ToLower(filepath.Ext(path))] {
+		return true
+	}
+	info, err := entry.Info()
+	return err == nil && info.Mode()&0o111 != 0
+}
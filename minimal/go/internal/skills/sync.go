@@ -0,0 +1,352 @@
+package skills
+
+import (
+	"archive/tar"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"minimal-go/internal/config"
+)
+
+const (
+	repoCacheDir  = ".repo"
+	syncStateFile = ".sync.json"
+	maxChangeLog  = 10
+)
+
+type Installed struct {
+	Commit string `json:"commit"`
+	Tree   string `json:"tree"`
+	Pin    string `json:"pin,omitempty"`
+}
+
+type SyncState struct {
+	Repo   string               `json:"repo"`
+	Skills map[string]Installed `json:"skills"`
+}
+
+type Change struct {
+	Name string
+	Kind string
+	From string
+	To   string
+	Pin  string
+	Log  []string
+	Note string
+}
+
+const (
+	ChangeAdded     = "added"
+	ChangeUpdated   = "updated"
+	ChangeRemoved   = "removed"
+	ChangeUnchanged = "unchanged"
+	ChangeSkipped   = "skipped"
+)
+
+func LoadSyncState() (SyncState, error) {
+	state := SyncState{Skills: map[string]Installed{}}
+	data, err := os.ReadFile(filepath.Join(config.SkillsDir, syncStateFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return state, nil
+	}
+	if err != nil {
+		return state, err
+	}
+	if err := json.Unmarshal(data, &state); err != nil {
+		return state, err
+	}
+	if state.Skills == nil {
+		state.Skills = map[string]Installed{}
+	}
+	return state, nil
+}
+
+func saveSyncState(state SyncState) error {
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(config.SkillsDir, syncStateFile), append(data, '\n'), 0o644)
+}
+
+func Sync(cfg config.SkillsConfig) ([]Change, error) {
+	if cfg.Repo == "" {
+		return nil, errors.New("skills.repo is not set in config.json")
+	}
+	if err := os.MkdirAll(config.SkillsDir, 0o755); err != nil {
+		return nil, err
+	}
+	cache := filepath.Join(config.SkillsDir, repoCacheDir)
+	if err := updateCache(cache, cfg.Repo); err != nil {
+		return nil, err
+	}
+	head, err := resolveCommit(cache, cfg.Ref)
+	if err != nil {
+		return nil, err
+	}
+	state, err := LoadSyncState()
+	if err != nil {
+		return nil, err
+	}
+	if state.Repo != "" && state.Repo != cfg.Repo {
+		state.Skills = map[string]Installed{}
+	}
+	state.Repo = cfg.Repo
+
+	subdir := strings.Trim(path.Clean(filepath.ToSlash(cfg.Subdir)), "/")
+	if subdir == "." {
+		subdir = ""
+	}
+	available, err := listRemoteSkills(cache, head, subdir)
+	if err != nil {
+		return nil, err
+	}
+
+	var changes []Change
+	for _, name := range sortedNames(available) {
+		source := available[name]
+		commit, pin := head, cfg.Pins[name]
+		if pin != "" {
+			if commit, err = resolveCommit(cache, pin); err != nil {
+				changes = append(changes, Change{Name: name, Kind: ChangeSkipped, Pin: pin, Note: err.Error()})
+				continue
+			}
+			if source, err = skillPath(cache, commit, subdir, name); err != nil {
+				changes = append(changes, Change{Name: name, Kind: ChangeSkipped, Pin: pin, Note: "not present at the pinned version"})
+				continue
+			}
+		}
+		tree, err := git(cache, "rev-parse", commit+":"+source)
+		if err != nil {
+			return changes, err
+		}
+
+		previous, managed := state.Skills[name]
+		change := Change{Name: name, Pin: pin, To: commit}
+		switch {
+		case !managed && localExists(name):
+			changes = append(changes, Change{Name: name, Kind: ChangeSkipped, Note: "a local skill with this name exists; not overwritten"})
+			continue
+		case !managed:
+			change.Kind = ChangeAdded
+		case previous.Tree == tree:
+			change.Kind, change.From = ChangeUnchanged, previous.Commit
+			state.Skills[name] = Installed{Commit: commit, Tree: tree, Pin: pin}
+			changes = append(changes, change)
+			continue
+		default:
+			change.Kind, change.From = ChangeUpdated, previous.Commit
+			change.Log = changeLog(cache, previous.Commit, commit, source)
+		}
+		if err := install(cache, commit, source, name); err != nil {
+			return changes, fmt.Errorf("installing %s: %w", name, err)
+		}
+		state.Skills[name] = Installed{Commit: commit, Tree: tree, Pin: pin}
+		changes = append(changes, change)
+	}
+
+	for _, name := range sortedNames(state.Skills) {
+		if _, ok := available[name]; ok {
+			continue
+		}
+		removeLocal(name)
+		changes = append(changes, Change{Name: name, Kind: ChangeRemoved, From: state.Skills[name].Commit})
+		delete(state.Skills, name)
+	}
+	for name := range cfg.Pins {
+		if _, ok := available[name]; !ok {
+			changes = append(changes, Change{Name: name, Kind: ChangeSkipped, Pin: cfg.Pins[name], Note: "pinned but not in the repository"})
+		}
+	}
+	return changes, saveSyncState(state)
+}
+
+func updateCache(cache string, repo string) error {
+	if _, err := os.Stat(filepath.Join(cache, ".git")); err != nil {
+		_ = os.RemoveAll(cache)
+		_, err := git("", "clone", "--quiet", "--no-checkout", repo, cache)
+		return err
+	}
+	if url, _ := git(cache, "remote", "get-url", "origin"); url != repo {
+		if _, err := git(cache, "remote", "set-url", "origin", repo); err != nil {
+			return err
+		}
+	}
+	_, err := git(cache, "fetch", "--quiet", "--prune", "--tags", "--force", "origin", "+refs/heads/*:refs/remotes/origin/*")
+	return err
+}
+
+func resolveCommit(cache string, ref string) (string, error) {
+	if ref == "" {
+		ref = "HEAD"
+	}
+	for _, candidate := range []string{"origin/" + ref, ref} {
+		if commit, err := git(cache, "rev-parse", "--verify", "--quiet", candidate+"^{commit}"); err == nil {
+			return commit, nil
+		}
+	}
+	return "", fmt.Errorf("unknown ref %q", ref)
+}
+
+func listRemoteSkills(cache string, commit string, subdir string) (map[string]string, error) {
+	treeish := commit
+	if subdir != "" {
+		treeish += ":" + subdir
+	}
+	out, err := git(cache, "ls-tree", treeish)
+	if err != nil {
+		return nil, err
+	}
+	available := map[string]string{}
+	for _, line := range strings.Split(out, "\n") {
+		meta, name, ok := strings.Cut(line, "\t")
+		if !ok || strings.HasPrefix(name, ".") {
+			continue
+		}
+		fields := strings.Fields(meta)
+		if len(fields) < 2 {
+			continue
+		}
+		source := path.Join(subdir, name)
+		switch {
+		case fields[1] == "tree":
+			if _, err := git(cache, "cat-file", "-e", commit+":"+path.Join(source, SkillFile)); err == nil {
+				available[name] = source
+			}
+		case fields[1] == "blob" && strings.HasSuffix(name, ".md") && !strings.EqualFold(name, "README.md"):
+			available[strings.TrimSuffix(name, ".md")] = source
+		}
+	}
+	return available, nil
+}
+
+func skillPath(cache string, commit string, subdir string, name string) (string, error) {
+	available, err := listRemoteSkills(cache, commit, subdir)
+	if err != nil {
+		return "", err
+	}
+	source, ok := available[name]
+	if !ok {
+		return "", fmt.Errorf("%s not found", name)
+	}
+	return source, nil
+}
+
+func changeLog(cache string, from string, to string, source string) []string {
+	out, err := git(cache, "log", "--oneline", "--no-decorate", fmt.Sprintf("-%d", maxChangeLog), from+".."+to, "--", source)
+	if err != nil || out == "" {
+		return nil
+	}
+	return strings.Split(out, "\n")
+}
+
+func install(cache string, commit string, source string, name string) error {
+	cmd := exec.Command("git", "-C", cache, "archive", "--format=tar", commit, "--", source)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	archive, err := cmd.Output()
+	if err != nil {
+		return fmt.Errorf("git archive: %s", strings.TrimSpace(stderr.String()))
+	}
+
+	staging, err := os.MkdirTemp(config.SkillsDir, ".sync-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(staging)
+	prefix := path.Dir(source)
+	reader := tar.NewReader(bytes.NewReader(archive))
+	for {
+		header, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		rel := strings.TrimPrefix(strings.TrimPrefix(header.Name, prefix), "/")
+		if prefix == "." {
+			rel = header.Name
+		}
+		target := filepath.Join(staging, filepath.FromSlash(rel))
+		if !strings.HasPrefix(target, staging+string(filepath.Separator)) {
+			continue
+		}
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, 0o755); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+				return err
+			}
+			file, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, os.FileMode(header.Mode)&0o755|0o644)
+			if err != nil {
+				return err
+			}
+			_, err = io.Copy(file, reader)
+			file.Close()
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	removeLocal(name)
+	base := path.Base(source)
+	destination := filepath.Join(config.SkillsDir, base)
+	if !strings.HasSuffix(base, ".md") {
+		destination = filepath.Join(config.SkillsDir, name)
+	}
+	return os.Rename(filepath.Join(staging, base), destination)
+}
+
+func localExists(name string) bool {
+	for _, candidate := range []string{filepath.Join(config.SkillsDir, name), filepath.Join(config.SkillsDir, name+".md")} {
+		if _, err := os.Stat(candidate); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+func removeLocal(name string) {
+	_ = os.RemoveAll(filepath.Join(config.SkillsDir, name))
+	_ = os.Remove(filepath.Join(config.SkillsDir, name+".md"))
+}
+
+func git(dir string, args ...string) (string, error) {
+	if dir != "" {
+		args = append([]string{"-C", dir}, args...)
+	}
+	cmd := exec.Command("git", args...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if message := strings.TrimSpace(stderr.String()); message != "" {
+			return "", fmt.Errorf("git %s: %s", args[len(args)-1], message)
+		}
+		return "", fmt.Errorf("git %s: %w", strings.Join(args, " "), err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+func sortedNames[T any](m map[string]T) []string {
+	names := make([]string, 0, len(m))
+	for name := range m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
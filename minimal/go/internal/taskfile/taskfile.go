@@ -0,0 +1,96 @@
+package taskfile
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"minimal-go/internal/config"
+)
+
+const (
+	ModeIsolated = "isolated"
+	ModeChained  = "chained"
+
+	ApproveDeny = "deny"
+	ApproveAll  = "all"
+)
+
+type Policy = config.PolicyOverride
+
+type Task struct {
+	Name    string  `json:"name"`
+	Prompt  string  `json:"prompt"`
+	Approve string  `json:"approve"`
+	Policy  *Policy `json:"policy"`
+}
+
+type File struct {
+	Mode        string  `json:"mode"`
+	Approve     string  `json:"approve"`
+	StopOnError bool    `json:"stopOnError"`
+	Policy      *Policy `json:"policy"`
+	Tasks       []Task  `json:"tasks"`
+}
+
+func Load(path string) (File, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return File{}, err
+	}
+
+	var tree interface{}
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		err = json.Unmarshal(data, &tree)
+	} else {
+		tree, err = parseYAML(string(data))
+	}
+	if err != nil {
+		return File{}, fmt.Errorf("%s: %w", path, err)
+	}
+	if list, ok := tree.([]interface{}); ok {
+		tree = map[string]interface{}{"tasks": list}
+	}
+
+	normalized, _ := json.Marshal(tree)
+	decoder := json.NewDecoder(strings.NewReader(string(normalized)))
+	decoder.DisallowUnknownFields()
+	var file File
+	if err := decoder.Decode(&file); err != nil {
+		return File{}, fmt.Errorf("%s: %w", path, err)
+	}
+	return file, file.normalize()
+}
+
+func (f *File) normalize() error {
+	if f.Mode == "" {
+		f.Mode = ModeIsolated
+	}
+	if f.Mode != ModeIsolated && f.Mode != ModeChained {
+		return fmt.Errorf("mode must be %q or %q, got %q", ModeIsolated, ModeChained, f.Mode)
+	}
+	if f.Approve == "" {
+		f.Approve = ApproveDeny
+	}
+	if len(f.Tasks) == 0 {
+		return fmt.Errorf("no tasks defined")
+	}
+	for i := range f.Tasks {
+		task := &f.Tasks[i]
+		if strings.TrimSpace(task.Prompt) == "" {
+			return fmt.Errorf("task %d has no prompt", i+1)
+		}
+		if task.Name == "" {
+			task.Name = fmt.Sprintf("task-%d", i+1)
+		}
+		if task.Approve == "" {
+			task.Approve = f.Approve
+		}
+		if task.Approve != ApproveDeny && task.Approve != ApproveAll {
+			return fmt.Errorf("task %q: approve must be %q or %q, got %q", task.Name, ApproveDeny, ApproveAll, task.Approve)
+		}
+	}
+	return nil
+}
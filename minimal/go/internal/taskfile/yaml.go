@@ -0,0 +1,337 @@
+package taskfile
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type yamlParser struct {
+	lines []string
+	pos   int
+}
+
+func parseYAML(data string) (interface{}, error) {
+	p := &yamlParser{lines: strings.Split(strings.ReplaceAll(data, "\r\n", "\n"), "\n")}
+	p.skipBlank()
+	if p.pos >= len(p.lines) {
+		return map[string]interface{}{}, nil
+	}
+	value, err := p.parseBlock(indentOf(p.lines[p.pos]))
+	if err != nil {
+		return nil, err
+	}
+	p.skipBlank()
+	if p.pos < len(p.lines) {
+		return nil, p.errorf("unexpected indentation")
+	}
+	return value, nil
+}
+
+func (p *yamlParser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("line %d: %s", p.pos+1, fmt.Sprintf(format, args...))
+}
+
+func (p *yamlParser) skipBlank() {
+	for p.pos < len(p.lines) {
+		trimmed := strings.TrimSpace(p.lines[p.pos])
+		if trimmed != "" && !strings.HasPrefix(trimmed, "#") && trimmed != "---" {
+			return
+		}
+		p.pos++
+	}
+}
+
+func (p *yamlParser) current() (int, string, bool) {
+	p.skipBlank()
+	if p.pos >= len(p.lines) {
+		return 0, "", false
+	}
+	line := p.lines[p.pos]
+	if strings.Contains(line[:indentOf(line)], "\t") {
+		return 0, "", false
+	}
+	return indentOf(line), stripComment(strings.TrimSpace(line)), true
+}
+
+func (p *yamlParser) parseBlock(indent int) (interface{}, error) {
+	_, text, ok := p.current()
+	if !ok {
+		return nil, nil
+	}
+	if isListItem(text) {
+		return p.parseList(indent)
+	}
+	return p.parseMap(indent)
+}
+
+func (p *yamlParser) parseList(indent int) ([]interface{}, error) {
+	items := []interface{}{}
+	for {
+		lineIndent, text, ok := p.current()
+		if !ok || lineIndent != indent || !isListItem(text) {
+			return items, nil
+		}
+		rest := strings.TrimSpace(text[1:])
+		switch {
+		case rest == "":
+			p.pos++
+			nextIndent, _, ok := p.current()
+			if !ok || nextIndent <= indent {
+				items = append(items, nil)
+				continue
+			}
+			item, err := p.parseBlock(nextIndent)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, item)
+		case isMapEntry(rest):
+			itemIndent := indent + len(text) - len(strings.TrimLeft(text[1:], " "))
+			p.lines[p.pos] = strings.Repeat(" ", itemIndent) + rest
+			item, err := p.parseMap(itemIndent)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, item)
+		default:
+			value, err := parseScalar(rest)
+			if err != nil {
+				return nil, p.errorf("%v", err)
+			}
+			items = append(items, value)
+			p.pos++
+		}
+	}
+}
+
+func (p *yamlParser) parseMap(indent int) (map[string]interface{}, error) {
+	values := map[string]interface{}{}
+	for {
+		lineIndent, text, ok := p.current()
+		if !ok || lineIndent < indent {
+			return values, nil
+		}
+		if lineIndent > indent {
+			return nil, p.errorf("unexpected indentation")
+		}
+		if isListItem(text) {
+			return nil, p.errorf("unexpected list item")
+		}
+		key, rest, found := splitMapEntry(text)
+		if !found {
+			return nil, p.errorf("expected \"key: value\", got %q", text)
+		}
+		if _, exists := values[key]; exists {
+			return nil, p.errorf("duplicate key %q", key)
+		}
+		p.pos++
+
+		switch {
+		case strings.HasPrefix(rest, "|") || strings.HasPrefix(rest, ">"):
+			values[key] = p.blockScalar(indent, rest)
+		case rest == "":
+			nextIndent, nextText, ok := p.current()
+			switch {
+			case ok && nextIndent > indent:
+				value, err := p.parseBlock(nextIndent)
+				if err != nil {
+					return nil, err
+				}
+				values[key] = value
+			case ok && nextIndent == indent && isListItem(nextText):
+				value, err := p.parseList(indent)
+				if err != nil {
+					return nil, err
+				}
+				values[key] = value
+			default:
+				values[key] = nil
+			}
+		default:
+			value, err := parseScalar(rest)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %v", p.pos, err)
+			}
+			values[key] = value
+		}
+	}
+}
+
+func (p *yamlParser) blockScalar(parentIndent int, header string) string {
+	folded := strings.HasPrefix(header, ">")
+	chomp := strings.TrimSpace(header[1:])
+
+	var lines []string
+	blockIndent := -1
+	for p.pos < len(p.lines) {
+		line := p.lines[p.pos]
+		if strings.TrimSpace(line) == "" {
+			lines = append(lines, "")
+			p.pos++
+			continue
+		}
+		lineIndent := indentOf(line)
+		if lineIndent <= parentIndent {
+			break
+		}
+		if blockIndent < 0 {
+			blockIndent = lineIndent
+		}
+		if lineIndent < blockIndent {
+			break
+		}
+		lines = append(lines, line[blockIndent:])
+		p.pos++
+	}
+	for len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+		p.pos--
+	}
+	p.skipBlank()
+
+	var text string
+	if folded {
+		var b strings.Builder
+		for i, line := range lines {
+			switch {
+			case i == 0:
+			case line == "" || lines[i-1] == "":
+				b.WriteString("\n")
+			default:
+				b.WriteString(" ")
+			}
+			b.WriteString(line)
+		}
+		text = b.String()
+	} else {
+		text = strings.Join(lines, "\n")
+	}
+	if chomp != "-" && text != "" {
+		text += "\n"
+	}
+	return text
+}
+
+func parseScalar(text string) (interface{}, error) {
+	switch {
+	case strings.HasPrefix(text, `"`):
+		value, err := strconv.Unquote(text)
+		if err != nil {
+			return nil, fmt.Errorf("invalid double-quoted string %s", text)
+		}
+		return value, nil
+	case strings.HasPrefix(text, "'"):
+		if len(text) < 2 || !strings.HasSuffix(text, "'") {
+			return nil, fmt.Errorf("unterminated single-quoted string %s", text)
+		}
+		return strings.ReplaceAll(text[1:len(text)-1], "''", "'"), nil
+	case strings.HasPrefix(text, "["):
+		if !strings.HasSuffix(text, "]") {
+			return nil, fmt.Errorf("unterminated list %s", text)
+		}
+		items := []interface{}{}
+		for _, part := range splitFlow(text[1 : len(text)-1]) {
+			value, err := parseScalar(part)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, value)
+		}
+		return items, nil
+	case text == "{}":
+		return map[string]interface{}{}, nil
+	case text == "true", text == "True", text == "yes":
+		return true, nil
+	case text == "false", text == "False", text == "no":
+		return false, nil
+	case text == "null", text == "~":
+		return nil, nil
+	}
+	if number, err := strconv.ParseFloat(text, 64); err == nil {
+		return number, nil
+	}
+	return text, nil
+}
+
+func splitFlow(text string) []string {
+	var parts []string
+	var quote rune
+	start := 0
+	for i, r := range text {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '"' || r == '\'':
+			quote = r
+		case r == ',':
+			parts = append(parts, strings.TrimSpace(text[start:i]))
+			start = i + 1
+		}
+	}
+	if last := strings.TrimSpace(text[start:]); last != "" || len(parts) > 0 {
+		parts = append(parts, last)
+	}
+	return parts
+}
+
+func splitMapEntry(text string) (string, string, bool) {
+	var quote rune
+	for i, r := range text {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case (r == '"' || r == '\'') && i == 0:
+			quote = r
+		case r == ':' && (i == len(text)-1 || text[i+1] == ' '):
+			key := strings.TrimSpace(text[:i])
+			if unquoted, err := parseScalar(key); err == nil {
+				if s, ok := unquoted.(string); ok {
+					key = s
+				}
+			}
+			return key, strings.TrimSpace(text[i+1:]), key != ""
+		}
+	}
+	return "", "", false
+}
+
+func stripComment(text string) string {
+	var quote rune
+	for i, r := range text {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '"' || r == '\'':
+			if i == 0 || text[i-1] == ' ' || text[i-1] == '[' || text[i-1] == ',' {
+				quote = r
+			}
+		case r == '#' && (i == 0 || text[i-1] == ' '):
+			return strings.TrimSpace(text[:i])
+		}
+	}
+	return text
+}
+
+func isListItem(text string) bool {
+	return text == "-" || strings.HasPrefix(text, "- ")
+}
+
+func isMapEntry(text string) bool {
+	if strings.HasPrefix(text, `"`) || strings.HasPrefix(text, "'") || strings.HasPrefix(text, "[") {
+		_, _, found := splitMapEntry(text)
+		return found && !strings.HasPrefix(text, "[")
+	}
+	_, _, found := splitMapEntry(text)
+	return found
+}
+
+func indentOf(line string) int {
+	return len(line) - len(strings.TrimLeft(line, " \t"))
+}
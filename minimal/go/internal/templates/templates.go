@@ -0,0 +1,128 @@
+package templates
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+
+	"minimal-go/internal/config"
+)
+
+//go:embed all:builtin
+var builtinFS embed.FS
+
+type Data struct {
+	Name string
+}
+
+type Result struct {
+	Created []string
+	Skipped []string
+}
+
+func List() []string {
+	names := map[string]bool{}
+	if entries, err := fs.ReadDir(builtinFS, "builtin"); err == nil {
+		for _, entry := range entries {
+			if entry.IsDir() {
+				names[entry.Name()] = true
+			}
+		}
+	}
+	if entries, err := os.ReadDir(config.TemplatesDir); err == nil {
+		for _, entry := range entries {
+			if entry.IsDir() {
+				names[entry.Name()] = true
+			}
+		}
+	}
+
+	list := make([]string, 0, len(names))
+	for name := range names {
+		list = append(list, name)
+	}
+	sort.Strings(list)
+	return list
+}
+
+func source(name string) (fs.FS, error) {
+	userDir := filepath.Join(config.TemplatesDir, filepath.Base(name))
+	if info, err := os.Stat(userDir); err == nil && info.IsDir() {
+		return os.DirFS(userDir), nil
+	}
+	sub, err := fs.Sub(builtinFS, "builtin/"+name)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := fs.Stat(sub, "."); err != nil {
+		return nil, fmt.Errorf("unknown template: %s", name)
+	}
+	return sub, nil
+}
+
+func Scaffold(name string, targetDir string) (Result, error) {
+	src, err := source(name)
+	if err != nil {
+		return Result{}, err
+	}
+
+	absTarget, err := filepath.Abs(targetDir)
+	if err != nil {
+		return Result{}, err
+	}
+	data := Data{Name: filepath.Base(absTarget)}
+
+	var result Result
+	err = fs.WalkDir(src, ".", func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			return nil
+		}
+
+		relative := strings.TrimSuffix(path, ".tmpl")
+		destination := filepath.Join(absTarget, filepath.FromSlash(relative))
+		if _, err := os.Stat(destination); err == nil {
+			result.Skipped = append(result.Skipped, relative)
+			return nil
+		} else if !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+
+		raw, err := fs.ReadFile(src, path)
+		if err != nil {
+			return err
+		}
+		tmpl, err := template.New(path).Option("missingkey=error").Parse(string(raw))
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		var rendered strings.Builder
+		if err := tmpl.Execute(&rendered, data); err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+
+		if err := os.MkdirAll(filepath.Dir(destination), 0o755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(destination, []byte(rendered.String()), 0o644); err != nil {
+			return err
+		}
+		result.Created = append(result.Created, relative)
+		return nil
+	})
+	if err != nil {
+		return result, err
+	}
+	if len(result.Created) == 0 && len(result.Skipped) == 0 {
+		return result, fmt.Errorf("template %s is empty", name)
+	}
+	return result, nil
+}
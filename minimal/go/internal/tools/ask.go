@@ -0,0 +1,27 @@
+package tools
+
+import "minimal-go/internal/types"
+
+var AskUserTool = types.Tool{
+	Name:        "ask_user",
+	Description: "Ask the user a clarifying question and wait for the answer. Use it when a decision is genuinely theirs (conflicting requirements, a choice between designs, missing information you cannot find in the workspace) instead of ending your turn with a question. Offer options when the likely answers are known.",
+	InputSchema: map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"question": map[string]interface{}{
+				"type":        "string",
+				"description": "The question, in one or two sentences.",
+			},
+			"options": map[string]interface{}{
+				"type":        "array",
+				"items":       map[string]interface{}{"type": "string"},
+				"description": "Up to 9 short answers for the user to pick from, best first.",
+			},
+			"allow_other": map[string]interface{}{
+				"type":        "boolean",
+				"description": "Whether the user may type an answer that is not one of the options (default true).",
+			},
+		},
+		"required": []string{"question"},
+	},
+}
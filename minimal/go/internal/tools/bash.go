@@ -4,7 +4,7 @@ import "minimal-go/internal/types"
 
 var BashTool = types.Tool{
 	Name:        "bash",
-	Description: "Execute a shell command in the workspace.",
+	Description: "Execute a shell command in the workspace. Each call runs in a fresh shell; a leading `cd <dir>` (alone or followed by &&) changes the current directory for later calls.",
 	InputSchema: map[string]interface{}{
 		"type": "object",
 		"properties": map[string]interface{}{
@@ -12,6 +12,15 @@ var BashTool = types.Tool{
 				"type":        "string",
 				"description": "Shell command to run.",
 			},
+			"cwd": map[string]interface{}{
+				"type":        "string",
+				"description": "Directory to run in, relative to the current directory. Must stay inside the workspace.",
+			},
+			"env": map[string]interface{}{
+				"type":                 "object",
+				"description":          "Extra environment variables for this command.",
+				"additionalProperties": map[string]interface{}{"type": "string"},
+			},
 		},
 		"required": []string{"command"},
 	},
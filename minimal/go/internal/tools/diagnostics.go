@@ -0,0 +1,18 @@
+package tools
+
+import "minimal-go/internal/types"
+
+var DiagnosticsTool = types.Tool{
+	Name:        "diagnostics",
+	Description: "Run the project's compiler/linter checks (go vet or gopls, ruff or py_compile, tsc, cargo check, or configured commands) on files and return structured errors. Defaults to the files you edited since the last check.",
+	InputSchema: map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"files": map[string]interface{}{
+				"type":        "array",
+				"items":       map[string]interface{}{"type": "string"},
+				"description": "Files to check, relative to the workspace root. Omit to check recently edited files.",
+			},
+		},
+	},
+}
@@ -0,0 +1,49 @@
+package tools
+
+import "minimal-go/internal/types"
+
+var WriteFileTool = types.Tool{
+	Name:        "write_file",
+	Description: "Create or overwrite a file in the workspace with the given content. The user reviews a diff before it is written.",
+	InputSchema: map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"path": map[string]interface{}{
+				"type":        "string",
+				"description": "File path, relative to the workspace root.",
+			},
+			"content": map[string]interface{}{
+				"type":        "string",
+				"description": "Complete new file content.",
+			},
+		},
+		"required": []string{"path", "content"},
+	},
+}
+
+var EditFileTool = types.Tool{
+	Name:        "edit_file",
+	Description: "Replace an exact snippet of an existing file. old_string must match exactly once unless replace_all is true. The user reviews a diff before it is written.",
+	InputSchema: map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"path": map[string]interface{}{
+				"type":        "string",
+				"description": "File path, relative to the workspace root.",
+			},
+			"old_string": map[string]interface{}{
+				"type":        "string",
+				"description": "Exact text to replace, including whitespace.",
+			},
+			"new_string": map[string]interface{}{
+				"type":        "string",
+				"description": "Replacement text.",
+			},
+			"replace_all": map[string]interface{}{
+				"type":        "boolean",
+				"description": "Replace every occurrence instead of exactly one.",
+			},
+		},
+		"required": []string{"path", "old_string", "new_string"},
+	},
+}
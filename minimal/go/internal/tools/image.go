@@ -0,0 +1,18 @@
+package tools
+
+import "minimal-go/internal/types"
+
+var ViewImageTool = types.Tool{
+	Name:        "view_image",
+	Description: "Look at an image file in the workspace (png, jpeg, gif or webp), e.g. a screenshot or rendered chart. The image is attached to the tool result.",
+	InputSchema: map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"path": map[string]interface{}{
+				"type":        "string",
+				"description": "Image path, relative to the workspace root.",
+			},
+		},
+		"required": []string{"path"},
+	},
+}
@@ -0,0 +1,26 @@
+package tools
+
+import "minimal-go/internal/types"
+
+var ReadFileTool = types.Tool{
+	Name:        "read_file",
+	Description: "Read lines from a text file in the workspace. Use offset and limit to page through large files, such as attachments the user's message points to.",
+	InputSchema: map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"path": map[string]interface{}{
+				"type":        "string",
+				"description": "File path, relative to the workspace root.",
+			},
+			"offset": map[string]interface{}{
+				"type":        "integer",
+				"description": "First line to read, starting at 1 (default 1).",
+			},
+			"limit": map[string]interface{}{
+				"type":        "integer",
+				"description": "Maximum number of lines to return (default 400).",
+			},
+		},
+		"required": []string{"path"},
+	},
+}
@@ -0,0 +1,18 @@
+package tools
+
+import "minimal-go/internal/types"
+
+var RememberTool = types.Tool{
+	Name:        "remember",
+	Description: "Save a durable fact (project convention, user preference) to long-term memory so it is available in future sessions.",
+	InputSchema: map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"fact": map[string]interface{}{
+				"type":        "string",
+				"description": "One concise sentence to remember.",
+			},
+		},
+		"required": []string{"fact"},
+	},
+}
@@ -0,0 +1,26 @@
+package tools
+
+import "minimal-go/internal/types"
+
+var SearchCodeTool = types.Tool{
+	Name:        "search_code",
+	Description: "Search the workspace index for code by symbol name or topic and return the best matching snippets, ranked. Prefer it over repeated grep calls when you do not know where something lives; use grep for exact strings.",
+	InputSchema: map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"query": map[string]interface{}{
+				"type":        "string",
+				"description": "Identifier or words to look for, e.g. \"parseToolInput\" or \"retry backoff\".",
+			},
+			"path": map[string]interface{}{
+				"type":        "string",
+				"description": "Directory or glob to search within, relative to the workspace root (default: everything).",
+			},
+			"limit": map[string]interface{}{
+				"type":        "integer",
+				"description": "Maximum number of results (default 8).",
+			},
+		},
+		"required": []string{"query"},
+	},
+}
@@ -0,0 +1,12 @@
+package tools
+
+import "minimal-go/internal/types"
+
+var ResetShellTool = types.Tool{
+	Name:        "reset_shell",
+	Description: "Restart the persistent shell, discarding environment variables, activated virtualenvs, functions and the current directory.",
+	InputSchema: map[string]interface{}{
+		"type":       "object",
+		"properties": map[string]interface{}{},
+	},
+}
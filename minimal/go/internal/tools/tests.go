@@ -0,0 +1,21 @@
+package tools
+
+import "minimal-go/internal/types"
+
+var RunTestsTool = types.Tool{
+	Name:        "run_tests",
+	Description: "Run the project's test suite (go test, npm test, pytest or cargo test, detected from the workspace) and return pass/fail counts with the names and output of failing tests. Prefer this over running tests through bash.",
+	InputSchema: map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"path": map[string]interface{}{
+				"type":        "string",
+				"description": "Directory or package to test, relative to the workspace root. Defaults to the whole project.",
+			},
+			"filter": map[string]interface{}{
+				"type":        "string",
+				"description": "Only run tests matching this name or pattern.",
+			},
+		},
+	},
+}
@@ -13,6 +13,9 @@ type ToolCall struct {
 	ID    string      `json:"id"`
 	Name  string      `json:"name"`
 	Input interface{} `json:"input"`
+	// ThoughtSignature is Gemini's opaque record of the reasoning behind the
+	// call; it must be sent back with the call on the next request.
+	ThoughtSignature string `json:"thoughtSignature,omitempty"`
 }
 
 type Message struct {
@@ -21,6 +24,13 @@ type Message struct {
 	Thinking   string     `json:"thinking,omitempty"`
 	ToolCallID string     `json:"toolCallId,omitempty"`
 	ToolCalls  []ToolCall `json:"toolCalls,omitempty"`
+	Images     []Image    `json:"images,omitempty"`
+}
+
+type Image struct {
+	Path      string `json:"path,omitempty"`
+	MediaType string `json:"mediaType"`
+	Data      string `json:"data"`
 }
 
 type Tool struct {
@@ -33,4 +43,5 @@ type Usage struct {
 	PromptTokens     int `json:"promptTokens"`
 	CompletionTokens int `json:"completionTokens"`
 	TotalTokens      int `json:"totalTokens"`
+	CacheReadTokens  int `json:"cacheReadTokens,omitempty"`
 }
@@ -1,6 +1,6 @@
 package ui
 
-import "fmt"
+import "os"
 
 const (
 	colorReset   = "\033[0m"
@@ -13,34 +13,72 @@ const (
 	colorGray    = "\033[90m"
 )
 
+type Theme struct {
+	Enabled bool
+	Styles  map[Role]string
+}
+
+var currentTheme = Theme{Enabled: true, Styles: defaultStyles()}
+
+func SetTheme(theme Theme) {
+	if theme.Styles == nil {
+		theme.Styles = defaultStyles()
+	}
+	currentTheme = theme
+}
+
+func CurrentTheme() Theme {
+	return currentTheme
+}
+
+func ColorEnabled(noColorFlag bool) bool {
+	if noColorFlag {
+		return false
+	}
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	if force := os.Getenv("CLICOLOR_FORCE"); force != "" && force != "0" {
+		return true
+	}
+	return IsTerminal(os.Stdout)
+}
+
+func (t Theme) paint(code string, text string) string {
+	if !t.Enabled {
+		return text
+	}
+	return code + text + colorReset
+}
+
 func Red(text string) string {
-	return colorRed + text + colorReset
+	return currentTheme.paint(colorRed, text)
 }
 
 func Green(text string) string {
-	return colorGreen + text + colorReset
+	return currentTheme.paint(colorGreen, text)
 }
 
 func Yellow(text string) string {
-	return colorYellow + text + colorReset
+	return currentTheme.paint(colorYellow, text)
 }
 
 func Magenta(text string) string {
-	return colorMagenta + text + colorReset
+	return currentTheme.paint(colorMagenta, text)
 }
 
 func Cyan(text string) string {
-	return colorCyan + text + colorReset
+	return currentTheme.paint(colorCyan, text)
 }
 
 func Gray(text string) string {
-	return colorGray + text + colorReset
+	return currentTheme.paint(colorGray, text)
 }
 
 func Bold(text string) string {
-	return colorBold + text + colorReset
+	return currentTheme.paint(colorBold, text)
 }
 
 func BoldCyan(text string) string {
-	return fmt.Sprintf("%s%s%s", colorBold+colorCyan, text, colorReset)
+	return currentTheme.paint(colorBold+colorCyan, text)
 }
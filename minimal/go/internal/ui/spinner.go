@@ -0,0 +1,61 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+var spinnerFrames = []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
+
+const spinnerInterval = 100 * time.Millisecond
+
+type Spinner struct {
+	label   string
+	enabled bool
+	stop    chan struct{}
+	done    sync.WaitGroup
+	once    sync.Once
+}
+
+func IsTerminal(file *os.File) bool {
+	info, err := file.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+func StartSpinner(label string) *Spinner {
+	s := &Spinner{label: label, enabled: IsTerminal(os.Stdout), stop: make(chan struct{})}
+	if !s.enabled {
+		return s
+	}
+
+	started := time.Now()
+	s.done.Add(1)
+	go func() {
+		defer s.done.Done()
+		ticker := time.NewTicker(spinnerInterval)
+		defer ticker.Stop()
+		for frame := 0; ; frame++ {
+			elapsed := time.Since(started).Seconds()
+			fmt.Fprintf(os.Stdout, "\r\033[K%s", Muted(fmt.Sprintf("%s %s %.1fs", spinnerFrames[frame%len(spinnerFrames)], s.label, elapsed)))
+			select {
+			case <-s.stop:
+				fmt.Fprint(os.Stdout, "\r\033[K")
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	return s
+}
+
+func (s *Spinner) Stop() {
+	s.once.Do(func() {
+		close(s.stop)
+		s.done.Wait()
+	})
+}
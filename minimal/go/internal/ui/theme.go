@@ -0,0 +1,216 @@
+package ui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+type Role string
+
+const (
+	RoleError    Role = "error"
+	RoleSuccess  Role = "success"
+	RoleWarning  Role = "warning"
+	RoleDebug    Role = "debug"
+	RoleThinking Role = "thinking"
+	RolePrompt   Role = "prompt"
+	RoleMuted    Role = "muted"
+	RoleAccent   Role = "accent"
+	RoleDiffAdd  Role = "diff_add"
+	RoleDiffDel  Role = "diff_del"
+)
+
+var styleCodes = map[string]string{
+	"bold":           "1",
+	"dim":            "2",
+	"italic":         "3",
+	"underline":      "4",
+	"black":          "30",
+	"red":            "31",
+	"green":          "32",
+	"yellow":         "33",
+	"blue":           "34",
+	"magenta":        "35",
+	"cyan":           "36",
+	"white":          "37",
+	"gray":           "90",
+	"grey":           "90",
+	"bright-red":     "91",
+	"bright-green":   "92",
+	"bright-yellow":  "93",
+	"bright-blue":    "94",
+	"bright-magenta": "95",
+	"bright-cyan":    "96",
+	"bright-white":   "97",
+	"none":           "",
+}
+
+var presets = map[string]map[Role]string{
+	"default": {
+		RoleError:    "red",
+		RoleSuccess:  "green",
+		RoleWarning:  "yellow",
+		RoleDebug:    "magenta",
+		RoleThinking: "gray",
+		RolePrompt:   "cyan",
+		RoleMuted:    "gray",
+		RoleAccent:   "bold cyan",
+		RoleDiffAdd:  "green",
+		RoleDiffDel:  "red",
+	},
+	"light": {
+		RoleError:    "red",
+		RoleSuccess:  "green",
+		RoleWarning:  "blue",
+		RoleDebug:    "magenta",
+		RoleThinking: "dim",
+		RolePrompt:   "blue",
+		RoleMuted:    "dim",
+		RoleAccent:   "bold blue",
+		RoleDiffAdd:  "green",
+		RoleDiffDel:  "red",
+	},
+	"high-contrast": {
+		RoleError:    "bold bright-red",
+		RoleSuccess:  "bold bright-green",
+		RoleWarning:  "bold bright-yellow",
+		RoleDebug:    "bold bright-magenta",
+		RoleThinking: "bright-white",
+		RolePrompt:   "bold bright-cyan",
+		RoleMuted:    "white",
+		RoleAccent:   "bold underline bright-cyan",
+		RoleDiffAdd:  "bright-green",
+		RoleDiffDel:  "bright-red",
+	},
+	"monochrome": {
+		RoleError:    "bold",
+		RoleSuccess:  "none",
+		RoleWarning:  "bold",
+		RoleDebug:    "dim",
+		RoleThinking: "dim",
+		RolePrompt:   "bold",
+		RoleMuted:    "dim",
+		RoleAccent:   "bold",
+		RoleDiffAdd:  "bold",
+		RoleDiffDel:  "dim",
+	},
+}
+
+func Presets() []string {
+	names := make([]string, 0, len(presets))
+	for name := range presets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func ParseStyle(spec string) (string, error) {
+	var codes []string
+	for _, word := range strings.Fields(strings.ToLower(spec)) {
+		code, ok := styleCodes[word]
+		if !ok {
+			return "", fmt.Errorf("unknown style %q", word)
+		}
+		if code != "" {
+			codes = append(codes, code)
+		}
+	}
+	if len(codes) == 0 {
+		return "", nil
+	}
+	return "\033[" + strings.Join(codes, ";") + "m", nil
+}
+
+func BuildTheme(enabled bool, preset string, overrides map[string]string) (Theme, error) {
+	if preset == "" {
+		preset = "default"
+	}
+	base, ok := presets[preset]
+	if !ok {
+		return Theme{Enabled: enabled, Styles: defaultStyles()}, fmt.Errorf("unknown theme preset %q (available: %s)", preset, strings.Join(Presets(), ", "))
+	}
+
+	theme := Theme{Enabled: enabled, Styles: map[Role]string{}}
+	for role, spec := range base {
+		code, _ := ParseStyle(spec)
+		theme.Styles[role] = code
+	}
+
+	var problems []string
+	for role, spec := range overrides {
+		if _, known := base[Role(role)]; !known {
+			problems = append(problems, fmt.Sprintf("unknown role %q", role))
+			continue
+		}
+		code, err := ParseStyle(spec)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %v", role, err))
+			continue
+		}
+		theme.Styles[Role(role)] = code
+	}
+	if len(problems) > 0 {
+		sort.Strings(problems)
+		return theme, fmt.Errorf("ui.theme: %s", strings.Join(problems, "; "))
+	}
+	return theme, nil
+}
+
+func defaultStyles() map[Role]string {
+	styles := map[Role]string{}
+	for role, spec := range presets["default"] {
+		code, _ := ParseStyle(spec)
+		styles[role] = code
+	}
+	return styles
+}
+
+func Style(role Role, text string) string {
+	code := currentTheme.Styles[role]
+	if code == "" {
+		return text
+	}
+	return currentTheme.paint(code, text)
+}
+
+func Error(text string) string {
+	return Style(RoleError, text)
+}
+
+func Success(text string) string {
+	return Style(RoleSuccess, text)
+}
+
+func Warning(text string) string {
+	return Style(RoleWarning, text)
+}
+
+func Debug(text string) string {
+	return Style(RoleDebug, text)
+}
+
+func Thinking(text string) string {
+	return Style(RoleThinking, text)
+}
+
+func Prompt(text string) string {
+	return Style(RolePrompt, text)
+}
+
+func Muted(text string) string {
+	return Style(RoleMuted, text)
+}
+
+func Accent(text string) string {
+	return Style(RoleAccent, text)
+}
+
+func DiffAdd(text string) string {
+	return Style(RoleDiffAdd, text)
+}
+
+func DiffDel(text string) string {
+	return Style(RoleDiffDel, text)
+}
@@ -0,0 +1,148 @@
+package usage
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"minimal-go/internal/config"
+)
+
+const retainDays = 90
+
+type Totals struct {
+	Tokens int     `json:"tokens"`
+	Cost   float64 `json:"cost"`
+}
+
+// Entry breaks a day's totals down by model and project (workspace path).
+// Days recorded before entries existed only have totals.
+type Entry struct {
+	Day     string `json:"day"`
+	Model   string `json:"model"`
+	Project string `json:"project"`
+	Totals
+}
+
+type ledger struct {
+	Days    map[string]Totals `json:"days"`
+	Entries []Entry           `json:"entries,omitempty"`
+}
+
+func Today() (Totals, error) {
+	current, err := load()
+	if err != nil {
+		return Totals{}, err
+	}
+	return current.Days[dayKey(time.Now())], nil
+}
+
+func Record(model string, project string, tokens int, cost float64) error {
+	current, err := load()
+	if err != nil {
+		return err
+	}
+	key := dayKey(time.Now())
+	totals := current.Days[key]
+	totals.Tokens += tokens
+	totals.Cost += cost
+	current.Days[key] = totals
+
+	found := false
+	for i, entry := range current.Entries {
+		if entry.Day == key && entry.Model == model && entry.Project == project {
+			current.Entries[i].Tokens += tokens
+			current.Entries[i].Cost += cost
+			found = true
+			break
+		}
+	}
+	if !found {
+		current.Entries = append(current.Entries, Entry{Day: key, Model: model, Project: project, Totals: Totals{Tokens: tokens, Cost: cost}})
+	}
+	prune(&current)
+	return save(current)
+}
+
+// Since returns the per-day totals and entries from day (YYYY-MM-DD) on.
+func Since(day string) (map[string]Totals, []Entry, error) {
+	current, err := load()
+	if err != nil {
+		return nil, nil, err
+	}
+	days := map[string]Totals{}
+	for key, totals := range current.Days {
+		if key >= day {
+			days[key] = totals
+		}
+	}
+	var entries []Entry
+	for _, entry := range current.Entries {
+		if entry.Day >= day {
+			entries = append(entries, entry)
+		}
+	}
+	return days, entries, nil
+}
+
+func load() (ledger, error) {
+	current := ledger{Days: map[string]Totals{}}
+	data, err := os.ReadFile(config.UsagePath)
+	if errors.Is(err, os.ErrNotExist) {
+		return current, nil
+	}
+	if err != nil {
+		return current, err
+	}
+	if err := json.Unmarshal(data, &current); err != nil {
+		return ledger{Days: map[string]Totals{}}, err
+	}
+	if current.Days == nil {
+		current.Days = map[string]Totals{}
+	}
+	return current, nil
+}
+
+func save(current ledger) error {
+	data, err := json.MarshalIndent(current, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(config.UsagePath), 0o755); err != nil {
+		return err
+	}
+	tmp := config.UsagePath + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, config.UsagePath)
+}
+
+func prune(current *ledger) {
+	keys := make([]string, 0, len(current.Days))
+	for key := range current.Days {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for len(keys) > retainDays {
+		delete(current.Days, keys[0])
+		keys = keys[1:]
+	}
+	if len(keys) == 0 {
+		return
+	}
+	kept := current.Entries[:0]
+	for _, entry := range current.Entries {
+		if entry.Day >= keys[0] {
+			kept = append(kept, entry)
+		}
+	}
+	current.Entries = kept
+}
+
+func dayKey(t time.Time) string {
+	return t.Format("2006-01-02")
+}
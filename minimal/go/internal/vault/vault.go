@@ -0,0 +1,388 @@
+package vault
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"sync"
+
+	"minimal-go/internal/config"
+)
+
+// Encrypted files start with fileMagic and encrypted lines of append-only
+// logs with linePrefix, so plaintext written before encryption was turned
+// on keeps loading and is encrypted on its next save.
+const (
+	fileMagic       = "mini-go:vault:v1\n"
+	linePrefix      = "vault:v1:"
+	passphraseEnv   = "MINI_GO_PASSPHRASE"
+	keychainService = "mini-go"
+	keychainAccount = "storage"
+	kdfIterations   = 210000
+	checkPlaintext  = "mini-go vault"
+)
+
+var ErrLocked = errors.New("encrypted storage is locked")
+
+type vaultFile struct {
+	Salt  string `json:"salt"`
+	Check string `json:"check"`
+}
+
+var state struct {
+	mu      sync.Mutex
+	aead    cipher.AEAD
+	enabled bool
+}
+
+// Enable encrypts everything written from now on. The key is resolved here
+// so that a missing keychain entry or a wrong passphrase is reported at
+// startup rather than at the first save.
+func Enable(source string) error {
+	state.mu.Lock()
+	defer state.mu.Unlock()
+	if state.aead == nil {
+		aead, err := unlock(source, true)
+		if err != nil {
+			return err
+		}
+		state.aead = aead
+	}
+	state.enabled = true
+	return nil
+}
+
+func Enabled() bool {
+	state.mu.Lock()
+	defer state.mu.Unlock()
+	return state.enabled
+}
+
+// current returns the key, resolving it on first use for commands that
+// read encrypted files without loading the config.
+func current() (cipher.AEAD, error) {
+	state.mu.Lock()
+	defer state.mu.Unlock()
+	if state.aead == nil {
+		aead, err := unlock("", false)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrLocked, err)
+		}
+		state.aead = aead
+	}
+	return state.aead, nil
+}
+
+func Seal(plaintext []byte) ([]byte, error) {
+	if !Enabled() {
+		return plaintext, nil
+	}
+	aead, err := current()
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(fileMagic), seal(aead, plaintext)...), nil
+}
+
+func Open(data []byte) ([]byte, error) {
+	if !bytes.HasPrefix(data, []byte(fileMagic)) {
+		return data, nil
+	}
+	aead, err := current()
+	if err != nil {
+		return nil, err
+	}
+	return open(aead, data[len(fileMagic):])
+}
+
+// SealLine encrypts one line of an append-only log; the result contains no
+// newline.
+func SealLine(line []byte) ([]byte, error) {
+	if !Enabled() {
+		return line, nil
+	}
+	aead, err := current()
+	if err != nil {
+		return nil, err
+	}
+	return []byte(linePrefix + base64.StdEncoding.EncodeToString(seal(aead, line))), nil
+}
+
+func OpenLine(line []byte) ([]byte, error) {
+	if !bytes.HasPrefix(line, []byte(linePrefix)) {
+		return line, nil
+	}
+	sealed, err := base64.StdEncoding.DecodeString(string(line[len(linePrefix):]))
+	if err != nil {
+		return nil, err
+	}
+	aead, err := current()
+	if err != nil {
+		return nil, err
+	}
+	return open(aead, sealed)
+}
+
+func ReadFile(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Open(data)
+}
+
+func WriteFile(path string, data []byte, perm os.FileMode) error {
+	sealed, err := Seal(data)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, sealed, perm)
+}
+
+func seal(aead cipher.AEAD, plaintext []byte) []byte {
+	nonce := make([]byte, aead.NonceSize())
+	_, _ = rand.Read(nonce)
+	return aead.Seal(nonce, nonce, plaintext, nil)
+}
+
+func open(aead cipher.AEAD, sealed []byte) ([]byte, error) {
+	if len(sealed) < aead.NonceSize() {
+		return nil, errors.New("encrypted data is truncated")
+	}
+	plaintext, err := aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], nil)
+	if err != nil {
+		return nil, errors.New("cannot decrypt: wrong key or corrupted data")
+	}
+	return plaintext, nil
+}
+
+// unlock derives the key from the secret and the salt in ~/.minimal/vault.json.
+// Setting up (create) writes that file on first use, together with a check
+// value that later catches a mistyped passphrase before anything is written
+// under the wrong key.
+func unlock(source string, create bool) (cipher.AEAD, error) {
+	var file vaultFile
+	data, err := os.ReadFile(config.VaultPath)
+	exists := err == nil
+	if exists {
+		if err := json.Unmarshal(data, &file); err != nil {
+			return nil, fmt.Errorf("%s: %w", config.VaultPath, err)
+		}
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	} else if !create {
+		return nil, errors.New(config.VaultPath + " is missing")
+	}
+
+	secret, err := resolveSecret(source, create && !exists)
+	if err != nil {
+		return nil, err
+	}
+
+	var salt []byte
+	if exists {
+		if salt, err = base64.StdEncoding.DecodeString(file.Salt); err != nil {
+			return nil, fmt.Errorf("%s: bad salt: %w", config.VaultPath, err)
+		}
+	} else {
+		salt = make([]byte, 16)
+		_, _ = rand.Read(salt)
+	}
+	block, err := aes.NewCipher(pbkdf2SHA256([]byte(secret), salt, kdfIterations, 32))
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	if exists {
+		check, err := base64.StdEncoding.DecodeString(file.Check)
+		if err != nil {
+			return nil, fmt.Errorf("%s: bad check value: %w", config.VaultPath, err)
+		}
+		if plaintext, err := open(aead, check); err != nil || string(plaintext) != checkPlaintext {
+			return nil, errors.New("the storage key does not match " + config.VaultPath + " (wrong passphrase?)")
+		}
+		return aead, nil
+	}
+	file = vaultFile{
+		Salt:  base64.StdEncoding.EncodeToString(salt),
+		Check: base64.StdEncoding.EncodeToString(seal(aead, []byte(checkPlaintext))),
+	}
+	data, _ = json.MarshalIndent(file, "", "  ")
+	if err := os.WriteFile(config.VaultPath, data, 0o600); err != nil {
+		return nil, err
+	}
+	return aead, nil
+}
+
+func resolveSecret(source string, first bool) (string, error) {
+	if value := os.Getenv(passphraseEnv); value != "" {
+		return value, nil
+	}
+	if source != config.EncryptPassphrase {
+		if secret := keychainLookup(); secret != "" {
+			return secret, nil
+		}
+	}
+	switch source {
+	case config.EncryptKeychain:
+		if !first {
+			return "", errors.New("no storage key in the keychain (service \"mini-go\", account \"storage\"); the encrypted files need the key they were written with")
+		}
+		random := make([]byte, 32)
+		_, _ = rand.Read(random)
+		secret := hex.EncodeToString(random)
+		if err := keychainStore(secret); err != nil {
+			return "", fmt.Errorf("cannot store the storage key in the keychain: %w", err)
+		}
+		return secret, nil
+	case "":
+		if !isTerminal() {
+			return "", errors.New("set " + passphraseEnv + " or store the key in the keychain (service \"mini-go\", account \"storage\")")
+		}
+	}
+	return promptPassphrase(first)
+}
+
+func keychainLookup() string {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("security", "find-generic-password", "-s", keychainService, "-a", keychainAccount, "-w")
+	case "linux":
+		cmd = exec.Command("secret-tool", "lookup", "service", keychainService, "account", keychainAccount)
+	default:
+		return ""
+	}
+	out, err := cmd.Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// keychainStore hands the secret over on stdin so that it never shows up in
+// another process's argument list; security reads commands from stdin in
+// interactive mode.
+func keychainStore(secret string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("security", "-i")
+		cmd.Stdin = strings.NewReader(fmt.Sprintf("add-generic-password -U -s %s -a %s -w %s\n", keychainService, keychainAccount, secret))
+	case "linux":
+		cmd = exec.Command("secret-tool", "store", "--label=mini-go storage key", "service", keychainService, "account", keychainAccount)
+		cmd.Stdin = strings.NewReader(secret)
+	default:
+		return fmt.Errorf("no keychain support on %s; use storage.encrypt \"passphrase\"", runtime.GOOS)
+	}
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("%v: %s", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+func promptPassphrase(confirm bool) (string, error) {
+	if !isTerminal() {
+		return "", errors.New("storage.encrypt is \"passphrase\" but there is no terminal to ask on; set " + passphraseEnv)
+	}
+	passphrase, err := readHidden("Storage passphrase: ")
+	if err != nil {
+		return "", err
+	}
+	if passphrase == "" {
+		return "", errors.New("empty passphrase")
+	}
+	if confirm {
+		again, err := readHidden("Repeat passphrase: ")
+		if err != nil {
+			return "", err
+		}
+		if again != passphrase {
+			return "", errors.New("passphrases do not match")
+		}
+	}
+	return passphrase, nil
+}
+
+// readHidden reads one line byte by byte so that nothing past the newline is
+// taken from the REPL's own reader.
+func readHidden(label string) (string, error) {
+	fmt.Fprint(os.Stderr, label)
+	stty(os.Stdin, "-echo")
+	defer func() {
+		stty(os.Stdin, "echo")
+		fmt.Fprintln(os.Stderr)
+	}()
+	var line []byte
+	buf := make([]byte, 1)
+	for {
+		n, err := os.Stdin.Read(buf)
+		if n == 1 {
+			if buf[0] == '\n' {
+				break
+			}
+			line = append(line, buf[0])
+		}
+		if err != nil {
+			return "", err
+		}
+	}
+	return strings.TrimRight(string(line), "\r"), nil
+}
+
+func stty(in *os.File, arg string) {
+	cmd := exec.Command("stty", arg)
+	cmd.Stdin = in
+	_ = cmd.Run()
+}
+
+// isTerminal asks stty rather than checking for a character device, which
+// /dev/null also is.
+func isTerminal() bool {
+	cmd := exec.Command("stty", "-g")
+	cmd.Stdin = os.Stdin
+	return cmd.Run() == nil
+}
+
+// pbkdf2SHA256 is PBKDF2 (RFC 8018) with HMAC-SHA256.
+func pbkdf2SHA256(password []byte, salt []byte, iterations int, keyLength int) []byte {
+	prf := hmac.New(sha256.New, password)
+	size := prf.Size()
+	var derived []byte
+	for block := uint32(1); len(derived) < keyLength; block++ {
+		prf.Reset()
+		prf.Write(salt)
+		var counter [4]byte
+		binary.BigEndian.PutUint32(counter[:], block)
+		prf.Write(counter[:])
+		u := prf.Sum(nil)
+		t := append([]byte{}, u...)
+		for i := 1; i < iterations; i++ {
+			prf.Reset()
+			prf.Write(u)
+			u = prf.Sum(u[:0])
+			for j := 0; j < size; j++ {
+				t[j] ^= u[j]
+			}
+		}
+		derived = append(derived, t...)
+	}
+	return derived[:keyLength]
+}
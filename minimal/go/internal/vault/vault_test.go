@@ -0,0 +1,115 @@
+package vault
+
+import (
+	"bytes"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"minimal-go/internal/config"
+)
+
+// The RFC 6070 test vectors, computed with HMAC-SHA256 instead of SHA-1.
+func TestPBKDF2SHA256Vectors(t *testing.T) {
+	tests := []struct {
+		password   string
+		salt       string
+		iterations int
+		keyLength  int
+		want       string
+	}{
+		{"password", "salt", 1, 32, "120fb6cffcf8b32c43e7225256c4f837a86548c92ccc35480805987cb70be17b"},
+		{"password", "salt", 2, 32, "ae4d0c95af6b46d32d0adff928f06dd02a303f8ef3c251dfd6e2d85a95474c43"},
+		{"password", "salt", 4096, 32, "c5e478d59288c841aa530db6845c4c8d962893a001ce4e11a4963873aa98134a"},
+		{"passwordPASSWORDpassword", "saltSALTsaltSALTsaltSALTsaltSALTsalt", 4096, 40, "348c89dbcbd32b2f32d814b8116e84cf2b17347ebc1800181c4e2a1fb8dd53e1c635518c7dac47e9"},
+		{"pass\x00word", "sa\x00lt", 4096, 16, "89b69d0516f829893c696226650a8687"},
+	}
+	for _, test := range tests {
+		got := hex.EncodeToString(pbkdf2SHA256([]byte(test.password), []byte(test.salt), test.iterations, test.keyLength))
+		if got != test.want {
+			t.Errorf("pbkdf2SHA256(%q, %q, %d) = %s, want %s", test.password, test.salt, test.iterations, got, test.want)
+		}
+	}
+}
+
+// useVault points the vault at a fresh vault.json and forgets any key.
+func useVault(t *testing.T, passphrase string) {
+	t.Helper()
+	path := config.VaultPath
+	config.VaultPath = filepath.Join(t.TempDir(), "vault.json")
+	t.Cleanup(func() {
+		config.VaultPath = path
+		lock()
+	})
+	lock()
+	t.Setenv(passphraseEnv, passphrase)
+}
+
+func lock() {
+	state.mu.Lock()
+	state.aead, state.enabled = nil, false
+	state.mu.Unlock()
+}
+
+func TestRoundTrip(t *testing.T) {
+	useVault(t, "correct horse")
+	if err := Enable(config.EncryptPassphrase); err != nil {
+		t.Fatal(err)
+	}
+	plaintext := []byte("secret prompt\nwith two lines")
+
+	path := filepath.Join(t.TempDir(), "file")
+	if err := WriteFile(path, plaintext, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	raw, _ := os.ReadFile(path)
+	if bytes.Contains(raw, []byte("secret")) || !bytes.HasPrefix(raw, []byte(fileMagic)) {
+		t.Fatalf("file is not encrypted: %q", raw)
+	}
+	if got, err := ReadFile(path); err != nil || !bytes.Equal(got, plaintext) {
+		t.Fatalf("ReadFile = %q, %v", got, err)
+	}
+
+	line, err := SealLine(plaintext)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.ContainsRune(line, '\n') || bytes.Contains(line, []byte("secret")) {
+		t.Fatalf("sealed line leaks: %q", line)
+	}
+	if got, err := OpenLine(line); err != nil || !bytes.Equal(got, plaintext) {
+		t.Fatalf("OpenLine = %q, %v", got, err)
+	}
+
+	// Plaintext written before encryption was enabled still loads.
+	if got, err := OpenLine([]byte("old line")); err != nil || string(got) != "old line" {
+		t.Fatalf("OpenLine(plaintext) = %q, %v", got, err)
+	}
+}
+
+func TestWrongPassphrase(t *testing.T) {
+	useVault(t, "correct horse")
+	if err := Enable(config.EncryptPassphrase); err != nil {
+		t.Fatal(err)
+	}
+	sealed, err := Seal([]byte("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lock()
+	t.Setenv(passphraseEnv, "battery staple")
+	if err := Enable(config.EncryptPassphrase); err == nil {
+		t.Fatal("Enable accepted the wrong passphrase")
+	}
+	if _, err := Open(sealed); err == nil {
+		t.Fatal("Open decrypted with the wrong passphrase")
+	}
+
+	lock()
+	t.Setenv(passphraseEnv, "correct horse")
+	if got, err := Open(sealed); err != nil || string(got) != "secret" {
+		t.Fatalf("Open = %q, %v", got, err)
+	}
+}
@@ -0,0 +1,167 @@
+// Package agent embeds the mini-go coding agent in other Go programs.
+//
+// A program loads (or builds) a Config, creates an agent for a workspace and
+// runs turns:
+//
+//	cfg, err := agent.LoadConfig()
+//	a, err := agent.New(agent.Options{
+//		Config:         &cfg,
+//		WorkspaceRoot:  dir,
+//		OnEvent:        func(e agent.Event) { log.Println(e.Kind, e.Text) },
+//		ApproveCommand: func(cmd string) (bool, string, error) { return true, "", nil },
+//	})
+//	a.AddUserMessage("make the failing test pass")
+//	err = a.RunAgentTurn()
+//
+// Output is delivered as Events; nothing is written to stdout unless OnEvent
+// is nil. Agent methods are safe for concurrent use, and only one turn runs
+// at a time. Configuration changes (SetModel, ApplyConfig) wait for the
+// current turn to finish, so they must not be called from inside callbacks.
+package agent
+
+import (
+	"errors"
+	"io"
+
+	"minimal-go/internal/config"
+	"minimal-go/internal/core"
+	"minimal-go/internal/types"
+	"minimal-go/pkg/providers"
+)
+
+type (
+	Agent        = core.Agent
+	Config       = config.Config
+	Message      = types.Message
+	Event        = core.Event
+	EventKind    = core.EventKind
+	EventLevel   = core.EventLevel
+	FileChange   = core.FileChange
+	FileApproval = core.FileApproval
+	TurnRecord   = core.TurnRecord
+	TokenUsage   = core.TokenUsage
+	Question     = core.Question
+)
+
+const (
+	EventTurnStarted     = core.EventTurnStarted
+	EventRequestStarted  = core.EventRequestStarted
+	EventRequestFinished = core.EventRequestFinished
+	EventUsage           = core.EventUsage
+	EventThinking        = core.EventThinking
+	EventAssistant       = core.EventAssistant
+	EventCommandOutput   = core.EventCommandOutput
+	EventNotice          = core.EventNotice
+	EventToolCall        = core.EventToolCall
+	EventApproval        = core.EventApproval
+	EventToolResult      = core.EventToolResult
+	EventTurnCompleted   = core.EventTurnCompleted
+	EventError           = core.EventError
+
+	LevelInfo    = core.LevelInfo
+	LevelSuccess = core.LevelSuccess
+	LevelWarning = core.LevelWarning
+	LevelError   = core.LevelError
+
+	FileRejected       = core.FileRejected
+	FileApproved       = core.FileApproved
+	FileApprovedAlways = core.FileApprovedAlways
+)
+
+var ErrBudgetExceeded = core.ErrBudgetExceeded
+
+// Options configures New. Only WorkspaceRoot is required.
+type Options struct {
+	// Config defaults to DefaultConfig().
+	Config *Config
+	// SystemPrompt is used as given. When empty, ~/.minimal/system.md plus the
+	// workspace context (project instructions, memory, file map) is used.
+	SystemPrompt  string
+	WorkspaceRoot string
+	// Provider replaces the backend selected by Config.
+	Provider providers.Provider
+
+	// OnEvent receives all agent output. When nil, events are rendered as
+	// text to Output (stdout when Output is nil too).
+	OnEvent func(Event)
+	Output  io.Writer
+
+	// ApproveCommand is asked before any command the policy does not
+	// auto-run. It may return a replacement command to run instead. When nil,
+	// such commands are rejected.
+	ApproveCommand func(command string) (approved bool, replacement string, err error)
+	// ApproveFileChanges is asked before write_file/edit_file and commands
+	// that redirect into files. When nil, ApproveCommand decides for the
+	// whole batch.
+	ApproveFileChanges func(command string, changes []FileChange) ([]FileApproval, error)
+	// AskUser answers the model's ask_user questions; answered is false when
+	// the question is skipped. When nil, the model is told no one can answer.
+	AskUser func(question Question) (answer string, answered bool, err error)
+	// OnAutoApproved and OnDenied observe policy outcomes.
+	OnAutoApproved func(command string)
+	OnDenied       func(command string)
+	// OnDebugLog receives request/response payloads when Debug is set.
+	Debug      bool
+	OnDebugLog func(label string, data interface{})
+}
+
+// New creates an agent for options.WorkspaceRoot.
+func New(options Options) (Agent, error) {
+	if options.WorkspaceRoot == "" {
+		return nil, errors.New("agent: WorkspaceRoot is required")
+	}
+	cfg := config.DefaultConfig()
+	if options.Config != nil {
+		cfg = *options.Config
+	}
+	systemPrompt := options.SystemPrompt
+	if systemPrompt == "" {
+		base, err := config.LoadSystemPrompt()
+		if err != nil {
+			return nil, err
+		}
+		systemPrompt = core.BuildSystemPrompt(base, options.WorkspaceRoot)
+	}
+
+	approveCommand := options.ApproveCommand
+	if approveCommand == nil {
+		approveCommand = func(string) (bool, string, error) { return false, "", nil }
+	}
+	return core.CreateAgent(core.AgentOptions{
+		Config:        cfg,
+		SystemPrompt:  systemPrompt,
+		WorkspaceRoot: options.WorkspaceRoot,
+		Debug:         options.Debug,
+		Provider:      options.Provider,
+		Output:        options.Output,
+		OnEvent:       options.OnEvent,
+		Callbacks: core.AgentCallbacks{
+			PromptApproval: func(command string) (bool, error) {
+				approved, _, err := approveCommand(command)
+				return approved, err
+			},
+			PromptCommand:     approveCommand,
+			PromptFileChanges: options.ApproveFileChanges,
+			AskUser:           options.AskUser,
+			OnAutoApproved:    options.OnAutoApproved,
+			OnDenied:          options.OnDenied,
+			OnDebugLog:        options.OnDebugLog,
+		},
+	})
+}
+
+// LoadConfig reads ~/.minimal/config.json, falling back to DefaultConfig
+// when it does not exist.
+func LoadConfig() (Config, error) {
+	return config.LoadConfig()
+}
+
+// ParseConfig parses config.json content.
+func ParseConfig(data []byte) (Config, error) {
+	return config.ParseConfig(data)
+}
+
+// DefaultConfig is the configuration used when no config.json exists.
+func DefaultConfig() Config {
+	return config.DefaultConfig()
+}
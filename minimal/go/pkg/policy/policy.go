@@ -0,0 +1,82 @@
+// Package policy classifies shell commands and paths the way the agent does
+// before running them, so embedders can pre-screen input or build their own
+// approval flows on the same rules.
+package policy
+
+import (
+	"minimal-go/internal/config"
+	"minimal-go/internal/policy"
+)
+
+// Decision is the outcome of evaluating a command: auto-run, ask, confirm or
+// deny, with the matching rule and a suggestion when denied.
+type Decision = policy.Decision
+
+// Result is the action part of a Decision.
+type Result = policy.PolicyResult
+
+const (
+	Auto = policy.PolicyAuto
+	Ask  = policy.PolicyAsk
+	Deny = policy.PolicyDeny
+	// Confirm asks the user to type ConfirmPhrase before running a command a
+	// built-in rule would otherwise deny (see policy.confirmDangerous).
+	Confirm = policy.PolicyConfirm
+)
+
+// ConfirmPhrase is what the user types to run a Confirm command.
+const ConfirmPhrase = policy.ConfirmPhrase
+
+// Evaluate checks command against cfg.Policy and the built-in deny rules.
+func Evaluate(command string, cfg config.Config) Decision {
+	return policy.EvaluatePolicy(command, cfg)
+}
+
+// EvaluateIn also flags paths outside workspaceRoot (and cfg.Policy.AllowedPaths)
+// for a command run from dir.
+func EvaluateIn(command string, cfg config.Config, workspaceRoot string, dir string) Decision {
+	return policy.EvaluateInWorkspace(command, cfg, workspaceRoot, dir)
+}
+
+// ResolvePath resolves path against workspaceRoot, failing when it escapes
+// the workspace and every allowed path.
+func ResolvePath(path string, workspaceRoot string, allowedPaths []string) (string, error) {
+	return policy.ResolvePath(path, workspaceRoot, allowedPaths)
+}
+
+// SensitivePath reports whether reading path is denied (keys, credentials,
+// dependency trees) and why.
+func SensitivePath(path string) (Decision, bool) {
+	return policy.SensitivePath(path)
+}
+
+// Assessment lists what a command would do ("modifies git history",
+// "network egress") and the highest risk among those labels.
+type Assessment = policy.Assessment
+
+// Classifier labels commands; see Classify.
+type Classifier = policy.Classifier
+
+type Finding = policy.Finding
+
+type Risk = policy.Risk
+
+const (
+	RiskLow    = policy.RiskLow
+	RiskMedium = policy.RiskMedium
+	RiskHigh   = policy.RiskHigh
+)
+
+// Classify labels command with the built-in risk rules and cfg.Policy.RiskRules,
+// followed by any extra classifiers. Labels only inform; they do not change
+// the Decision.
+func Classify(command string, cfg config.Config, extra ...Classifier) Assessment {
+	return policy.Classify(command, append([]Classifier{policy.RiskClassifier(cfg)}, extra...)...)
+}
+
+// CheckFile reports whether tools may not read or write path (absolute or
+// relative to workspaceRoot) under cfg.Policy.Files and the built-in rules.
+func CheckFile(path string, workspaceRoot string, cfg config.Config) (Decision, bool) {
+	cfg.Policy.Files = cfg.Policy.Files.ForWorkspace(workspaceRoot)
+	return policy.CheckFile(path, workspaceRoot, cfg)
+}
@@ -0,0 +1,65 @@
+// Package providers exposes the chat model backends used by the agent.
+//
+// Any type implementing Provider can be passed to agent.Options to replace
+// the configured backend, for example to route requests through an existing
+// client or to script responses in tests.
+package providers
+
+import (
+	"minimal-go/internal/config"
+	"minimal-go/internal/core/providers"
+	"minimal-go/internal/types"
+)
+
+// Provider sends one chat request and returns the model's reply.
+type Provider = providers.ChatProvider
+
+// Request is the input to Provider.CreateChatCompletion.
+type Request = providers.CreateChatParams
+
+// Response is the output of Provider.CreateChatCompletion.
+type Response = providers.ChatResponse
+
+// Sampling holds top_p, top_k, penalties, stop sequences and seed. Set
+// Request.Sampling to a variant's Settings.Sampling.Override(...) to change
+// them for one call.
+type Sampling = config.Sampling
+
+// ResponseFormat asks the model for a JSON object, optionally matching a schema.
+type ResponseFormat = providers.ResponseFormat
+
+// Settings is a fully resolved provider variant (schema, model, key, base URL).
+type Settings = config.ResolvedLlmConfig
+
+const (
+	FormatJSONObject = providers.FormatJSONObject
+	FormatJSONSchema = providers.FormatJSONSchema
+)
+
+// New builds the provider described by settings (OpenAI-compatible,
+// Anthropic or fixture-backed mock).
+func New(settings Settings) Provider {
+	return providers.CreateProvider(settings)
+}
+
+// Resolve picks the current variant out of cfg and fills in defaults.
+func Resolve(cfg config.Config) (Settings, error) {
+	return config.ResolveLlmConfig(cfg)
+}
+
+// Scripted returns a provider that replays the given assistant messages in
+// order, one per request.
+func Scripted(responses []types.Message) Provider {
+	return providers.NewMockProvider(responses)
+}
+
+// ListModels returns the model ids the provider advertises.
+func ListModels(settings Settings) ([]string, error) {
+	return providers.ListModels(settings)
+}
+
+// DecodeJSON parses a model reply produced with a ResponseFormat into target,
+// tolerating code fences and trailing commas.
+func DecodeJSON(content string, format *ResponseFormat, target interface{}) error {
+	return providers.DecodeJSON(content, format, target)
+}
@@ -0,0 +1,28 @@
+// Package tools lists the tool schemas the agent offers to the model.
+package tools
+
+import (
+	"minimal-go/internal/tools"
+	"minimal-go/internal/types"
+)
+
+// Tool is a tool definition: name, description and JSON input schema.
+type Tool = types.Tool
+
+var (
+	Bash        = tools.BashTool
+	ReadFile    = tools.ReadFileTool
+	WriteFile   = tools.WriteFileTool
+	EditFile    = tools.EditFileTool
+	Diagnostics = tools.DiagnosticsTool
+	RunTests    = tools.RunTestsTool
+	ViewImage   = tools.ViewImageTool
+	Remember    = tools.RememberTool
+	ResetShell  = tools.ResetShellTool
+)
+
+// Default returns the tools a new agent starts with (ResetShell is added
+// only when the persistent shell is enabled).
+func Default() []Tool {
+	return []Tool{Bash, ReadFile, WriteFile, EditFile, Diagnostics, RunTests, ViewImage, Remember}
+}